@@ -0,0 +1,355 @@
+package collector
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"mikrotik-exporter/config"
+
+	log "github.com/sirupsen/logrus"
+	routeros "gopkg.in/routeros.v2"
+)
+
+// apiClient wraps a RouterOS API connection and adapts the commands issued by
+// the collectors to the RouterOS version and packages installed on the device.
+type apiClient struct {
+	*routeros.Client
+	device  *config.Device
+	version routerOSVersion
+	// release is the version as reported by the device, e.g. 7.14.2 (stable)
+	release string
+	limiter *rateLimiter
+	// ssh runs the commands configured to run on the command line, nil if
+	// there are none
+	ssh *sshRunner
+	// series is the series left to the device in the scrape, nil if
+	// unlimited
+	series *seriesBudget
+
+	// bootTime is the time the device booted, zero if unknown
+	bootTime time.Time
+
+	// reconnect dials the device again if the connection dropped, which is
+	// done at most retry.attempts times per scrape
+	reconnect   func() (*routeros.Client, error)
+	retry       retryPolicy
+	retries     int
+	reconnectMu sync.Mutex
+
+	// mu guards replacing the connection against aborting the scrape
+	mu      sync.Mutex
+	aborted bool
+	done    chan struct{}
+	// async is set if commands run concurrently over the connection
+	async bool
+
+	// span is the span the commands are traced in, the span of the running
+	// collector unless collectors run concurrently
+	span *span
+	// commands counts the commands sent in the scrape
+	commands atomic.Int64
+
+	// wirelessTree is the menu of the wifi package replacing the legacy
+	// wireless package, empty if the legacy package is used
+	wirelessTree     string
+	wirelessDetected bool
+	wirelessMu       sync.Mutex
+}
+
+func newAPIClient(cl *routeros.Client, d *config.Device, limiter *rateLimiter, retry retryPolicy, reconnect func() (*routeros.Client, error)) *apiClient {
+	c := &apiClient{
+		Client:    cl,
+		device:    d,
+		limiter:   limiter,
+		reconnect: reconnect,
+		retry:     retry,
+		done:      make(chan struct{}),
+	}
+	c.detectVersion()
+	return c
+}
+
+func (c *apiClient) detectVersion() {
+	reply, err := c.run("/system/resource/print", "=.proplist=version,uptime")
+	if err != nil || len(reply.Re) == 0 {
+		log.WithFields(log.Fields{
+			"device":  c.device.Name,
+			"address": c.device.Address,
+			"error":   err,
+		}).Warn("could not detect RouterOS version, assuming RouterOS 6")
+		c.version = routerOSVersion{major: 6}
+		return
+	}
+
+	c.release = reply.Re[0].Map["version"]
+	v, err := parseRouterOSVersion(c.release)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device":  c.device.Name,
+			"address": c.device.Address,
+			"error":   err,
+		}).Warn("could not parse RouterOS version, assuming RouterOS 6")
+		v = routerOSVersion{major: 6}
+	}
+	c.version = v
+
+	if uptime, err := parseUptime(reply.Re[0].Map["uptime"]); err == nil {
+		c.bootTime = time.Now().Add(-time.Duration(uptime * float64(time.Second)))
+	}
+
+	log.WithFields(log.Fields{
+		"device":  c.device.Name,
+		"address": c.device.Address,
+		"version": v,
+	}).Debug("detected RouterOS version")
+}
+
+// detectWirelessTree checks whether one of the wifi packages replacing the
+// legacy wireless package is installed.
+func (c *apiClient) detectWirelessTree() {
+	c.wirelessDetected = true
+
+	reply, err := c.run("/system/package/print", "=.proplist=name,disabled")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device":  c.device.Name,
+			"address": c.device.Address,
+			"error":   err,
+		}).Warn("could not detect wireless package, assuming legacy wireless")
+		return
+	}
+
+	for _, re := range reply.Re {
+		if re.Map["disabled"] == "true" {
+			continue
+		}
+		if tree, ok := wifiPackages[re.Map["name"]]; ok {
+			c.wirelessTree = tree
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"device":  c.device.Name,
+		"address": c.device.Address,
+		"tree":    c.wirelessTree,
+	}).Debug("detected wireless package")
+}
+
+// wifiTree returns the menu of the wifi package replacing the legacy wireless
+// package, empty if the legacy package is used.
+func (c *apiClient) wifiTree() string {
+	if c.version.major < 7 {
+		return ""
+	}
+
+	c.wirelessMu.Lock()
+	defer c.wirelessMu.Unlock()
+
+	if !c.wirelessDetected {
+		c.detectWirelessTree()
+	}
+
+	return c.wirelessTree
+}
+
+// mapping returns the translation for a command written for RouterOS 6 with
+// the legacy wireless package.
+func (c *apiClient) mapping(command string) (commandMapping, bool) {
+	if c.version.major < 7 {
+		return commandMapping{}, false
+	}
+
+	if m, ok := v7Commands[command]; ok {
+		return m, true
+	}
+
+	m, ok := wifiCommands[command]
+	if !ok {
+		return commandMapping{}, false
+	}
+
+	tree := c.wifiTree()
+	if tree == "" {
+		return commandMapping{}, false
+	}
+
+	m.path = tree + m.path
+	return m, true
+}
+
+// Run issues a command written for RouterOS 6, translating it for devices
+// running RouterOS 7.
+func (c *apiClient) Run(sentence ...string) (*routeros.Reply, error) {
+	if len(sentence) == 0 {
+		return c.run(sentence...)
+	}
+
+	m, ok := c.mapping(sentence[0])
+	if !ok {
+		return c.run(sentence...)
+	}
+
+	reply, err := c.run(m.translate(sentence)...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, re := range reply.Re {
+		m.translateReply(re)
+	}
+
+	return reply, nil
+}
+
+// run sends the command to the device once the rate limit allows it. If the
+// connection dropped, the device is reconnected with backoff and the command
+// is retried.
+func (c *apiClient) run(sentence ...string) (reply *routeros.Reply, err error) {
+	c.commands.Add(1)
+	if c.span != nil && len(sentence) > 0 {
+		s := c.span.child(sentence[0])
+		defer func() { s.end(err) }()
+	}
+
+	if c.limiter != nil {
+		c.limiter.wait()
+	}
+
+	if c.ssh != nil && len(sentence) > 0 && c.ssh.handles(sentence[0]) {
+		return c.ssh.run(sentence...)
+	}
+
+	cl := c.current()
+	reply, err = cl.Run(sentence...)
+	for err != nil && c.reconnect != nil && isConnectionError(err) {
+		cl, err = c.replace(cl, err)
+		if err != nil {
+			return nil, err
+		}
+
+		if c.limiter != nil {
+			c.limiter.wait()
+		}
+
+		reply, err = cl.Run(sentence...)
+	}
+
+	return reply, err
+}
+
+// current returns the connection to run commands over.
+func (c *apiClient) current() *routeros.Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.Client
+}
+
+// replace reconnects the device after the connection broke with err and
+// returns the new connection. Commands running concurrently over the broken
+// connection share the new one.
+func (c *apiClient) replace(broken *routeros.Client, err error) (*routeros.Client, error) {
+	c.reconnectMu.Lock()
+	defer c.reconnectMu.Unlock()
+
+	if cl := c.current(); cl != broken {
+		return cl, nil
+	}
+
+	for c.retries < c.retry.attempts {
+		c.retries++
+		if !c.retry.wait(c.retries, time.Time{}, c.done) {
+			return nil, err
+		}
+
+		log.WithFields(log.Fields{
+			"device":  c.device.Name,
+			"address": c.device.Address,
+			"attempt": c.retries,
+			"error":   err,
+		}).Warn("connection to device dropped, reconnecting")
+
+		cl, rerr := c.reconnect()
+		if rerr != nil {
+			log.WithFields(log.Fields{
+				"device":  c.device.Name,
+				"address": c.device.Address,
+				"error":   rerr,
+			}).Error("error reconnecting to device")
+			err = rerr
+			if !isConnectionError(rerr) {
+				return nil, err
+			}
+			continue
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		if c.aborted {
+			cl.Close()
+			return nil, err
+		}
+		if c.async {
+			cl.Async()
+		}
+		c.Client.Close()
+		c.Client = cl
+
+		return cl, nil
+	}
+
+	return nil, err
+}
+
+// enableAsync allows running commands concurrently over the connection.
+func (c *apiClient) enableAsync() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.async = true
+	// fails for connections kept open from a previous scrape, which are in
+	// asynchronous mode already
+	c.Client.Async()
+}
+
+// abort closes the connection, failing the running and all further commands.
+func (c *apiClient) abort() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.aborted {
+		return
+	}
+	c.aborted = true
+	close(c.done)
+	c.Client.Close()
+}
+
+// errAsyncLoopEnded is the message of the unexported error returned by the
+// routeros package for commands sent after the connection of a client in
+// asynchronous mode broke
+const errAsyncLoopEnded = "Async() loop has ended - probably read error"
+
+// isConnectionError returns whether the error was caused by a broken
+// connection rather than the device rejecting the command.
+func isConnectionError(err error) bool {
+	var deviceErr *routeros.DeviceError
+	if errors.As(err, &deviceErr) {
+		return false
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, net.ErrClosed) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		err.Error() == errAsyncLoopEnded
+}
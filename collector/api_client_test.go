@@ -0,0 +1,83 @@
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	routeros "gopkg.in/routeros.v2"
+	"gopkg.in/routeros.v2/proto"
+)
+
+func TestIsConnectionError(t *testing.T) {
+	assert.True(t, isConnectionError(io.EOF))
+	assert.True(t, isConnectionError(fmt.Errorf("read: %w", syscall.ECONNRESET)))
+	assert.False(t, isConnectionError(&routeros.DeviceError{Sentence: &proto.Sentence{}}))
+	assert.False(t, isConnectionError(fmt.Errorf("invalid reply")))
+}
+
+// fakeDevice returns a client connected to a device which answers each
+// command with the sentences returned by reply, recording the commands.
+func fakeDevice(t *testing.T, reply func(command []string) [][]string) (*routeros.Client, func() [][]string) {
+	conn, server := net.Pipe()
+	t.Cleanup(func() { server.Close() })
+
+	var (
+		mu       sync.Mutex
+		commands [][]string
+	)
+	go func() {
+		r := bufio.NewReader(server)
+		w := proto.NewWriter(server)
+		for {
+			command, err := readSentence(r)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			commands = append(commands, command)
+			mu.Unlock()
+
+			for _, words := range reply(command) {
+				w.BeginSentence()
+				for _, word := range words {
+					w.WriteWord(word)
+				}
+				if err := w.EndSentence(); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	cl, err := routeros.NewClient(conn)
+	assert.NoError(t, err)
+	t.Cleanup(cl.Close)
+
+	return cl, func() [][]string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return commands
+	}
+}
+
+// readSentence reads the words of a sentence sent to the device.
+func readSentence(r *bufio.Reader) ([]string, error) {
+	var words []string
+	for {
+		word, err := readWord(r)
+		if err != nil {
+			return nil, err
+		}
+		if word == "" {
+			return words, nil
+		}
+		words = append(words, word)
+	}
+}
@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+// arpCollector counts the entries of the ARP table and of the IPv6 neighbor
+// table per interface, VRF and status.
+type arpCollector struct {
+	entriesDesc *prometheus.Desc
+}
+
+func newARPCollector() routerOSCollector {
+	c := &arpCollector{}
+	c.init()
+	return c
+}
+
+func (c *arpCollector) init() {
+	c.entriesDesc = description("arp", "entries", "number of ARP and IPv6 neighbor entries per interface, VRF and status",
+		[]string{"name", "address", "ip_version", "interface", "vrf", "status"})
+}
+
+func (c *arpCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.entriesDesc
+}
+
+func (c *arpCollector) collect(ctx *collectorContext) error {
+	vrfs, err := fetchInterfaceVRFs(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = c.collectForIPVersion("4", "/ip/arp/print", vrfs, ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.collectForIPVersion("6", "/ipv6/neighbor/print", vrfs, ctx)
+}
+
+func (c *arpCollector) collectForIPVersion(ipVersion, command string, vrfs map[string]string, ctx *collectorContext) error {
+	reply, err := ctx.client.Run(command, "=.proplist=interface,status")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"ip_version": ipVersion,
+			"error":      err,
+		}).Error("error fetching ARP entries")
+		return err
+	}
+
+	type key struct{ iface, status string }
+	counts := make(map[key]float64)
+	for _, re := range reply.Re {
+		counts[key{re.Map["interface"], re.Map["status"]}]++
+	}
+
+	for k, v := range counts {
+		ctx.ch <- prometheus.MustNewConstMetric(c.entriesDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address,
+			ipVersion, k.iface, interfaceVRF(vrfs, k.iface), k.status)
+	}
+
+	return nil
+}
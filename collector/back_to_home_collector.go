@@ -0,0 +1,135 @@
+package collector
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+// backToHomeConnectedWindow is the time since the last handshake within which
+// a user counts as connected, WireGuard renews handshakes every 2 minutes
+// while traffic flows
+const backToHomeConnectedWindow = 3 * time.Minute
+
+// backToHomeInterface is the WireGuard interface of Back To Home
+const backToHomeInterface = "back-to-home-vpn"
+
+// backToHomeCollector exports the users of the Back To Home VPN of RouterOS 7,
+// joined with their WireGuard peers by public key for the traffic.
+type backToHomeCollector struct {
+	usersDesc     *prometheus.Desc
+	connectedDesc *prometheus.Desc
+	lastSeenDesc  *prometheus.Desc
+	rxDesc        *prometheus.Desc
+	txDesc        *prometheus.Desc
+}
+
+func newBackToHomeCollector() routerOSCollector {
+	c := &backToHomeCollector{}
+	c.init()
+	return c
+}
+
+func (c *backToHomeCollector) init() {
+	const prefix = "back_to_home"
+	labelNames := []string{"name", "address", "user"}
+	c.usersDesc = description(prefix, "users", "number of enabled Back To Home users", []string{"name", "address"})
+	c.connectedDesc = description(prefix, "users_connected", "number of Back To Home users with a handshake within the last 3 minutes", []string{"name", "address"})
+	c.lastSeenDesc = description(prefix, "user_last_seen_seconds", "time since the last handshake of the Back To Home user", labelNames)
+	c.rxDesc = description(prefix, "user_rx_bytes", "number of bytes received from the Back To Home user", labelNames)
+	c.txDesc = description(prefix, "user_tx_bytes", "number of bytes sent to the Back To Home user", labelNames)
+}
+
+func (c *backToHomeCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.usersDesc
+	ch <- c.connectedDesc
+	ch <- c.lastSeenDesc
+	ch <- c.rxDesc
+	ch <- c.txDesc
+}
+
+func (c *backToHomeCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/ip/cloud/back-to-home-users/print", "?disabled=false", "=.proplist=name,public-key")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching back to home users")
+		return err
+	}
+
+	peers, err := c.fetchPeers(ctx)
+	if err != nil {
+		return err
+	}
+
+	connected := 0.0
+	for _, re := range reply.Re {
+		peer, ok := peers[re.Map["public-key"]]
+		if !ok {
+			continue
+		}
+		if c.collectForUser(re.Map["name"], peer, ctx) {
+			connected++
+		}
+	}
+
+	ctx.ch <- prometheus.MustNewConstMetric(c.usersDesc, prometheus.GaugeValue, float64(len(reply.Re)), ctx.device.Name, ctx.device.Address)
+	ctx.ch <- prometheus.MustNewConstMetric(c.connectedDesc, prometheus.GaugeValue, connected, ctx.device.Name, ctx.device.Address)
+
+	return nil
+}
+
+// fetchPeers returns the WireGuard peers of Back To Home by public key.
+func (c *backToHomeCollector) fetchPeers(ctx *collectorContext) (map[string]*proto.Sentence, error) {
+	reply, err := ctx.client.Run("/interface/wireguard/peers/print", "?interface="+backToHomeInterface, "=.proplist=public-key,rx,tx,last-handshake")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching back to home peers")
+		return nil, err
+	}
+
+	peers := make(map[string]*proto.Sentence)
+	for _, re := range reply.Re {
+		peers[re.Map["public-key"]] = re
+	}
+
+	return peers, nil
+}
+
+// collectForUser exports the traffic and last handshake of the user and
+// returns whether the user is connected.
+func (c *backToHomeCollector) collectForUser(user string, peer *proto.Sentence, ctx *collectorContext) bool {
+	labelValues := []string{ctx.device.Name, ctx.device.Address, user}
+
+	for desc, property := range map[*prometheus.Desc]string{c.rxDesc: "rx", c.txDesc: "tx"} {
+		if v, err := strconv.ParseFloat(peer.Map[property], 64); err == nil {
+			ctx.ch <- ctx.counter(desc, v, labelValues...)
+		}
+	}
+
+	value := peer.Map["last-handshake"]
+	if value == "" {
+		// users which never connected have no last-handshake
+		return false
+	}
+	v, err := parseDuration(value)
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"user":  user,
+			"value": value,
+			"error": err,
+		}).Error("error parsing back to home last handshake")
+		return false
+	}
+	ctx.ch <- prometheus.MustNewConstMetric(c.lastSeenDesc, prometheus.GaugeValue, v, labelValues...)
+
+	return v <= backToHomeConnectedWindow.Seconds()
+}
+
+func (c *backToHomeCollector) requiredMenu() string {
+	return "/ip/cloud/back-to-home-users"
+}
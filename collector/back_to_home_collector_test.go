@@ -0,0 +1,33 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/routeros.v2/proto"
+
+	"mikrotik-exporter/config"
+)
+
+func TestBackToHomeUser(t *testing.T) {
+	c := newBackToHomeCollector().(*backToHomeCollector)
+	ch := make(chan prometheus.Metric, 10)
+	ctx := &collectorContext{ch: ch, device: &config.Device{Name: "r1"}, client: &apiClient{}}
+
+	for _, tc := range []struct {
+		peer      map[string]string
+		connected bool
+		metrics   int
+	}{
+		{map[string]string{"rx": "1024", "tx": "2048", "last-handshake": "1m10s"}, true, 3},
+		{map[string]string{"rx": "1024", "tx": "2048", "last-handshake": "2h5m"}, false, 3},
+		{map[string]string{"rx": "0", "tx": "0"}, false, 2},
+	} {
+		assert.Equal(t, tc.connected, c.collectForUser("alice", &proto.Sentence{Map: tc.peer}, ctx), tc.peer)
+		assert.Len(t, ch, tc.metrics, tc.peer)
+		for len(ch) > 0 {
+			<-ch
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package collector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"mikrotik-exporter/config"
+)
+
+var pollAgeDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "scrape", "poll_age_seconds"),
+	"mikrotik_exporter: age of the metrics of the device polled in the background",
+	[]string{"device"},
+	nil,
+)
+
+// backgroundPoller keeps the metrics of the last poll of each device.
+type backgroundPoller struct {
+	interval time.Duration
+	done     chan struct{}
+	stopOnce sync.Once
+	// polled is closed once the first poll finished
+	polled     chan struct{}
+	polledOnce sync.Once
+
+	mu      sync.Mutex
+	entries map[string]staleEntry
+}
+
+func newBackgroundPoller(interval time.Duration) *backgroundPoller {
+	return &backgroundPoller{
+		interval: interval,
+		done:     make(chan struct{}),
+		polled:   make(chan struct{}),
+		entries:  make(map[string]staleEntry),
+	}
+}
+
+func (p *backgroundPoller) store(device string, metrics []prometheus.Metric, taken time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.entries[device] = staleEntry{metrics: metrics, taken: taken}
+}
+
+// retain drops the metrics of the devices which are gone, e.g. from an SRV
+// record.
+func (p *backgroundPoller) retain(devices []config.Device) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	names := make(map[string]bool, len(devices))
+	for _, d := range devices {
+		names[d.Name] = true
+	}
+	for name := range p.entries {
+		if !names[name] {
+			delete(p.entries, name)
+		}
+	}
+}
+
+// collect sends the metrics of the last poll of each device with their age.
+// It waits for the first poll, so the scrapes of a new collector, e.g. after
+// reloading the config, are not empty.
+func (p *backgroundPoller) collect(ch chan<- prometheus.Metric) {
+	select {
+	case <-p.polled:
+	case <-p.done:
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for device, e := range p.entries {
+		for _, m := range e.metrics {
+			ch <- m
+		}
+		ch <- prometheus.MustNewConstMetric(pollAgeDesc, prometheus.GaugeValue, time.Since(e.taken).Seconds(), device)
+	}
+}
+
+func (p *backgroundPoller) stop() {
+	p.stopOnce.Do(func() {
+		close(p.done)
+	})
+}
+
+// poll scrapes the devices in the interval of the poller until it is stopped.
+// Followers of the leader election don't poll.
+func (c *collector) poll() {
+	ticker := time.NewTicker(c.poller.interval)
+	defer ticker.Stop()
+
+	for {
+		if c.isLeader == nil || c.isLeader() {
+			s := c.startTrace("poll")
+			devices := c.Resolve()
+			c.forEachDevice(devices, func(d config.Device) {
+				begin := time.Now()
+				metrics, _ := collectBuffered(func(ch chan<- prometheus.Metric) error {
+					c.collectForDevice(d, ch, &selection{collectors: c.collectors}, s)
+					return nil
+				})
+				c.poller.store(d.Name, metrics, begin)
+			})
+			c.retain(devices)
+			c.finishTrace(s)
+		}
+		// followers don't wait for a poll until they are elected
+		c.poller.polledOnce.Do(func() {
+			close(c.poller.polled)
+		})
+
+		select {
+		case <-c.poller.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type intervalKey struct {
+	device    string
+	collector string
+}
+
+// intervalCache keeps the metrics of the collectors running in a longer
+// interval than the scrapes.
+type intervalCache struct {
+	mu      sync.Mutex
+	entries map[intervalKey]staleEntry
+}
+
+func newIntervalCache() *intervalCache {
+	return &intervalCache{entries: make(map[intervalKey]staleEntry)}
+}
+
+func (s *intervalCache) store(device, collector string, metrics []prometheus.Metric) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[intervalKey{device, collector}] = staleEntry{metrics: metrics, taken: time.Now()}
+}
+
+// load returns the last metrics of the collector for the device if they were
+// collected within the interval.
+func (s *intervalCache) load(device, collector string, interval time.Duration) ([]prometheus.Metric, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[intervalKey{device, collector}]
+	if !ok || time.Since(e.taken) >= interval {
+		return nil, false
+	}
+
+	return e.metrics, true
+}
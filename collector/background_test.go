@@ -0,0 +1,68 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+
+	"mikrotik-exporter/config"
+)
+
+func TestIntervalCache(t *testing.T) {
+	s := newIntervalCache()
+	m := prometheus.MustNewConstMetric(prometheus.NewDesc("test_metric", "test", nil, nil), prometheus.GaugeValue, 1)
+
+	_, ok := s.load("r1", "firmware", time.Minute)
+	assert.False(t, ok)
+
+	s.store("r1", "firmware", []prometheus.Metric{m})
+	metrics, ok := s.load("r1", "firmware", time.Minute)
+	assert.True(t, ok)
+	assert.Len(t, metrics, 1)
+
+	_, ok = s.load("r2", "firmware", time.Minute)
+	assert.False(t, ok, "metrics must be kept per device")
+	_, ok = s.load("r1", "firmware", 0)
+	assert.False(t, ok, "metrics older than the interval must not be served")
+}
+
+func TestBackgroundPoller(t *testing.T) {
+	p := newBackgroundPoller(time.Minute)
+	m := prometheus.MustNewConstMetric(prometheus.NewDesc("test_metric", "test", nil, nil), prometheus.GaugeValue, 1)
+
+	p.store("r1", []prometheus.Metric{m}, time.Now())
+	p.store("r2", []prometheus.Metric{m}, time.Now())
+	p.retain([]config.Device{{Name: "r1"}})
+	close(p.polled)
+
+	metrics, err := collectBuffered(func(ch chan<- prometheus.Metric) error {
+		p.collect(ch)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 2, "expected the metric and the poll age of r1 only")
+}
+
+func TestBackgroundPollerWaitsForFirstPoll(t *testing.T) {
+	p := newBackgroundPoller(time.Minute)
+
+	collected := make(chan struct{})
+	go func() {
+		_, _ = collectBuffered(func(ch chan<- prometheus.Metric) error {
+			p.collect(ch)
+			return nil
+		})
+		close(collected)
+	}()
+
+	select {
+	case <-collected:
+		t.Fatal("expected collect to wait for the first poll")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	p.stop()
+	<-collected
+}
@@ -0,0 +1,293 @@
+package collector
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/robfig/cron/v3"
+	log "github.com/sirupsen/logrus"
+	routeros "gopkg.in/routeros.v2"
+
+	"mikrotik-exporter/config"
+)
+
+const defaultBandwidthTestDuration = 10 * time.Second
+
+// bandwidthTest is a configured test with its parsed schedule.
+type bandwidthTest struct {
+	cfg      config.BandwidthTest
+	schedule cron.Schedule
+}
+
+// bandwidthTestResult is the outcome of the last run of a test.
+type bandwidthTestResult struct {
+	finished time.Time
+	success  bool
+	values   map[string]float64
+}
+
+// bandwidthTestCollector runs the bandwidth tests on their schedules on
+// dedicated connections and exports the results of their last runs.
+type bandwidthTestCollector struct {
+	tests   []bandwidthTest
+	devices func() []config.Device
+	dial    func(d *config.Device) (*routeros.Client, error)
+	skip    func(device string) bool
+
+	successDesc   *prometheus.Desc
+	timestampDesc *prometheus.Desc
+	valueDescs    map[string]*prometheus.Desc
+
+	mu      sync.Mutex
+	results map[int]bandwidthTestResult
+	done    chan struct{}
+}
+
+func newBandwidthTestCollector(tests []config.BandwidthTest) (*bandwidthTestCollector, error) {
+	const prefix = "bandwidth_test"
+
+	labelNames := []string{"name", "address", "target"}
+	c := &bandwidthTestCollector{
+		successDesc:   description(prefix, "success", "whether the last bandwidth test to the target succeeded", labelNames),
+		timestampDesc: description(prefix, "last_run_timestamp_seconds", "time the last bandwidth test to the target finished", labelNames),
+		valueDescs: map[string]*prometheus.Desc{
+			"tx-total-average": description(prefix, "tx_bits_per_second", "average transmit rate achieved by the last bandwidth test to the target", labelNames),
+			"rx-total-average": description(prefix, "rx_bits_per_second", "average receive rate achieved by the last bandwidth test to the target", labelNames),
+			"lost-packets":     description(prefix, "lost_packets", "number of packets lost during the last UDP bandwidth test to the target", labelNames),
+		},
+		results: make(map[int]bandwidthTestResult),
+		done:    make(chan struct{}),
+	}
+
+	for _, t := range tests {
+		if t.Device == "" || t.Target == "" {
+			return nil, fmt.Errorf("missing device or target of bandwidth test")
+		}
+		switch t.Protocol {
+		case "", "tcp", "udp":
+		default:
+			return nil, fmt.Errorf("unknown protocol %s of bandwidth test from %s to %s", t.Protocol, t.Device, t.Target)
+		}
+		switch t.Direction {
+		case "", "both", "receive", "transmit":
+		default:
+			return nil, fmt.Errorf("unknown direction %s of bandwidth test from %s to %s", t.Direction, t.Device, t.Target)
+		}
+		if t.Duration == 0 {
+			t.Duration = defaultBandwidthTestDuration
+		}
+
+		schedule, err := cron.ParseStandard(t.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q of bandwidth test from %s to %s: %w", t.Schedule, t.Device, t.Target, err)
+		}
+		c.tests = append(c.tests, bandwidthTest{cfg: t, schedule: schedule})
+	}
+
+	return c, nil
+}
+
+func (c *bandwidthTestCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.successDesc
+	ch <- c.timestampDesc
+	for _, d := range c.valueDescs {
+		ch <- d
+	}
+}
+
+func (c *bandwidthTestCollector) collect(ctx *collectorContext) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, t := range c.tests {
+		r, ok := c.results[i]
+		if !ok || t.cfg.Device != ctx.device.Name {
+			continue
+		}
+
+		success := 0.0
+		if r.success {
+			success = 1.0
+		}
+
+		labelValues := []string{ctx.device.Name, ctx.device.Address, t.cfg.Target}
+		ctx.ch <- prometheus.MustNewConstMetric(c.successDesc, prometheus.GaugeValue, success, labelValues...)
+		ctx.ch <- prometheus.MustNewConstMetric(c.timestampDesc, prometheus.GaugeValue, float64(r.finished.Unix()), labelValues...)
+		for p, v := range r.values {
+			ctx.ch <- prometheus.MustNewConstMetric(c.valueDescs[p], prometheus.GaugeValue, v, labelValues...)
+		}
+	}
+
+	return nil
+}
+
+// start runs the tests on their schedules until the collector is stopped.
+func (c *bandwidthTestCollector) start() {
+	for i := range c.tests {
+		go c.schedule(i)
+	}
+}
+
+// stop ends the schedules and aborts running tests.
+func (c *bandwidthTestCollector) stop() {
+	close(c.done)
+}
+
+func (c *bandwidthTestCollector) schedule(i int) {
+	t := c.tests[i]
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-time.After(time.Until(t.schedule.Next(time.Now()))):
+		}
+
+		if c.skip(t.cfg.Device) {
+			log.WithFields(log.Fields{
+				"device": t.cfg.Device,
+				"target": t.cfg.Target,
+			}).Debug("skipping bandwidth test")
+			continue
+		}
+
+		values, err := c.run(t.cfg)
+		select {
+		case <-c.done:
+			return
+		default:
+		}
+		if err != nil {
+			log.WithFields(log.Fields{
+				"device": t.cfg.Device,
+				"target": t.cfg.Target,
+				"error":  err,
+			}).Error("error running bandwidth test")
+		}
+
+		c.mu.Lock()
+		c.results[i] = bandwidthTestResult{finished: time.Now(), success: err == nil, values: values}
+		c.mu.Unlock()
+	}
+}
+
+// run runs the test on a dedicated connection, so that it does not hold up
+// the scrapes of the device.
+func (c *bandwidthTestCollector) run(t config.BandwidthTest) (map[string]float64, error) {
+	var d *config.Device
+	for _, dev := range c.devices() {
+		if dev.Name == t.Device {
+			d = &dev
+			break
+		}
+	}
+	if d == nil {
+		return nil, fmt.Errorf("unknown device %s", t.Device)
+	}
+
+	cl, err := c.dial(d)
+	if err != nil {
+		return nil, err
+	}
+	defer cl.Close()
+
+	ended := make(chan struct{})
+	defer close(ended)
+	go func() {
+		select {
+		case <-c.done:
+			cl.Close()
+		case <-ended:
+		}
+	}()
+
+	reply, err := cl.Run(bandwidthTestSentence(t)...)
+	if err != nil {
+		return nil, err
+	}
+	if len(reply.Re) == 0 {
+		return nil, fmt.Errorf("no bandwidth test results")
+	}
+
+	// each reply carries the averages of the test so far
+	return parseBandwidthTestResult(reply.Re[len(reply.Re)-1].Map)
+}
+
+func bandwidthTestSentence(t config.BandwidthTest) []string {
+	sentence := []string{
+		"/tool/bandwidth-test",
+		"=address=" + t.Target,
+		fmt.Sprintf("=duration=%ds", int(t.Duration.Seconds())),
+	}
+	if t.Protocol != "" {
+		sentence = append(sentence, "=protocol="+t.Protocol)
+	}
+	if t.Direction != "" {
+		sentence = append(sentence, "=direction="+t.Direction)
+	}
+	if t.LocalTxSpeed != "" {
+		sentence = append(sentence, "=local-tx-speed="+t.LocalTxSpeed)
+	}
+	if t.RemoteTxSpeed != "" {
+		sentence = append(sentence, "=remote-tx-speed="+t.RemoteTxSpeed)
+	}
+	if t.User != "" {
+		sentence = append(sentence, "=user="+t.User, "=password="+t.Password)
+	}
+
+	return sentence
+}
+
+// parseBandwidthTestResult parses the averages of a test, which failed if it
+// ended in another status than running or done, e.g. can not connect.
+func parseBandwidthTestResult(re map[string]string) (map[string]float64, error) {
+	switch status := re["status"]; status {
+	case "running", "done testing":
+	default:
+		return nil, fmt.Errorf("bandwidth test ended with status %q", status)
+	}
+
+	values := make(map[string]float64)
+	for _, p := range []string{"tx-total-average", "rx-total-average", "lost-packets"} {
+		value := re[p]
+		if value == "" {
+			continue
+		}
+
+		v, err := parseBitRate(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", p, err)
+		}
+		values[p] = v
+	}
+
+	return values, nil
+}
+
+// parseBitRate parses rates in bits per second with an optional unit like
+// 94.2Mbps, as printed in the terminal.
+func parseBitRate(rate string) (float64, error) {
+	number := strings.TrimSuffix(rate, "bps")
+	multiplier := 1.0
+	switch {
+	case strings.HasSuffix(number, "k"):
+		multiplier = 1e3
+	case strings.HasSuffix(number, "M"):
+		multiplier = 1e6
+	case strings.HasSuffix(number, "G"):
+		multiplier = 1e9
+	}
+	if multiplier != 1 {
+		number = number[:len(number)-1]
+	}
+
+	v, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rate %q", rate)
+	}
+
+	return v * multiplier, nil
+}
@@ -0,0 +1,63 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"mikrotik-exporter/config"
+)
+
+func TestNewBandwidthTestCollector(t *testing.T) {
+	co, err := newBandwidthTestCollector([]config.BandwidthTest{{Device: "r1", Target: "10.0.0.1", Schedule: "0 3 * * *"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Second, co.tests[0].cfg.Duration)
+
+	for _, bt := range []config.BandwidthTest{
+		{Target: "10.0.0.1", Schedule: "0 3 * * *"},
+		{Device: "r1", Target: "10.0.0.1", Schedule: "daily"},
+		{Device: "r1", Target: "10.0.0.1", Schedule: "0 3 * * *", Protocol: "icmp"},
+		{Device: "r1", Target: "10.0.0.1", Schedule: "0 3 * * *", Direction: "up"},
+	} {
+		_, err := newBandwidthTestCollector([]config.BandwidthTest{bt})
+		assert.Error(t, err, "%+v", bt)
+	}
+}
+
+func TestBandwidthTestSentence(t *testing.T) {
+	sentence := bandwidthTestSentence(config.BandwidthTest{
+		Target:       "10.0.0.1",
+		Duration:     30 * time.Second,
+		Protocol:     "udp",
+		LocalTxSpeed: "100M",
+		User:         "btest",
+		Password:     "secret",
+	})
+	assert.Equal(t, []string{
+		"/tool/bandwidth-test",
+		"=address=10.0.0.1",
+		"=duration=30s",
+		"=protocol=udp",
+		"=local-tx-speed=100M",
+		"=user=btest",
+		"=password=secret",
+	}, sentence)
+}
+
+func TestParseBandwidthTestResult(t *testing.T) {
+	values, err := parseBandwidthTestResult(map[string]string{
+		"status":           "done testing",
+		"tx-total-average": "94200000",
+		"rx-total-average": "12.5Mbps",
+		"lost-packets":     "3",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"tx-total-average": 94.2e6, "rx-total-average": 12.5e6, "lost-packets": 3}, values)
+
+	_, err = parseBandwidthTestResult(map[string]string{"status": "can not connect"})
+	assert.Error(t, err)
+
+	_, err = parseBandwidthTestResult(map[string]string{"status": "running", "tx-total-average": "fast"})
+	assert.Error(t, err)
+}
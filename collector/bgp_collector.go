@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -9,9 +10,22 @@ import (
 	"gopkg.in/routeros.v2/proto"
 )
 
+// bgpAddressFamilies maps the RouterOS address family names to the afi label
+// value and the menu holding the routes received for the family.
+var bgpAddressFamilies = map[string]struct {
+	afi   string
+	topic string
+}{
+	"ip":    {"ipv4", "/ip/route"},
+	"ipv6":  {"ipv6", "/ipv6/route"},
+	"vpnv4": {"vpnv4", "/routing/bgp/vpnv4-route"},
+}
+
 type bgpCollector struct {
-	props        []string
-	descriptions map[string]*prometheus.Desc
+	props                  []string
+	descriptions           map[string]*prometheus.Desc
+	prefixesReceivedDesc   *prometheus.Desc
+	prefixesAdvertisedDesc *prometheus.Desc
 }
 
 func newBGPCollector() routerOSCollector {
@@ -21,7 +35,7 @@ func newBGPCollector() routerOSCollector {
 }
 
 func (c *bgpCollector) init() {
-	c.props = []string{"name", "remote-as", "state", "prefix-count", "updates-sent", "updates-received", "withdrawn-sent", "withdrawn-received"}
+	c.props = []string{"name", "remote-as", "state", "prefix-count", "updates-sent", "updates-received", "withdrawn-sent", "withdrawn-received", "messages-sent", "messages-received", "uptime", "address-families"}
 
 	const prefix = "bgp"
 	labelNames := []string{"name", "address", "session", "asn"}
@@ -29,15 +43,21 @@ func (c *bgpCollector) init() {
 	c.descriptions = make(map[string]*prometheus.Desc)
 	c.descriptions["state"] = description(prefix, "up", "BGP session is established (up = 1)", labelNames)
 
-	for _, p := range c.props[3:] {
+	for _, p := range c.props[3 : len(c.props)-1] {
 		c.descriptions[p] = descriptionForPropertyName(prefix, p, labelNames)
 	}
+
+	afiLabelNames := append(labelNames, "afi")
+	c.prefixesReceivedDesc = description(prefix, "prefixes_received", "number of prefixes received from the peer per address family", afiLabelNames)
+	c.prefixesAdvertisedDesc = description(prefix, "prefixes_advertised", "number of prefixes advertised to the peer per address family", afiLabelNames)
 }
 
 func (c *bgpCollector) describe(ch chan<- *prometheus.Desc) {
 	for _, d := range c.descriptions {
 		ch <- d
 	}
+	ch <- c.prefixesReceivedDesc
+	ch <- c.prefixesAdvertisedDesc
 }
 
 func (c *bgpCollector) collect(ctx *collectorContext) error {
@@ -56,9 +76,8 @@ func (c *bgpCollector) collect(ctx *collectorContext) error {
 func (c *bgpCollector) fetch(ctx *collectorContext) ([]*proto.Sentence, error) {
 	reply, err := ctx.client.Run("/routing/bgp/peer/print", "=.proplist="+strings.Join(c.props, ","))
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device": ctx.device.Name,
-			"error":  err,
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
 		}).Error("error fetching bgp metrics")
 		return nil, err
 	}
@@ -70,17 +89,86 @@ func (c *bgpCollector) collectForStat(re *proto.Sentence, ctx *collectorContext)
 	asn := re.Map["remote-as"]
 	session := re.Map["name"]
 
-	for _, p := range c.props[2:] {
+	for _, p := range c.props[2 : len(c.props)-1] {
 		c.collectMetricForProperty(p, session, asn, re, ctx)
 	}
+
+	if re.Map["state"] == "established" {
+		c.collectPrefixCounts(session, asn, re, ctx)
+	}
+}
+
+func (c *bgpCollector) collectPrefixCounts(session, asn string, re *proto.Sentence, ctx *collectorContext) {
+	for _, family := range strings.Split(re.Map["address-families"], ",") {
+		af, ok := bgpAddressFamilies[family]
+		if !ok {
+			continue
+		}
+
+		reply, err := ctx.client.Run(af.topic+"/print", fmt.Sprintf("?received-from=%s", session), "=count-only=")
+		if err != nil {
+			ctx.logger().WithFields(log.Fields{
+				"session": session,
+				"afi":     af.afi,
+				"error":   err,
+			}).Error("error fetching bgp received prefix count")
+			continue
+		}
+
+		c.collectCount(c.prefixesReceivedDesc, reply.Done.Map["ret"], session, asn, af.afi, ctx)
+
+		// the advertisements have no address family before RouterOS 7, so
+		// the query would match none of them
+		if ctx.client.version.major < 7 {
+			continue
+		}
+
+		reply, err = ctx.client.Run("/routing/bgp/advertisements/print", fmt.Sprintf("?peer=%s", session), fmt.Sprintf("?afi=%s", family), "=count-only=")
+		if err != nil {
+			ctx.logger().WithFields(log.Fields{
+				"session": session,
+				"afi":     af.afi,
+				"error":   err,
+			}).Error("error fetching bgp advertised prefix count")
+			continue
+		}
+
+		c.collectCount(c.prefixesAdvertisedDesc, reply.Done.Map["ret"], session, asn, af.afi, ctx)
+	}
+}
+
+func (c *bgpCollector) collectCount(desc *prometheus.Desc, ret, session, asn, afi string, ctx *collectorContext) {
+	if ret == "" {
+		return
+	}
+
+	v, err := strconv.ParseFloat(ret, 64)
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"session": session,
+			"afi":     afi,
+			"value":   ret,
+			"error":   err,
+		}).Error("error parsing bgp prefix count")
+		return
+	}
+
+	ctx.ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, session, asn, afi)
 }
 
 func (c *bgpCollector) collectMetricForProperty(property, session, asn string, re *proto.Sentence, ctx *collectorContext) {
+	switch property {
+	case "messages-sent", "messages-received", "uptime":
+		// only reported by some RouterOS versions
+		if re.Map[property] == "" {
+			return
+		}
+	}
+
 	desc := c.descriptions[property]
 	v, err := c.parseValueForProperty(property, re.Map[property])
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device":   ctx.device.Name,
+		ctx.logger().WithFields(log.Fields{
 			"session":  session,
 			"property": property,
 			"value":    re.Map[property],
@@ -89,7 +177,15 @@ func (c *bgpCollector) collectMetricForProperty(property, session, asn string, r
 		return
 	}
 
-	ctx.ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, session, asn)
+	vtype := prometheus.GaugeValue
+	switch property {
+	case "updates-sent", "updates-received", "withdrawn-sent", "withdrawn-received", "messages-sent", "messages-received":
+		if !ctx.legacyMetricTypes {
+			vtype = prometheus.CounterValue
+		}
+	}
+
+	ctx.ch <- prometheus.MustNewConstMetric(desc, vtype, v, ctx.device.Name, ctx.device.Address, session, asn)
 }
 
 func (c *bgpCollector) parseValueForProperty(property, value string) (float64, error) {
@@ -105,5 +201,13 @@ func (c *bgpCollector) parseValueForProperty(property, value string) (float64, e
 		return 0, nil
 	}
 
+	if property == "uptime" {
+		return parseDuration(value)
+	}
+
 	return strconv.ParseFloat(value, 64)
 }
+
+func (c *bgpCollector) requiredMenu() string {
+	return "/routing/bgp/peer"
+}
@@ -0,0 +1,68 @@
+package collector
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/routeros.v2/proto"
+
+	"mikrotik-exporter/config"
+)
+
+// collectBGPPrefixCounts returns the prefix counts collected for an
+// established session negotiating ip and ipv6 and the commands sent.
+func collectBGPPrefixCounts(t *testing.T, version routerOSVersion) (received, advertised map[string]float64, commands [][]string) {
+	cl, sent := fakeDevice(t, func(command []string) [][]string {
+		return [][]string{{"!done", "=ret=3"}}
+	})
+	c := newBGPCollector().(*bgpCollector)
+	ch := make(chan prometheus.Metric, 10)
+	ctx := &collectorContext{ch: ch, device: &config.Device{Name: "r1"}, client: &apiClient{Client: cl, version: version}}
+
+	c.collectPrefixCounts("peer1", "65001", &proto.Sentence{Map: map[string]string{"address-families": "ip,ipv6"}}, ctx)
+	close(ch)
+
+	received = make(map[string]float64)
+	advertised = make(map[string]float64)
+	for m := range ch {
+		var out dto.Metric
+		assert.NoError(t, m.Write(&out))
+
+		afi := ""
+		for _, l := range out.GetLabel() {
+			if l.GetName() == "afi" {
+				afi = l.GetValue()
+			}
+		}
+		switch m.Desc() {
+		case c.prefixesReceivedDesc:
+			received[afi] = out.GetGauge().GetValue()
+		case c.prefixesAdvertisedDesc:
+			advertised[afi] = out.GetGauge().GetValue()
+		}
+	}
+
+	return received, advertised, sent()
+}
+
+func TestBGPPrefixCountsV7(t *testing.T) {
+	received, advertised, commands := collectBGPPrefixCounts(t, routerOSVersion{major: 7})
+
+	assert.Equal(t, map[string]float64{"ipv4": 3, "ipv6": 3}, received)
+	assert.Equal(t, map[string]float64{"ipv4": 3, "ipv6": 3}, advertised)
+	assert.Contains(t, commands, []string{"/routing/bgp/advertisements/print", "?peer=peer1", "?afi=ip", "=count-only="})
+}
+
+func TestBGPPrefixCountsV6(t *testing.T) {
+	received, advertised, commands := collectBGPPrefixCounts(t, routerOSVersion{major: 6})
+
+	assert.Equal(t, map[string]float64{"ipv4": 3, "ipv6": 3}, received)
+	// the advertisements of RouterOS 6 can't be counted per address family
+	assert.Empty(t, advertised)
+	for _, command := range commands {
+		assert.False(t, strings.HasPrefix(command[0], "/routing/bgp/advertisements"), command)
+	}
+}
@@ -0,0 +1,167 @@
+package collector
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+// bondHistory is the last seen set of active slaves of a bond and the number
+// of changes of it seen by the exporter.
+type bondHistory struct {
+	active    string
+	failovers float64
+}
+
+type bondingCollector struct {
+	activeDesc    *prometheus.Desc
+	runningDesc   *prometheus.Desc
+	activeCount   *prometheus.Desc
+	partnerDesc   *prometheus.Desc
+	failoversDesc *prometheus.Desc
+
+	mu      sync.Mutex
+	history map[string]*bondHistory
+}
+
+func newBondingCollector() routerOSCollector {
+	c := &bondingCollector{
+		history: make(map[string]*bondHistory),
+	}
+	c.init()
+	return c
+}
+
+func (c *bondingCollector) init() {
+	const prefix = "bonding"
+	bondLabels := []string{"name", "address", "bond", "mode"}
+	slaveLabels := []string{"name", "address", "bond", "slave"}
+	c.activeDesc = description(prefix, "slave_active", "whether the slave is active in the bond", slaveLabels)
+	c.runningDesc = description(prefix, "slave_running", "whether the link of the slave is up", slaveLabels)
+	c.activeCount = description(prefix, "active_slaves", "number of active slaves of the bond", bondLabels)
+	c.partnerDesc = description(prefix, "lacp_partner_info", "LACP partner of the bond", append(bondLabels, "partner_system_id", "partner_key"))
+	c.failoversDesc = description(prefix, "failovers", "number of changes of the active slaves seen by the exporter", bondLabels)
+}
+
+func (c *bondingCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.activeDesc
+	ch <- c.runningDesc
+	ch <- c.activeCount
+	ch <- c.partnerDesc
+	ch <- c.failoversDesc
+}
+
+func (c *bondingCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/interface/bonding/print", "?disabled=false", "=.proplist=name,mode,slaves")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching bonding interfaces")
+		return err
+	}
+	if len(reply.Re) == 0 {
+		return nil
+	}
+
+	running, err := c.fetchRunning(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, bond := range reply.Re {
+		err := c.collectForBond(bond, running, ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fetchRunning returns the running state of all interfaces by name.
+func (c *bondingCollector) fetchRunning(ctx *collectorContext) (map[string]string, error) {
+	reply, err := ctx.client.Run("/interface/print", "=.proplist=name,running")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching interface states")
+		return nil, err
+	}
+
+	running := make(map[string]string)
+	for _, re := range reply.Re {
+		running[re.Map["name"]] = re.Map["running"]
+	}
+
+	return running, nil
+}
+
+func (c *bondingCollector) collectForBond(bond *proto.Sentence, running map[string]string, ctx *collectorContext) error {
+	name := bond.Map["name"]
+	reply, err := ctx.client.Run("/interface/bonding/monitor", "=numbers="+name, "=once=")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"bond":  name,
+			"error": err,
+		}).Error("error fetching bonding monitor metrics")
+		return err
+	}
+	if len(reply.Re) == 0 {
+		return nil
+	}
+
+	re := reply.Re[0]
+	bondLabels := []string{ctx.device.Name, ctx.device.Address, name, bond.Map["mode"]}
+
+	active := splitList(re.Map["active-slaves"])
+	for _, slave := range splitList(bond.Map["slaves"]) {
+		v := 0.0
+		if slices.Contains(active, slave) {
+			v = 1.0
+		}
+		ctx.ch <- prometheus.MustNewConstMetric(c.activeDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, name, slave)
+		ctx.ch <- prometheus.MustNewConstMetric(c.runningDesc, prometheus.GaugeValue, boolToFloat(running[slave]), ctx.device.Name, ctx.device.Address, name, slave)
+	}
+	ctx.ch <- prometheus.MustNewConstMetric(c.activeCount, prometheus.GaugeValue, float64(len(active)), bondLabels...)
+
+	if partner := re.Map["lacp-partner-system-id"]; partner != "" {
+		ctx.ch <- prometheus.MustNewConstMetric(c.partnerDesc, prometheus.GaugeValue, 1, append(bondLabels, partner, re.Map["lacp-partner-key"])...)
+	}
+
+	failovers := c.failover(ctx.device.Name+"/"+name, strings.Join(active, ","))
+	ctx.ch <- prometheus.MustNewConstMetric(c.failoversDesc, prometheus.CounterValue, failovers, bondLabels...)
+
+	return nil
+}
+
+// failover records the active slaves of the bond and returns the number of
+// changes seen so far.
+func (c *bondingCollector) failover(key, active string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h, ok := c.history[key]
+	if !ok {
+		c.history[key] = &bondHistory{active: active}
+		return 0
+	}
+
+	if h.active != active {
+		h.active = active
+		h.failovers++
+	}
+
+	return h.failovers
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	return strings.Split(value, ",")
+}
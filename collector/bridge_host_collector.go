@@ -0,0 +1,63 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+type bridgeHostCollector struct {
+	hostsDesc *prometheus.Desc
+}
+
+func newBridgeHostCollector() routerOSCollector {
+	c := &bridgeHostCollector{}
+	c.init()
+	return c
+}
+
+func (c *bridgeHostCollector) init() {
+	labelNames := []string{"name", "address", "bridge", "port", "type"}
+	c.hostsDesc = description("bridge", "hosts", "number of MAC addresses in the bridge host table by port and type (dynamic, local or static)", labelNames)
+}
+
+func (c *bridgeHostCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hostsDesc
+}
+
+func (c *bridgeHostCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/interface/bridge/host/print", "=.proplist=bridge,on-interface,dynamic,local")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching bridge host metrics")
+		return err
+	}
+
+	type hostKey struct {
+		bridge   string
+		port     string
+		hostType string
+	}
+	counts := make(map[hostKey]float64)
+	for _, re := range reply.Re {
+		counts[hostKey{re.Map["bridge"], re.Map["on-interface"], bridgeHostType(re)}]++
+	}
+
+	for k, v := range counts {
+		ctx.ch <- prometheus.MustNewConstMetric(c.hostsDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, k.bridge, k.port, k.hostType)
+	}
+
+	return nil
+}
+
+func bridgeHostType(re *proto.Sentence) string {
+	switch {
+	case re.Map["local"] == "true":
+		return "local"
+	case re.Map["dynamic"] == "true":
+		return "dynamic"
+	default:
+		return "static"
+	}
+}
@@ -0,0 +1,97 @@
+package collector
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	routeros "gopkg.in/routeros.v2"
+)
+
+// capabilityProbeInterval defines how long the result of a probe is cached
+// before the menu is probed again, e.g. to pick up newly installed packages.
+const capabilityProbeInterval = time.Hour
+
+// menuDependent is implemented by collectors which query a menu that only
+// exists if an optional RouterOS package or piece of hardware is present.
+type menuDependent interface {
+	requiredMenu() string
+}
+
+type capability struct {
+	supported bool
+	probed    time.Time
+}
+
+// capabilityCache remembers per device which menus are available.
+type capabilityCache struct {
+	mu      sync.Mutex
+	entries map[string]capability
+}
+
+func newCapabilityCache() *capabilityCache {
+	return &capabilityCache{
+		entries: make(map[string]capability),
+	}
+}
+
+// supported reports whether the collector can run against the device and
+// exports the result for collectors which depend on an optional menu.
+func (c *capabilityCache) supported(co namedCollector, ctx *collectorContext) bool {
+	md, ok := co.routerOSCollector.(menuDependent)
+	if !ok {
+		return true
+	}
+
+	menu := md.requiredMenu()
+	key := ctx.device.Name + menu
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+
+	if !ok || time.Since(entry.probed) > capabilityProbeInterval {
+		supported, err := probeMenu(ctx, menu)
+		if err != nil {
+			// let the collector run and report the actual error
+			return true
+		}
+
+		entry = capability{supported: supported, probed: time.Now()}
+		c.mu.Lock()
+		c.entries[key] = entry
+		c.mu.Unlock()
+
+		if !supported {
+			ctx.logger().WithFields(log.Fields{
+				"collector": co.name,
+				"menu":      menu,
+			}).Info("skipping collector not supported by device")
+		}
+	}
+
+	v := 0.0
+	if !entry.supported {
+		v = 1.0
+	}
+	ctx.ch <- prometheus.MustNewConstMetric(collectorUnsupportedDesc, prometheus.GaugeValue, v, ctx.device.Name, co.name)
+
+	return entry.supported
+}
+
+func probeMenu(ctx *collectorContext, menu string) (bool, error) {
+	_, err := ctx.client.Run(menu+"/print", "=.proplist=.id")
+	if err == nil {
+		return true, nil
+	}
+
+	var de *routeros.DeviceError
+	if errors.As(err, &de) && strings.Contains(de.Sentence.Map["message"], "no such command") {
+		return false, nil
+	}
+
+	return false, err
+}
@@ -12,6 +12,10 @@ import (
 type capsmanCollector struct {
 	props        []string
 	descriptions map[string]*prometheus.Desc
+	capsDesc     *prometheus.Desc
+	capStateDesc *prometheus.Desc
+	capInfoDesc  *prometheus.Desc
+	capRadioDesc *prometheus.Desc
 }
 
 func newCapsmanCollector() routerOSCollector {
@@ -32,12 +36,22 @@ func (c *capsmanCollector) init() {
 		c.descriptions["tx_"+p] = descriptionForPropertyName("capsman_station", "tx_"+p, labelNames)
 		c.descriptions["rx_"+p] = descriptionForPropertyName("capsman_station", "rx_"+p, labelNames)
 	}
+
+	capLabels := []string{"name", "address", "identity", "cap_address"}
+	c.capsDesc = description("capsman", "remote_caps", "number of CAPs joined to the controller", []string{"name", "address"})
+	c.capStateDesc = description("capsman", "remote_cap_state", "state of the CAP, 1 for the current state", append(capLabels, "state"))
+	c.capInfoDesc = description("capsman", "remote_cap_info", "board and version of the CAP", append(capLabels, "board", "version"))
+	c.capRadioDesc = description("capsman", "remote_cap_radios", "number of radios of the CAP", capLabels)
 }
 
 func (c *capsmanCollector) describe(ch chan<- *prometheus.Desc) {
 	for _, d := range c.descriptions {
 		ch <- d
 	}
+	ch <- c.capsDesc
+	ch <- c.capStateDesc
+	ch <- c.capInfoDesc
+	ch <- c.capRadioDesc
 }
 
 func (c *capsmanCollector) collect(ctx *collectorContext) error {
@@ -50,15 +64,36 @@ func (c *capsmanCollector) collect(ctx *collectorContext) error {
 		c.collectForStat(re, ctx)
 	}
 
+	return c.collectRemoteCaps(ctx)
+}
+
+func (c *capsmanCollector) collectRemoteCaps(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/caps-man/remote-cap/print", "=.proplist=identity,address,state,board,version,radios")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching capsman remote cap metrics")
+		return err
+	}
+
+	ctx.ch <- prometheus.MustNewConstMetric(c.capsDesc, prometheus.GaugeValue, float64(len(reply.Re)), ctx.device.Name, ctx.device.Address)
+	for _, re := range reply.Re {
+		labelValues := []string{ctx.device.Name, ctx.device.Address, re.Map["identity"], re.Map["address"]}
+		ctx.ch <- prometheus.MustNewConstMetric(c.capStateDesc, prometheus.GaugeValue, 1, append(labelValues, re.Map["state"])...)
+		ctx.ch <- prometheus.MustNewConstMetric(c.capInfoDesc, prometheus.GaugeValue, 1, append(labelValues, re.Map["board"], re.Map["version"])...)
+		if v, err := strconv.ParseFloat(re.Map["radios"], 64); err == nil {
+			ctx.ch <- prometheus.MustNewConstMetric(c.capRadioDesc, prometheus.GaugeValue, v, labelValues...)
+		}
+	}
+
 	return nil
 }
 
 func (c *capsmanCollector) fetch(ctx *collectorContext) ([]*proto.Sentence, error) {
 	reply, err := ctx.client.Run("/caps-man/registration-table/print", "=.proplist="+strings.Join(c.props, ","))
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device": ctx.device.Name,
-			"error":  err,
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
 		}).Error("error fetching wlan station metrics")
 		return nil, err
 	}
@@ -96,8 +131,7 @@ func (c *capsmanCollector) collectMetricForProperty(property, iface, mac, ssid s
 		v, err = parseDuration(p)
 	}
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device":   ctx.device.Name,
+		ctx.logger().WithFields(log.Fields{
 			"property": property,
 			"value":    re.Map[property],
 			"error":    err,
@@ -112,8 +146,7 @@ func (c *capsmanCollector) collectMetricForProperty(property, iface, mac, ssid s
 func (c *capsmanCollector) collectMetricForTXRXCounters(property, iface, mac, ssid string, re *proto.Sentence, ctx *collectorContext) {
 	tx, rx, err := splitStringToFloats(re.Map[property])
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device":   ctx.device.Name,
+		ctx.logger().WithFields(log.Fields{
 			"property": property,
 			"value":    re.Map[property],
 			"error":    err,
@@ -125,3 +158,7 @@ func (c *capsmanCollector) collectMetricForTXRXCounters(property, iface, mac, ss
 	ctx.ch <- prometheus.MustNewConstMetric(desc_tx, prometheus.CounterValue, tx, ctx.device.Name, ctx.device.Address, iface, mac, ssid)
 	ctx.ch <- prometheus.MustNewConstMetric(desc_rx, prometheus.CounterValue, rx, ctx.device.Name, ctx.device.Address, iface, mac, ssid)
 }
+
+func (c *capsmanCollector) requiredMenu() string {
+	return "/caps-man/registration-table"
+}
@@ -0,0 +1,123 @@
+package collector
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultCircuitProbeInterval is the default interval an unreachable device
+// is probed in while its circuit is open
+const DefaultCircuitProbeInterval = 30 * time.Second
+
+var circuitOpenDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "device", "circuit_open"),
+	"mikrotik_exporter: whether scrapes of the device are skipped after consecutive failures until it is reachable again",
+	[]string{"device"},
+	nil,
+)
+
+var errCircuitOpen = errors.New("skipped unreachable device, circuit open")
+
+// circuitBreaker skips scraping devices which failed a number of consecutive
+// scrapes, so that they do not hold up every scrape for the full timeout. The
+// devices are probed in the background until they are reachable again.
+type circuitBreaker struct {
+	threshold     int
+	probeInterval time.Duration
+
+	mu       sync.Mutex
+	failures map[string]int
+	open     map[string]bool
+	done     chan struct{}
+	stopped  bool
+}
+
+func newCircuitBreaker(threshold int, probeInterval time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold:     threshold,
+		probeInterval: probeInterval,
+		failures:      make(map[string]int),
+		open:          make(map[string]bool),
+		done:          make(chan struct{}),
+	}
+}
+
+// isOpen returns whether scrapes of the device are skipped.
+func (b *circuitBreaker) isOpen(device string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.open[device]
+}
+
+// record counts the result of a scrape of the device. The circuit opens once
+// the threshold of consecutive failures is reached, and probe is run in the
+// background until it succeeds.
+func (b *circuitBreaker) record(device string, err error, probe func() error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		delete(b.failures, device)
+		return
+	}
+
+	b.failures[device]++
+	if b.failures[device] < b.threshold || b.open[device] || b.stopped {
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"device":   device,
+		"failures": b.failures[device],
+	}).Warn("device unreachable, skipping scrapes until it recovers")
+
+	b.open[device] = true
+	go b.probe(device, probe)
+}
+
+func (b *circuitBreaker) probe(device string, probe func() error) {
+	t := time.NewTicker(b.probeInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-t.C:
+		}
+
+		if err := probe(); err != nil {
+			log.WithFields(log.Fields{
+				"device": device,
+				"error":  err,
+			}).Debug("device still unreachable")
+			continue
+		}
+
+		b.mu.Lock()
+		delete(b.open, device)
+		delete(b.failures, device)
+		b.mu.Unlock()
+
+		log.WithFields(log.Fields{
+			"device": device,
+		}).Info("device reachable again, resuming scrapes")
+		return
+	}
+}
+
+// stop ends probing the devices.
+func (b *circuitBreaker) stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.stopped {
+		b.stopped = true
+		close(b.done)
+	}
+}
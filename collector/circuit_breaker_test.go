@@ -0,0 +1,38 @@
+package collector
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	b := newCircuitBreaker(2, time.Millisecond)
+	defer b.stop()
+
+	var probes atomic.Int32
+	probe := func() error {
+		if probes.Add(1) < 3 {
+			return errors.New("unreachable")
+		}
+		return nil
+	}
+	failed := errors.New("timeout")
+
+	b.record("r1", failed, probe)
+	b.record("r1", nil, probe)
+	b.record("r1", failed, probe)
+	assert.False(t, b.isOpen("r1"), "failures must be consecutive")
+
+	b.record("r1", failed, probe)
+	assert.True(t, b.isOpen("r1"))
+	assert.False(t, b.isOpen("r2"))
+
+	assert.Eventually(t, func() bool {
+		return !b.isOpen("r1")
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, int32(3), probes.Load())
+}
@@ -1,8 +1,10 @@
 package collector
 
 import (
+	"context"
 	"crypto/md5"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -10,6 +12,7 @@ import (
 	"mikrotik-exporter/config"
 	"net"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -33,129 +36,229 @@ const (
 
 var (
 	scrapeDurationDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "scrape", "collector_duration_seconds"),
-		"mikrotik_exporter: duration of a device collector scrape",
+		prometheus.BuildFQName(namespace, "scrape", "duration_seconds"),
+		"mikrotik_exporter: duration of a device scrape",
 		[]string{"device"},
 		nil,
 	)
 	scrapeSuccessDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "scrape", "success"),
+		"mikrotik_exporter: whether a device scrape succeeded",
+		[]string{"device"},
+		nil,
+	)
+	collectorDurationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "scrape", "collector_duration_seconds"),
+		"mikrotik_exporter: duration of a device collector scrape",
+		[]string{"device", "collector"},
+		nil,
+	)
+	collectorSuccessDesc = prometheus.NewDesc(
 		prometheus.BuildFQName(namespace, "scrape", "collector_success"),
 		"mikrotik_exporter: whether a device collector succeeded",
-		[]string{"device"},
+		[]string{"device", "collector"},
+		nil,
+	)
+	leaderDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "exporter", "leader"),
+		"mikrotik_exporter: whether this instance holds the lease and polls the devices",
+		nil,
+		nil,
+	)
+	upDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "up"),
+		"mikrotik_exporter: whether the last scrape of the device succeeded",
+		[]string{"name", "address"},
+		nil,
+	)
+	collectorUnsupportedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "collector", "unsupported"),
+		"mikrotik_exporter: whether a collector is skipped as the device lacks the required package or menu",
+		[]string{"device", "collector"},
 		nil,
 	)
 )
 
 type collector struct {
-	devices     []config.Device
-	collectors  []routerOSCollector
-	timeout     time.Duration
-	enableTLS   bool
-	insecureTLS bool
+	devices       []config.Device
+	collectors    []namedCollector
+	capabilities  *capabilityCache
+	timeout       time.Duration
+	scrapeTimeout time.Duration
+	enableTLS     bool
+	insecureTLS   bool
+	caFile        string
+
+	legacyMetricTypes bool
+	isLeader          func() bool
+
+	stale           *staleCache
+	scrapeCache     *scrapeCache
+	poller          *backgroundPoller
+	warm            *warmConnections
+	warmUpDevices   bool
+	poolIdleTimeout time.Duration
+	retry           retryPolicy
+	proxy           config.Proxy
+	sourceAddress   string
+	jumpHosts       *jumpHosts
+	maxConcurrent   int
+	parallelism     int
+	breaker         *circuitBreaker
+	scrapeErrors    *scrapeErrors
+	connections     *connectionStats
+	statuses        *deviceStatuses
+	lastErrorInfo   bool
+
+	collectorIntervals map[string]time.Duration
+	intervalCache      *intervalCache
+
+	metricFilterConfig map[string]config.MetricFilter
+	metricFilters      map[string]*metricFilter
+	relabelConfig      []config.RelabelRule
+	relabelRules       []relabelRule
+	customCollectors   []config.CustomCollector
+	scripts            []config.Script
+	bandwidthTests     []config.BandwidthTest
+
+	seriesLimit           int
+	collectorSeriesLimits map[string]int
+	seriesLimited         *seriesLimited
+
+	identityLabels string
+	identities     *identityCache
+
+	resolvedMu sync.Mutex
+	resolved   []config.Device
+
+	maintenanceWindows []config.MaintenanceWindow
+	maintenance        []*maintenanceWindow
+
+	rateLimit  float64
+	rateBurst  int
+	limitersMu sync.Mutex
+	limiters   map[string]*rateLimiter
+	// deviceRateLimits is set if devices have their own rate limits
+	deviceRateLimits bool
+
+	defaultFeatures []string
+	deviceLabels    bool
+	profiles        map[string]config.Profile
+	defaults        config.Profile
+	credentials     *credentialTracker
+	tracer          Tracer
 }
 
 // WithBGP enables BGP routing metrics
 func WithBGP() Option {
 	return func(c *collector) {
-		c.collectors = append(c.collectors, newBGPCollector())
+		c.add("bgp", newBGPCollector())
 	}
 }
 
 // WithRoutes enables routing table metrics
 func WithRoutes() Option {
 	return func(c *collector) {
-		c.collectors = append(c.collectors, newRoutesCollector())
+		c.add("routes", newRoutesCollector())
 	}
 }
 
 // WithDHCP enables DHCP serrver metrics
 func WithDHCP() Option {
 	return func(c *collector) {
-		c.collectors = append(c.collectors, newDHCPCollector())
+		c.add("dhcp", newDHCPCollector())
 	}
 }
 
-// WithDHCPL enables DHCP server leases
-func WithDHCPL() Option {
+// WithDHCPL enables DHCP server leases, labelled with their comments if
+// commentLabels is set
+func WithDHCPL(commentLabels bool) Option {
 	return func(c *collector) {
-		c.collectors = append(c.collectors, newDHCPLCollector())
+		c.add("dhcpl", newDHCPLCollector(commentLabels))
 	}
 }
 
 // WithDHCPv6 enables DHCPv6 serrver metrics
 func WithDHCPv6() Option {
 	return func(c *collector) {
-		c.collectors = append(c.collectors, newDHCPv6Collector())
+		c.add("dhcpv6", newDHCPv6Collector())
 	}
 }
 
 // WithFirmware grab installed firmware and version
 func WithFirmware() Option {
 	return func(c *collector) {
-		c.collectors = append(c.collectors, newFirmwareCollector())
+		c.add("firmware", newFirmwareCollector())
+	}
+}
+
+// WithHistory enables configuration change metrics
+func WithHistory() Option {
+	return func(c *collector) {
+		c.add("history", newHistoryCollector())
 	}
 }
 
 // WithHealth enables board Health metrics
 func WithHealth() Option {
 	return func(c *collector) {
-		c.collectors = append(c.collectors, newhealthCollector())
+		c.add("health", newhealthCollector())
 	}
 }
 
 // WithPOE enables PoE metrics
 func WithPOE() Option {
 	return func(c *collector) {
-		c.collectors = append(c.collectors, newPOECollector())
+		c.add("poe", newPOECollector())
 	}
 }
 
 // WithPools enables IP(v6) pool metrics
 func WithPools() Option {
 	return func(c *collector) {
-		c.collectors = append(c.collectors, newPoolCollector())
+		c.add("pools", newPoolCollector())
 	}
 }
 
 // WithOptics enables optical diagnstocs
 func WithOptics() Option {
 	return func(c *collector) {
-		c.collectors = append(c.collectors, newOpticsCollector())
+		c.add("optics", newOpticsCollector())
 	}
 }
 
 // WithW60G enables w60g metrics
 func WithW60G() Option {
 	return func(c *collector) {
-		c.collectors = append(c.collectors, neww60gInterfaceCollector())
+		c.add("w60g", neww60gInterfaceCollector())
 	}
 }
 
 // WithWlanSTA enables wlan STA metrics
 func WithWlanSTA() Option {
 	return func(c *collector) {
-		c.collectors = append(c.collectors, newWlanSTACollector())
+		c.add("wlansta", newWlanSTACollector())
 	}
 }
 
 // WithWlanIF enables wireless interface metrics
 func WithCapsman() Option {
 	return func(c *collector) {
-		c.collectors = append(c.collectors, newCapsmanCollector())
+		c.add("capsman", newCapsmanCollector())
 	}
 }
 
 // WithWlanIF enables wireless interface metrics
 func WithWlanIF() Option {
 	return func(c *collector) {
-		c.collectors = append(c.collectors, newWlanIFCollector())
+		c.add("wlanif", newWlanIFCollector())
 	}
 }
 
 // WithMonitor enables ethernet monitor collector metrics
 func Monitor() Option {
 	return func(c *collector) {
-		c.collectors = append(c.collectors, newMonitorCollector())
+		c.add("monitor", newMonitorCollector())
 	}
 }
 
@@ -174,231 +277,1673 @@ func WithTLS(insecure bool) Option {
 	}
 }
 
-// WithIpsec enables ipsec metrics
-func WithIpsec() Option {
+// WithTLSCA verifies the certificates of the devices with the CA certificates
+// in file instead of the system roots
+func WithTLSCA(file string) Option {
 	return func(c *collector) {
-		c.collectors = append(c.collectors, newIpsecCollector())
+		c.caFile = file
 	}
 }
 
-// WithConntrack enables firewall/NAT connection tracking metrics
-func WithConntrack() Option {
+// WithCounterWrapCorrection corrects wrapping 32-bit interface counters
+func WithCounterWrapCorrection() Option {
 	return func(c *collector) {
-		c.collectors = append(c.collectors, newConntrackCollector())
+		for _, co := range c.collectors {
+			if ic, ok := co.routerOSCollector.(*interfaceCollector); ok {
+				ic.wraps = newCounterWraps()
+			}
+		}
 	}
 }
 
-// WithLte enables lte metrics
-func WithLte() Option {
+// WithLegacyMetricTypes exports metrics with the types of earlier releases
+func WithLegacyMetricTypes() Option {
 	return func(c *collector) {
-		c.collectors = append(c.collectors, newLteCollector())
+		c.legacyMetricTypes = true
 	}
 }
 
-// WithNetwatch enables netwatch metrics
-func WithNetwatch() Option {
+// WithLeaderElection only polls devices while isLeader reports true
+func WithLeaderElection(isLeader func() bool) Option {
 	return func(c *collector) {
-		c.collectors = append(c.collectors, newNetwatchCollector())
+		c.isLeader = isLeader
 	}
 }
 
-// Option applies options to collector
-type Option func(*collector)
+// WithRateLimit limits the API commands per second sent to each device
+func WithRateLimit(rate float64, burst int) Option {
+	return func(c *collector) {
+		c.rateLimit = rate
+		c.rateBurst = burst
+	}
+}
 
-// NewCollector creates a collector instance
-func NewCollector(cfg *config.Config, opts ...Option) (prometheus.Collector, error) {
-	log.WithFields(log.Fields{
-		"numDevices": len(cfg.Devices),
-	}).Info("setting up collector for devices")
+// WithStaleGracePeriod serves the metrics of the last successful scrape of an
+// unreachable device for the grace period
+func WithStaleGracePeriod(grace time.Duration) Option {
+	return func(c *collector) {
+		c.stale = newStaleCache(grace)
+	}
+}
 
-	c := &collector{
-		devices: cfg.Devices,
-		timeout: DefaultTimeout,
-		collectors: []routerOSCollector{
-			newInterfaceCollector(),
-			newResourceCollector(),
-		},
+// WithScrapeCacheTTL serves the metrics of devices scraped within the TTL
+// from memory and collapses concurrent scrapes of a device into one
+func WithScrapeCacheTTL(ttl time.Duration) Option {
+	return func(c *collector) {
+		c.scrapeCache = newScrapeCache(ttl)
 	}
+}
 
-	for _, o := range opts {
-		o(c)
+// WithBackgroundPolling scrapes the devices in the interval in the background,
+// the scrapes serve the metrics of the last poll
+func WithBackgroundPolling(interval time.Duration) Option {
+	return func(c *collector) {
+		c.poller = newBackgroundPoller(interval)
 	}
+}
 
-	return c, nil
+// WithCollectorIntervals runs the named collectors at most once per interval
+// against each device, serving their last metrics in between
+func WithCollectorIntervals(intervals map[string]time.Duration) Option {
+	return func(c *collector) {
+		c.collectorIntervals = intervals
+		c.intervalCache = newIntervalCache()
+	}
 }
 
-// Describe implements the prometheus.Collector interface.
-func (c *collector) Describe(ch chan<- *prometheus.Desc) {
-	ch <- scrapeDurationDesc
-	ch <- scrapeSuccessDesc
+// WithMetricFilters drops the metrics of the named collectors not selected by
+// their filter
+func WithMetricFilters(filters map[string]config.MetricFilter) Option {
+	return func(c *collector) {
+		c.metricFilterConfig = filters
+	}
+}
 
-	for _, co := range c.collectors {
-		co.describe(ch)
+// WithRelabeling rewrites the labels of the metrics of the devices by the
+// rules
+func WithRelabeling(rules []config.RelabelRule) Option {
+	return func(c *collector) {
+		c.relabelConfig = rules
 	}
 }
 
-// Collect implements the prometheus.Collector interface.
-func (c *collector) Collect(ch chan<- prometheus.Metric) {
-	wg := sync.WaitGroup{}
+// WithCustomCollectors exports the replies of the API commands configured by
+// the user
+func WithCustomCollectors(cs []config.CustomCollector) Option {
+	return func(c *collector) {
+		c.customCollectors = cs
+	}
+}
 
-	var realDevices []config.Device
+// WithScripts runs the scripts on the devices and exports the values they
+// print
+func WithScripts(scripts []config.Script) Option {
+	return func(c *collector) {
+		c.scripts = scripts
+	}
+}
 
-	for _, dev := range c.devices {
-		if (config.SrvRecord{}) != dev.Srv {
-			log.WithFields(log.Fields{
-				"SRV": dev.Srv.Record,
-			}).Info("SRV configuration detected")
-			conf, _ := dns.ClientConfigFromFile("/etc/resolv.conf")
-			dnsServer := net.JoinHostPort(conf.Servers[0], strconv.Itoa(dnsPort))
-			if (config.DnsServer{}) != dev.Srv.Dns {
-				dnsServer = net.JoinHostPort(dev.Srv.Dns.Address, strconv.Itoa(dev.Srv.Dns.Port))
-				log.WithFields(log.Fields{
-					"DnsServer": dnsServer,
-				}).Info("Custom DNS config detected")
-			}
-			dnsMsg := new(dns.Msg)
-			dnsCli := new(dns.Client)
+// WithBandwidthTests runs the bandwidth tests on their schedules and exports
+// the results of their last runs
+func WithBandwidthTests(tests []config.BandwidthTest) Option {
+	return func(c *collector) {
+		c.bandwidthTests = tests
+	}
+}
 
-			dnsMsg.RecursionDesired = true
-			dnsMsg.SetQuestion(dns.Fqdn(dev.Srv.Record), dns.TypeSRV)
-			r, _, err := dnsCli.Exchange(dnsMsg, dnsServer)
-			if err != nil {
-				os.Exit(1)
-			}
+// WithSeriesLimits truncates the series of each device and of the named
+// collectors per device to the limits
+func WithSeriesLimits(device int, collectors map[string]int) Option {
+	return func(c *collector) {
+		c.seriesLimit = device
+		c.collectorSeriesLimits = collectors
+	}
+}
 
-			for _, k := range r.Answer {
-				if s, ok := k.(*dns.SRV); ok {
-					d := config.Device{}
-					d.Name = strings.TrimRight(s.Target, ".")
-					d.Address = strings.TrimRight(s.Target, ".")
-					d.User = dev.User
-					d.Password = dev.Password
-					_ = c.getIdentity(&d)
-					realDevices = append(realDevices, d)
-				}
-			}
-		} else {
-			realDevices = append(realDevices, dev)
-		}
+// WithIdentityLabels labels the metrics of the devices with their system
+// identity, replacing the name of the device or adding an identity label
+func WithIdentityLabels(mode string) Option {
+	return func(c *collector) {
+		c.identityLabels = mode
+		c.identities = newIdentityCache()
 	}
+}
 
-	wg.Add(len(realDevices))
+// WithWarmUp connects to all devices in the background so the first scrape
+// can reuse the authenticated connections
+func WithWarmUp() Option {
+	return func(c *collector) {
+		c.warmUpDevices = true
+	}
+}
 
-	for _, dev := range realDevices {
-		go func(d config.Device) {
-			c.collectForDevice(d, ch)
-			wg.Done()
-		}(dev)
+// WithScrapeTimeout aborts scraping a device after the timeout
+func WithScrapeTimeout(d time.Duration) Option {
+	return func(c *collector) {
+		c.scrapeTimeout = d
+	}
+}
+
+// WithConnectionPool keeps the connections to the devices open between
+// scrapes until they were idle for the timeout
+func WithConnectionPool(idleTimeout time.Duration) Option {
+	return func(c *collector) {
+		c.poolIdleTimeout = idleTimeout
 	}
+}
 
-	wg.Wait()
+// WithRetry sets how often connecting to a device and commands failing due to
+// a dropped connection are retried per scrape, doubling the backoff each time
+func WithRetry(attempts int, backoff time.Duration) Option {
+	return func(c *collector) {
+		c.retry = retryPolicy{attempts: attempts, backoff: backoff}
+	}
 }
 
-func (c *collector) getIdentity(d *config.Device) error {
-	cl, err := c.connect(d)
-	if err != nil {
-		log.WithFields(log.Fields{
-			"device": d.Name,
-			"error":  err,
-		}).Error("error dialing device fetching identity")
-		return err
+// WithProxy connects to the devices through a SOCKS5 proxy or SSH jump host
+// unless a device has its own proxy
+func WithProxy(p config.Proxy) Option {
+	return func(c *collector) {
+		c.proxy = p
 	}
-	defer cl.Close()
-	reply, err := cl.Run("/system/identity/print")
-	if err != nil {
-		log.WithFields(log.Fields{
-			"device": d.Name,
-			"error":  err,
-		}).Error("error fetching ethernet interfaces")
-		return err
+}
+
+// WithSourceAddress makes the connections to the devices originate from the
+// address unless a device has its own source address
+func WithSourceAddress(address string) Option {
+	return func(c *collector) {
+		c.sourceAddress = address
 	}
-	for _, id := range reply.Re {
-		d.Name = id.Map["name"]
+}
+
+// WithLastErrorInfo exports the class of the last error scraping each device
+func WithLastErrorInfo() Option {
+	return func(c *collector) {
+		c.lastErrorInfo = true
 	}
-	return nil
 }
 
-func (c *collector) collectForDevice(d config.Device, ch chan<- prometheus.Metric) {
-	begin := time.Now()
+// WithMaxConcurrentScrapes limits the devices scraped at the same time
+func WithMaxConcurrentScrapes(n int) Option {
+	return func(c *collector) {
+		c.maxConcurrent = n
+	}
+}
 
-	err := c.connectAndCollect(&d, ch)
+// WithParallelism runs up to n collectors of a device at the same time over
+// the connection to the device
+func WithParallelism(n int) Option {
+	return func(c *collector) {
+		c.parallelism = n
+	}
+}
 
-	duration := time.Since(begin)
-	var success float64
-	if err != nil {
-		log.Errorf("ERROR: %s collector failed after %fs: %s", d.Name, duration.Seconds(), err)
-		success = 0
-	} else {
-		log.Debugf("OK: %s collector succeeded after %fs.", d.Name, duration.Seconds())
-		success = 1
+// WithCircuitBreaker skips devices after consecutive failed scrapes and
+// probes them in the interval until they are reachable again
+func WithCircuitBreaker(failures int, probeInterval time.Duration) Option {
+	return func(c *collector) {
+		c.breaker = newCircuitBreaker(failures, probeInterval)
 	}
+}
 
-	ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, duration.Seconds(), d.Name)
-	ch <- prometheus.MustNewConstMetric(scrapeSuccessDesc, prometheus.GaugeValue, success, d.Name)
+// WithMaintenanceWindows pauses scraping devices during the windows
+func WithMaintenanceWindows(windows []config.MaintenanceWindow) Option {
+	return func(c *collector) {
+		c.maintenanceWindows = windows
+	}
 }
 
-func (c *collector) connectAndCollect(d *config.Device, ch chan<- prometheus.Metric) error {
-	cl, err := c.connect(d)
-	if err != nil {
-		log.WithFields(log.Fields{
-			"device": d.Name,
-			"error":  err,
-		}).Error("error dialing device")
-		return err
+// WithIpsec enables ipsec metrics
+func WithIpsec() Option {
+	return func(c *collector) {
+		c.add("ipsec", newIpsecCollector())
 	}
-	defer cl.Close()
+}
 
-	for _, co := range c.collectors {
-		ctx := &collectorContext{ch, d, cl}
-		err = co.collect(ctx)
-		if err != nil {
-			return err
-		}
+// WithConntrack enables firewall/NAT connection tracking metrics
+func WithConntrack() Option {
+	return func(c *collector) {
+		c.add("conntrack", newConntrackCollector())
 	}
+}
 
-	return nil
+// WithLte enables lte metrics
+func WithLte() Option {
+	return func(c *collector) {
+		c.add("lte", newLteCollector())
+	}
 }
 
-func (c *collector) connect(d *config.Device) (*routeros.Client, error) {
-	var conn net.Conn
-	var err error
+// WithNetwatch enables netwatch metrics
+func WithNetwatch() Option {
+	return func(c *collector) {
+		c.add("netwatch", newNetwatchCollector())
+	}
+}
 
-	log.WithField("device", d.Name).Debug("trying to Dial")
-	if !c.enableTLS {
-		if (d.Port) == "" {
-			d.Port = apiPort
-		}
-		conn, err = net.DialTimeout("tcp", d.Address+":"+d.Port, c.timeout)
-		if err != nil {
-			return nil, err
-		}
-		//		return routeros.DialTimeout(d.Address+apiPort, d.User, d.Password, c.timeout)
-	} else {
-		tlsCfg := &tls.Config{
-			InsecureSkipVerify: c.insecureTLS,
-		}
-		if (d.Port) == "" {
-			d.Port = apiPortTLS
-		}
-		conn, err = tls.DialWithDialer(&net.Dialer{
-			Timeout: c.timeout,
-		},
-			"tcp", d.Address+":"+d.Port, tlsCfg)
-		if err != nil {
-			return nil, err
-		}
+// WithTorch enables rate limited torch top talkers sampling
+func WithTorch(cfg config.TorchConfig) Option {
+	return func(c *collector) {
+		c.add("torch", newTorchCollector(cfg))
 	}
-	log.WithField("device", d.Name).Debug("done dialing")
+}
 
-	client, err := routeros.NewClient(conn)
+// WithTraffic enables streaming interface rates from monitor-traffic
+func WithTraffic(interfaces []string) Option {
+	return func(c *collector) {
+		c.add("traffic", newTrafficCollector(interfaces, c.connect))
+	}
+}
+
+// WithWireguard enables wireguard peer metrics
+func WithWireguard() Option {
+	return func(c *collector) {
+		c.add("wireguard", newWireguardCollector())
+	}
+}
+
+// WithOSPF enables OSPF neighbor and LSA metrics
+func WithOSPF() Option {
+	return func(c *collector) {
+		c.add("ospf", newOSPFCollector())
+	}
+}
+
+// WithQueues enables simple queue metrics
+func WithQueues() Option {
+	return func(c *collector) {
+		c.add("queues", newQueueCollector())
+	}
+}
+
+// WithNAT enables NAT rule counters
+func WithNAT() Option {
+	return func(c *collector) {
+		c.add("nat", newFirewallCollector("nat"))
+	}
+}
+
+// WithMangle enables mangle rule counters
+func WithMangle() Option {
+	return func(c *collector) {
+		c.add("mangle", newFirewallCollector("mangle"))
+	}
+}
+
+// WithBridgeHosts enables bridge host table metrics
+func WithBridgeHosts() Option {
+	return func(c *collector) {
+		c.add("bridge_hosts", newBridgeHostCollector())
+	}
+}
+
+// WithSTP enables bridge spanning tree metrics
+func WithSTP() Option {
+	return func(c *collector) {
+		c.add("stp", newSTPCollector())
+	}
+}
+
+// WithVRRP enables VRRP metrics
+func WithVRRP() Option {
+	return func(c *collector) {
+		c.add("vrrp", newVRRPCollector())
+	}
+}
+
+// WithPPP enables PPP session metrics, per session or aggregated by service
+func WithPPP(perSession bool) Option {
+	return func(c *collector) {
+		c.add("ppp", newPPPCollector(perSession))
+	}
+}
+
+// WithPPPoE enables PPPoE server metrics
+func WithPPPoE() Option {
+	return func(c *collector) {
+		c.add("pppoe", newPPPoECollector())
+	}
+}
+
+// WithHotspot enables hotspot user metrics
+func WithHotspot() Option {
+	return func(c *collector) {
+		c.add("hotspot", newHotspotCollector())
+	}
+}
+
+// WithUserManager enables User Manager metrics
+func WithUserManager() Option {
+	return func(c *collector) {
+		c.add("usermanager", newUserManagerCollector())
+	}
+}
+
+// WithDNS enables DNS cache metrics
+func WithDNS() Option {
+	return func(c *collector) {
+		c.add("dns", newDNSCollector())
+	}
+}
+
+// WithNTP enables NTP client metrics
+func WithNTP() Option {
+	return func(c *collector) {
+		c.add("ntp", newNTPCollector())
+	}
+}
+
+// WithDisk enables disk metrics
+func WithDisk() Option {
+	return func(c *collector) {
+		c.add("disk", newDiskCollector())
+	}
+}
+
+// WithContainer enables container metrics
+func WithContainer() Option {
+	return func(c *collector) {
+		c.add("container", newContainerCollector())
+	}
+}
+
+// WithGPS enables GPS metrics
+func WithGPS() Option {
+	return func(c *collector) {
+		c.add("gps", newGPSCollector())
+	}
+}
+
+// WithMPLS enables MPLS and LDP metrics
+func WithMPLS() Option {
+	return func(c *collector) {
+		c.add("mpls", newMPLSCollector())
+	}
+}
+
+// WithTunnels enables EoIP, GRE and IPIP tunnel metrics
+func WithTunnels() Option {
+	return func(c *collector) {
+		c.add("tunnels", newTunnelCollector())
+	}
+}
+
+// WithVXLAN enables VXLAN metrics
+func WithVXLAN() Option {
+	return func(c *collector) {
+		c.add("vxlan", newVXLANCollector())
+	}
+}
+
+// WithBonding enables bonding metrics
+func WithBonding() Option {
+	return func(c *collector) {
+		c.add("bonding", newBondingCollector())
+	}
+}
+
+// WithSwitchPorts enables switch chip port metrics
+func WithSwitchPorts() Option {
+	return func(c *collector) {
+		c.add("switch_ports", newSwitchPortCollector())
+	}
+}
+
+// WithCPU enables per-core CPU metrics
+func WithCPU() Option {
+	return func(c *collector) {
+		c.add("cpu", newCPUCollector())
+	}
+}
+
+// WithDot1x enables 802.1X session metrics
+func WithDot1x() Option {
+	return func(c *collector) {
+		c.add("dot1x", newDot1xCollector())
+	}
+}
+
+// WithIPServices enables management service metrics
+func WithIPServices() Option {
+	return func(c *collector) {
+		c.add("ip_services", newIPServiceCollector())
+	}
+}
+
+// WithLog enables log entry counters
+func WithLog() Option {
+	return func(c *collector) {
+		c.add("log", newLogCollector())
+	}
+}
+
+// WithScheduler enables scheduler and script metrics
+func WithScheduler() Option {
+	return func(c *collector) {
+		c.add("scheduler", newSchedulerCollector())
+	}
+}
+
+// WithInterfaceQueues enables interface queue metrics
+func WithInterfaceQueues() Option {
+	return func(c *collector) {
+		c.add("interface_queues", newInterfaceQueueCollector())
+	}
+}
+
+// WithVLAN enables VLAN interface metrics
+func WithVLAN() Option {
+	return func(c *collector) {
+		c.add("vlan", newVLANCollector())
+	}
+}
+
+// WithNeighbors enables neighbor discovery metrics
+func WithNeighbors() Option {
+	return func(c *collector) {
+		c.add("neighbors", newNeighborCollector())
+	}
+}
+
+// WithRIP enables RIP metrics
+func WithRIP() Option {
+	return func(c *collector) {
+		c.add("rip", newRIPCollector())
+	}
+}
+
+// WithKidControl enables kid control metrics
+func WithKidControl() Option {
+	return func(c *collector) {
+		c.add("kid_control", newKidControlCollector())
+	}
+}
+
+// WithWebProxy enables web proxy metrics
+func WithWebProxy() Option {
+	return func(c *collector) {
+		c.add("web_proxy", newWebProxyCollector())
+	}
+}
+
+// WithUPnP enables UPnP metrics
+func WithUPnP() Option {
+	return func(c *collector) {
+		c.add("upnp", newUPnPCollector())
+	}
+}
+
+// WithDHCPClient enables DHCP client metrics
+func WithDHCPClient() Option {
+	return func(c *collector) {
+		c.add("dhcp_client", newDHCPClientCollector())
+	}
+}
+
+// WithDHCPv6Client enables DHCPv6 client metrics
+func WithDHCPv6Client() Option {
+	return func(c *collector) {
+		c.add("dhcpv6_client", newDHCPv6ClientCollector())
+	}
+}
+
+// WithRADIUS enables RADIUS client metrics
+func WithRADIUS() Option {
+	return func(c *collector) {
+		c.add("radius", newRADIUSCollector())
+	}
+}
+
+// WithZeroTier enables ZeroTier metrics
+func WithZeroTier() Option {
+	return func(c *collector) {
+		c.add("zerotier", newZeroTierCollector())
+	}
+}
+
+// WithSMB enables SMB service metrics
+func WithSMB() Option {
+	return func(c *collector) {
+		c.add("smb", newSMBCollector())
+	}
+}
+
+// WithWlanSpectrum enables wireless spectrum metrics
+func WithWlanSpectrum() Option {
+	return func(c *collector) {
+		c.add("wlan_spectrum", newWlanSpectrumCollector())
+	}
+}
+
+// WithTrafficFlow enables traffic flow export metrics
+func WithTrafficFlow() Option {
+	return func(c *collector) {
+		c.add("traffic_flow", newTrafficFlowCollector())
+	}
+}
+
+// WithBackToHome enables Back To Home VPN user metrics
+func WithBackToHome() Option {
+	return func(c *collector) {
+		c.add("back_to_home", newBackToHomeCollector())
+	}
+}
+
+// WithProfile enables rate limited CPU usage by process sampled with
+// /tool profile
+func WithProfile(cfg config.ProfileConfig) Option {
+	return func(c *collector) {
+		c.add("profile", newProfileCollector(cfg))
+	}
+}
+
+// WithARP enables ARP and IPv6 neighbor table metrics
+func WithARP() Option {
+	return func(c *collector) {
+		c.add("arp", newARPCollector())
+	}
+}
+
+// WithPing enables pinging the targets from the devices
+func WithPing(cfg config.PingConfig) Option {
+	return func(c *collector) {
+		c.add("ping", newPingCollector(cfg))
+	}
+}
+
+// WithDefaultFeatures sets the features collected from devices which do not
+// select their own features
+func WithDefaultFeatures(features []string) Option {
+	return func(c *collector) {
+		c.defaultFeatures = features
+	}
+}
+
+// Option applies options to collector
+type Option func(*collector)
+
+// namedCollector is a routerOSCollector registered under its feature name
+type namedCollector struct {
+	name string
+	routerOSCollector
+}
+
+func (c *collector) add(name string, co routerOSCollector) {
+	c.collectors = append(c.collectors, namedCollector{name, co})
+}
+
+// NewCollector creates a collector instance
+func NewCollector(cfg *config.Config, opts ...Option) (prometheus.Collector, error) {
+	log.WithFields(log.Fields{
+		"numDevices": len(cfg.Devices),
+	}).Info("setting up collector for devices")
+
+	c := &collector{
+		devices:      cfg.Devices,
+		timeout:      DefaultTimeout,
+		capabilities: newCapabilityCache(),
+		limiters:     make(map[string]*rateLimiter),
+		profiles:     cfg.Profiles,
+		defaults:     cfg.Defaults,
+		credentials:  newCredentialTracker(),
+		jumpHosts:    newJumpHosts(),
+		scrapeErrors: newScrapeErrors(),
+		connections:  newConnectionStats(),
+		statuses:     newDeviceStatuses(),
+		retry:        retryPolicy{attempts: DefaultRetryAttempts, backoff: DefaultRetryBackoff},
+		deviceLabels: cfg.DeviceLabels(),
+	}
+	c.deviceRateLimits = cfg.DeviceRateLimits()
+	c.seriesLimited = newSeriesLimited()
+	addParseErrorHook.Do(func() {
+		log.AddHook(parseErrors)
+	})
+	c.add("interface", newInterfaceCollector())
+	c.add("resource", newResourceCollector())
+
+	for _, o := range opts {
+		o(c)
+	}
+
+	for _, w := range c.maintenanceWindows {
+		m, err := newMaintenanceWindow(w)
+		if err != nil {
+			return nil, err
+		}
+		c.maintenance = append(c.maintenance, m)
+	}
+
+	if c.warmUpDevices || c.poolIdleTimeout > 0 {
+		maxAge := warmConnectionMaxAge
+		if c.poolIdleTimeout > 0 {
+			maxAge = c.poolIdleTimeout
+		}
+		c.warm = newWarmConnections(maxAge)
+	}
+
+	c.metricFilters = make(map[string]*metricFilter, len(c.metricFilterConfig))
+	for name, f := range c.metricFilterConfig {
+		mf, err := newMetricFilter(f)
+		if err != nil {
+			return nil, fmt.Errorf("collector %s: %w", name, err)
+		}
+		c.metricFilters[name] = mf
+	}
+
+	if err := validIdentityMode(c.identityLabels); err != nil {
+		return nil, err
+	}
+
+	rules, err := newRelabelRules(c.relabelConfig)
+	if err != nil {
+		return nil, err
+	}
+	c.relabelRules = rules
+
+	for _, cc := range c.customCollectors {
+		co, err := newCustomCollector(cc)
+		if err != nil {
+			return nil, fmt.Errorf("custom collector %s: %w", cc.Name, err)
+		}
+		name := "custom_" + cc.Name
+		if c.hasCollector(name) {
+			return nil, fmt.Errorf("duplicate custom collector %s", cc.Name)
+		}
+		c.add(name, co)
+	}
+	if len(c.scripts) > 0 {
+		co, err := newScriptCollector(c.scripts)
+		if err != nil {
+			return nil, err
+		}
+		c.add("scripts", co)
+	}
+	if len(c.bandwidthTests) > 0 {
+		co, err := newBandwidthTestCollector(c.bandwidthTests)
+		if err != nil {
+			return nil, err
+		}
+		co.devices = c.Devices
+		co.dial = c.connect
+		co.skip = func(device string) bool {
+			return (c.isLeader != nil && !c.isLeader()) || c.inMaintenance(device, time.Now())
+		}
+		co.start()
+		c.add("bandwidth_test", co)
+	}
+
+	for name := range c.collectorIntervals {
+		if !c.hasCollector(name) {
+			log.WithFields(log.Fields{
+				"collector": name,
+			}).Warn("collector in collector intervals is not enabled")
+		}
+	}
+	for name := range c.metricFilters {
+		if !c.hasCollector(name) {
+			log.WithFields(log.Fields{
+				"collector": name,
+			}).Warn("collector in metric filters is not enabled")
+		}
+	}
+	for name := range c.collectorSeriesLimits {
+		if !c.hasCollector(name) {
+			log.WithFields(log.Fields{
+				"collector": name,
+			}).Warn("collector in collector series limits is not enabled")
+		}
+	}
+
+	if c.warmUpDevices {
+		go c.warmUp()
+	}
+
+	if c.poller != nil && len(c.devices) == 0 {
+		// nothing to poll, e.g. for probes, which scrape their targets
+		// on demand
+		c.poller = nil
+	}
+	if c.poller != nil {
+		go c.poll()
+	}
+
+	return c, nil
+}
+
+func (c *collector) hasCollector(name string) bool {
+	return slices.ContainsFunc(c.collectors, func(co namedCollector) bool {
+		return co.name == name
+	})
+}
+
+// stoppable is implemented by collectors which keep background work running
+// between scrapes.
+type stoppable interface {
+	stop()
+}
+
+// Close stops the background work of the collector and closes the
+// connections kept open for the next scrape, e.g. when the config is
+// reloaded. The collector must not be used afterwards.
+func (c *collector) Close() error {
+	if c.warm != nil {
+		c.warm.closeAll()
+	}
+
+	if c.breaker != nil {
+		c.breaker.stop()
+	}
+
+	if c.poller != nil {
+		c.poller.stop()
+	}
+
+	c.jumpHosts.closeAll()
+
+	for _, co := range c.collectors {
+		if s, ok := co.routerOSCollector.(stoppable); ok {
+			s.stop()
+		}
+	}
+
+	return nil
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	if c.deviceLabels || len(c.relabelRules) > 0 || c.identityLabels == identityAdd {
+		// the labels of the devices differ, which is only possible with an
+		// unchecked collector
+		return
+	}
+
+	ch <- scrapeDurationDesc
+	ch <- scrapeSuccessDesc
+	ch <- collectorDurationDesc
+	ch <- collectorSuccessDesc
+	ch <- scrapeErrorsDesc
+	ch <- collectorErrorsDesc
+	ch <- parseErrorsDesc
+	ch <- cardinalityLimitedDesc
+	ch <- seriesDroppedDesc
+	ch <- connectionsOpenDesc
+	ch <- dialAttemptsDesc
+	ch <- dialFailuresDesc
+	ch <- reconnectsDesc
+	ch <- resolveErrorsDesc
+	if c.lastErrorInfo {
+		ch <- lastErrorDesc
+	}
+	ch <- upDesc
+	ch <- collectorUnsupportedDesc
+
+	if c.stale != nil {
+		ch <- staleDesc
+	}
+
+	if c.poller != nil {
+		ch <- pollAgeDesc
+	}
+
+	if len(c.maintenance) > 0 {
+		ch <- maintenanceDesc
+	}
+
+	if c.breaker != nil {
+		ch <- circuitOpenDesc
+	}
+
+	if c.rateLimit > 0 || c.deviceRateLimits {
+		ch <- rateLimitedDesc
+		ch <- rateLimitWaitDesc
+	}
+
+	if c.isLeader != nil {
+		ch <- leaderDesc
+	}
+
+	for _, co := range c.collectors {
+		co.describe(ch)
+	}
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	c.collect(ch, &selection{collectors: c.collectors})
+}
+
+// collect scrapes the devices with the selected collectors.
+func (c *collector) collect(ch chan<- prometheus.Metric, sel *selection) {
+	if c.isLeader != nil {
+		leader := c.isLeader()
+		v := 0.0
+		if leader {
+			v = 1.0
+		}
+		ch <- prometheus.MustNewConstMetric(leaderDesc, prometheus.GaugeValue, v)
+
+		if !leader {
+			return
+		}
+	}
+
+	for _, dev := range c.devices {
+		if (config.SrvRecord{}) != dev.Srv {
+			c.connections.collectResolveErrors(ch, dev.Name)
+		}
+	}
+
+	if c.poller != nil {
+		c.poller.collect(ch)
+		return
+	}
+
+	s := c.startTrace("scrape")
+	defer c.finishTrace(s)
+
+	devices := c.Resolve()
+	defer c.retain(devices)
+
+	c.forEachDevice(devices, func(d config.Device) {
+		if c.scrapeCache == nil {
+			c.collectForDevice(d, ch, sel, s)
+			return
+		}
+
+		metrics := c.scrapeCache.get(sel.cacheKey(d.Name), func(ch chan<- prometheus.Metric) {
+			c.collectForDevice(d, ch, sel, s)
+		})
+		for _, m := range metrics {
+			ch <- m
+		}
+	})
+}
+
+// retain drops the state kept for the devices which are gone, e.g. from an
+// SRV record.
+func (c *collector) retain(devices []config.Device) {
+	if c.poller != nil {
+		c.poller.retain(devices)
+	}
+	for _, co := range c.collectors {
+		if ic, ok := co.routerOSCollector.(*interfaceCollector); ok && ic.wraps != nil {
+			ic.wraps.retain(devices)
+		}
+	}
+}
+
+// forEachDevice runs f for the devices on a pool of workers in the order they
+// were configured in.
+func (c *collector) forEachDevice(devices []config.Device, f func(d config.Device)) {
+	wg := sync.WaitGroup{}
+
+	workers := len(devices)
+	if c.maxConcurrent > 0 && c.maxConcurrent < workers {
+		workers = c.maxConcurrent
+	}
+	queue := make(chan config.Device)
+
+	wg.Add(workers)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for d := range queue {
+				f(d)
+			}
+			wg.Done()
+		}()
+	}
+
+	for _, dev := range devices {
+		queue <- dev
+	}
+	close(queue)
+
+	wg.Wait()
+}
+
+// Resolve expands the devices configured by SRV records, Consul or MNDP and
+// returns the devices to scrape.
+func (c *collector) Resolve() []config.Device {
+	devices := c.resolveDevices()
+
+	c.resolvedMu.Lock()
+	c.resolved = devices
+	c.resolvedMu.Unlock()
+
+	return devices
+}
+
+// Devices returns the devices of the last scrape, with those configured by
+// SRV records, Consul or MNDP expanded, or the configured devices before the
+// first scrape.
+func (c *collector) Devices() []config.Device {
+	c.resolvedMu.Lock()
+	defer c.resolvedMu.Unlock()
+
+	if c.resolved == nil {
+		return c.devices
+	}
+
+	return c.resolved
+}
+
+// resolveDevices expands the devices configured by SRV records.
+func (c *collector) resolveDevices() []config.Device {
+	var realDevices []config.Device
+
+	for _, dev := range c.devices {
+		if (config.SrvRecord{}) != dev.Srv {
+			log.WithFields(log.Fields{
+				"SRV": dev.Srv.Record,
+			}).Info("SRV configuration detected")
+			conf, _ := dns.ClientConfigFromFile("/etc/resolv.conf")
+			dnsServer := net.JoinHostPort(conf.Servers[0], strconv.Itoa(dnsPort))
+			if (config.DnsServer{}) != dev.Srv.Dns {
+				dnsServer = net.JoinHostPort(dev.Srv.Dns.Address, strconv.Itoa(dev.Srv.Dns.Port))
+				log.WithFields(log.Fields{
+					"DnsServer": dnsServer,
+				}).Info("Custom DNS config detected")
+			}
+			dnsMsg := new(dns.Msg)
+			dnsCli := new(dns.Client)
+
+			dnsMsg.RecursionDesired = true
+			dnsMsg.SetQuestion(dns.Fqdn(dev.Srv.Record), dns.TypeSRV)
+			r, _, err := dnsCli.Exchange(dnsMsg, dnsServer)
+			if err != nil {
+				log.WithFields(log.Fields{
+					"device": dev.Name,
+					"SRV":    dev.Srv.Record,
+					"error":  err,
+				}).Error("error resolving SRV record")
+				c.connections.resolveFailed(dev.Name)
+				continue
+			}
+
+			for _, k := range r.Answer {
+				if s, ok := k.(*dns.SRV); ok {
+					d := dev
+					d.Srv = config.SrvRecord{}
+					d.Name = strings.TrimRight(s.Target, ".")
+					d.Address, err = resolveTarget(dnsCli, dnsServer, s.Target, r.Extra)
+					if err != nil {
+						log.WithFields(log.Fields{
+							"device": d.Name,
+							"error":  err,
+						}).Warn("error resolving SRV target")
+						c.connections.resolveFailed(dev.Name)
+					}
+					_ = c.getIdentity(&d)
+					realDevices = append(realDevices, d)
+				}
+			}
+		} else if dev.Consul.Service != "" {
+			realDevices = append(realDevices, c.discoverConsul(dev)...)
+		} else if len(dev.MNDP.Interfaces) > 0 {
+			realDevices = append(realDevices, c.discoverMNDP(dev)...)
+		} else {
+			realDevices = append(realDevices, dev)
+		}
+	}
+
+	return realDevices
+}
+
+// resolveTarget resolves the target of an SRV record to its IPv4 or IPv6
+// address with the DNS server the record came from, using the addresses sent
+// along with the record if there are any. It falls back to the name of the
+// target if looking it up fails.
+func resolveTarget(cli *dns.Client, server, target string, extra []dns.RR) (string, error) {
+	if addr := targetAddress(target, extra); addr != "" {
+		return addr, nil
+	}
+
+	var lookupErr error
+	for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		m := new(dns.Msg)
+		m.RecursionDesired = true
+		m.SetQuestion(dns.Fqdn(target), qtype)
+		r, _, err := cli.Exchange(m, server)
+		if err != nil {
+			lookupErr = err
+			continue
+		}
+		// the answer may also hold the CNAME records leading to the address
+		if addr := targetAddress("", r.Answer); addr != "" {
+			return addr, nil
+		}
+	}
+
+	return strings.TrimRight(target, "."), lookupErr
+}
+
+// targetAddress returns the first A or AAAA record of the target in rrs, or of
+// any name if target is empty.
+func targetAddress(target string, rrs []dns.RR) string {
+	for _, rr := range rrs {
+		if target != "" && !strings.EqualFold(rr.Header().Name, dns.Fqdn(target)) {
+			continue
+		}
+		switch rr := rr.(type) {
+		case *dns.A:
+			return rr.A.String()
+		case *dns.AAAA:
+			return rr.AAAA.String()
+		}
+	}
+
+	return ""
+}
+
+func (c *collector) getIdentity(d *config.Device) error {
+	cl, err := c.connect(d)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": d.Name,
+			"error":  err,
+		}).Error("error dialing device fetching identity")
+		return err
+	}
+	defer cl.Close()
+	reply, err := cl.Run("/system/identity/print")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": d.Name,
+			"error":  err,
+		}).Error("error fetching ethernet interfaces")
+		return err
+	}
+	for _, id := range reply.Re {
+		d.Name = id.Map["name"]
+	}
+	return nil
+}
+
+// collectForDevice scrapes the device, traced in a child span of parent.
+func (c *collector) collectForDevice(d config.Device, ch chan<- prometheus.Metric, sel *selection, parent *span) {
+	begin := time.Now()
+
+	s := parent.child("device")
+	s.setAttribute("device", d.Name)
+	s.setAttribute("address", d.Address)
+	var err error
+	defer func() { s.end(err) }()
+
+	if len(c.relabelRules) > 0 {
+		var flush func()
+		ch, flush = withRelabeling(ch, c.relabelRules)
+		defer flush()
+	}
+
+	if len(d.Labels) > 0 {
+		var flush func()
+		ch, flush = withDeviceLabels(ch, d.Labels)
+		defer flush()
+	}
+
+	if c.identities != nil {
+		var flush func()
+		ch, flush = withIdentity(ch, d.Name, c.identityLabels, c.identities)
+		defer flush()
+	}
+
+	if len(c.maintenance) > 0 {
+		if c.inMaintenance(d.Name, begin) {
+			log.WithFields(log.Fields{
+				"device": d.Name,
+			}).Debug("skipping device in maintenance")
+			ch <- prometheus.MustNewConstMetric(maintenanceDesc, prometheus.GaugeValue, 1, d.Name)
+			s.setAttribute("maintenance", true)
+			return
+		}
+		ch <- prometheus.MustNewConstMetric(maintenanceDesc, prometheus.GaugeValue, 0, d.Name)
+	}
+
+	if c.stale != nil {
+		err = c.collectWithStaleFallback(&d, ch, sel, s)
+	} else {
+		err = c.connectAndCollect(&d, ch, sel, s)
+	}
+
+	duration := time.Since(begin)
+	var success float64
+	l := log.WithFields(log.Fields{
+		"device":   d.Name,
+		"address":  d.Address,
+		"duration": duration.Seconds(),
+	})
+	if err != nil {
+		l.WithError(err).Error("scraping device failed")
+		success = 0
+	} else {
+		l.Debug("scraping device succeeded")
+		success = 1
+	}
+
+	ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, duration.Seconds(), d.Name)
+	ch <- prometheus.MustNewConstMetric(scrapeSuccessDesc, prometheus.GaugeValue, success, d.Name)
+	ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, success, d.Name, d.Address)
+
+	c.scrapeErrors.recordScrape(d.Name, err, parseErrors.count(d.Name))
+	c.scrapeErrors.collect(ch, d.Name, c.lastErrorInfo)
+	c.seriesLimited.collect(ch, d.Name)
+	c.connections.collect(ch, d.Name)
+	c.statuses.record(&d, begin, duration, err, c.enabledCollectors(&d))
+
+	if l := c.rateLimiter(&d); l != nil {
+		l.collect(ch, d.Name)
+	}
+
+	if c.breaker != nil {
+		if err != errCircuitOpen {
+			c.breaker.record(d.Name, err, func() error {
+				return c.probe(&d)
+			})
+		}
+		v := 0.0
+		if c.breaker.isOpen(d.Name) {
+			v = 1.0
+		}
+		ch <- prometheus.MustNewConstMetric(circuitOpenDesc, prometheus.GaugeValue, v, d.Name)
+	}
+}
+
+// probe checks whether the device can be reached.
+func (c *collector) probe(d *config.Device) error {
+	if d.Transport == config.TransportSNMP {
+		s, err := c.dialSNMP(d)
+		if err != nil {
+			return err
+		}
+		defer s.close()
+
+		_, err = s.get(oidSysUpTime)
+		return err
+	}
+
+	cl, err := c.connect(d)
+	if err != nil {
+		return err
+	}
+	cl.Close()
+
+	return nil
+}
+
+// deviceTimeout returns the timeout connecting to the device.
+func (c *collector) deviceTimeout(d *config.Device) time.Duration {
+	if d.Timeout > 0 {
+		return d.Timeout
+	}
+
+	return c.timeout
+}
+
+// rateLimiter returns the rate limiter of the device, nil if commands are not
+// rate limited. The rate limit of the device takes precedence over the global
+// one.
+func (c *collector) rateLimiter(d *config.Device) *rateLimiter {
+	rate, burst := d.RateLimit.Limit()
+	if rate <= 0 {
+		rate, burst = c.rateLimit, c.rateBurst
+	}
+	if rate <= 0 {
+		return nil
+	}
+
+	c.limitersMu.Lock()
+	defer c.limitersMu.Unlock()
+
+	l, ok := c.limiters[d.Name]
+	if !ok {
+		l = newRateLimiter(rate, burst)
+		c.limiters[d.Name] = l
+	}
+
+	return l
+}
+
+// collectWithStaleFallback collects the metrics of the device and serves the
+// metrics of the last successful scrape if the device is unreachable.
+func (c *collector) collectWithStaleFallback(d *config.Device, ch chan<- prometheus.Metric, sel *selection, s *span) error {
+	metrics, err := collectBuffered(func(buf chan<- prometheus.Metric) error {
+		return c.connectAndCollect(d, buf, sel, s)
+	})
+
+	return c.serveWithStaleFallback(d, sel.cacheKey(d.Name), ch, metrics, err)
+}
+
+// serveWithStaleFallback sends the collected metrics, or the stale ones if
+// connecting to the device failed or its circuit is open. A failing collector
+// of a reachable device doesn't replace the fresh metrics of the others.
+func (c *collector) serveWithStaleFallback(d *config.Device, key string, ch chan<- prometheus.Metric, metrics []prometheus.Metric, err error) error {
+	if err == nil {
+		c.stale.store(key, metrics)
+	}
+
+	var ce *connectError
+	unreachable := errors.As(err, &ce) || errors.Is(err, errCircuitOpen)
+	e, ok := c.stale.load(key)
+	if !unreachable || !ok {
+		for _, m := range metrics {
+			ch <- m
+		}
+		ch <- prometheus.MustNewConstMetric(staleDesc, prometheus.GaugeValue, 0, d.Name)
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"device": d.Name,
+		"age":    time.Since(e.taken),
+	}).Warn("serving stale metrics of unreachable device")
+
+	for _, m := range e.metrics {
+		ch <- m
+	}
+	ch <- prometheus.MustNewConstMetric(staleDesc, prometheus.GaugeValue, time.Since(e.taken).Seconds(), d.Name)
+
+	return err
+}
+
+// connectAndCollect connects to the device and runs the selected collectors,
+// traced in s.
+func (c *collector) connectAndCollect(d *config.Device, ch chan<- prometheus.Metric, sel *selection, s *span) (err error) {
+	if c.breaker != nil && c.breaker.isOpen(d.Name) {
+		return errCircuitOpen
+	}
+
+	if d.Transport == config.TransportSNMP {
+		return c.collectSNMP(d, ch, sel, s)
+	}
+
+	scrapeTimeout := c.scrapeTimeout
+	if d.ScrapeTimeout > 0 {
+		scrapeTimeout = d.ScrapeTimeout
+	}
+	var deadline time.Time
+	if scrapeTimeout > 0 {
+		deadline = time.Now().Add(scrapeTimeout)
+	}
+
+	connecting := s.child("connect")
+	cl, err := c.connectWithRetry(d, deadline)
+	connecting.end(err)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": d.Name,
+			"error":  err,
+		}).Error("error dialing device")
+		return &connectError{err}
+	}
+	client := newAPIClient(cl, d, c.rateLimiter(d), c.retry, func() (*routeros.Client, error) {
+		c.connections.reconnected(d.Name)
+		return c.connect(d)
+	})
+	client.span = s
+	defer func() {
+		s.setAttribute("version", client.release)
+		s.setAttribute("commands", client.commands.Load())
+	}()
+	c.statuses.setVersion(d.Name, client.release)
+	client.bootTime = c.statuses.bootTime(d.Name, client.bootTime)
+	client.series = newSeriesBudget(c.deviceSeriesLimit(d))
+
+	if len(d.SSH.Commands) > 0 {
+		client.ssh = newSSHRunner(d, c.deviceTimeout(d), func(address string) (net.Conn, error) {
+			return c.dial(d, address, c.deviceTimeout(d))
+		})
+		defer client.ssh.close()
+	}
+
+	timedOut := false
+	defer func() {
+		// keep the connection for the next scrape unless it broke
+		if c.poolIdleTimeout > 0 && !timedOut && !isConnectionError(err) {
+			c.warm.put(d.Name, client.Client)
+			return
+		}
+		client.Close()
+	}()
+
+	if scrapeTimeout > 0 {
+		t := time.AfterFunc(time.Until(deadline), client.abort)
+		defer func() {
+			if !t.Stop() {
+				timedOut = true
+				err = fmt.Errorf("%w after %s", errScrapeTimeout, scrapeTimeout)
+			}
+		}()
+	}
+
+	if c.identities != nil {
+		c.identities.refresh(d.Name, client)
+	}
+
+	parallelism := c.parallelism
+	if d.Parallelism > 0 {
+		parallelism = d.Parallelism
+	}
+	if parallelism > 1 {
+		client.enableAsync()
+		return c.collectParallel(d, ch, client, parallelism, sel)
+	}
+
+	for _, co := range sel.collectors {
+		err = c.runCollector(co, d, ch, client)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// collectParallel runs the collectors of the device on a pool of workers
+// sharing the connection and returns the first error.
+func (c *collector) collectParallel(d *config.Device, ch chan<- prometheus.Metric, client *apiClient, parallelism int, sel *selection) error {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	collectors := make(chan namedCollector)
+	wg.Add(parallelism)
+
+	for i := 0; i < parallelism; i++ {
+		go func() {
+			defer wg.Done()
+			for co := range collectors {
+				err := c.runCollector(co, d, ch, client)
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, co := range sel.collectors {
+		collectors <- co
+	}
+	close(collectors)
+
+	wg.Wait()
+
+	return firstErr
+}
+
+func (c *collector) runCollector(co namedCollector, d *config.Device, ch chan<- prometheus.Metric, client *apiClient) error {
+	if !c.runsOn(co, d) {
+		return nil
+	}
+
+	interval := c.collectorIntervals[co.name]
+	if interval <= 0 {
+		return c.runCollectorNow(co, d, ch, client)
+	}
+
+	metrics, ok := c.intervalCache.load(d.Name, co.name, interval)
+	if !ok {
+		var err error
+		metrics, err = collectBuffered(func(ch chan<- prometheus.Metric) error {
+			return c.runCollectorNow(co, d, ch, client)
+		})
+		if err != nil {
+			for _, m := range metrics {
+				ch <- m
+			}
+			return err
+		}
+		c.intervalCache.store(d.Name, co.name, metrics)
+	}
+
+	for _, m := range metrics {
+		ch <- m
+	}
+
+	return nil
+}
+
+// runCollectorNow runs the collector against the device regardless of its
+// interval.
+func (c *collector) runCollectorNow(co namedCollector, d *config.Device, ch chan<- prometheus.Metric, client *apiClient) error {
+	out := ch
+	if limit := c.collectorSeriesLimits[co.name]; limit > 0 || client.series != nil {
+		var flush func() int
+		out, flush = withSeriesLimit(ch, newSeriesBudget(limit), client.series)
+		defer func() {
+			if dropped := flush(); dropped > 0 {
+				log.WithFields(log.Fields{
+					"device":    d.Name,
+					"collector": co.name,
+					"dropped":   dropped,
+				}).Warn("series limit exceeded, truncating metrics")
+				c.seriesLimited.record(d.Name, co.name)
+				ch <- prometheus.MustNewConstMetric(seriesDroppedDesc, prometheus.GaugeValue, float64(dropped), d.Name, co.name)
+			}
+		}()
+	}
+	if f, ok := c.metricFilters[co.name]; ok {
+		var flush func()
+		out, flush = withMetricFilter(out, f)
+		defer flush()
+	}
+
+	s := client.span.child(co.name)
+	s.setAttribute("collector", co.name)
+	// the commands of collectors running concurrently can't be told apart
+	sequential := s != nil && !client.async
+	var commands int64
+	if sequential {
+		parent := client.span
+		commands = client.commands.Load()
+		client.span = s
+		defer func() { client.span = parent }()
+	}
+
+	ctx := &collectorContext{out, d, client, co.name, c.legacyMetricTypes}
+	if !c.capabilities.supported(co, ctx) {
+		s.setAttribute("skipped", true)
+		s.end(nil)
+		return nil
+	}
+
+	begin := time.Now()
+	err := co.collect(ctx)
+	if sequential {
+		s.setAttribute("commands", client.commands.Load()-commands)
+	}
+	s.end(err)
+
+	success := 1.0
+	if err != nil {
+		success = 0
+	}
+	ch <- prometheus.MustNewConstMetric(collectorDurationDesc, prometheus.GaugeValue, time.Since(begin).Seconds(), d.Name, co.name)
+	ch <- prometheus.MustNewConstMetric(collectorSuccessDesc, prometheus.GaugeValue, success, d.Name, co.name)
+	c.scrapeErrors.recordCollector(d.Name, co.name, err)
+
+	return err
+}
+
+// connectWithRetry connects to the device, retrying with backoff while
+// dialing fails and the retry can start before the deadline.
+func (c *collector) connectWithRetry(d *config.Device, deadline time.Time) (*routeros.Client, error) {
+	cl, err := c.warmOrConnect(d)
+	for retry := 1; err != nil && retry <= c.retry.attempts && isConnectionError(err); retry++ {
+		if !c.retry.wait(retry, deadline, nil) {
+			break
+		}
+
+		log.WithFields(log.Fields{
+			"device":  d.Name,
+			"attempt": retry,
+			"error":   err,
+		}).Warn("error dialing device, retrying")
+
+		cl, err = c.connect(d)
+	}
+
+	return cl, err
+}
+
+// deviceSeriesLimit returns the series limit of the device, which takes
+// precedence over the limit of all devices.
+func (c *collector) deviceSeriesLimit(d *config.Device) int {
+	if d.SeriesLimit > 0 {
+		return d.SeriesLimit
+	}
+
+	return c.seriesLimit
+}
+
+// runsOn returns whether the collector runs against the device. Devices
+// selecting their own features run only those besides the interface and
+// resource metrics, the custom collectors, the scripts and the bandwidth
+// tests.
+func (c *collector) runsOn(co namedCollector, d *config.Device) bool {
+	if co.name == "interface" || co.name == "resource" {
+		return true
+	}
+	switch co.routerOSCollector.(type) {
+	case *customCollector, *scriptCollector, *bandwidthTestCollector:
+		return true
+	}
+	if len(d.Features) > 0 {
+		return slices.Contains(d.Features, co.name)
+	}
+
+	return c.defaultFeatures == nil || slices.Contains(c.defaultFeatures, co.name)
+}
+
+// tlsConfig returns the TLS config to connect to the device, trusting the CA
+// of the device if set or the CA set for all devices. The settings of the
+// device take precedence.
+func (c *collector) tlsConfig(d *config.Device) (*tls.Config, error) {
+	tlsCfg := &tls.Config{
+		InsecureSkipVerify: c.insecureTLS,
+		ServerName:         d.ServerName,
+	}
+	if d.Insecure != nil {
+		tlsCfg.InsecureSkipVerify = *d.Insecure
+	}
+
+	if d.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(d.CertFile, d.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	caFile := d.CAFile
+	if caFile == "" {
+		caFile = c.caFile
+	}
+	if caFile == "" {
+		return tlsCfg, nil
+	}
+
+	b, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("no CA certificates found in %s", caFile)
+	}
+	tlsCfg.RootCAs = pool
+
+	return tlsCfg, nil
+}
+
+func (c *collector) connect(d *config.Device) (_ *routeros.Client, err error) {
+	var conn net.Conn
+
+	l := log.WithFields(log.Fields{
+		"device":  d.Name,
+		"address": d.Address,
+	})
+	c.connections.dialed(d.Name)
+	defer func() {
+		if err != nil {
+			c.connections.failed(d.Name, err)
+		}
+	}()
+
+	timeout := c.deviceTimeout(d)
+	enableTLS := c.enableTLS
+	if d.TLS != nil {
+		enableTLS = *d.TLS
+	}
+
+	l.Debug("trying to Dial")
+	if !enableTLS {
+		if (d.Port) == "" {
+			d.Port = apiPort
+		}
+		conn, err = c.dial(d, net.JoinHostPort(d.Address, d.Port), timeout)
+		if err != nil {
+			return nil, err
+		}
+		//		return routeros.DialTimeout(d.Address+apiPort, d.User, d.Password, c.timeout)
+	} else {
+		tlsCfg, err := c.tlsConfig(d)
+		if err != nil {
+			return nil, err
+		}
+		if tlsCfg.ServerName == "" {
+			tlsCfg.ServerName = d.Address
+		}
+		if (d.Port) == "" {
+			d.Port = apiPortTLS
+		}
+		raw, err := c.dial(d, net.JoinHostPort(d.Address, d.Port), timeout)
+		if err != nil {
+			return nil, err
+		}
+		tlsConn := tls.Client(raw, tlsCfg)
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err = tlsConn.HandshakeContext(ctx)
+		cancel()
+		if err != nil {
+			raw.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+	l.Debug("done dialing")
+	conn = c.connections.track(d.Name, conn)
+
+	// the timeout covers the login as well
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+	defer func() {
+		_ = conn.SetDeadline(time.Time{})
+	}()
+
+	client, err := routeros.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	l.Debug("got client")
+
+	user, password, err := d.Credentials()
 	if err != nil {
+		client.Close()
 		return nil, err
 	}
-	log.WithField("device", d.Name).Debug("got client")
+	c.credentials.changed(d.Name, user, password)
 
-	log.WithField("device", d.Name).Debug("trying to login")
-	r, err := client.Run("/login", "=name="+d.User, "=password="+d.Password)
+	l.Debug("trying to login")
+	r, err := client.Run("/login", "=name="+user, "=password="+password)
 	if err != nil {
+		client.Close()
 		return nil, err
 	}
 	ret, ok := r.Done.Map["ret"]
@@ -407,22 +1952,25 @@ func (c *collector) connect(d *config.Device) (*routeros.Client, error) {
 		if r.Done != nil {
 			return client, nil
 		}
+		client.Close()
 		return nil, errors.New("RouterOS: /login: no ret (challenge) received")
 	}
 
 	// Login method pre-6.43 two stages, challenge
 	b, err := hex.DecodeString(ret)
 	if err != nil {
+		client.Close()
 		return nil, fmt.Errorf(
 			"RouterOS: /login: invalid ret (challenge) hex string received: %s",
 			err,
 		)
 	}
 
-	if _, err = client.Run("/login", "=name="+d.User, "=response="+challengeResponse(b, d.Password)); err != nil {
+	if _, err = client.Run("/login", "=name="+user, "=response="+challengeResponse(b, password)); err != nil {
+		client.Close()
 		return nil, err
 	}
-	log.WithField("device", d.Name).Debug("done wth login")
+	l.Debug("done wth login")
 
 	return client, nil
 
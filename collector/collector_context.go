@@ -1,14 +1,47 @@
 package collector
 
 import (
+	"time"
+
 	"mikrotik-exporter/config"
 
 	"github.com/prometheus/client_golang/prometheus"
-	routeros "gopkg.in/routeros.v2"
+	log "github.com/sirupsen/logrus"
 )
 
 type collectorContext struct {
-	ch     chan<- prometheus.Metric
-	device *config.Device
-	client *routeros.Client
+	ch        chan<- prometheus.Metric
+	device    *config.Device
+	client    *apiClient
+	collector string
+
+	// legacyMetricTypes exports monotonic values with the metric types of
+	// earlier releases
+	legacyMetricTypes bool
+}
+
+// logger returns a log entry with the device and collector the entries are
+// about.
+func (ctx *collectorContext) logger() *log.Entry {
+	return log.WithFields(log.Fields{
+		"device":    ctx.device.Name,
+		"address":   ctx.device.Address,
+		"collector": ctx.collector,
+	})
+}
+
+// counter returns a counter metric of the device created at the time the
+// device booted, as device counters are reset on boot.
+func (ctx *collectorContext) counter(desc *prometheus.Desc, v float64, labelValues ...string) prometheus.Metric {
+	return counterSince(ctx.client.bootTime, desc, v, labelValues...)
+}
+
+// counterSince returns a counter metric with the created timestamp set if
+// known.
+func counterSince(created time.Time, desc *prometheus.Desc, v float64, labelValues ...string) prometheus.Metric {
+	if created.IsZero() {
+		return prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v, labelValues...)
+	}
+
+	return prometheus.MustNewConstMetricWithCreatedTimestamp(desc, prometheus.CounterValue, v, created, labelValues...)
 }
@@ -0,0 +1,147 @@
+package collector
+
+import (
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	connectionsOpenDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "exporter", "connections_open"),
+		"mikrotik_exporter: number of open API connections to the device",
+		[]string{"device"},
+		nil,
+	)
+	dialAttemptsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "exporter", "dial_attempts_total"),
+		"mikrotik_exporter: number of attempts to connect to the API of the device",
+		[]string{"device"},
+		nil,
+	)
+	dialFailuresDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "exporter", "dial_failures_total"),
+		"mikrotik_exporter: number of failed attempts to connect to the API of the device, including the login",
+		[]string{"device"},
+		nil,
+	)
+	reconnectsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "exporter", "reconnects_total"),
+		"mikrotik_exporter: number of reconnects to the device after the connection dropped during a scrape",
+		[]string{"device"},
+		nil,
+	)
+	resolveErrorsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "exporter", "resolve_errors_total"),
+		"mikrotik_exporter: number of failed DNS lookups of the address or SRV record of the device",
+		[]string{"device"},
+		nil,
+	)
+)
+
+// connectionStats counts the connections to each device over the lifetime of
+// the collector.
+type connectionStats struct {
+	mu            sync.Mutex
+	open          map[string]float64
+	dials         map[string]float64
+	dialFailures  map[string]float64
+	reconnects    map[string]float64
+	resolveErrors map[string]float64
+}
+
+func newConnectionStats() *connectionStats {
+	return &connectionStats{
+		open:          make(map[string]float64),
+		dials:         make(map[string]float64),
+		dialFailures:  make(map[string]float64),
+		reconnects:    make(map[string]float64),
+		resolveErrors: make(map[string]float64),
+	}
+}
+
+func (s *connectionStats) dialed(device string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dials[device]++
+}
+
+// failed counts a failed connection attempt, which failed resolving the
+// address of the device if err is a DNS error.
+func (s *connectionStats) failed(device string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dialFailures[device]++
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		s.resolveErrors[device]++
+	}
+}
+
+func (s *connectionStats) reconnected(device string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reconnects[device]++
+}
+
+func (s *connectionStats) resolveFailed(device string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.resolveErrors[device]++
+}
+
+// track counts the connection as open until it is closed.
+func (s *connectionStats) track(device string, conn net.Conn) net.Conn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.open[device]++
+
+	return &trackedConn{Conn: conn, closed: func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.open[device]--
+	}}
+}
+
+func (s *connectionStats) collect(ch chan<- prometheus.Metric, device string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch <- prometheus.MustNewConstMetric(connectionsOpenDesc, prometheus.GaugeValue, s.open[device], device)
+	ch <- prometheus.MustNewConstMetric(dialAttemptsDesc, prometheus.CounterValue, s.dials[device], device)
+	ch <- prometheus.MustNewConstMetric(dialFailuresDesc, prometheus.CounterValue, s.dialFailures[device], device)
+	ch <- prometheus.MustNewConstMetric(reconnectsDesc, prometheus.CounterValue, s.reconnects[device], device)
+	ch <- prometheus.MustNewConstMetric(resolveErrorsDesc, prometheus.CounterValue, s.resolveErrors[device], device)
+}
+
+// collectResolveErrors sends the resolve errors of a device which is not
+// scraped itself, like one configured by an SRV record.
+func (s *connectionStats) collectResolveErrors(ch chan<- prometheus.Metric, device string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch <- prometheus.MustNewConstMetric(resolveErrorsDesc, prometheus.CounterValue, s.resolveErrors[device], device)
+}
+
+// trackedConn is a connection which reports being closed once.
+type trackedConn struct {
+	net.Conn
+
+	once   sync.Once
+	closed func()
+}
+
+func (c *trackedConn) Close() error {
+	c.once.Do(c.closed)
+
+	return c.Conn.Close()
+}
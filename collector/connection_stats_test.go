@@ -0,0 +1,33 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnectionStats(t *testing.T) {
+	s := newConnectionStats()
+
+	client, server := net.Pipe()
+	defer server.Close()
+
+	s.dialed("r1")
+	conn := s.track("r1", client)
+	assert.Equal(t, 1.0, s.open["r1"])
+
+	conn.Close()
+	conn.Close()
+	assert.Equal(t, 0.0, s.open["r1"], "closing twice must count once")
+
+	s.dialed("r1")
+	s.failed("r1", fmt.Errorf("dial: %w", &net.DNSError{Err: "no such host", Name: "r1.example.com"}))
+	s.dialed("r1")
+	s.failed("r1", errors.New("connection refused"))
+	assert.Equal(t, 3.0, s.dials["r1"])
+	assert.Equal(t, 2.0, s.dialFailures["r1"])
+	assert.Equal(t, 1.0, s.resolveErrors["r1"])
+}
@@ -34,9 +34,8 @@ func (c *conntrackCollector) describe(ch chan<- *prometheus.Desc) {
 func (c *conntrackCollector) collect(ctx *collectorContext) error {
 	reply, err := ctx.client.Run("/ip/firewall/connection/tracking/print", "=.proplist="+strings.Join(c.props, ","))
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device": ctx.device.Name,
-			"error":  err,
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
 		}).Error("error fetching conntrack table metrics")
 		return err
 	}
@@ -55,8 +54,7 @@ func (c *conntrackCollector) collectMetricForProperty(property string, desc *pro
 	}
 	v, err := strconv.ParseFloat(re.Map[property], 64)
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device":   ctx.device.Name,
+		ctx.logger().WithFields(log.Fields{
 			"property": property,
 			"value":    re.Map[property],
 			"error":    err,
@@ -0,0 +1,143 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"mikrotik-exporter/config"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// consulAddress is the address of the local Consul agent
+const consulAddress = "localhost:8500"
+
+// consulService is an entry of a service in the Consul catalog.
+type consulService struct {
+	Node           string
+	Address        string
+	ServiceAddress string
+	ServicePort    int
+	ServiceTags    []string
+	ServiceMeta    map[string]string
+}
+
+// discoverConsul returns the devices registered as the Consul service of the
+// device. The settings of the device apply to all discovered devices.
+func (c *collector) discoverConsul(dev config.Device) []config.Device {
+	services, err := c.fetchConsulServices(dev.Consul)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"service": dev.Consul.Service,
+			"error":   err,
+		}).Error("error discovering devices in consul")
+		return nil
+	}
+
+	return consulDevices(dev, services, c.profiles, c.defaults)
+}
+
+func (c *collector) fetchConsulServices(s config.ConsulService) ([]consulService, error) {
+	address := s.Address
+	if address == "" {
+		address = consulAddress
+	}
+
+	q := url.Values{}
+	if s.Datacenter != "" {
+		q.Set("dc", s.Datacenter)
+	}
+	u := url.URL{
+		Scheme:   "http",
+		Host:     address,
+		Path:     "/v1/catalog/service/" + url.PathEscape(s.Service),
+		RawQuery: q.Encode(),
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.Token != "" {
+		req.Header.Set("X-Consul-Token", s.Token)
+	}
+
+	cl := http.Client{Timeout: c.timeout}
+	resp, err := cl.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var services []consulService
+	err = json.NewDecoder(resp.Body).Decode(&services)
+	if err != nil {
+		return nil, err
+	}
+
+	return services, nil
+}
+
+// consulDevices maps the service entries having all tags of the device to
+// devices. The name, port and profile can be set in the service metadata.
+func consulDevices(dev config.Device, services []consulService, profiles map[string]config.Profile, defaults config.Profile) []config.Device {
+	var devices []config.Device
+
+	for _, s := range services {
+		if !containsAll(s.ServiceTags, dev.Consul.Tags) {
+			continue
+		}
+
+		d := dev
+		d.Consul = config.ConsulService{}
+		d.Name = s.Node
+		d.Address = s.Address
+		if s.ServiceAddress != "" {
+			d.Address = s.ServiceAddress
+		}
+		if s.ServicePort > 0 {
+			d.Port = strconv.Itoa(s.ServicePort)
+		}
+		if name := s.ServiceMeta["name"]; name != "" {
+			d.Name = name
+		}
+		if port := s.ServiceMeta["port"]; port != "" {
+			d.Port = port
+		}
+		if profile := s.ServiceMeta["profile"]; profile != "" {
+			p, ok := profiles[profile]
+			if !ok {
+				log.WithFields(log.Fields{
+					"device":  d.Name,
+					"profile": profile,
+				}).Error("unknown profile of device discovered in consul")
+				continue
+			}
+			d.Profile = profile
+			p.Apply(&d)
+		}
+		defaults.Apply(&d)
+
+		devices = append(devices, d)
+	}
+
+	return devices
+}
+
+func containsAll(values, required []string) bool {
+	for _, r := range required {
+		if !slices.Contains(values, r) {
+			return false
+		}
+	}
+
+	return true
+}
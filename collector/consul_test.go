@@ -0,0 +1,38 @@
+package collector
+
+import (
+	"testing"
+
+	"mikrotik-exporter/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsulDevices(t *testing.T) {
+	dev := config.Device{
+		Name:     "consul",
+		User:     "prometheus",
+		Password: "changeme",
+		Consul:   config.ConsulService{Service: "mikrotik", Tags: []string{"prod"}},
+	}
+	services := []consulService{
+		{Node: "core1", Address: "10.0.0.1", ServiceTags: []string{"prod", "core"}},
+		{Node: "node2", Address: "10.0.0.2", ServiceAddress: "10.0.1.2", ServicePort: 8729, ServiceTags: []string{"prod"},
+			ServiceMeta: map[string]string{"name": "cpe2", "profile": "cpe"}},
+		{Node: "lab1", Address: "10.0.0.3", ServiceTags: []string{"lab"}},
+	}
+	profiles := map[string]config.Profile{"cpe": {Password: "other", Features: []string{"lte"}}}
+
+	devices := consulDevices(dev, services, profiles, config.Profile{Port: "8729"})
+	assert.Len(t, devices, 2)
+
+	assert.Equal(t, "core1", devices[0].Name)
+	assert.Equal(t, "10.0.0.1", devices[0].Address)
+	assert.Equal(t, "8729", devices[0].Port)
+
+	assert.Equal(t, "cpe2", devices[1].Name)
+	assert.Equal(t, "10.0.1.2", devices[1].Address)
+	assert.Equal(t, "8729", devices[1].Port)
+	assert.Equal(t, "changeme", devices[1].Password)
+	assert.Equal(t, []string{"lte"}, devices[1].Features)
+}
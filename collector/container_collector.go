@@ -0,0 +1,92 @@
+package collector
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+type containerCollector struct {
+	runningDesc     *prometheus.Desc
+	statusDesc      *prometheus.Desc
+	memoryDesc      *prometheus.Desc
+	memoryLimitDesc *prometheus.Desc
+	rootDirDesc     *prometheus.Desc
+}
+
+func newContainerCollector() routerOSCollector {
+	c := &containerCollector{}
+	c.init()
+	return c
+}
+
+func (c *containerCollector) init() {
+	const prefix = "container"
+	labelNames := []string{"name", "address", "container", "tag"}
+	c.runningDesc = description(prefix, "running", "whether the container is running", labelNames)
+	c.statusDesc = description(prefix, "status_info", "status of the container", append(labelNames, "status"))
+	c.memoryDesc = description(prefix, "memory_bytes", "memory used by the container", labelNames)
+	c.memoryLimitDesc = description(prefix, "memory_limit_bytes", "memory limit of the container", labelNames)
+	c.rootDirDesc = description(prefix, "root_dir_bytes", "disk space used by the root directory of the container", labelNames)
+}
+
+func (c *containerCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.runningDesc
+	ch <- c.statusDesc
+	ch <- c.memoryDesc
+	ch <- c.memoryLimitDesc
+	ch <- c.rootDirDesc
+}
+
+func (c *containerCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/container/print")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching container metrics")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		c.collectForStat(re, ctx)
+	}
+
+	return nil
+}
+
+func (c *containerCollector) collectForStat(re *proto.Sentence, ctx *collectorContext) {
+	// containers without a name are identified by their id
+	name := re.Map["name"]
+	if name == "" {
+		name = re.Map[".id"]
+	}
+	labelValues := []string{ctx.device.Name, ctx.device.Address, name, re.Map["tag"]}
+
+	status := re.Map["status"]
+	running := 0.0
+	if status == "running" {
+		running = 1.0
+	}
+	ctx.ch <- prometheus.MustNewConstMetric(c.runningDesc, prometheus.GaugeValue, running, labelValues...)
+	if status != "" {
+		ctx.ch <- prometheus.MustNewConstMetric(c.statusDesc, prometheus.GaugeValue, 1, append(labelValues, status)...)
+	}
+
+	// the usage fields differ between RouterOS releases and are only
+	// exported if reported
+	for desc, property := range map[*prometheus.Desc]string{
+		c.memoryDesc:      "memory-current",
+		c.memoryLimitDesc: "memory-high",
+		c.rootDirDesc:     "root-dir-size",
+	} {
+		if v, err := strconv.ParseFloat(re.Map[property], 64); err == nil {
+			ctx.ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, labelValues...)
+		}
+	}
+}
+
+func (c *containerCollector) requiredMenu() string {
+	return "/container"
+}
@@ -0,0 +1,118 @@
+package collector
+
+import (
+	"sync"
+
+	"mikrotik-exporter/config"
+)
+
+const (
+	// counter32Max is the number of distinct values of a 32-bit counter.
+	counter32Max = float64(1 << 32)
+	// counter32Near is the distance to counter32Max within which a counter
+	// must have been before dropping close to 0 to count as wrapped
+	counter32Near = counter32Max / 8
+	// counterWrapMaxMissed is the number of scrapes of a device after which
+	// the accumulator of a counter not reported anymore, e.g. of a removed
+	// interface, is dropped
+	counterWrapMaxMissed = 10
+)
+
+// counterWraps keeps 64-bit accumulators for counters which are reported as
+// 32-bit values by some RouterOS versions and wrap quickly on fast links.
+type counterWraps struct {
+	mu      sync.Mutex
+	devices map[string]*deviceCounterWraps
+}
+
+type deviceCounterWraps struct {
+	scrapes uint64
+	states  map[string]*counterWrapState
+}
+
+type counterWrapState struct {
+	last   float64
+	offset float64
+	// wide is set once the counter reported a value beyond 32 bits, after
+	// which it never wraps
+	wide bool
+	// seen is the scrape of the device the counter was last reported in
+	seen uint64
+}
+
+func newCounterWraps() *counterWraps {
+	return &counterWraps{
+		devices: make(map[string]*deviceCounterWraps),
+	}
+}
+
+// correct returns the accumulated value for the counter of the device
+// identified by key. A decreasing value is treated as a 32-bit wrap if the
+// counter never exceeded 32 bits, the previous value was near 2^32 and the
+// new one is near 0, otherwise as a counter reset, e.g. by a reboot or
+// /interface reset-counters.
+func (w *counterWraps) correct(device, key string, v float64) float64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	dw, ok := w.devices[device]
+	if !ok {
+		dw = &deviceCounterWraps{states: make(map[string]*counterWrapState)}
+		w.devices[device] = dw
+	}
+
+	s, ok := dw.states[key]
+	if !ok {
+		s = &counterWrapState{}
+		dw.states[key] = s
+	} else if v < s.last {
+		if !s.wide && s.last >= counter32Max-counter32Near && v < counter32Near {
+			s.offset += counter32Max
+		} else {
+			s.offset = 0
+		}
+	}
+	if v >= counter32Max {
+		s.wide = true
+	}
+	s.last = v
+	s.seen = dw.scrapes
+
+	return v + s.offset
+}
+
+// scraped ends a scrape of the device, dropping the accumulators of the
+// counters missing from the last scrapes.
+func (w *counterWraps) scraped(device string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	dw, ok := w.devices[device]
+	if !ok {
+		return
+	}
+
+	for key, s := range dw.states {
+		if dw.scrapes-s.seen >= counterWrapMaxMissed {
+			delete(dw.states, key)
+		}
+	}
+	dw.scrapes++
+}
+
+// retain drops the accumulators of the devices which are gone, e.g. from an
+// SRV record.
+func (w *counterWraps) retain(devices []config.Device) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	names := make(map[string]bool, len(devices))
+	for _, d := range devices {
+		names[d.Name] = true
+	}
+	for name := range w.devices {
+		if !names[name] {
+			delete(w.devices, name)
+		}
+	}
+}
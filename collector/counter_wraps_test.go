@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"mikrotik-exporter/config"
+)
+
+func TestCounterWrapsCorrect(t *testing.T) {
+	w := newCounterWraps()
+
+	assert.Equal(t, float64(4000000000), w.correct("r1", "ether1", 4000000000))
+	// wrapped around 2^32
+	assert.Equal(t, counter32Max+100, w.correct("r1", "ether1", 100))
+	assert.Equal(t, counter32Max+200, w.correct("r1", "ether1", 200))
+	// reset, e.g. after a reboot
+	assert.Equal(t, float64(50), w.correct("r1", "ether1", 50))
+	// other counters are tracked independently
+	assert.Equal(t, float64(10), w.correct("r1", "ether2", 10))
+	assert.Equal(t, float64(10), w.correct("r2", "ether1", 10))
+}
+
+func TestCounterWrapsReset(t *testing.T) {
+	w := newCounterWraps()
+
+	// a reset of a 64-bit counter is no wrap
+	w.correct("r1", "ether1", 5000000000)
+	assert.Equal(t, float64(4200000000), w.correct("r1", "ether1", 4200000000))
+	assert.Equal(t, float64(100), w.correct("r1", "ether1", 100))
+
+	// neither is a reset of a 32-bit counter far from 2^32
+	w.correct("r1", "ether2", 3000000000)
+	assert.Equal(t, float64(10), w.correct("r1", "ether2", 10))
+
+	// nor a drop near 2^32 to a value far from 0
+	w.correct("r1", "ether3", 4200000000)
+	assert.Equal(t, float64(2000000000), w.correct("r1", "ether3", 2000000000))
+}
+
+func TestCounterWrapsEviction(t *testing.T) {
+	w := newCounterWraps()
+
+	w.correct("r1", "ether1", 4000000000)
+	w.correct("r1", "ether2", 4000000000)
+	w.correct("r2", "ether1", 4000000000)
+	w.scraped("r1")
+
+	// ether2 is gone
+	for i := 1; i < counterWrapMaxMissed; i++ {
+		w.correct("r1", "ether1", 4000000000)
+		w.scraped("r1")
+	}
+	assert.Len(t, w.devices["r1"].states, 2)
+
+	w.correct("r1", "ether1", 4000000000)
+	w.scraped("r1")
+	assert.Len(t, w.devices["r1"].states, 1)
+	assert.Equal(t, counter32Max+100, w.correct("r1", "ether1", 100))
+
+	w.retain([]config.Device{{Name: "r1"}})
+	assert.Contains(t, w.devices, "r1")
+	assert.NotContains(t, w.devices, "r2")
+}
@@ -0,0 +1,80 @@
+package collector
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+type cpuCollector struct {
+	props        []string
+	descriptions map[string]*prometheus.Desc
+}
+
+func newCPUCollector() routerOSCollector {
+	c := &cpuCollector{}
+	c.init()
+	return c
+}
+
+func (c *cpuCollector) init() {
+	c.props = []string{"cpu", "load", "irq", "disk"}
+
+	labelNames := []string{"name", "address", "cpu"}
+	c.descriptions = map[string]*prometheus.Desc{
+		"load": description("system", "cpu_core_load", "load of the CPU core in percent", labelNames),
+		"irq":  description("system", "cpu_core_irq", "load of the CPU core caused by interrupts in percent", labelNames),
+		"disk": description("system", "cpu_core_disk", "load of the CPU core caused by disk I/O in percent", labelNames),
+	}
+}
+
+func (c *cpuCollector) describe(ch chan<- *prometheus.Desc) {
+	for _, d := range c.descriptions {
+		ch <- d
+	}
+}
+
+func (c *cpuCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/system/resource/cpu/print", "=.proplist="+strings.Join(c.props, ","))
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching cpu metrics")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		c.collectForStat(re, ctx)
+	}
+
+	return nil
+}
+
+func (c *cpuCollector) collectForStat(re *proto.Sentence, ctx *collectorContext) {
+	for _, p := range c.props[1:] {
+		c.collectMetricForProperty(p, re, ctx)
+	}
+}
+
+func (c *cpuCollector) collectMetricForProperty(property string, re *proto.Sentence, ctx *collectorContext) {
+	value := re.Map[property]
+	if value == "" {
+		return
+	}
+
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"cpu":      re.Map["cpu"],
+			"property": property,
+			"value":    value,
+			"error":    err,
+		}).Error("error parsing cpu metric value")
+		return
+	}
+
+	ctx.ch <- prometheus.MustNewConstMetric(c.descriptions[property], prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, re.Map["cpu"])
+}
@@ -0,0 +1,58 @@
+package collector
+
+import (
+	"crypto/sha256"
+	"sync"
+
+	"mikrotik-exporter/config"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// credentialTracker notices when the credentials of a device change, e.g.
+// after a secret mounted as password file was rotated, so that connections
+// logged in with the previous credentials are not reused.
+type credentialTracker struct {
+	mu     sync.Mutex
+	hashes map[string][sha256.Size]byte
+}
+
+func newCredentialTracker() *credentialTracker {
+	return &credentialTracker{
+		hashes: make(map[string][sha256.Size]byte),
+	}
+}
+
+// changed records the credentials of the device and returns whether they
+// differ from the ones seen before.
+func (t *credentialTracker) changed(device, user, password string) bool {
+	h := sha256.Sum256([]byte(user + "\x00" + password))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	last, ok := t.hashes[device]
+	t.hashes[device] = h
+
+	return ok && last != h
+}
+
+// credentialsChanged returns whether the credentials of the device changed
+// since they were last used.
+func (c *collector) credentialsChanged(d *config.Device) bool {
+	user, password, err := d.Credentials()
+	if err != nil {
+		// reported when connecting
+		return false
+	}
+
+	if !c.credentials.changed(d.Name, user, password) {
+		return false
+	}
+
+	log.WithFields(log.Fields{
+		"device": d.Name,
+	}).Info("credentials changed, discarding open connection")
+
+	return true
+}
@@ -0,0 +1,16 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCredentialTracker(t *testing.T) {
+	tr := newCredentialTracker()
+
+	assert.False(t, tr.changed("router1", "prometheus", "old"))
+	assert.False(t, tr.changed("router1", "prometheus", "old"))
+	assert.True(t, tr.changed("router1", "prometheus", "new"))
+	assert.False(t, tr.changed("router2", "prometheus", "other"))
+}
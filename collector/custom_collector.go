@@ -0,0 +1,160 @@
+package collector
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"mikrotik-exporter/config"
+)
+
+var (
+	// customName matches the names of custom collectors, which are part of
+	// their metric names
+	customName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	// invalidNameChars matches the characters of RouterOS attribute names
+	// not allowed in metric and label names, e.g. in remote.as
+	invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+)
+
+// customCollector exports the fields of the entries an API command returns,
+// as configured by the user.
+type customCollector struct {
+	sentence []string
+	labels   []string
+	values   []customValue
+}
+
+type customValue struct {
+	field     string
+	valueType prometheus.ValueType
+	desc      *prometheus.Desc
+}
+
+func newCustomCollector(cfg config.CustomCollector) (routerOSCollector, error) {
+	if !customName.MatchString(cfg.Name) {
+		return nil, fmt.Errorf("invalid name %q", cfg.Name)
+	}
+	if !strings.HasPrefix(cfg.Command, "/") {
+		return nil, fmt.Errorf("invalid command %q", cfg.Command)
+	}
+	if len(cfg.Values) == 0 {
+		return nil, fmt.Errorf("missing values")
+	}
+
+	c := &customCollector{sentence: []string{cfg.Command}, labels: cfg.Labels}
+	for _, q := range cfg.Query {
+		if !strings.HasPrefix(q, "?") && !strings.HasPrefix(q, "=") {
+			return nil, fmt.Errorf("invalid query %q", q)
+		}
+		c.sentence = append(c.sentence, q)
+	}
+
+	labelNames := []string{"name", "address"}
+	for _, l := range cfg.Labels {
+		n := invalidNameChars.ReplaceAllString(l, "_")
+		if slices.Contains(labelNames, n) {
+			return nil, fmt.Errorf("duplicate label %s", n)
+		}
+		labelNames = append(labelNames, n)
+	}
+
+	props := slices.Clone(cfg.Labels)
+	for _, v := range cfg.Values {
+		if v.Field == "" {
+			return nil, fmt.Errorf("missing field of value")
+		}
+
+		cv := customValue{field: v.Field}
+		switch v.Type {
+		case "", "gauge":
+			cv.valueType = prometheus.GaugeValue
+		case "counter":
+			cv.valueType = prometheus.CounterValue
+		default:
+			return nil, fmt.Errorf("unknown type %s of value %s", v.Type, v.Field)
+		}
+
+		help := v.Help
+		if help == "" {
+			help = v.Field
+		}
+		cv.desc = description("custom_"+cfg.Name, invalidNameChars.ReplaceAllString(v.Field, "_"), help, labelNames)
+
+		c.values = append(c.values, cv)
+		props = append(props, v.Field)
+	}
+	c.sentence = append(c.sentence, "=.proplist="+strings.Join(props, ","))
+
+	return c, nil
+}
+
+func (c *customCollector) describe(ch chan<- *prometheus.Desc) {
+	for _, v := range c.values {
+		ch <- v.desc
+	}
+}
+
+func (c *customCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run(c.sentence...)
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"command": c.sentence[0],
+			"error":   err,
+		}).Error("error fetching custom metrics")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		labelValues := []string{ctx.device.Name, ctx.device.Address}
+		for _, l := range c.labels {
+			labelValues = append(labelValues, re.Map[l])
+		}
+
+		for _, v := range c.values {
+			value := re.Map[v.field]
+			if value == "" {
+				continue
+			}
+
+			f, err := parseCustomValue(value)
+			if err != nil {
+				ctx.logger().WithFields(log.Fields{
+					"command": c.sentence[0],
+					"field":   v.field,
+					"value":   value,
+					"error":   err,
+				}).Error("error parsing custom metric value")
+				continue
+			}
+
+			if v.valueType == prometheus.CounterValue {
+				ctx.ch <- ctx.counter(v.desc, f, labelValues...)
+			} else {
+				ctx.ch <- prometheus.MustNewConstMetric(v.desc, prometheus.GaugeValue, f, labelValues...)
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseCustomValue parses numbers, booleans and durations, which are
+// converted to seconds.
+func parseCustomValue(value string) (float64, error) {
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f, nil
+	}
+
+	switch value {
+	case "true", "yes", "false", "no":
+		return boolToFloat(value), nil
+	}
+
+	return parseDuration(value)
+}
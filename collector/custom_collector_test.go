@@ -0,0 +1,58 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"mikrotik-exporter/config"
+)
+
+func TestNewCustomCollector(t *testing.T) {
+	co, err := newCustomCollector(config.CustomCollector{
+		Name:    "bfd",
+		Command: "/routing/bfd/session/print",
+		Query:   []string{"?disabled=false"},
+		Labels:  []string{"remote-address"},
+		Values: []config.CustomValue{
+			{Field: "state-changes", Type: "counter"},
+			{Field: "up"},
+		},
+	})
+	assert.NoError(t, err)
+
+	c := co.(*customCollector)
+	assert.Equal(t, []string{"/routing/bfd/session/print", "?disabled=false", "=.proplist=remote-address,state-changes,up"}, c.sentence)
+	assert.Contains(t, c.values[0].desc.String(), `fqName: "mikrotik_custom_bfd_state_changes"`)
+	assert.Contains(t, c.values[0].desc.String(), "variableLabels: {name,address,remote_address}")
+
+	invalid := []config.CustomCollector{
+		{Name: "bfd-sessions", Command: "/routing/bfd/session/print", Values: []config.CustomValue{{Field: "up"}}},
+		{Name: "bfd", Command: "routing/bfd/session/print", Values: []config.CustomValue{{Field: "up"}}},
+		{Name: "bfd", Command: "/routing/bfd/session/print"},
+		{Name: "bfd", Command: "/routing/bfd/session/print", Values: []config.CustomValue{{Field: "up", Type: "histogram"}}},
+		{Name: "bfd", Command: "/routing/bfd/session/print", Labels: []string{"name"}, Values: []config.CustomValue{{Field: "up"}}},
+		{Name: "bfd", Command: "/routing/bfd/session/print", Query: []string{"disabled=false"}, Values: []config.CustomValue{{Field: "up"}}},
+	}
+	for _, cfg := range invalid {
+		_, err := newCustomCollector(cfg)
+		assert.Error(t, err, "%+v", cfg)
+	}
+}
+
+func TestParseCustomValue(t *testing.T) {
+	for value, expected := range map[string]float64{
+		"42":     42,
+		"-1.5":   -1.5,
+		"true":   1,
+		"no":     0,
+		"1d2h3s": 93603,
+	} {
+		v, err := parseCustomValue(value)
+		assert.NoError(t, err, value)
+		assert.Equal(t, expected, v, value)
+	}
+
+	_, err := parseCustomValue("up")
+	assert.Error(t, err)
+}
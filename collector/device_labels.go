@@ -0,0 +1,57 @@
+package collector
+
+import (
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// labelledMetric adds the static labels of a device to a metric. Labels the
+// metric already has take precedence.
+type labelledMetric struct {
+	prometheus.Metric
+	labels map[string]string
+}
+
+func (m *labelledMetric) Write(out *dto.Metric) error {
+	if err := m.Metric.Write(out); err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool, len(out.Label))
+	for _, l := range out.Label {
+		existing[l.GetName()] = true
+	}
+
+	for name, value := range m.labels {
+		if existing[name] {
+			continue
+		}
+		out.Label = append(out.Label, &dto.LabelPair{Name: &name, Value: &value})
+	}
+	sort.Slice(out.Label, func(i, j int) bool {
+		return out.Label[i].GetName() < out.Label[j].GetName()
+	})
+
+	return nil
+}
+
+// withDeviceLabels returns a channel adding the labels to the metrics sent to
+// ch and a function to call once all metrics have been sent.
+func withDeviceLabels(ch chan<- prometheus.Metric, labels map[string]string) (chan<- prometheus.Metric, func()) {
+	labelled := make(chan prometheus.Metric)
+	done := make(chan struct{})
+
+	go func() {
+		for m := range labelled {
+			ch <- &labelledMetric{Metric: m, labels: labels}
+		}
+		close(done)
+	}()
+
+	return labelled, func() {
+		close(labelled)
+		<-done
+	}
+}
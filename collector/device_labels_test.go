@@ -0,0 +1,26 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabelledMetric(t *testing.T) {
+	desc := prometheus.NewDesc("test_metric", "test", []string{"name", "site"}, nil)
+	m := &labelledMetric{
+		Metric: prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, "router1", "fra1"),
+		labels: map[string]string{"site": "ams1", "role": "core"},
+	}
+
+	out := &dto.Metric{}
+	assert.NoError(t, m.Write(out))
+
+	labels := make([]string, 0, len(out.Label))
+	for _, l := range out.Label {
+		labels = append(labels, l.GetName()+"="+l.GetValue())
+	}
+	assert.Equal(t, []string{"name=router1", "role=core", "site=fra1"}, labels)
+}
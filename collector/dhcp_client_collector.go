@@ -0,0 +1,72 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+type dhcpClientCollector struct {
+	boundDesc   *prometheus.Desc
+	statusDesc  *prometheus.Desc
+	expiresDesc *prometheus.Desc
+	infoDesc    *prometheus.Desc
+}
+
+func newDHCPClientCollector() routerOSCollector {
+	c := &dhcpClientCollector{}
+	c.init()
+	return c
+}
+
+func (c *dhcpClientCollector) init() {
+	const prefix = "dhcp_client"
+	labelNames := []string{"name", "address", "interface"}
+	c.boundDesc = description(prefix, "bound", "whether the DHCP client has a lease", labelNames)
+	c.statusDesc = description(prefix, "status", "status of the DHCP client, 1 for the current status", append(labelNames, "status"))
+	c.expiresDesc = description(prefix, "expires_after_seconds", "time until the lease expires", labelNames)
+	c.infoDesc = description(prefix, "info", "lease obtained by the DHCP client", append(labelNames, "client_address", "gateway", "dhcp_server"))
+}
+
+func (c *dhcpClientCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.boundDesc
+	ch <- c.statusDesc
+	ch <- c.expiresDesc
+	ch <- c.infoDesc
+}
+
+func (c *dhcpClientCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/ip/dhcp-client/print", "?disabled=false", "=.proplist=interface,status,address,gateway,dhcp-server,expires-after")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching dhcp client metrics")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		c.collectForStat(re, ctx)
+	}
+
+	return nil
+}
+
+func (c *dhcpClientCollector) collectForStat(re *proto.Sentence, ctx *collectorContext) {
+	labelValues := []string{ctx.device.Name, ctx.device.Address, re.Map["interface"]}
+
+	bound := 0.0
+	if re.Map["status"] == "bound" {
+		bound = 1.0
+	}
+	ctx.ch <- prometheus.MustNewConstMetric(c.boundDesc, prometheus.GaugeValue, bound, labelValues...)
+	ctx.ch <- prometheus.MustNewConstMetric(c.statusDesc, prometheus.GaugeValue, 1, append(labelValues, re.Map["status"])...)
+
+	if bound == 0 {
+		return
+	}
+
+	if v, err := parseDuration(re.Map["expires-after"]); err == nil {
+		ctx.ch <- prometheus.MustNewConstMetric(c.expiresDesc, prometheus.GaugeValue, v, labelValues...)
+	}
+	ctx.ch <- prometheus.MustNewConstMetric(c.infoDesc, prometheus.GaugeValue, 1, append(labelValues, re.Map["address"], re.Map["gateway"], re.Map["dhcp-server"])...)
+}
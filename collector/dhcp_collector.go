@@ -1,8 +1,11 @@
 package collector
 
 import (
+	"encoding/binary"
 	"fmt"
+	"net/netip"
 	"strconv"
+	"strings"
 
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
@@ -10,6 +13,16 @@ import (
 
 type dhcpCollector struct {
 	leasesActiveCountDesc *prometheus.Desc
+	poolSizeDesc          *prometheus.Desc
+	poolUsedDesc          *prometheus.Desc
+	poolUtilizationDesc   *prometheus.Desc
+}
+
+// dhcpServer is a DHCP server with the name of the pool it leases addresses
+// from, which is static-only if it only serves static leases.
+type dhcpServer struct {
+	name string
+	pool string
 }
 
 func (c *dhcpCollector) init() {
@@ -17,6 +30,11 @@ func (c *dhcpCollector) init() {
 
 	labelNames := []string{"name", "address", "server"}
 	c.leasesActiveCountDesc = description(prefix, "leases_active_count", "number of active leases per DHCP server", labelNames)
+
+	poolLabelNames := []string{"name", "address", "server", "pool"}
+	c.poolSizeDesc = description(prefix, "pool_size", "number of addresses in the address pool of the DHCP server", poolLabelNames)
+	c.poolUsedDesc = description(prefix, "pool_used", "number of active leases of the DHCP server from its address pool", poolLabelNames)
+	c.poolUtilizationDesc = description(prefix, "pool_utilization_ratio", "ratio of the addresses of the pool of the DHCP server actively leased", poolLabelNames)
 }
 
 func newDHCPCollector() routerOSCollector {
@@ -27,65 +45,153 @@ func newDHCPCollector() routerOSCollector {
 
 func (c *dhcpCollector) describe(ch chan<- *prometheus.Desc) {
 	ch <- c.leasesActiveCountDesc
+	ch <- c.poolSizeDesc
+	ch <- c.poolUsedDesc
+	ch <- c.poolUtilizationDesc
 }
 
 func (c *dhcpCollector) collect(ctx *collectorContext) error {
-	names, err := c.fetchDHCPServerNames(ctx)
+	servers, err := c.fetchDHCPServers(ctx)
 	if err != nil {
 		return err
 	}
 
-	for _, n := range names {
-		err := c.colllectForDHCPServer(ctx, n)
+	var pools map[string]float64
+	for _, s := range servers {
+		if s.pool != "" && s.pool != "static-only" {
+			pools, err = c.fetchPoolSizes(ctx)
+			if err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	for _, s := range servers {
+		active, err := c.colllectForDHCPServer(ctx, s.name)
 		if err != nil {
 			return err
 		}
+
+		size, ok := pools[s.pool]
+		if !ok || active < 0 {
+			continue
+		}
+		labelValues := []string{ctx.device.Name, ctx.device.Address, s.name, s.pool}
+		ctx.ch <- prometheus.MustNewConstMetric(c.poolSizeDesc, prometheus.GaugeValue, size, labelValues...)
+		ctx.ch <- prometheus.MustNewConstMetric(c.poolUsedDesc, prometheus.GaugeValue, active, labelValues...)
+		if size > 0 {
+			ctx.ch <- prometheus.MustNewConstMetric(c.poolUtilizationDesc, prometheus.GaugeValue, active/size, labelValues...)
+		}
 	}
 
 	return nil
 }
 
-func (c *dhcpCollector) fetchDHCPServerNames(ctx *collectorContext) ([]string, error) {
-	reply, err := ctx.client.Run("/ip/dhcp-server/print", "=.proplist=name")
+func (c *dhcpCollector) fetchDHCPServers(ctx *collectorContext) ([]dhcpServer, error) {
+	reply, err := ctx.client.Run("/ip/dhcp-server/print", "=.proplist=name,address-pool")
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device": ctx.device.Name,
-			"error":  err,
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
 		}).Error("error fetching DHCP server names")
 		return nil, err
 	}
 
-	names := []string{}
+	servers := []dhcpServer{}
+	for _, re := range reply.Re {
+		servers = append(servers, dhcpServer{name: re.Map["name"], pool: re.Map["address-pool"]})
+	}
+
+	return servers, nil
+}
+
+// fetchPoolSizes returns the number of addresses of the IPv4 pools by name.
+func (c *dhcpCollector) fetchPoolSizes(ctx *collectorContext) (map[string]float64, error) {
+	reply, err := ctx.client.Run("/ip/pool/print", "=.proplist=name,ranges")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching DHCP pool ranges")
+		return nil, err
+	}
+
+	sizes := make(map[string]float64)
 	for _, re := range reply.Re {
-		names = append(names, re.Map["name"])
+		size, err := poolSize(re.Map["ranges"])
+		if err != nil {
+			ctx.logger().WithFields(log.Fields{
+				"pool":  re.Map["name"],
+				"error": err,
+			}).Error("error parsing DHCP pool ranges")
+			continue
+		}
+		sizes[re.Map["name"]] = size
 	}
 
-	return names, nil
+	return sizes, nil
 }
 
-func (c *dhcpCollector) colllectForDHCPServer(ctx *collectorContext, dhcpServer string) error {
+// poolSize returns the number of addresses in the IPv4 ranges of a pool, like
+// 10.0.0.10-10.0.0.254,10.0.1.0/24.
+func poolSize(ranges string) (float64, error) {
+	var total float64
+	for _, r := range strings.Split(ranges, ",") {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+
+		if strings.Contains(r, "/") {
+			prefix, err := netip.ParsePrefix(r)
+			if err != nil || !prefix.Addr().Is4() {
+				return 0, fmt.Errorf("invalid range %s", r)
+			}
+			total += float64(uint64(1) << (32 - prefix.Bits()))
+			continue
+		}
+
+		from, to, ok := strings.Cut(r, "-")
+		if !ok {
+			to = from
+		}
+		first, err := netip.ParseAddr(from)
+		if err != nil || !first.Is4() {
+			return 0, fmt.Errorf("invalid range %s", r)
+		}
+		last, err := netip.ParseAddr(to)
+		if err != nil || !last.Is4() || last.Less(first) {
+			return 0, fmt.Errorf("invalid range %s", r)
+		}
+		a, b := first.As4(), last.As4()
+		total += float64(binary.BigEndian.Uint32(b[:])-binary.BigEndian.Uint32(a[:])) + 1
+	}
+
+	return total, nil
+}
+
+// colllectForDHCPServer exports the number of active leases of the server and
+// returns it, or -1 if the device did not report it.
+func (c *dhcpCollector) colllectForDHCPServer(ctx *collectorContext, dhcpServer string) (float64, error) {
 	reply, err := ctx.client.Run("/ip/dhcp-server/lease/print", fmt.Sprintf("?server=%s", dhcpServer), "=active=", "=count-only=")
 	if err != nil {
-		log.WithFields(log.Fields{
+		ctx.logger().WithFields(log.Fields{
 			"dhcp_server": dhcpServer,
-			"device":      ctx.device.Name,
 			"error":       err,
 		}).Error("error fetching DHCP lease counts")
-		return err
+		return 0, err
 	}
 	if reply.Done.Map["ret"] == "" {
-		return nil
+		return -1, nil
 	}
 	v, err := strconv.ParseFloat(reply.Done.Map["ret"], 32)
 	if err != nil {
-		log.WithFields(log.Fields{
+		ctx.logger().WithFields(log.Fields{
 			"dhcp_server": dhcpServer,
-			"device":      ctx.device.Name,
 			"error":       err,
 		}).Error("error parsing DHCP lease counts")
-		return err
+		return 0, err
 	}
 
 	ctx.ch <- prometheus.MustNewConstMetric(c.leasesActiveCountDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, dhcpServer)
-	return nil
+	return v, nil
 }
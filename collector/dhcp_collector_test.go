@@ -0,0 +1,25 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolSize(t *testing.T) {
+	for ranges, size := range map[string]float64{
+		"10.0.0.10-10.0.0.254":             245,
+		"10.0.0.10-10.0.0.254,10.0.1.0/24": 501,
+		"192.168.88.1":                     1,
+		"":                                 0,
+	} {
+		v, err := poolSize(ranges)
+		assert.NoError(t, err, ranges)
+		assert.Equal(t, size, v, ranges)
+	}
+
+	for _, ranges := range []string{"10.0.0.254-10.0.0.1", "fd00::/64", "10.0.0.1-fd00::1", "pool"} {
+		_, err := poolSize(ranges)
+		assert.Error(t, err, ranges)
+	}
+}
@@ -9,27 +9,60 @@ import (
 	"gopkg.in/routeros.v2/proto"
 )
 
+// leaseExpiryBuckets are the upper bounds, in seconds, of the remaining lease
+// time histogram. They range from one minute to one week.
+var leaseExpiryBuckets = []float64{60, 300, 900, 1800, 3600, 7200, 21600, 43200, 86400, 259200, 604800}
+
 type dhcpLeaseCollector struct {
-	props        []string
-	descriptions *prometheus.Desc
+	commentLabels    bool
+	props            []string
+	descriptions     *prometheus.Desc
+	statusCountDesc  *prometheus.Desc
+	typeCountDesc    *prometheus.Desc
+	expiresAfterDesc *prometheus.Desc
+	leaseExpiryDesc  *prometheus.Desc
+}
+
+// leaseSummary aggregates the leases of a single DHCP server.
+type leaseSummary struct {
+	statusCounts map[string]float64
+	typeCounts   map[string]float64
+	expiryCount  uint64
+	expirySum    float64
+	expiryCounts []uint64
 }
 
 func (c *dhcpLeaseCollector) init() {
-	c.props = []string{"active-mac-address", "server", "status", "expires-after", "active-address", "host-name"}
+	c.props = []string{"active-mac-address", "server", "status", "expires-after", "active-address", "host-name", "dynamic"}
 
 	labelNames := []string{"name", "address", "activemacaddress", "server", "status", "expiresafter", "activeaddress", "hostname"}
+	leaseLabelNames := []string{"activemacaddress", "activeaddress", "hostname"}
+	if c.commentLabels {
+		c.props = append(c.props, "comment")
+		labelNames = append(labelNames, "comment")
+		leaseLabelNames = append(leaseLabelNames, "comment")
+	}
 	c.descriptions = description("dhcp", "leases_metrics", "number of metrics", labelNames)
 
+	summaryLabelNames := []string{"name", "address", "server"}
+	c.statusCountDesc = description("dhcp", "leases_status_count", "number of leases per DHCP server and lease status", append(summaryLabelNames, "status"))
+	c.typeCountDesc = description("dhcp", "leases_type_count", "number of dynamic and static leases per DHCP server", append(summaryLabelNames, "type"))
+	c.expiresAfterDesc = description("dhcp", "leases_expires_after_seconds", "distribution of the remaining lease time per DHCP server", summaryLabelNames)
+	c.leaseExpiryDesc = description("dhcp", "lease_expires_after_seconds", "remaining time of the bound lease", append(summaryLabelNames, leaseLabelNames...))
 }
 
-func newDHCPLCollector() routerOSCollector {
-	c := &dhcpLeaseCollector{}
+func newDHCPLCollector(commentLabels bool) routerOSCollector {
+	c := &dhcpLeaseCollector{commentLabels: commentLabels}
 	c.init()
 	return c
 }
 
 func (c *dhcpLeaseCollector) describe(ch chan<- *prometheus.Desc) {
 	ch <- c.descriptions
+	ch <- c.statusCountDesc
+	ch <- c.typeCountDesc
+	ch <- c.expiresAfterDesc
+	ch <- c.leaseExpiryDesc
 }
 
 func (c *dhcpLeaseCollector) collect(ctx *collectorContext) error {
@@ -38,19 +71,25 @@ func (c *dhcpLeaseCollector) collect(ctx *collectorContext) error {
 		return err
 	}
 
+	summaries := make(map[string]*leaseSummary)
 	for _, re := range stats {
-		c.collectMetric(ctx, re)
+		c.summarize(ctx, re, summaries)
+
+		if re.Map["status"] == "bound" {
+			c.collectMetric(ctx, re)
+		}
 	}
 
+	c.collectSummaries(ctx, summaries)
+
 	return nil
 }
 
 func (c *dhcpLeaseCollector) fetch(ctx *collectorContext) ([]*proto.Sentence, error) {
-	reply, err := ctx.client.Run("/ip/dhcp-server/lease/print", "?status=bound", "=.proplist="+strings.Join(c.props, ","))
+	reply, err := ctx.client.Run("/ip/dhcp-server/lease/print", "=.proplist="+strings.Join(c.props, ","))
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device": ctx.device.Name,
-			"error":  err,
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
 		}).Error("error fetching DHCP leases metrics")
 		return nil, err
 	}
@@ -58,13 +97,75 @@ func (c *dhcpLeaseCollector) fetch(ctx *collectorContext) ([]*proto.Sentence, er
 	return reply.Re, nil
 }
 
+func (c *dhcpLeaseCollector) summarize(ctx *collectorContext, re *proto.Sentence, summaries map[string]*leaseSummary) {
+	server := re.Map["server"]
+	s, ok := summaries[server]
+	if !ok {
+		s = &leaseSummary{
+			statusCounts: make(map[string]float64),
+			typeCounts:   make(map[string]float64),
+			expiryCounts: make([]uint64, len(leaseExpiryBuckets)),
+		}
+		summaries[server] = s
+	}
+
+	if status := re.Map["status"]; status != "" {
+		s.statusCounts[status]++
+	}
+
+	if re.Map["dynamic"] == "true" {
+		s.typeCounts["dynamic"]++
+	} else {
+		s.typeCounts["static"]++
+	}
+
+	// static leases which are not in use have no expiry
+	if re.Map["expires-after"] == "" {
+		return
+	}
+
+	f, err := parseDuration(re.Map["expires-after"])
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"property": "expires-after",
+			"value":    re.Map["expires-after"],
+			"error":    err,
+		}).Error("error parsing duration metric value")
+		return
+	}
+
+	s.expiryCount++
+	s.expirySum += f
+	for i, b := range leaseExpiryBuckets {
+		if f <= b {
+			s.expiryCounts[i]++
+		}
+	}
+}
+
+func (c *dhcpLeaseCollector) collectSummaries(ctx *collectorContext, summaries map[string]*leaseSummary) {
+	for server, s := range summaries {
+		for status, v := range s.statusCounts {
+			ctx.ch <- prometheus.MustNewConstMetric(c.statusCountDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, server, status)
+		}
+		for t, v := range s.typeCounts {
+			ctx.ch <- prometheus.MustNewConstMetric(c.typeCountDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, server, t)
+		}
+
+		buckets := make(map[float64]uint64, len(leaseExpiryBuckets))
+		for i, b := range leaseExpiryBuckets {
+			buckets[b] = s.expiryCounts[i]
+		}
+		ctx.ch <- prometheus.MustNewConstHistogram(c.expiresAfterDesc, s.expiryCount, s.expirySum, buckets, ctx.device.Name, ctx.device.Address, server)
+	}
+}
+
 func (c *dhcpLeaseCollector) collectMetric(ctx *collectorContext, re *proto.Sentence) {
 	v := 1.0
 
 	f, err := parseDuration(re.Map["expires-after"])
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device":   ctx.device.Name,
+		ctx.logger().WithFields(log.Fields{
 			"property": "expires-after",
 			"value":    re.Map["expires-after"],
 			"error":    err,
@@ -79,13 +180,21 @@ func (c *dhcpLeaseCollector) collectMetric(ctx *collectorContext, re *proto.Sent
 	// QuoteToASCII because of broken DHCP clients
 	hostname := strconv.QuoteToASCII(re.Map["host-name"])
 
-	metric, err := prometheus.NewConstMetric(c.descriptions, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, activemacaddress, server, status, strconv.FormatFloat(f, 'f', 0, 64), activeaddress, hostname)
+	labelValues := []string{ctx.device.Name, ctx.device.Address, activemacaddress, server, status, strconv.FormatFloat(f, 'f', 0, 64), activeaddress, hostname}
+	expiryLabelValues := []string{ctx.device.Name, ctx.device.Address, server, activemacaddress, activeaddress, hostname}
+	if c.commentLabels {
+		labelValues = append(labelValues, re.Map["comment"])
+		expiryLabelValues = append(expiryLabelValues, re.Map["comment"])
+	}
+
+	metric, err := prometheus.NewConstMetric(c.descriptions, prometheus.GaugeValue, v, labelValues...)
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device": ctx.device.Name,
-			"error":  err,
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
 		}).Error("error parsing dhcp lease")
 		return
 	}
 	ctx.ch <- metric
+
+	ctx.ch <- prometheus.MustNewConstMetric(c.leaseExpiryDesc, prometheus.GaugeValue, f, expiryLabelValues...)
 }
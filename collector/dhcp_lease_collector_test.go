@@ -0,0 +1,47 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/routeros.v2/proto"
+
+	"mikrotik-exporter/config"
+)
+
+func TestLeaseCommentLabels(t *testing.T) {
+	re := &proto.Sentence{Map: map[string]string{
+		"active-mac-address": "AA:BB:CC:DD:EE:FF",
+		"server":             "lan",
+		"status":             "bound",
+		"expires-after":      "5m",
+		"active-address":     "10.0.0.10",
+		"host-name":          "printer",
+		"comment":            "office printer",
+	}}
+
+	for _, commentLabels := range []bool{false, true} {
+		c := newDHCPLCollector(commentLabels).(*dhcpLeaseCollector)
+		ch := make(chan prometheus.Metric, 2)
+		c.collectMetric(&collectorContext{ch: ch, device: &config.Device{Name: "r1"}}, re)
+		close(ch)
+
+		for m := range ch {
+			var out dto.Metric
+			assert.NoError(t, m.Write(&out))
+			comment := ""
+			for _, l := range out.Label {
+				if l.GetName() == "comment" {
+					comment = l.GetValue()
+				}
+			}
+			if commentLabels {
+				assert.Equal(t, "office printer", comment)
+			} else {
+				assert.Empty(t, comment)
+			}
+		}
+	}
+}
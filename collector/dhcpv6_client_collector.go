@@ -0,0 +1,127 @@
+package collector
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+type dhcpv6ClientCollector struct {
+	boundDesc   *prometheus.Desc
+	statusDesc  *prometheus.Desc
+	prefixDesc  *prometheus.Desc
+	expiresDesc *prometheus.Desc
+	changesDesc *prometheus.Desc
+
+	mu       sync.Mutex
+	prefixes map[string]*prefixHistory
+}
+
+// prefixHistory is the last seen delegated prefix of a DHCPv6 client and the
+// number of changes of it seen by the exporter.
+type prefixHistory struct {
+	prefix  string
+	changes float64
+}
+
+func newDHCPv6ClientCollector() routerOSCollector {
+	c := &dhcpv6ClientCollector{
+		prefixes: make(map[string]*prefixHistory),
+	}
+	c.init()
+	return c
+}
+
+func (c *dhcpv6ClientCollector) init() {
+	const prefix = "dhcpv6_client"
+	labelNames := []string{"name", "address", "interface"}
+	c.boundDesc = description(prefix, "bound", "whether the DHCPv6 client has a lease", labelNames)
+	c.statusDesc = description(prefix, "status", "status of the DHCPv6 client, 1 for the current status", append(labelNames, "status"))
+	c.prefixDesc = description(prefix, "prefix_info", "prefix delegated to the DHCPv6 client", append(labelNames, "prefix"))
+	c.expiresDesc = description(prefix, "prefix_expires_after_seconds", "time until the delegated prefix expires", labelNames)
+	c.changesDesc = description(prefix, "prefix_changes", "number of changes of the delegated prefix seen by the exporter", labelNames)
+}
+
+func (c *dhcpv6ClientCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.boundDesc
+	ch <- c.statusDesc
+	ch <- c.prefixDesc
+	ch <- c.expiresDesc
+	ch <- c.changesDesc
+}
+
+func (c *dhcpv6ClientCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/ipv6/dhcp-client/print", "?disabled=false", "=.proplist=interface,status,prefix,prefix-expires-after")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching dhcpv6 client metrics")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		c.collectForStat(re, ctx)
+	}
+
+	return nil
+}
+
+func (c *dhcpv6ClientCollector) collectForStat(re *proto.Sentence, ctx *collectorContext) {
+	iface := re.Map["interface"]
+	labelValues := []string{ctx.device.Name, ctx.device.Address, iface}
+
+	bound := 0.0
+	if re.Map["status"] == "bound" {
+		bound = 1.0
+	}
+	ctx.ch <- prometheus.MustNewConstMetric(c.boundDesc, prometheus.GaugeValue, bound, labelValues...)
+	ctx.ch <- prometheus.MustNewConstMetric(c.statusDesc, prometheus.GaugeValue, 1, append(labelValues, re.Map["status"])...)
+
+	prefix, expires := parseDelegatedPrefix(re.Map["prefix"])
+	if expires == "" {
+		expires = re.Map["prefix-expires-after"]
+	}
+	if prefix == "" {
+		return
+	}
+
+	ctx.ch <- prometheus.MustNewConstMetric(c.prefixDesc, prometheus.GaugeValue, 1, append(labelValues, prefix)...)
+	if expires != "" {
+		if v, err := parseDuration(expires); err == nil {
+			ctx.ch <- prometheus.MustNewConstMetric(c.expiresDesc, prometheus.GaugeValue, v, labelValues...)
+		}
+	}
+
+	changes := c.change(ctx.device.Name+"/"+iface, prefix)
+	ctx.ch <- prometheus.MustNewConstMetric(c.changesDesc, prometheus.CounterValue, changes, labelValues...)
+}
+
+// change records the delegated prefix of the client and returns the number of
+// changes seen so far.
+func (c *dhcpv6ClientCollector) change(key, prefix string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h, ok := c.prefixes[key]
+	if !ok {
+		c.prefixes[key] = &prefixHistory{prefix: prefix}
+		return 0
+	}
+
+	if h.prefix != prefix {
+		h.prefix = prefix
+		h.changes++
+	}
+
+	return h.changes
+}
+
+// parseDelegatedPrefix splits the prefix reported by RouterOS 6, which is
+// followed by the time until it expires, e.g. "2001:db8:1::/56, 2d23h59m".
+func parseDelegatedPrefix(value string) (string, string) {
+	prefix, expires, _ := strings.Cut(value, ",")
+	return strings.TrimSpace(prefix), strings.TrimSpace(expires)
+}
@@ -0,0 +1,25 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDelegatedPrefix(t *testing.T) {
+	prefix, expires := parseDelegatedPrefix("2001:db8:1::/56, 2d23h59m")
+	assert.Equal(t, "2001:db8:1::/56", prefix)
+	assert.Equal(t, "2d23h59m", expires)
+
+	prefix, expires = parseDelegatedPrefix("2001:db8:1::/56")
+	assert.Equal(t, "2001:db8:1::/56", prefix)
+	assert.Equal(t, "", expires)
+}
+
+func TestDHCPv6ClientPrefixChanges(t *testing.T) {
+	c := newDHCPv6ClientCollector().(*dhcpv6ClientCollector)
+
+	assert.Equal(t, float64(0), c.change("router1/ether1", "2001:db8:1::/56"))
+	assert.Equal(t, float64(0), c.change("router1/ether1", "2001:db8:1::/56"))
+	assert.Equal(t, float64(1), c.change("router1/ether1", "2001:db8:2::/56"))
+}
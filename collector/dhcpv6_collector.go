@@ -48,9 +48,8 @@ func (c *dhcpv6Collector) collect(ctx *collectorContext) error {
 func (c *dhcpv6Collector) fetchDHCPServerNames(ctx *collectorContext) ([]string, error) {
 	reply, err := ctx.client.Run("/ipv6/dhcp-server/print", "=.proplist=name")
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device": ctx.device.Name,
-			"error":  err,
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
 		}).Error("error fetching DHCPv6 server names")
 		return nil, err
 	}
@@ -66,9 +65,8 @@ func (c *dhcpv6Collector) fetchDHCPServerNames(ctx *collectorContext) ([]string,
 func (c *dhcpv6Collector) colllectForDHCPServer(ctx *collectorContext, dhcpServer string) error {
 	reply, err := ctx.client.Run("/ipv6/dhcp-server/binding/print", fmt.Sprintf("?server=%s", dhcpServer), "=count-only=")
 	if err != nil {
-		log.WithFields(log.Fields{
+		ctx.logger().WithFields(log.Fields{
 			"dhcpv6_server": dhcpServer,
-			"device":        ctx.device.Name,
 			"error":         err,
 		}).Error("error fetching DHCPv6 binding counts")
 		return err
@@ -76,9 +74,8 @@ func (c *dhcpv6Collector) colllectForDHCPServer(ctx *collectorContext, dhcpServe
 
 	v, err := strconv.ParseFloat(reply.Done.Map["ret"], 32)
 	if err != nil {
-		log.WithFields(log.Fields{
+		ctx.logger().WithFields(log.Fields{
 			"dhcpv6_server": dhcpServer,
-			"device":        ctx.device.Name,
 			"error":         err,
 		}).Error("error parsing DHCPv6 binding counts")
 		return err
@@ -87,3 +84,7 @@ func (c *dhcpv6Collector) colllectForDHCPServer(ctx *collectorContext, dhcpServe
 	ctx.ch <- prometheus.MustNewConstMetric(c.bindingCountDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, dhcpServer)
 	return nil
 }
+
+func (c *dhcpv6Collector) requiredMenu() string {
+	return "/ipv6/dhcp-server"
+}
@@ -0,0 +1,110 @@
+package collector
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+type diskCollector struct {
+	sizeDesc      *prometheus.Desc
+	freeDesc      *prometheus.Desc
+	statusDesc    *prometheus.Desc
+	badBlocksDesc *prometheus.Desc
+	writesDesc    *prometheus.Desc
+}
+
+func newDiskCollector() routerOSCollector {
+	c := &diskCollector{}
+	c.init()
+	return c
+}
+
+func (c *diskCollector) init() {
+	const prefix = "disk"
+	labelNames := []string{"name", "address", "disk", "type"}
+	c.sizeDesc = description(prefix, "size_bytes", "size of the disk", labelNames)
+	c.freeDesc = description(prefix, "free_bytes", "free space on the disk", labelNames)
+	c.statusDesc = description(prefix, "status_info", "status of the disk", append(labelNames, "status"))
+	c.badBlocksDesc = description("system", "bad_blocks_ratio", "percentage of bad blocks of the system storage", []string{"name", "address"})
+	c.writesDesc = description("system", "write_sectors", "number of sectors written to the system storage", []string{"name", "address"})
+}
+
+func (c *diskCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.sizeDesc
+	ch <- c.freeDesc
+	ch <- c.statusDesc
+	ch <- c.badBlocksDesc
+	ch <- c.writesDesc
+}
+
+func (c *diskCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/disk/print")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching disk metrics")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		c.collectForStat(re, ctx)
+	}
+
+	return c.collectSystemStorage(ctx)
+}
+
+func (c *diskCollector) collectForStat(re *proto.Sentence, ctx *collectorContext) {
+	// RouterOS 7 identifies disks by slot, RouterOS 6 by name
+	disk := re.Map["slot"]
+	if disk == "" {
+		disk = re.Map["name"]
+	}
+	labelValues := []string{ctx.device.Name, ctx.device.Address, disk, re.Map["type"]}
+
+	for desc, property := range map[*prometheus.Desc]string{c.sizeDesc: "size", c.freeDesc: "free"} {
+		if re.Map[property] == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(re.Map[property], 64)
+		if err != nil {
+			ctx.logger().WithFields(log.Fields{
+				"disk":     disk,
+				"property": property,
+				"value":    re.Map[property],
+				"error":    err,
+			}).Error("error parsing disk metric value")
+			continue
+		}
+		ctx.ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, labelValues...)
+	}
+
+	if status := re.Map["status"]; status != "" {
+		ctx.ch <- prometheus.MustNewConstMetric(c.statusDesc, prometheus.GaugeValue, 1, append(labelValues, status)...)
+	}
+}
+
+func (c *diskCollector) collectSystemStorage(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/system/resource/print", "=.proplist=bad-blocks,write-sect-total")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching system storage metrics")
+		return err
+	}
+	if len(reply.Re) == 0 {
+		return nil
+	}
+
+	re := reply.Re[0]
+	if v, err := strconv.ParseFloat(re.Map["bad-blocks"], 64); err == nil {
+		ctx.ch <- prometheus.MustNewConstMetric(c.badBlocksDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address)
+	}
+	if v, err := strconv.ParseFloat(re.Map["write-sect-total"], 64); err == nil {
+		ctx.ch <- prometheus.MustNewConstMetric(c.writesDesc, prometheus.CounterValue, v, ctx.device.Name, ctx.device.Address)
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package collector
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+type dnsCollector struct {
+	cacheSizeDesc    *prometheus.Desc
+	cacheUsedDesc    *prometheus.Desc
+	cacheEntriesDesc *prometheus.Desc
+	serverDesc       *prometheus.Desc
+}
+
+func newDNSCollector() routerOSCollector {
+	c := &dnsCollector{}
+	c.init()
+	return c
+}
+
+func (c *dnsCollector) init() {
+	const prefix = "dns"
+	labelNames := []string{"name", "address"}
+	c.cacheSizeDesc = description(prefix, "cache_size_bytes", "configured maximum size of the DNS cache", labelNames)
+	c.cacheUsedDesc = description(prefix, "cache_used_bytes", "used size of the DNS cache", labelNames)
+	c.cacheEntriesDesc = description(prefix, "cache_entries", "number of entries in the DNS cache", labelNames)
+	c.serverDesc = description(prefix, "server_info", "DNS servers used by the resolver (static or dynamic)", append(labelNames, "server", "type"))
+}
+
+func (c *dnsCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.cacheSizeDesc
+	ch <- c.cacheUsedDesc
+	ch <- c.cacheEntriesDesc
+	ch <- c.serverDesc
+}
+
+func (c *dnsCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/ip/dns/print", "=.proplist=cache-size,cache-used,servers,dynamic-servers")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching dns metrics")
+		return err
+	}
+	if len(reply.Re) == 0 {
+		return nil
+	}
+
+	re := reply.Re[0]
+	for property, desc := range map[string]*prometheus.Desc{"cache-size": c.cacheSizeDesc, "cache-used": c.cacheUsedDesc} {
+		v, err := parseKiB(re.Map[property])
+		if err != nil {
+			ctx.logger().WithFields(log.Fields{
+				"property": property,
+				"value":    re.Map[property],
+				"error":    err,
+			}).Error("error parsing dns metric value")
+			continue
+		}
+		ctx.ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address)
+	}
+
+	for property, serverType := range map[string]string{"servers": "static", "dynamic-servers": "dynamic"} {
+		for _, s := range strings.Split(re.Map[property], ",") {
+			if s == "" {
+				continue
+			}
+			ctx.ch <- prometheus.MustNewConstMetric(c.serverDesc, prometheus.GaugeValue, 1, ctx.device.Name, ctx.device.Address, s, serverType)
+		}
+	}
+
+	return c.collectCacheEntries(ctx)
+}
+
+func (c *dnsCollector) collectCacheEntries(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/ip/dns/cache/print", "=count-only=")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching dns cache entries")
+		return err
+	}
+
+	v, err := strconv.ParseFloat(reply.Done.Map["ret"], 64)
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"value": reply.Done.Map["ret"],
+			"error": err,
+		}).Error("error parsing dns cache entries")
+		return nil
+	}
+	ctx.ch <- prometheus.MustNewConstMetric(c.cacheEntriesDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address)
+
+	return nil
+}
+
+// parseKiB parses sizes in KiB, which RouterOS 7 reports with a unit suffix.
+func parseKiB(value string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(value, "KiB"), 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return v * 1024, nil
+}
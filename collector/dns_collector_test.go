@@ -0,0 +1,20 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKiB(t *testing.T) {
+	v, err := parseKiB("2048")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(2097152), v)
+
+	v, err = parseKiB("40KiB")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(40960), v)
+
+	_, err = parseKiB("")
+	assert.Error(t, err)
+}
@@ -0,0 +1,55 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+type dot1xCollector struct {
+	sessionsDesc *prometheus.Desc
+	sessionDesc  *prometheus.Desc
+}
+
+func newDot1xCollector() routerOSCollector {
+	c := &dot1xCollector{}
+	c.init()
+	return c
+}
+
+func (c *dot1xCollector) init() {
+	const prefix = "dot1x"
+	c.sessionsDesc = description(prefix, "sessions", "number of 802.1X sessions on the interface", []string{"name", "address", "interface"})
+	c.sessionDesc = description(prefix, "session_info", "active 802.1X session", []string{"name", "address", "interface", "username", "vlan", "auth_method"})
+}
+
+func (c *dot1xCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.sessionsDesc
+	ch <- c.sessionDesc
+}
+
+func (c *dot1xCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/interface/dot1x/server/active/print", "=.proplist=interface,username,vlan-id,auth-method")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching dot1x sessions")
+		return err
+	}
+
+	counts := make(map[string]float64)
+	for _, re := range reply.Re {
+		counts[re.Map["interface"]]++
+		ctx.ch <- prometheus.MustNewConstMetric(c.sessionDesc, prometheus.GaugeValue, 1, ctx.device.Name, ctx.device.Address,
+			re.Map["interface"], re.Map["username"], re.Map["vlan-id"], re.Map["auth-method"])
+	}
+
+	for iface, v := range counts {
+		ctx.ch <- prometheus.MustNewConstMetric(c.sessionsDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, iface)
+	}
+
+	return nil
+}
+
+func (c *dot1xCollector) requiredMenu() string {
+	return "/interface/dot1x/server"
+}
@@ -0,0 +1,94 @@
+package collector
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+// firewallCollector exports the rule counters of a firewall table, e.g. nat.
+type firewallCollector struct {
+	table        string
+	props        []string
+	descriptions map[string]*prometheus.Desc
+}
+
+func newFirewallCollector(table string) routerOSCollector {
+	c := &firewallCollector{table: table}
+	c.init()
+	return c
+}
+
+func (c *firewallCollector) init() {
+	c.props = []string{".id", "chain", "action", "comment", "packets", "bytes"}
+
+	prefix := "firewall_" + c.table
+	labelNames := []string{"name", "address", "id", "chain", "action", "comment"}
+	c.descriptions = map[string]*prometheus.Desc{
+		"packets": description(prefix, "rule_packets", "number of packets matched by the "+c.table+" rule", labelNames),
+		"bytes":   description(prefix, "rule_bytes", "number of bytes matched by the "+c.table+" rule", labelNames),
+	}
+}
+
+func (c *firewallCollector) describe(ch chan<- *prometheus.Desc) {
+	for _, d := range c.descriptions {
+		ch <- d
+	}
+}
+
+func (c *firewallCollector) collect(ctx *collectorContext) error {
+	stats, err := c.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, re := range stats {
+		c.collectForStat(re, ctx)
+	}
+
+	return nil
+}
+
+func (c *firewallCollector) fetch(ctx *collectorContext) ([]*proto.Sentence, error) {
+	reply, err := ctx.client.Run("/ip/firewall/"+c.table+"/print", "?disabled=false", "=.proplist="+strings.Join(c.props, ","))
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"table": c.table,
+			"error": err,
+		}).Error("error fetching firewall metrics")
+		return nil, err
+	}
+
+	return reply.Re, nil
+}
+
+func (c *firewallCollector) collectForStat(re *proto.Sentence, ctx *collectorContext) {
+	for _, p := range c.props[4:] {
+		c.collectMetricForProperty(p, re, ctx)
+	}
+}
+
+func (c *firewallCollector) collectMetricForProperty(property string, re *proto.Sentence, ctx *collectorContext) {
+	value := re.Map[property]
+	if value == "" {
+		return
+	}
+
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"table":    c.table,
+			"rule":     re.Map[".id"],
+			"property": property,
+			"value":    value,
+			"error":    err,
+		}).Error("error parsing firewall metric value")
+		return
+	}
+
+	ctx.ch <- prometheus.MustNewConstMetric(c.descriptions[property], prometheus.CounterValue, v, ctx.device.Name, ctx.device.Address,
+		re.Map[".id"], re.Map["chain"], re.Map["action"], re.Map["comment"])
+}
@@ -29,9 +29,8 @@ func (c *firmwareCollector) describe(ch chan<- *prometheus.Desc) {
 func (c *firmwareCollector) collect(ctx *collectorContext) error {
 	reply, err := ctx.client.Run("/system/package/getall")
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device": ctx.device.Name,
-			"error":  err,
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
 		})
 		return err
 	}
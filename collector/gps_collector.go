@@ -0,0 +1,145 @@
+package collector
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+type gpsCollector struct {
+	validDesc      *prometheus.Desc
+	satellitesDesc *prometheus.Desc
+	latitudeDesc   *prometheus.Desc
+	longitudeDesc  *prometheus.Desc
+	altitudeDesc   *prometheus.Desc
+	speedDesc      *prometheus.Desc
+}
+
+func newGPSCollector() routerOSCollector {
+	c := &gpsCollector{}
+	c.init()
+	return c
+}
+
+func (c *gpsCollector) init() {
+	const prefix = "gps"
+	labelNames := []string{"name", "address"}
+	c.validDesc = description(prefix, "valid", "whether the GPS receiver has a valid fix", labelNames)
+	c.satellitesDesc = description(prefix, "satellites", "number of satellites used for the fix", labelNames)
+	c.latitudeDesc = description(prefix, "latitude_degrees", "latitude of the device", labelNames)
+	c.longitudeDesc = description(prefix, "longitude_degrees", "longitude of the device", labelNames)
+	c.altitudeDesc = description(prefix, "altitude_meters", "altitude of the device", labelNames)
+	c.speedDesc = description(prefix, "speed_meters_per_second", "speed of the device", labelNames)
+}
+
+func (c *gpsCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.validDesc
+	ch <- c.satellitesDesc
+	ch <- c.latitudeDesc
+	ch <- c.longitudeDesc
+	ch <- c.altitudeDesc
+	ch <- c.speedDesc
+}
+
+func (c *gpsCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/system/gps/monitor", "=once=")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching gps metrics")
+		return err
+	}
+	if len(reply.Re) == 0 {
+		return nil
+	}
+
+	re := reply.Re[0]
+	valid := boolToFloat(re.Map["valid"])
+	ctx.ch <- prometheus.MustNewConstMetric(c.validDesc, prometheus.GaugeValue, valid, ctx.device.Name, ctx.device.Address)
+
+	if v, err := strconv.ParseFloat(re.Map["satellites"], 64); err == nil {
+		ctx.ch <- prometheus.MustNewConstMetric(c.satellitesDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address)
+	}
+
+	// the position is meaningless without a fix
+	if valid == 0 {
+		return nil
+	}
+
+	for desc, property := range map[*prometheus.Desc]string{c.latitudeDesc: "latitude", c.longitudeDesc: "longitude"} {
+		v, err := parseCoordinate(re.Map[property])
+		if err != nil {
+			ctx.logger().WithFields(log.Fields{
+				"property": property,
+				"value":    re.Map[property],
+				"error":    err,
+			}).Error("error parsing gps coordinate")
+			continue
+		}
+		ctx.ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address)
+	}
+
+	if v, err := parseLeadingFloat(re.Map["altitude"]); err == nil {
+		ctx.ch <- prometheus.MustNewConstMetric(c.altitudeDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address)
+	}
+	if v, err := parseLeadingFloat(re.Map["speed"]); err == nil {
+		ctx.ch <- prometheus.MustNewConstMetric(c.speedDesc, prometheus.GaugeValue, v/3.6, ctx.device.Name, ctx.device.Address)
+	}
+
+	return nil
+}
+
+func (c *gpsCollector) requiredMenu() string {
+	return "/system/gps"
+}
+
+// parseLeadingFloat parses values followed by a unit, e.g. "23.4 m".
+func parseLeadingFloat(value string) (float64, error) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("empty value")
+	}
+
+	return strconv.ParseFloat(strings.TrimRightFunc(fields[0], func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	}), 64)
+}
+
+// parseCoordinate parses coordinates in decimal degrees ("56.943436") or in
+// degrees, minutes and seconds ("N 56 56' 36.369''").
+func parseCoordinate(value string) (float64, error) {
+	if v, err := strconv.ParseFloat(value, 64); err == nil {
+		return v, nil
+	}
+
+	fields := strings.Fields(strings.NewReplacer("'", " ", "\"", " ").Replace(value))
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("invalid coordinate %q", value)
+	}
+
+	sign := 1.0
+	switch fields[0] {
+	case "N", "E":
+	case "S", "W":
+		sign = -1.0
+	default:
+		return 0, fmt.Errorf("invalid hemisphere in coordinate %q", value)
+	}
+
+	var degrees float64
+	for i, f := range fields[1:] {
+		if i > 2 {
+			break
+		}
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid coordinate %q: %w", value, err)
+		}
+		degrees += v / [3]float64{1, 60, 3600}[i]
+	}
+
+	return sign * degrees, nil
+}
@@ -0,0 +1,37 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCoordinate(t *testing.T) {
+	v, err := parseCoordinate("56.943436")
+	assert.NoError(t, err)
+	assert.Equal(t, 56.943436, v)
+
+	v, err = parseCoordinate("N 56 56' 36.369''")
+	assert.NoError(t, err)
+	assert.InDelta(t, 56.943436, v, 1e-6)
+
+	v, err = parseCoordinate("W 24 6' 18''")
+	assert.NoError(t, err)
+	assert.InDelta(t, -24.105, v, 1e-6)
+
+	_, err = parseCoordinate("none")
+	assert.Error(t, err)
+}
+
+func TestParseLeadingFloat(t *testing.T) {
+	v, err := parseLeadingFloat("23.400000 m")
+	assert.NoError(t, err)
+	assert.Equal(t, 23.4, v)
+
+	v, err = parseLeadingFloat("36.0km/h")
+	assert.NoError(t, err)
+	assert.Equal(t, 36.0, v)
+
+	_, err = parseLeadingFloat("")
+	assert.Error(t, err)
+}
@@ -10,6 +10,7 @@ import (
 type healthCollector struct {
 	props        []string
 	descriptions map[string]*prometheus.Desc
+	sensorDesc   *prometheus.Desc
 }
 
 func newhealthCollector() routerOSCollector {
@@ -27,12 +28,14 @@ func (c *healthCollector) init() {
 	for i, p := range c.props {
 		c.descriptions[p] = descriptionForPropertyNameHelpText("health", p, labelNames, helpText[i])
 	}
+	c.sensorDesc = description("health", "sensor", "value of a health sensor of RouterOS 7 in the unit of its type, e.g. C, V, W or RPM", []string{"name", "address", "sensor", "type"})
 }
 
 func (c *healthCollector) describe(ch chan<- *prometheus.Desc) {
 	for _, d := range c.descriptions {
 		ch <- d
 	}
+	ch <- c.sensorDesc
 }
 
 func (c *healthCollector) collect(ctx *collectorContext) error {
@@ -43,7 +46,10 @@ func (c *healthCollector) collect(ctx *collectorContext) error {
 
 	for _, re := range stats {
 		if metric, ok := re.Map["name"]; ok {
-			c.collectMetricForProperty(metric, re, ctx)
+			c.collectSensor(metric, re, ctx)
+			if _, ok := c.descriptions[metric]; ok {
+				c.collectMetricForProperty(metric, re, ctx)
+			}
 		} else {
 			c.collectForStat(re, ctx)
 		}
@@ -55,9 +61,8 @@ func (c *healthCollector) collect(ctx *collectorContext) error {
 func (c *healthCollector) fetch(ctx *collectorContext) ([]*proto.Sentence, error) {
 	reply, err := ctx.client.Run("/system/health/print")
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device": ctx.device.Name,
-			"error":  err,
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
 		}).Error("error fetching system health metrics")
 		return nil, err
 	}
@@ -71,6 +76,22 @@ func (c *healthCollector) collectForStat(re *proto.Sentence, ctx *collectorConte
 	}
 }
 
+// collectSensor exports a sensor row of RouterOS 7 with name, value and type,
+// e.g. of several PSUs, temperature probes or fans. Sensors reporting states
+// like ok instead of numbers are skipped.
+func (c *healthCollector) collectSensor(sensor string, re *proto.Sentence, ctx *collectorContext) {
+	v, err := strconv.ParseFloat(re.Map["value"], 64)
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"sensor": sensor,
+			"value":  re.Map["value"],
+		}).Debug("skipping health sensor without numeric value")
+		return
+	}
+
+	ctx.ch <- prometheus.MustNewConstMetric(c.sensorDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, sensor, re.Map["type"])
+}
+
 func (c *healthCollector) collectMetricForProperty(property string, re *proto.Sentence, ctx *collectorContext) {
 	var v float64
 	var err error
@@ -87,8 +108,7 @@ func (c *healthCollector) collectMetricForProperty(property string, re *proto.Se
 	v, err = strconv.ParseFloat(value, 64)
 
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device":   ctx.device.Name,
+		ctx.logger().WithFields(log.Fields{
 			"property": name,
 			"value":    value,
 			"error":    err,
@@ -99,3 +119,7 @@ func (c *healthCollector) collectMetricForProperty(property string, re *proto.Se
 	desc := c.descriptions[name]
 	ctx.ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address)
 }
+
+func (c *healthCollector) requiredMenu() string {
+	return "/system/health"
+}
@@ -0,0 +1,41 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/routeros.v2/proto"
+
+	"mikrotik-exporter/config"
+)
+
+func TestHealthSensors(t *testing.T) {
+	c := newhealthCollector().(*healthCollector)
+	ch := make(chan prometheus.Metric, 3)
+	ctx := &collectorContext{ch: ch, device: &config.Device{Name: "r1"}}
+	for _, m := range []map[string]string{
+		{"name": "fan1-speed", "value": "5280", "type": "RPM"},
+		{"name": "psu1-state", "value": "ok", "type": ""},
+		{"name": "temperature", "value": "41", "type": "C"},
+	} {
+		c.collectSensor(m["name"], &proto.Sentence{Map: m}, ctx)
+	}
+	close(ch)
+
+	var sensors []string
+	for m := range ch {
+		var out dto.Metric
+		assert.NoError(t, m.Write(&out))
+		labels := make(map[string]string)
+		for _, l := range out.Label {
+			labels[l.GetName()] = l.GetValue()
+		}
+		sensors = append(sensors, labels["sensor"]+"/"+labels["type"])
+		if labels["sensor"] == "fan1-speed" {
+			assert.Equal(t, 5280.0, out.GetGauge().GetValue())
+		}
+	}
+	assert.Equal(t, []string{"fan1-speed/RPM", "temperature/C"}, sensors)
+}
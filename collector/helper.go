@@ -3,7 +3,6 @@ package collector
 import (
 	"fmt"
 	"math"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -12,13 +11,10 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-var durationRegex *regexp.Regexp
-var durationParts [6]time.Duration
-
-func init() {
-	durationRegex = regexp.MustCompile(`(?:(\d*)w)?(?:(\d*)d)?(?:(\d*)h)?(?:(\d*)m)?(?:(\d*)s)?(?:(\d*)ms)?`)
+var (
+	durationUnits = [6]string{"w", "d", "h", "m", "s", "ms"}
 	durationParts = [6]time.Duration{time.Hour * 168, time.Hour * 24, time.Hour, time.Minute, time.Second, time.Millisecond}
-}
+)
 
 func metricStringCleanup(in string) string {
 	return strings.Replace(in, "-", "_", -1)
@@ -46,6 +42,15 @@ func description(prefix, name, helpText string, labelNames []string) *prometheus
 	)
 }
 
+// boolToFloat converts RouterOS boolean values to 1 or 0.
+func boolToFloat(value string) float64 {
+	if value == "true" || value == "yes" {
+		return 1
+	}
+
+	return 0
+}
+
 func splitStringToFloats(metric string) (float64, float64, error) {
 	strs := strings.Split(metric, ",")
 	if len(strs) == 0 {
@@ -62,29 +67,53 @@ func splitStringToFloats(metric string) (float64, float64, error) {
 	return m1, m2, nil
 }
 
+// parseDuration parses RouterOS durations like 1w2d3h4m5s600ms into seconds
+// without allocating, as it runs for many values of each scrape.
 func parseDuration(duration string) (float64, error) {
-	var u time.Duration
-
-	reMatch := durationRegex.FindAllStringSubmatch(duration, -1)
-
-	// should get one and only one match back on the regex
-	if len(reMatch) != 1 {
-		return 0, fmt.Errorf("invalid duration value sent to regex")
-	} else {
-		for i, match := range reMatch[0] {
-			if match != "" && i != 0 {
-				v, err := strconv.Atoi(match)
-				if err != nil {
-					log.WithFields(log.Fields{
-						"duration": duration,
-						"value":    match,
-						"error":    err,
-					}).Error("error parsing duration field value")
-					return float64(0), err
-				}
-				u += time.Duration(v) * durationParts[i-1]
-			}
+	var (
+		u    time.Duration
+		next int
+	)
+
+	for rest := duration; rest != ""; {
+		i := 0
+		for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+			i++
 		}
+		digits := rest[:i]
+		rest = rest[i:]
+
+		j := 0
+		for j < len(rest) && (rest[j] < '0' || rest[j] > '9') {
+			j++
+		}
+		unit := rest[:j]
+		rest = rest[j:]
+
+		// units must follow each other in the order of durationUnits
+		k := next
+		for k < len(durationUnits) && durationUnits[k] != unit {
+			k++
+		}
+		if k == len(durationUnits) {
+			return 0, fmt.Errorf("invalid duration %q", duration)
+		}
+		next = k + 1
+
+		if digits == "" {
+			continue
+		}
+		v, err := strconv.Atoi(digits)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"duration": duration,
+				"value":    digits,
+				"error":    err,
+			}).Error("error parsing duration field value")
+			return float64(0), err
+		}
+		u += time.Duration(v) * durationParts[k]
 	}
+
 	return u.Seconds(), nil
 }
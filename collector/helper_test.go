@@ -105,11 +105,26 @@ func TestParseDuration(t *testing.T) {
 			4786440,
 			false,
 		},
+		{
+			"5s600ms",
+			5.6,
+			false,
+		},
+		{
+			"1ms",
+			0.001,
+			false,
+		},
 		{
 			"59",
 			0,
 			true,
 		},
+		{
+			"4m3h",
+			0,
+			true,
+		},
 		{
 			"s",
 			0,
@@ -0,0 +1,104 @@
+package collector
+
+import (
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+// configState is the last seen configuration history of a device.
+type configState struct {
+	hash    uint32
+	changes float64
+	changed time.Time
+}
+
+type historyCollector struct {
+	props       []string
+	hashDesc    *prometheus.Desc
+	changesDesc *prometheus.Desc
+	changedDesc *prometheus.Desc
+
+	mu     sync.Mutex
+	states map[string]*configState
+}
+
+func newHistoryCollector() routerOSCollector {
+	c := &historyCollector{
+		states: make(map[string]*configState),
+	}
+	c.init()
+	return c
+}
+
+func (c *historyCollector) init() {
+	c.props = []string{".id", "action", "by", "policy", "time"}
+
+	const prefix = "config"
+	labelNames := []string{"name", "address"}
+	c.hashDesc = description(prefix, "hash", "hash of the configuration history of the device", labelNames)
+	c.changesDesc = description(prefix, "changes_total", "number of configuration changes seen by the exporter", labelNames)
+	c.changedDesc = description(prefix, "last_change_timestamp_seconds", "time the exporter last saw the configuration change", labelNames)
+}
+
+func (c *historyCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hashDesc
+	ch <- c.changesDesc
+	ch <- c.changedDesc
+}
+
+func (c *historyCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/system/history/print", "=.proplist="+strings.Join(c.props, ","))
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching configuration history")
+		return err
+	}
+
+	s := c.update(ctx.device.Name, historyHash(reply.Re, c.props), time.Now())
+
+	ctx.ch <- prometheus.MustNewConstMetric(c.hashDesc, prometheus.GaugeValue, float64(s.hash), ctx.device.Name, ctx.device.Address)
+	ctx.ch <- prometheus.MustNewConstMetric(c.changesDesc, prometheus.CounterValue, s.changes, ctx.device.Name, ctx.device.Address)
+	if !s.changed.IsZero() {
+		ctx.ch <- prometheus.MustNewConstMetric(c.changedDesc, prometheus.GaugeValue, float64(s.changed.Unix()), ctx.device.Name, ctx.device.Address)
+	}
+
+	return nil
+}
+
+// update stores the hash of the device, counting a change if it differs from
+// the previously seen hash.
+func (c *historyCollector) update(device string, hash uint32, now time.Time) configState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.states[device]
+	if !ok {
+		s = &configState{hash: hash}
+		c.states[device] = s
+	} else if s.hash != hash {
+		s.hash = hash
+		s.changes++
+		s.changed = now
+	}
+
+	return *s
+}
+
+func historyHash(entries []*proto.Sentence, props []string) uint32 {
+	h := fnv.New32a()
+	for _, re := range entries {
+		for _, p := range props {
+			h.Write([]byte(re.Map[p]))
+			h.Write([]byte{0})
+		}
+	}
+
+	return h.Sum32()
+}
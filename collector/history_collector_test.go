@@ -0,0 +1,32 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/routeros.v2/proto"
+)
+
+func TestHistoryCollectorUpdate(t *testing.T) {
+	c := newHistoryCollector().(*historyCollector)
+	now := time.Now()
+
+	entries := []*proto.Sentence{{Map: map[string]string{".id": "*1", "action": "address added", "by": "admin"}}}
+	h1 := historyHash(entries, c.props)
+
+	s := c.update("router1", h1, now)
+	assert.Equal(t, float64(0), s.changes)
+	assert.True(t, s.changed.IsZero())
+
+	s = c.update("router1", h1, now.Add(time.Minute))
+	assert.Equal(t, float64(0), s.changes)
+
+	entries = append(entries, &proto.Sentence{Map: map[string]string{".id": "*2", "action": "route removed", "by": "admin"}})
+	h2 := historyHash(entries, c.props)
+	assert.NotEqual(t, h1, h2)
+
+	s = c.update("router1", h2, now.Add(2*time.Minute))
+	assert.Equal(t, float64(1), s.changes)
+	assert.Equal(t, now.Add(2*time.Minute), s.changed)
+}
@@ -0,0 +1,148 @@
+package collector
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+type hotspotCollector struct {
+	props        []string
+	descriptions map[string]*prometheus.Desc
+	activeDesc   *prometheus.Desc
+	hostsDesc    *prometheus.Desc
+}
+
+func newHotspotCollector() routerOSCollector {
+	c := &hotspotCollector{}
+	c.init()
+	return c
+}
+
+func (c *hotspotCollector) init() {
+	c.props = []string{"server", "user", "address", "mac-address", "bytes-in", "bytes-out", "uptime", "idle-time", "idle-timeout"}
+
+	const prefix = "hotspot"
+	labelNames := []string{"name", "address", "server", "user", "client_address", "mac_address"}
+	c.descriptions = map[string]*prometheus.Desc{
+		"bytes-in":     description(prefix, "user_bytes_in", "number of bytes received from the hotspot user", labelNames),
+		"bytes-out":    description(prefix, "user_bytes_out", "number of bytes sent to the hotspot user", labelNames),
+		"uptime":       description(prefix, "user_uptime_seconds", "time the hotspot user is logged in", labelNames),
+		"idle-time":    description(prefix, "user_idle_seconds", "time the hotspot user is idle", labelNames),
+		"idle-timeout": description(prefix, "user_idle_timeout_seconds", "idle time after which the hotspot user is logged out", labelNames),
+	}
+	c.activeDesc = description(prefix, "active_users", "number of users logged in to the hotspot", []string{"name", "address", "server"})
+	c.hostsDesc = description(prefix, "hosts", "number of hosts seen by the hotspot by state (authorized, bypassed or unauthorized)", []string{"name", "address", "server", "state"})
+}
+
+func (c *hotspotCollector) describe(ch chan<- *prometheus.Desc) {
+	for _, d := range c.descriptions {
+		ch <- d
+	}
+	ch <- c.activeDesc
+	ch <- c.hostsDesc
+}
+
+func (c *hotspotCollector) collect(ctx *collectorContext) error {
+	stats, err := c.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	active := make(map[string]float64)
+	for _, re := range stats {
+		active[re.Map["server"]]++
+		c.collectForStat(re, ctx)
+	}
+
+	for server, v := range active {
+		ctx.ch <- prometheus.MustNewConstMetric(c.activeDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, server)
+	}
+
+	return c.collectHosts(ctx)
+}
+
+func (c *hotspotCollector) fetch(ctx *collectorContext) ([]*proto.Sentence, error) {
+	reply, err := ctx.client.Run("/ip/hotspot/active/print", "=.proplist="+strings.Join(c.props, ","))
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching hotspot user metrics")
+		return nil, err
+	}
+
+	return reply.Re, nil
+}
+
+func (c *hotspotCollector) collectForStat(re *proto.Sentence, ctx *collectorContext) {
+	for _, p := range c.props[4:] {
+		c.collectMetricForProperty(p, re, ctx)
+	}
+}
+
+func (c *hotspotCollector) collectMetricForProperty(property string, re *proto.Sentence, ctx *collectorContext) {
+	value := re.Map[property]
+	if value == "" {
+		return
+	}
+
+	var (
+		v     float64
+		vtype = prometheus.GaugeValue
+		err   error
+	)
+	switch property {
+	case "bytes-in", "bytes-out":
+		vtype = prometheus.CounterValue
+		v, err = strconv.ParseFloat(value, 64)
+	default:
+		v, err = parseDuration(value)
+	}
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"user":     re.Map["user"],
+			"property": property,
+			"value":    value,
+			"error":    err,
+		}).Error("error parsing hotspot user metric value")
+		return
+	}
+
+	ctx.ch <- prometheus.MustNewConstMetric(c.descriptions[property], vtype, v, ctx.device.Name, ctx.device.Address,
+		re.Map["server"], re.Map["user"], re.Map["address"], re.Map["mac-address"])
+}
+
+func (c *hotspotCollector) collectHosts(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/ip/hotspot/host/print", "=.proplist=server,authorized,bypassed")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching hotspot host metrics")
+		return err
+	}
+
+	type hostKey struct {
+		server string
+		state  string
+	}
+	hosts := make(map[hostKey]float64)
+	for _, re := range reply.Re {
+		state := "unauthorized"
+		switch {
+		case re.Map["authorized"] == "true":
+			state = "authorized"
+		case re.Map["bypassed"] == "true":
+			state = "bypassed"
+		}
+		hosts[hostKey{re.Map["server"], state}]++
+	}
+
+	for k, v := range hosts {
+		ctx.ch <- prometheus.MustNewConstMetric(c.hostsDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, k.server, k.state)
+	}
+
+	return nil
+}
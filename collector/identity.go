@@ -0,0 +1,132 @@
+package collector
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	log "github.com/sirupsen/logrus"
+)
+
+// Modes of labelling the metrics of a device with its system identity
+const (
+	// identityReplace replaces the name of the device in its labels
+	identityReplace = "replace"
+	// identityAdd adds an identity label
+	identityAdd = "add"
+)
+
+// identityTTL is the time after which the identity of a device is fetched
+// again, e.g. once the router was renamed.
+const identityTTL = 10 * time.Minute
+
+// deviceLabelNames are the labels holding the name of the device.
+var deviceLabelNames = []string{"name", "device", "devicename"}
+
+type identityEntry struct {
+	identity string
+	fetched  time.Time
+}
+
+// identityCache keeps the system identities of the devices.
+type identityCache struct {
+	mu      sync.RWMutex
+	entries map[string]identityEntry
+}
+
+func newIdentityCache() *identityCache {
+	return &identityCache{entries: make(map[string]identityEntry)}
+}
+
+func (s *identityCache) get(device string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.entries[device].identity
+}
+
+// refresh fetches the identity of the device over the connection unless it
+// was fetched within the TTL.
+func (s *identityCache) refresh(device string, client *apiClient) {
+	s.mu.RLock()
+	e, ok := s.entries[device]
+	s.mu.RUnlock()
+	if ok && time.Since(e.fetched) < identityTTL {
+		return
+	}
+
+	reply, err := client.Run("/system/identity/print")
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": device,
+			"error":  err,
+		}).Warn("error fetching device identity")
+		return
+	}
+	for _, re := range reply.Re {
+		e.identity = re.Map["name"]
+	}
+	e.fetched = time.Now()
+
+	s.mu.Lock()
+	s.entries[device] = e
+	s.mu.Unlock()
+}
+
+func validIdentityMode(mode string) error {
+	switch mode {
+	case "", identityReplace, identityAdd:
+		return nil
+	default:
+		return fmt.Errorf("unknown identity labels mode %q", mode)
+	}
+}
+
+// withIdentity returns a channel labelling the metrics of the device sent to
+// ch with its identity and a function to call once all metrics have been sent.
+// The metrics are passed on unchanged until the identity is known.
+func withIdentity(ch chan<- prometheus.Metric, device, mode string, identities *identityCache) (chan<- prometheus.Metric, func()) {
+	labelled := make(chan prometheus.Metric)
+	done := make(chan struct{})
+
+	go func() {
+		for m := range labelled {
+			identity := identities.get(device)
+			switch {
+			case identity == "":
+				ch <- m
+			case mode == identityAdd:
+				ch <- &labelledMetric{Metric: m, labels: map[string]string{"identity": identity}}
+			default:
+				ch <- renamedMetric(m, device, identity)
+			}
+		}
+		close(done)
+	}()
+
+	return labelled, func() {
+		close(labelled)
+		<-done
+	}
+}
+
+// renamedMetric replaces the name of the device in the labels of the metric.
+func renamedMetric(m prometheus.Metric, device, identity string) prometheus.Metric {
+	var out dto.Metric
+	if err := m.Write(&out); err != nil {
+		return m
+	}
+
+	pairs := make([]*dto.LabelPair, len(out.Label))
+	for i, l := range out.Label {
+		pairs[i] = l
+		if l.GetValue() == device && slices.Contains(deviceLabelNames, l.GetName()) {
+			pairs[i] = &dto.LabelPair{Name: l.Name, Value: &identity}
+		}
+	}
+
+	return &relabeledMetric{Metric: m, labels: pairs}
+}
@@ -0,0 +1,36 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithIdentity(t *testing.T) {
+	desc := prometheus.NewDesc("test_metric", "test", []string{"name", "address", "interface"}, nil)
+	identities := newIdentityCache()
+
+	labels := func(mode string) []string {
+		ch := make(chan prometheus.Metric, 1)
+		out, flush := withIdentity(ch, "r1", mode, identities)
+		out <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, "r1", "10.0.0.1", "r1")
+		flush()
+
+		var m dto.Metric
+		assert.NoError(t, (<-ch).Write(&m))
+		res := make([]string, 0, len(m.Label))
+		for _, l := range m.Label {
+			res = append(res, l.GetName()+"="+l.GetValue())
+		}
+		return res
+	}
+
+	assert.Equal(t, []string{"address=10.0.0.1", "interface=r1", "name=r1"}, labels(identityReplace),
+		"metrics must be passed on unchanged until the identity is known")
+
+	identities.entries["r1"] = identityEntry{identity: "core-ams1"}
+	assert.Equal(t, []string{"address=10.0.0.1", "interface=r1", "name=core-ams1"}, labels(identityReplace))
+	assert.Equal(t, []string{"address=10.0.0.1", "identity=core-ams1", "interface=r1", "name=r1"}, labels(identityAdd))
+}
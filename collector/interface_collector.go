@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"slices"
 	"strconv"
 	"strings"
 
@@ -9,9 +10,16 @@ import (
 	"gopkg.in/routeros.v2/proto"
 )
 
+// interfaceLinkProps are the times the link of an interface last went up and
+// down, in the time zone of the device
+var interfaceLinkProps = []string{"last-link-up-time", "last-link-down-time"}
+
 type interfaceCollector struct {
 	props        []string
 	descriptions map[string]*prometheus.Desc
+	disabledDesc *prometheus.Desc
+	linkDescs    map[string]*prometheus.Desc
+	wraps        *counterWraps
 }
 
 func newInterfaceCollector() routerOSCollector {
@@ -21,18 +29,27 @@ func newInterfaceCollector() routerOSCollector {
 }
 
 func (c *interfaceCollector) init() {
-	c.props = []string{"name", "type", "disabled", "comment", "slave", "actual-mtu", "running", "rx-byte", "tx-byte", "rx-packet", "tx-packet", "rx-error", "tx-error", "rx-drop", "tx-drop", "link-downs"}
+	c.props = []string{"name", "type", "disabled", "comment", "slave", "actual-mtu", "running", "rx-byte", "tx-byte", "rx-packet", "tx-packet", "rx-error", "tx-error", "rx-drop", "tx-drop", "tx-queue-drop", "link-downs"}
 	labelNames := []string{"name", "address", "interface", "type", "disabled", "comment", "running", "slave"}
 	c.descriptions = make(map[string]*prometheus.Desc)
 	for _, p := range c.props[5:] {
 		c.descriptions[p] = descriptionForPropertyName("interface", p, labelNames)
 	}
+	c.disabledDesc = description("interface", "disabled", "whether the interface is disabled", labelNames)
+	c.linkDescs = map[string]*prometheus.Desc{
+		"last-link-up-time":   description("interface", "last_link_up_timestamp_seconds", "time the link of the interface last went up", labelNames),
+		"last-link-down-time": description("interface", "last_link_down_timestamp_seconds", "time the link of the interface last went down", labelNames),
+	}
 }
 
 func (c *interfaceCollector) describe(ch chan<- *prometheus.Desc) {
 	for _, d := range c.descriptions {
 		ch <- d
 	}
+	ch <- c.disabledDesc
+	for _, d := range c.linkDescs {
+		ch <- d
+	}
 }
 
 func (c *interfaceCollector) collect(ctx *collectorContext) error {
@@ -41,19 +58,39 @@ func (c *interfaceCollector) collect(ctx *collectorContext) error {
 		return err
 	}
 
+	// the clock offset is only needed for devices reporting link times, and
+	// not available over SNMP
+	var offset string
+	linkTimes := true
+	if slices.ContainsFunc(stats, func(re *proto.Sentence) bool {
+		return re.Map["last-link-up-time"] != "" || re.Map["last-link-down-time"] != ""
+	}) {
+		offset, err = fetchClockOffset(ctx)
+		if err != nil {
+			// the link times can't be converted without the offset, but
+			// the other interface metrics are still exported
+			ctx.logger().WithFields(log.Fields{
+				"error": err,
+			}).Warn("skipping interface link times")
+			linkTimes = false
+		}
+	}
+
 	for _, re := range stats {
-		c.collectForStat(re, ctx)
+		c.collectRow(re, offset, linkTimes, ctx)
+	}
+	if c.wraps != nil {
+		c.wraps.scraped(ctx.device.Name)
 	}
 
 	return nil
 }
 
 func (c *interfaceCollector) fetch(ctx *collectorContext) ([]*proto.Sentence, error) {
-	reply, err := ctx.client.Run("/interface/print", "=.proplist="+strings.Join(c.props, ","))
+	reply, err := ctx.client.Run("/interface/print", "=.proplist="+strings.Join(slices.Concat(c.props, interfaceLinkProps), ","))
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device": ctx.device.Name,
-			"error":  err,
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
 		}).Error("error fetching interface metrics")
 		return nil, err
 	}
@@ -62,12 +99,48 @@ func (c *interfaceCollector) fetch(ctx *collectorContext) ([]*proto.Sentence, er
 }
 
 func (c *interfaceCollector) collectForStat(re *proto.Sentence, ctx *collectorContext) {
+	c.collectRow(re, "", true, ctx)
+}
+
+func (c *interfaceCollector) collectRow(re *proto.Sentence, offset string, linkTimes bool, ctx *collectorContext) {
+	labels := ctx.rowLabels(c.descriptions["running"], ctx.device.Name, ctx.device.Address,
+		re.Map["name"], re.Map["type"], re.Map["disabled"], re.Map["comment"], re.Map["running"], re.Map["slave"])
 	for _, p := range c.props[5:] {
-		c.collectMetricForProperty(p, re, ctx)
+		c.collectMetricForProperty(p, re, labels, ctx)
+	}
+
+	if disabled := re.Map["disabled"]; disabled != "" {
+		ctx.ch <- labels.gauge(c.disabledDesc, boolToFloat(disabled))
+	}
+	if linkTimes {
+		c.collectLinkTimes(re, offset, labels, ctx)
+	}
+}
+
+// collectLinkTimes exports the times the link of the interface last went up
+// and down, which are missing if it never did since the device booted.
+func (c *interfaceCollector) collectLinkTimes(re *proto.Sentence, offset string, labels *rowLabels, ctx *collectorContext) {
+	for _, p := range interfaceLinkProps {
+		value := re.Map[p]
+		if value == "" {
+			continue
+		}
+
+		t, err := parseDeviceTime(value, offset)
+		if err != nil {
+			ctx.logger().WithFields(log.Fields{
+				"interface": re.Map["name"],
+				"property":  p,
+				"value":     value,
+				"error":     err,
+			}).Error("error parsing interface metric value")
+			continue
+		}
+		ctx.ch <- labels.gauge(c.linkDescs[p], float64(t.Unix()))
 	}
 }
 
-func (c *interfaceCollector) collectMetricForProperty(property string, re *proto.Sentence, ctx *collectorContext) {
+func (c *interfaceCollector) collectMetricForProperty(property string, re *proto.Sentence, labels *rowLabels, ctx *collectorContext) {
 	desc := c.descriptions[property]
 	if value := re.Map[property]; value != "" {
 		var (
@@ -91,8 +164,7 @@ func (c *interfaceCollector) collectMetricForProperty(property string, re *proto
 		default:
 			v, err = strconv.ParseFloat(value, 64)
 			if err != nil {
-				log.WithFields(log.Fields{
-					"device":    ctx.device.Name,
+				ctx.logger().WithFields(log.Fields{
 					"interface": re.Map["name"],
 					"property":  property,
 					"value":     value,
@@ -101,8 +173,14 @@ func (c *interfaceCollector) collectMetricForProperty(property string, re *proto
 				return
 			}
 		}
-		ctx.ch <- prometheus.MustNewConstMetric(desc, vtype, v, ctx.device.Name, ctx.device.Address,
-			re.Map["name"], re.Map["type"], re.Map["disabled"], re.Map["comment"], re.Map["running"], re.Map["slave"])
+		if vtype == prometheus.CounterValue && c.wraps != nil {
+			v = c.wraps.correct(ctx.device.Name, re.Map["name"]+"/"+property, v)
+		}
+		if vtype == prometheus.CounterValue {
+			ctx.ch <- labels.counter(desc, v)
+		} else {
+			ctx.ch <- labels.gauge(desc, v)
+		}
 
 	}
 }
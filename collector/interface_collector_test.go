@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/routeros.v2/proto"
+
+	"mikrotik-exporter/config"
+)
+
+func TestInterfaceLinkState(t *testing.T) {
+	c := newInterfaceCollector().(*interfaceCollector)
+	ch := make(chan prometheus.Metric, 10)
+	ctx := &collectorContext{ch: ch, device: &config.Device{Name: "r1"}, client: &apiClient{}}
+
+	c.collectRow(&proto.Sentence{Map: map[string]string{
+		"name":                "ether1",
+		"disabled":            "false",
+		"running":             "true",
+		"link-downs":          "4",
+		"last-link-up-time":   "2024-10-15 10:00:00",
+		"last-link-down-time": "2024-10-15 09:59:30",
+	}}, "+02:00", true, ctx)
+	close(ch)
+
+	values := make(map[string]float64)
+	for m := range ch {
+		var out dto.Metric
+		assert.NoError(t, m.Write(&out))
+		values[m.Desc().String()] = out.GetGauge().GetValue() + out.GetCounter().GetValue()
+	}
+
+	up := time.Date(2024, 10, 15, 8, 0, 0, 0, time.UTC)
+	assert.Equal(t, 0.0, values[c.disabledDesc.String()])
+	assert.Equal(t, 4.0, values[c.descriptions["link-downs"].String()])
+	assert.Equal(t, float64(up.Unix()), values[c.linkDescs["last-link-up-time"].String()])
+	assert.Equal(t, float64(up.Add(-30*time.Second).Unix()), values[c.linkDescs["last-link-down-time"].String()])
+}
+
+func TestInterfaceWithoutLinkTimes(t *testing.T) {
+	c := newInterfaceCollector().(*interfaceCollector)
+	ch := make(chan prometheus.Metric, 10)
+	ctx := &collectorContext{ch: ch, device: &config.Device{Name: "r1"}, client: &apiClient{}}
+
+	// the clock offset could not be fetched
+	c.collectRow(&proto.Sentence{Map: map[string]string{
+		"name":              "ether1",
+		"running":           "true",
+		"link-downs":        "4",
+		"last-link-up-time": "2024-10-15 10:00:00",
+	}}, "", false, ctx)
+	close(ch)
+
+	descs := make(map[string]bool)
+	for m := range ch {
+		descs[m.Desc().String()] = true
+	}
+
+	assert.True(t, descs[c.descriptions["link-downs"].String()])
+	assert.False(t, descs[c.linkDescs["last-link-up-time"].String()])
+}
@@ -0,0 +1,78 @@
+package collector
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+type interfaceQueueCollector struct {
+	props        []string
+	infoDesc     *prometheus.Desc
+	descriptions map[string]*prometheus.Desc
+}
+
+func newInterfaceQueueCollector() routerOSCollector {
+	c := &interfaceQueueCollector{}
+	c.init()
+	return c
+}
+
+func (c *interfaceQueueCollector) init() {
+	c.props = []string{"interface", "active-queue", "queued-bytes", "queued-packets", "dropped"}
+
+	const prefix = "interface_queue"
+	labelNames := []string{"name", "address", "interface"}
+	c.infoDesc = description(prefix, "info", "queue type active on the interface", append(labelNames, "queue"))
+	c.descriptions = map[string]*prometheus.Desc{
+		"queued-bytes":   description(prefix, "queued_bytes", "number of bytes waiting in the interface queue", labelNames),
+		"queued-packets": description(prefix, "queued_packets", "number of packets waiting in the interface queue", labelNames),
+		"dropped":        description(prefix, "dropped", "number of packets dropped by the interface queue", labelNames),
+	}
+}
+
+func (c *interfaceQueueCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.infoDesc
+	for _, d := range c.descriptions {
+		ch <- d
+	}
+}
+
+func (c *interfaceQueueCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/queue/interface/print", "=.proplist="+strings.Join(c.props, ","))
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching interface queue metrics")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		c.collectForStat(re, ctx)
+	}
+
+	return nil
+}
+
+func (c *interfaceQueueCollector) collectForStat(re *proto.Sentence, ctx *collectorContext) {
+	iface := re.Map["interface"]
+	ctx.ch <- prometheus.MustNewConstMetric(c.infoDesc, prometheus.GaugeValue, 1, ctx.device.Name, ctx.device.Address, iface, re.Map["active-queue"])
+
+	// the backlog is only reported by RouterOS versions keeping statistics
+	// of interface queues
+	for _, p := range c.props[2:] {
+		v, err := strconv.ParseFloat(re.Map[p], 64)
+		if err != nil {
+			continue
+		}
+
+		if p == "dropped" {
+			ctx.ch <- ctx.counter(c.descriptions[p], v, ctx.device.Name, ctx.device.Address, iface)
+			continue
+		}
+		ctx.ch <- prometheus.MustNewConstMetric(c.descriptions[p], prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, iface)
+	}
+}
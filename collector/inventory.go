@@ -0,0 +1,208 @@
+package collector
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	routeros "gopkg.in/routeros.v2"
+	"gopkg.in/routeros.v2/proto"
+
+	"mikrotik-exporter/config"
+)
+
+// inventoryTimeout bounds running a collector to record its commands, e.g.
+// for collectors streaming from the device.
+const inventoryTimeout = 2 * time.Second
+
+// CollectorInfo describes a collector and what it runs against the devices.
+type CollectorInfo struct {
+	Name     string   `json:"name"`
+	Commands []string `json:"commands"`
+	Metrics  []string `json:"metrics"`
+}
+
+// Inventory describes the collectors enabled by the options. The commands
+// are recorded by running the collectors against a RouterOS 6 and 7 device
+// without any entries, so commands only run for existing entries, e.g. to
+// monitor an interface, are missing.
+func Inventory(opts ...Option) []CollectorInfo {
+	c := &collector{}
+	c.add("interface", newInterfaceCollector())
+	c.add("resource", newResourceCollector())
+	for _, o := range opts {
+		o(c)
+	}
+
+	infos := make([]CollectorInfo, 0, len(c.collectors))
+	for _, co := range c.collectors {
+		info := CollectorInfo{Name: co.name, Commands: []string{}, Metrics: describedMetrics(co)}
+		for _, major := range []int{6, 7} {
+			for _, cmd := range recordCommands(co, major) {
+				if !slices.Contains(info.Commands, cmd) {
+					info.Commands = append(info.Commands, cmd)
+				}
+			}
+		}
+		sort.Strings(info.Commands)
+		infos = append(infos, info)
+
+		if s, ok := co.routerOSCollector.(stoppable); ok {
+			s.stop()
+		}
+	}
+
+	return infos
+}
+
+func describedMetrics(co namedCollector) []string {
+	ch := make(chan *prometheus.Desc)
+	go func() {
+		co.describe(ch)
+		close(ch)
+	}()
+
+	var names []string
+	for d := range ch {
+		if name := metricName(d); !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// recordCommands runs the collector against a device of the RouterOS major
+// version answering all commands with empty replies and returns the paths of
+// the commands.
+func recordCommands(co namedCollector, major int) []string {
+	conn, device := net.Pipe()
+	defer conn.Close()
+
+	var (
+		mu       sync.Mutex
+		commands []string
+	)
+	go func() {
+		r := bufio.NewReader(device)
+		w := proto.NewWriter(device)
+		for {
+			command, tag, err := readCommand(r)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			commands = append(commands, command)
+			mu.Unlock()
+
+			w.BeginSentence()
+			w.WriteWord("!done")
+			if tag != "" {
+				w.WriteWord(".tag=" + tag)
+			}
+			if err := w.EndSentence(); err != nil {
+				return
+			}
+		}
+	}()
+
+	cl, err := routeros.NewClient(conn)
+	if err != nil {
+		return nil
+	}
+	d := &config.Device{Name: "inventory"}
+	client := &apiClient{
+		Client:           cl,
+		device:           d,
+		version:          routerOSVersion{major: major},
+		wirelessDetected: true,
+		done:             make(chan struct{}),
+	}
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		for range ch {
+		}
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = co.collect(&collectorContext{ch: ch, device: d, client: client, collector: co.name})
+	}()
+	select {
+	case <-done:
+		close(ch)
+	case <-time.After(inventoryTimeout):
+		// the collector is left blocked on the closed connection
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	return commands
+}
+
+// readCommand reads a sentence sent to the device and returns the command
+// and tag. The sentence reader of the RouterOS client only reads replies,
+// which have no query words.
+func readCommand(r *bufio.Reader) (string, string, error) {
+	var command, tag string
+	for {
+		word, err := readWord(r)
+		if err != nil {
+			return "", "", err
+		}
+		switch {
+		case word == "":
+			return command, tag, nil
+		case command == "":
+			command = word
+		case strings.HasPrefix(word, ".tag="):
+			tag = strings.TrimPrefix(word, ".tag=")
+		}
+	}
+}
+
+func readWord(r *bufio.Reader) (string, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+
+	// the number of bytes following the first byte of the length
+	var n int
+	l := int(b)
+	switch {
+	case b&0x80 == 0:
+	case b&0xC0 == 0x80:
+		n, l = 1, l&^0xC0
+	case b&0xE0 == 0xC0:
+		n, l = 2, l&^0xE0
+	case b&0xF0 == 0xE0:
+		n, l = 3, l&^0xF0
+	default:
+		n, l = 4, 0
+	}
+	for i := 0; i < n; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		l = l<<8 | int(b)
+	}
+
+	word := make([]byte, l)
+	if _, err := io.ReadFull(r, word); err != nil {
+		return "", err
+	}
+
+	return string(word), nil
+}
@@ -0,0 +1,21 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInventory(t *testing.T) {
+	infos := Inventory(WithRoutes())
+	assert.Len(t, infos, 3)
+
+	assert.Equal(t, "interface", infos[0].Name)
+	assert.Equal(t, []string{"/interface/print"}, infos[0].Commands)
+	assert.Contains(t, infos[0].Metrics, "mikrotik_interface_rx_byte")
+
+	routes := infos[2]
+	assert.Equal(t, "routes", routes.Name)
+	assert.Equal(t, []string{"/ip/route/print", "/ipv6/route/print", "/routing/table/print"}, routes.Commands, "the commands must be recorded once")
+	assert.Contains(t, routes.Metrics, "mikrotik_routes_total_count")
+}
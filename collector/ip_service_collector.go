@@ -0,0 +1,71 @@
+package collector
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+type ipServiceCollector struct {
+	enabledDesc    *prometheus.Desc
+	portDesc       *prometheus.Desc
+	restrictedDesc *prometheus.Desc
+}
+
+func newIPServiceCollector() routerOSCollector {
+	c := &ipServiceCollector{}
+	c.init()
+	return c
+}
+
+func (c *ipServiceCollector) init() {
+	const prefix = "ip_service"
+	labelNames := []string{"name", "address", "service"}
+	c.enabledDesc = description(prefix, "enabled", "whether the management service is enabled", labelNames)
+	c.portDesc = description(prefix, "port", "port the management service listens on", labelNames)
+	c.restrictedDesc = description(prefix, "address_restricted", "whether access to the management service is restricted to a list of addresses", labelNames)
+}
+
+func (c *ipServiceCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.enabledDesc
+	ch <- c.portDesc
+	ch <- c.restrictedDesc
+}
+
+func (c *ipServiceCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/ip/service/print", "=.proplist=name,port,address,disabled")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching ip service metrics")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		c.collectForStat(re, ctx)
+	}
+
+	return nil
+}
+
+func (c *ipServiceCollector) collectForStat(re *proto.Sentence, ctx *collectorContext) {
+	labelValues := []string{ctx.device.Name, ctx.device.Address, re.Map["name"]}
+
+	enabled := 1.0
+	if re.Map["disabled"] == "true" {
+		enabled = 0.0
+	}
+	ctx.ch <- prometheus.MustNewConstMetric(c.enabledDesc, prometheus.GaugeValue, enabled, labelValues...)
+
+	if v, err := strconv.ParseFloat(re.Map["port"], 64); err == nil {
+		ctx.ch <- prometheus.MustNewConstMetric(c.portDesc, prometheus.GaugeValue, v, labelValues...)
+	}
+
+	restricted := 0.0
+	if re.Map["address"] != "" {
+		restricted = 1.0
+	}
+	ctx.ch <- prometheus.MustNewConstMetric(c.restrictedDesc, prometheus.GaugeValue, restricted, labelValues...)
+}
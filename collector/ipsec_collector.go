@@ -12,6 +12,11 @@ import (
 type ipsecCollector struct {
 	props        []string
 	descriptions map[string]*prometheus.Desc
+	peerProps    []string
+	peerDescs    map[string]*prometheus.Desc
+	saCountDesc  *prometheus.Desc
+	saBytesDesc  *prometheus.Desc
+	saPacketDesc *prometheus.Desc
 }
 
 func newIpsecCollector() routerOSCollector {
@@ -28,12 +33,35 @@ func (c *ipsecCollector) init() {
 	for _, p := range c.props[1:] {
 		c.descriptions[p] = descriptionForPropertyName("ipsec", p, labelNames)
 	}
+
+	c.peerProps = []string{"id", "remote-address", "state", "uptime", "last-seen", "rx-bytes", "tx-bytes", "rx-packets", "tx-packets"}
+	peerLabels := []string{"name", "address", "peer", "remote_address"}
+	c.peerDescs = map[string]*prometheus.Desc{
+		"state":      description("ipsec_peer", "established", "whether the IKE session with the peer is established", peerLabels),
+		"uptime":     description("ipsec_peer", "uptime_seconds", "uptime of the IKE session with the peer", peerLabels),
+		"last-seen":  description("ipsec_peer", "last_seen_seconds", "time since the last packet was received from the peer", peerLabels),
+		"rx-bytes":   description("ipsec_peer", "rx_bytes", "number of bytes received from the peer", peerLabels),
+		"tx-bytes":   description("ipsec_peer", "tx_bytes", "number of bytes sent to the peer", peerLabels),
+		"rx-packets": description("ipsec_peer", "rx_packets", "number of packets received from the peer", peerLabels),
+		"tx-packets": description("ipsec_peer", "tx_packets", "number of packets sent to the peer", peerLabels),
+	}
+
+	saLabels := []string{"name", "address", "remote_address"}
+	c.saCountDesc = description("ipsec_sa", "count", "number of installed SAs with the remote address", saLabels)
+	c.saBytesDesc = description("ipsec_sa", "current_bytes", "number of bytes passed through the installed SAs, reset on rekey", append(saLabels, "direction"))
+	c.saPacketDesc = description("ipsec_sa", "current_packets", "number of packets passed through the installed SAs, reset on rekey", append(saLabels, "direction"))
 }
 
 func (c *ipsecCollector) describe(ch chan<- *prometheus.Desc) {
 	for _, d := range c.descriptions {
 		ch <- d
 	}
+	for _, d := range c.peerDescs {
+		ch <- d
+	}
+	ch <- c.saCountDesc
+	ch <- c.saBytesDesc
+	ch <- c.saPacketDesc
 }
 
 func (c *ipsecCollector) collect(ctx *collectorContext) error {
@@ -46,15 +74,112 @@ func (c *ipsecCollector) collect(ctx *collectorContext) error {
 		c.collectForStat(re, ctx)
 	}
 
+	peers, err := c.collectPeers(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.collectInstalledSAs(peers, ctx)
+}
+
+// collectPeers exports the active peers and returns their remote addresses.
+func (c *ipsecCollector) collectPeers(ctx *collectorContext) (map[string]bool, error) {
+	reply, err := ctx.client.Run("/ip/ipsec/active-peers/print", "=.proplist="+strings.Join(c.peerProps, ","))
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching ipsec active peers")
+		return nil, err
+	}
+
+	peers := make(map[string]bool)
+	for _, re := range reply.Re {
+		peers[re.Map["remote-address"]] = true
+		c.collectForPeer(re, ctx)
+	}
+
+	return peers, nil
+}
+
+func (c *ipsecCollector) collectForPeer(re *proto.Sentence, ctx *collectorContext) {
+	labelValues := []string{ctx.device.Name, ctx.device.Address, re.Map["id"], re.Map["remote-address"]}
+
+	established := 0.0
+	if re.Map["state"] == "established" {
+		established = 1.0
+	}
+	ctx.ch <- prometheus.MustNewConstMetric(c.peerDescs["state"], prometheus.GaugeValue, established, labelValues...)
+
+	for _, p := range c.peerProps[3:] {
+		value := re.Map[p]
+		if value == "" {
+			continue
+		}
+
+		switch p {
+		case "uptime", "last-seen":
+			if v, err := parseDuration(value); err == nil {
+				ctx.ch <- prometheus.MustNewConstMetric(c.peerDescs[p], prometheus.GaugeValue, v, labelValues...)
+			}
+		default:
+			if v, err := strconv.ParseFloat(value, 64); err == nil {
+				ctx.ch <- prometheus.MustNewConstMetric(c.peerDescs[p], prometheus.CounterValue, v, labelValues...)
+			}
+		}
+	}
+}
+
+// collectInstalledSAs aggregates the installed SAs by the address of the
+// remote end, SAs towards the remote end are outbound.
+func (c *ipsecCollector) collectInstalledSAs(peers map[string]bool, ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/ip/ipsec/installed-sa/print", "=.proplist=src-address,dst-address,current-bytes,current-packets")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching ipsec installed sas")
+		return err
+	}
+
+	type saKey struct {
+		remote    string
+		direction string
+	}
+	counts := make(map[string]float64)
+	bytes := make(map[saKey]float64)
+	packets := make(map[saKey]float64)
+	for _, re := range reply.Re {
+		k := saKey{remote: re.Map["dst-address"], direction: "out"}
+		if peers[re.Map["src-address"]] {
+			k = saKey{remote: re.Map["src-address"], direction: "in"}
+		}
+
+		counts[k.remote]++
+		if v, err := strconv.ParseFloat(re.Map["current-bytes"], 64); err == nil {
+			bytes[k] += v
+		}
+		if v, err := strconv.ParseFloat(re.Map["current-packets"], 64); err == nil {
+			packets[k] += v
+		}
+	}
+
+	for remote, v := range counts {
+		ctx.ch <- prometheus.MustNewConstMetric(c.saCountDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, remote)
+	}
+	for k, v := range bytes {
+		ctx.ch <- prometheus.MustNewConstMetric(c.saBytesDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, k.remote, k.direction)
+	}
+	for k, v := range packets {
+		ctx.ch <- prometheus.MustNewConstMetric(c.saPacketDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, k.remote, k.direction)
+	}
+
 	return nil
 }
 
 func (c *ipsecCollector) fetch(ctx *collectorContext) ([]*proto.Sentence, error) {
 	reply, err := ctx.client.Run("/ip/ipsec/policy/print", "?disabled=false", "?dynamic=false", "=.proplist="+strings.Join(c.props, ","))
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device": ctx.device.Name,
-			"error":  err,
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
 		}).Error("error fetching interface metrics")
 		return nil, err
 	}
@@ -96,8 +221,7 @@ func (c *ipsecCollector) collectMetricForProperty(property, srcdst, comment stri
 		}
 
 		if err != nil {
-			log.WithFields(log.Fields{
-				"device":   ctx.device.Name,
+			ctx.logger().WithFields(log.Fields{
 				"srcdst":   srcdst,
 				"property": property,
 				"value":    value,
@@ -105,6 +229,10 @@ func (c *ipsecCollector) collectMetricForProperty(property, srcdst, comment stri
 			}).Error("error parsing ipsec metric value")
 			return
 		}
-		ctx.ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v, ctx.device.Name, srcdst, comment)
+		vtype := prometheus.GaugeValue
+		if ctx.legacyMetricTypes {
+			vtype = prometheus.CounterValue
+		}
+		ctx.ch <- prometheus.MustNewConstMetric(desc, vtype, v, ctx.device.Name, srcdst, comment)
 	}
 }
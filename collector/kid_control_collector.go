@@ -0,0 +1,105 @@
+package collector
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+type kidControlCollector struct {
+	props        []string
+	descriptions map[string]*prometheus.Desc
+	pausedDesc   *prometheus.Desc
+}
+
+func newKidControlCollector() routerOSCollector {
+	c := &kidControlCollector{}
+	c.init()
+	return c
+}
+
+func (c *kidControlCollector) init() {
+	c.props = []string{"name", "user", "mac-address", "rate-down", "rate-up", "bytes-down", "bytes-up", "blocked", "limited"}
+
+	const prefix = "kid_control_device"
+	labelNames := []string{"name", "address", "device", "user", "mac_address"}
+	c.descriptions = map[string]*prometheus.Desc{
+		"rate-down":  description(prefix, "rate_down_bits_per_second", "download rate of the device", labelNames),
+		"rate-up":    description(prefix, "rate_up_bits_per_second", "upload rate of the device", labelNames),
+		"bytes-down": description(prefix, "bytes_down", "number of bytes downloaded by the device", labelNames),
+		"bytes-up":   description(prefix, "bytes_up", "number of bytes uploaded by the device", labelNames),
+		"blocked":    description(prefix, "blocked", "whether the device is blocked", labelNames),
+		"limited":    description(prefix, "limited", "whether the rate of the device is limited", labelNames),
+	}
+	c.pausedDesc = description("kid_control", "paused", "whether internet access of the kid is paused", []string{"name", "address", "kid"})
+}
+
+func (c *kidControlCollector) describe(ch chan<- *prometheus.Desc) {
+	for _, d := range c.descriptions {
+		ch <- d
+	}
+	ch <- c.pausedDesc
+}
+
+func (c *kidControlCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/ip/kid-control/device/print", "=.proplist="+strings.Join(c.props, ","))
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching kid control device metrics")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		c.collectForStat(re, ctx)
+	}
+
+	return c.collectKids(ctx)
+}
+
+func (c *kidControlCollector) collectForStat(re *proto.Sentence, ctx *collectorContext) {
+	labelValues := []string{ctx.device.Name, ctx.device.Address, re.Map["name"], re.Map["user"], re.Map["mac-address"]}
+
+	for _, p := range c.props[3:] {
+		value := re.Map[p]
+		if value == "" {
+			continue
+		}
+
+		switch p {
+		case "blocked", "limited":
+			ctx.ch <- prometheus.MustNewConstMetric(c.descriptions[p], prometheus.GaugeValue, boolToFloat(value), labelValues...)
+		case "bytes-down", "bytes-up":
+			if v, err := strconv.ParseFloat(value, 64); err == nil {
+				ctx.ch <- prometheus.MustNewConstMetric(c.descriptions[p], prometheus.CounterValue, v, labelValues...)
+			}
+		default:
+			if v, err := strconv.ParseFloat(value, 64); err == nil {
+				ctx.ch <- prometheus.MustNewConstMetric(c.descriptions[p], prometheus.GaugeValue, v, labelValues...)
+			}
+		}
+	}
+}
+
+func (c *kidControlCollector) collectKids(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/ip/kid-control/print", "=.proplist=name,paused")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching kid control metrics")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		ctx.ch <- prometheus.MustNewConstMetric(c.pausedDesc, prometheus.GaugeValue, boolToFloat(re.Map["paused"]), ctx.device.Name, ctx.device.Address, re.Map["name"])
+	}
+
+	return nil
+}
+
+func (c *kidControlCollector) requiredMenu() string {
+	return "/ip/kid-control"
+}
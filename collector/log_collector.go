@@ -0,0 +1,137 @@
+package collector
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+// logSeverities are the topics RouterOS uses to mark the severity of a log
+// entry, in order of precedence.
+var logSeverities = []string{"critical", "error", "warning", "info", "debug"}
+
+type logKey struct {
+	topic    string
+	severity string
+}
+
+// logState is the id of the last log entry seen on a device and the number of
+// entries seen by the exporter since.
+type logState struct {
+	last   uint64
+	counts map[logKey]float64
+}
+
+type logCollector struct {
+	entriesDesc *prometheus.Desc
+
+	mu     sync.Mutex
+	states map[string]*logState
+}
+
+func newLogCollector() routerOSCollector {
+	c := &logCollector{
+		states: make(map[string]*logState),
+	}
+	c.init()
+	return c
+}
+
+func (c *logCollector) init() {
+	c.entriesDesc = description("log", "entries", "number of log entries seen by the exporter", []string{"name", "address", "topic", "severity"})
+}
+
+func (c *logCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.entriesDesc
+}
+
+func (c *logCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/log/print", "=.proplist=.id,topics")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching log entries")
+		return err
+	}
+
+	for k, v := range c.update(ctx.device.Name, reply.Re) {
+		ctx.ch <- prometheus.MustNewConstMetric(c.entriesDesc, prometheus.CounterValue, v, ctx.device.Name, ctx.device.Address, k.topic, k.severity)
+	}
+
+	return nil
+}
+
+// update counts the entries added to the log of the device since the last
+// scrape and returns the counts seen so far. Entries present on the first
+// scrape are not counted. The log is cleared on reboot, which is detected by
+// the ids starting over.
+func (c *logCollector) update(device string, entries []*proto.Sentence) map[logKey]float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var last uint64
+	for _, re := range entries {
+		if id, err := parseID(re.Map[".id"]); err == nil && id > last {
+			last = id
+		}
+	}
+
+	s, ok := c.states[device]
+	if !ok {
+		c.states[device] = &logState{last: last, counts: make(map[logKey]float64)}
+		return nil
+	}
+
+	since := s.last
+	if last < s.last {
+		since = 0
+	}
+	for _, re := range entries {
+		id, err := parseID(re.Map[".id"])
+		if err != nil || id <= since {
+			continue
+		}
+		topic, severity := parseLogTopics(re.Map["topics"])
+		s.counts[logKey{topic, severity}]++
+	}
+	s.last = last
+
+	counts := make(map[logKey]float64, len(s.counts))
+	for k, v := range s.counts {
+		counts[k] = v
+	}
+
+	return counts
+}
+
+// parseID parses RouterOS item ids, e.g. "*1A".
+func parseID(id string) (uint64, error) {
+	return strconv.ParseUint(strings.TrimPrefix(id, "*"), 16, 64)
+}
+
+// parseLogTopics splits the topics of a log entry, e.g. "system,error,critical",
+// into the first topic and the severity.
+func parseLogTopics(topics string) (string, string) {
+	parts := strings.Split(topics, ",")
+
+	severity := "info"
+	for _, s := range logSeverities {
+		if slices.Contains(parts, s) {
+			severity = s
+			break
+		}
+	}
+
+	for _, p := range parts {
+		if !slices.Contains(logSeverities, p) {
+			return p, severity
+		}
+	}
+
+	return "", severity
+}
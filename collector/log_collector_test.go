@@ -0,0 +1,47 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/routeros.v2/proto"
+)
+
+func TestParseLogTopics(t *testing.T) {
+	testCases := []struct {
+		topics   string
+		topic    string
+		severity string
+	}{
+		{"system,info", "system", "info"},
+		{"system,error,critical", "system", "critical"},
+		{"interface,warning", "interface", "warning"},
+		{"dhcp", "dhcp", "info"},
+		{"error", "", "error"},
+	}
+
+	for _, tc := range testCases {
+		topic, severity := parseLogTopics(tc.topics)
+		assert.Equal(t, tc.topic, topic, tc.topics)
+		assert.Equal(t, tc.severity, severity, tc.topics)
+	}
+}
+
+func TestLogCollectorUpdate(t *testing.T) {
+	c := newLogCollector().(*logCollector)
+	entry := func(id, topics string) *proto.Sentence {
+		return &proto.Sentence{Map: map[string]string{".id": id, "topics": topics}}
+	}
+
+	entries := []*proto.Sentence{entry("*1", "system,info"), entry("*2", "system,error")}
+	assert.Empty(t, c.update("router1", entries))
+
+	entries = append(entries, entry("*A", "system,error"), entry("*B", "ospf,warning"))
+	counts := c.update("router1", entries)
+	assert.Equal(t, float64(1), counts[logKey{"system", "error"}])
+	assert.Equal(t, float64(1), counts[logKey{"ospf", "warning"}])
+
+	// the log starts over after a reboot
+	counts = c.update("router1", []*proto.Sentence{entry("*1", "system,error")})
+	assert.Equal(t, float64(2), counts[logKey{"system", "error"}])
+}
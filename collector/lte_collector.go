@@ -10,9 +10,23 @@ import (
 	"gopkg.in/routeros.v2/proto"
 )
 
+// lteBand is a carrier of the LTE connection, e.g. "B3@20Mhz earfcn: 1300 phy-cellid: 123".
+type lteBand struct {
+	band      string
+	bandwidth string
+	earfcn    string
+	phyCellID string
+}
+
 type lteCollector struct {
 	props        []string
+	caProps      []string
 	descriptions map[string]*prometheus.Desc
+	bandDesc     *prometheus.Desc
+	carriersDesc *prometheus.Desc
+	uptimeDesc   *prometheus.Desc
+	classDesc    *prometheus.Desc
+	nrDescs      map[string]*prometheus.Desc
 }
 
 func newLteCollector() routerOSCollector {
@@ -22,18 +36,36 @@ func newLteCollector() routerOSCollector {
 }
 
 func (c *lteCollector) init() {
-	c.props = []string{"current-cellid", "primary-band" ,"ca-band", "rssi", "rsrp", "rsrq", "sinr"}
+	c.props = []string{"current-cellid", "primary-band", "ca-band", "rssi", "rsrp", "rsrq", "sinr"}
 	labelNames := []string{"name", "address", "interface", "cellid", "primaryband", "caband"}
 	c.descriptions = make(map[string]*prometheus.Desc)
 	for _, p := range c.props {
 		c.descriptions[p] = descriptionForPropertyName("lte_interface", p, labelNames)
 	}
+
+	c.caProps = []string{"session-uptime", "data-class", "nr-band", "nr-rsrp", "nr-rsrq", "nr-sinr"}
+	ifaceLabels := []string{"name", "address", "interface"}
+	c.bandDesc = description("lte_interface", "band_info", "carriers of the connection, carrier is primary, secondary or nr", append(ifaceLabels, "carrier", "band", "bandwidth", "earfcn", "phy_cellid"))
+	c.carriersDesc = description("lte_interface", "aggregated_carriers", "number of LTE carriers of the connection", ifaceLabels)
+	c.uptimeDesc = description("lte_interface", "session_uptime_seconds", "uptime of the data session", ifaceLabels)
+	c.classDesc = description("lte_interface", "data_class_info", "data class of the connection", append(ifaceLabels, "data_class"))
+	c.nrDescs = make(map[string]*prometheus.Desc)
+	for _, p := range c.caProps[3:] {
+		c.nrDescs[p] = descriptionForPropertyName("lte_interface", p, append(ifaceLabels, "band"))
+	}
 }
 
 func (c *lteCollector) describe(ch chan<- *prometheus.Desc) {
 	for _, d := range c.descriptions {
 		ch <- d
 	}
+	for _, d := range c.nrDescs {
+		ch <- d
+	}
+	ch <- c.bandDesc
+	ch <- c.carriersDesc
+	ch <- c.uptimeDesc
+	ch <- c.classDesc
 }
 
 func (c *lteCollector) collect(ctx *collectorContext) error {
@@ -55,9 +87,8 @@ func (c *lteCollector) collect(ctx *collectorContext) error {
 func (c *lteCollector) fetchInterfaceNames(ctx *collectorContext) ([]string, error) {
 	reply, err := ctx.client.Run("/interface/lte/print", "?disabled=false", "=.proplist=name")
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device": ctx.device.Name,
-			"error":  err,
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
 		}).Error("error fetching lte interface names")
 		return nil, err
 	}
@@ -71,11 +102,10 @@ func (c *lteCollector) fetchInterfaceNames(ctx *collectorContext) ([]string, err
 }
 
 func (c *lteCollector) collectForInterface(iface string, ctx *collectorContext) error {
-	reply, err := ctx.client.Run("/interface/lte/info", fmt.Sprintf("=number=%s", iface), "=once=", "=.proplist="+strings.Join(c.props, ","))
+	reply, err := ctx.client.Run("/interface/lte/info", fmt.Sprintf("=number=%s", iface), "=once=", "=.proplist="+strings.Join(append(c.props, c.caProps...), ","))
 	if err != nil {
-		log.WithFields(log.Fields{
+		ctx.logger().WithFields(log.Fields{
 			"interface": iface,
-			"device":    ctx.device.Name,
 			"error":     err,
 		}).Error("error fetching interface statistics")
 		return err
@@ -86,10 +116,48 @@ func (c *lteCollector) collectForInterface(iface string, ctx *collectorContext)
 		// have to explicitly specify the interface
 		c.collectMetricForProperty(p, iface, reply.Re[0], ctx)
 	}
+	c.collectCarriers(iface, reply.Re[0], ctx)
 
 	return nil
 }
 
+// collectCarriers exports the carrier aggregation info of the connection.
+func (c *lteCollector) collectCarriers(iface string, re *proto.Sentence, ctx *collectorContext) {
+	primary := parseLTEBands(re.Map["primary-band"])
+	secondary := parseLTEBands(re.Map["ca-band"])
+	nr := parseLTEBands(re.Map["nr-band"])
+
+	for carrier, bands := range map[string][]lteBand{"primary": primary, "secondary": secondary, "nr": nr} {
+		for _, b := range bands {
+			ctx.ch <- prometheus.MustNewConstMetric(c.bandDesc, prometheus.GaugeValue, 1, ctx.device.Name, ctx.device.Address, iface,
+				carrier, b.band, b.bandwidth, b.earfcn, b.phyCellID)
+		}
+	}
+	if len(primary) > 0 {
+		ctx.ch <- prometheus.MustNewConstMetric(c.carriersDesc, prometheus.GaugeValue, float64(len(primary)+len(secondary)), ctx.device.Name, ctx.device.Address, iface)
+	}
+
+	if uptime := re.Map["session-uptime"]; uptime != "" {
+		if v, err := parseDuration(uptime); err == nil {
+			ctx.ch <- prometheus.MustNewConstMetric(c.uptimeDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, iface)
+		}
+	}
+	if class := re.Map["data-class"]; class != "" {
+		ctx.ch <- prometheus.MustNewConstMetric(c.classDesc, prometheus.GaugeValue, 1, ctx.device.Name, ctx.device.Address, iface, class)
+	}
+
+	// signal values of the 5G carrier in non-standalone mode
+	nrBand := ""
+	if len(nr) > 0 {
+		nrBand = nr[0].band
+	}
+	for _, p := range c.caProps[3:] {
+		if v, err := strconv.ParseFloat(re.Map[p], 64); err == nil {
+			ctx.ch <- prometheus.MustNewConstMetric(c.nrDescs[p], prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, iface, nrBand)
+		}
+	}
+}
+
 func (c *lteCollector) collectMetricForProperty(property, iface string, re *proto.Sentence, ctx *collectorContext) {
 	desc := c.descriptions[property]
 	current_cellid := re.Map["current-cellid"]
@@ -108,10 +176,9 @@ func (c *lteCollector) collectMetricForProperty(property, iface string, re *prot
 	}
 	v, err := strconv.ParseFloat(re.Map[property], 64)
 	if err != nil {
-		log.WithFields(log.Fields{
+		ctx.logger().WithFields(log.Fields{
 			"property":  property,
 			"interface": iface,
-			"device":    ctx.device.Name,
 			"error":     err,
 		}).Error("error parsing interface metric value")
 		return
@@ -119,3 +186,33 @@ func (c *lteCollector) collectMetricForProperty(property, iface string, re *prot
 
 	ctx.ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, iface, current_cellid, primaryband, caband)
 }
+
+func (c *lteCollector) requiredMenu() string {
+	return "/interface/lte"
+}
+
+// parseLTEBands parses a list of carriers, e.g.
+// "B1@20Mhz earfcn: 300 phy-cellid: 10,B7@10Mhz earfcn: 3100 phy-cellid: 11".
+func parseLTEBands(value string) []lteBand {
+	var bands []lteBand
+	for _, v := range strings.Split(value, ",") {
+		fields := strings.Fields(v)
+		if len(fields) == 0 {
+			continue
+		}
+
+		var b lteBand
+		b.band, b.bandwidth, _ = strings.Cut(fields[0], "@")
+		for i := 1; i+1 < len(fields); i += 2 {
+			switch fields[i] {
+			case "earfcn:", "arfcn:":
+				b.earfcn = fields[i+1]
+			case "phy-cellid:":
+				b.phyCellID = fields[i+1]
+			}
+		}
+		bands = append(bands, b)
+	}
+
+	return bands
+}
@@ -0,0 +1,15 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLTEBands(t *testing.T) {
+	assert.Empty(t, parseLTEBands(""))
+	assert.Equal(t, []lteBand{{"B3", "20Mhz", "1300", "123"}}, parseLTEBands("B3@20Mhz earfcn: 1300 phy-cellid: 123"))
+	assert.Equal(t, []lteBand{{"B1", "20Mhz", "300", "10"}, {"B7", "10Mhz", "3100", "11"}},
+		parseLTEBands("B1@20Mhz earfcn: 300 phy-cellid: 10,B7@10Mhz earfcn: 3100 phy-cellid: 11"))
+	assert.Equal(t, []lteBand{{"n78", "100Mhz", "636666", "1"}}, parseLTEBands("n78@100Mhz arfcn: 636666 phy-cellid: 1"))
+}
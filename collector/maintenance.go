@@ -0,0 +1,82 @@
+package collector
+
+import (
+	"fmt"
+	"time"
+
+	"mikrotik-exporter/config"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/robfig/cron/v3"
+)
+
+var maintenanceDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "device", "maintenance"),
+	"mikrotik_exporter: whether the device is in a maintenance window and not scraped",
+	[]string{"device"},
+	nil,
+)
+
+// maintenanceWindow is a period during which devices are not scraped, either
+// once from start or repeatedly at the times of a cron schedule.
+type maintenanceWindow struct {
+	devices  map[string]bool
+	schedule cron.Schedule
+	start    time.Time
+	duration time.Duration
+}
+
+func newMaintenanceWindow(w config.MaintenanceWindow) (*maintenanceWindow, error) {
+	if w.Duration <= 0 {
+		return nil, fmt.Errorf("maintenance window without duration")
+	}
+
+	m := &maintenanceWindow{
+		start:    w.Start,
+		duration: w.Duration,
+	}
+
+	if w.Schedule != "" {
+		s, err := cron.ParseStandard(w.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maintenance schedule %q: %w", w.Schedule, err)
+		}
+		m.schedule = s
+	} else if w.Start.IsZero() {
+		return nil, fmt.Errorf("maintenance window without schedule or start")
+	}
+
+	if len(w.Devices) > 0 {
+		m.devices = make(map[string]bool)
+		for _, d := range w.Devices {
+			m.devices[d] = true
+		}
+	}
+
+	return m, nil
+}
+
+// active returns whether the window applies to the device at the given time.
+func (m *maintenanceWindow) active(device string, now time.Time) bool {
+	if m.devices != nil && !m.devices[device] {
+		return false
+	}
+
+	if m.schedule != nil {
+		// the window is active if it started within the last duration
+		return !m.schedule.Next(now.Add(-m.duration)).After(now)
+	}
+
+	return !now.Before(m.start) && now.Before(m.start.Add(m.duration))
+}
+
+// inMaintenance returns whether any maintenance window applies to the device.
+func (c *collector) inMaintenance(device string, now time.Time) bool {
+	for _, m := range c.maintenance {
+		if m.active(device, now) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,51 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"mikrotik-exporter/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaintenanceWindowSchedule(t *testing.T) {
+	m, err := newMaintenanceWindow(config.MaintenanceWindow{
+		Devices:  []string{"router1"},
+		Schedule: "0 2 * * *",
+		Duration: time.Hour,
+	})
+	assert.NoError(t, err)
+
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)
+	assert.False(t, m.active("router1", day.Add(time.Hour+59*time.Minute)))
+	assert.True(t, m.active("router1", day.Add(2*time.Hour)))
+	assert.True(t, m.active("router1", day.Add(2*time.Hour+59*time.Minute)))
+	assert.False(t, m.active("router1", day.Add(3*time.Hour)))
+	assert.False(t, m.active("router2", day.Add(2*time.Hour)))
+}
+
+func TestMaintenanceWindowStart(t *testing.T) {
+	start := time.Date(2024, 5, 1, 22, 0, 0, 0, time.UTC)
+	m, err := newMaintenanceWindow(config.MaintenanceWindow{
+		Start:    start,
+		Duration: 30 * time.Minute,
+	})
+	assert.NoError(t, err)
+
+	assert.False(t, m.active("router1", start.Add(-time.Second)))
+	assert.True(t, m.active("router1", start))
+	assert.True(t, m.active("router2", start.Add(29*time.Minute)))
+	assert.False(t, m.active("router1", start.Add(30*time.Minute)))
+}
+
+func TestMaintenanceWindowInvalid(t *testing.T) {
+	_, err := newMaintenanceWindow(config.MaintenanceWindow{Schedule: "0 2 * * *"})
+	assert.Error(t, err)
+
+	_, err = newMaintenanceWindow(config.MaintenanceWindow{Duration: time.Hour})
+	assert.Error(t, err)
+
+	_, err = newMaintenanceWindow(config.MaintenanceWindow{Schedule: "every day", Duration: time.Hour})
+	assert.Error(t, err)
+}
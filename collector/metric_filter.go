@@ -0,0 +1,159 @@
+package collector
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"mikrotik-exporter/config"
+)
+
+// metricFilter drops the metrics of a collector not selected by the filter of
+// the config.
+type metricFilter struct {
+	include       []*regexp.Regexp
+	exclude       []*regexp.Regexp
+	includeLabels map[string]*regexp.Regexp
+	excludeLabels map[string]*regexp.Regexp
+}
+
+func newMetricFilter(f config.MetricFilter) (*metricFilter, error) {
+	var (
+		m   = &metricFilter{}
+		err error
+	)
+	if m.include, err = compileAnchored(f.Include); err != nil {
+		return nil, err
+	}
+	if m.exclude, err = compileAnchored(f.Exclude); err != nil {
+		return nil, err
+	}
+	if m.includeLabels, err = compileLabels(f.IncludeLabels); err != nil {
+		return nil, err
+	}
+	if m.excludeLabels, err = compileLabels(f.ExcludeLabels); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// compileAnchored compiles the patterns to match whole strings like the
+// relabeling of Prometheus.
+func compileAnchored(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile("^(?:" + p + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid metric filter %q: %w", p, err)
+		}
+		res = append(res, re)
+	}
+
+	return res, nil
+}
+
+func compileLabels(patterns map[string]string) (map[string]*regexp.Regexp, error) {
+	res := make(map[string]*regexp.Regexp, len(patterns))
+	for label, p := range patterns {
+		re, err := compileAnchored([]string{p})
+		if err != nil {
+			return nil, err
+		}
+		res[label] = re[0]
+	}
+
+	return res, nil
+}
+
+// keep returns whether the metric is selected by the filter.
+func (f *metricFilter) keep(m prometheus.Metric) bool {
+	name := metricName(m.Desc())
+	if len(f.include) > 0 && !matchesAny(f.include, name) {
+		return false
+	}
+	if matchesAny(f.exclude, name) {
+		return false
+	}
+
+	if len(f.includeLabels) == 0 && len(f.excludeLabels) == 0 {
+		return true
+	}
+
+	var out dto.Metric
+	if err := m.Write(&out); err != nil {
+		return true
+	}
+	labels := make(map[string]string, len(out.Label))
+	for _, l := range out.Label {
+		labels[l.GetName()] = l.GetValue()
+	}
+	for label, re := range f.includeLabels {
+		if !re.MatchString(labels[label]) {
+			return false
+		}
+	}
+	for label, re := range f.excludeLabels {
+		if value, ok := labels[label]; ok && re.MatchString(value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// withMetricFilter returns a channel forwarding the metrics selected by the
+// filter to ch and a function to call once all metrics have been sent.
+func withMetricFilter(ch chan<- prometheus.Metric, f *metricFilter) (chan<- prometheus.Metric, func()) {
+	filtered := make(chan prometheus.Metric)
+	done := make(chan struct{})
+
+	go func() {
+		for m := range filtered {
+			if f.keep(m) {
+				ch <- m
+			}
+		}
+		close(done)
+	}()
+
+	return filtered, func() {
+		close(filtered)
+		<-done
+	}
+}
+
+func matchesAny(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// metricNames caches the names of the descriptors, which are only exposed by
+// their string representation.
+var metricNames sync.Map
+
+func metricName(d *prometheus.Desc) string {
+	if name, ok := metricNames.Load(d); ok {
+		return name.(string)
+	}
+
+	var name string
+	if _, rest, ok := strings.Cut(d.String(), "fqName: "); ok {
+		if quoted, err := strconv.QuotedPrefix(rest); err == nil {
+			name, _ = strconv.Unquote(quoted)
+		}
+	}
+	metricNames.Store(d, name)
+
+	return name
+}
@@ -0,0 +1,38 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+
+	"mikrotik-exporter/config"
+)
+
+func TestMetricFilter(t *testing.T) {
+	f, err := newMetricFilter(config.MetricFilter{
+		Exclude:       []string{"mikrotik_interface_(rx|tx)_drop"},
+		ExcludeLabels: map[string]string{"interface": "<pppoe-.*>"},
+	})
+	assert.NoError(t, err)
+
+	labels := []string{"name", "interface"}
+	rx := prometheus.NewDesc("mikrotik_interface_rx_byte", "test", labels, nil)
+	drop := prometheus.NewDesc("mikrotik_interface_rx_drop", "test", labels, nil)
+
+	assert.True(t, f.keep(prometheus.MustNewConstMetric(rx, prometheus.CounterValue, 1, "r1", "ether1")))
+	assert.False(t, f.keep(prometheus.MustNewConstMetric(rx, prometheus.CounterValue, 1, "r1", "<pppoe-alice>")))
+	assert.False(t, f.keep(prometheus.MustNewConstMetric(drop, prometheus.CounterValue, 1, "r1", "ether1")))
+
+	f, err = newMetricFilter(config.MetricFilter{
+		Include:       []string{"mikrotik_interface_.*_byte"},
+		IncludeLabels: map[string]string{"interface": "ether.*"},
+	})
+	assert.NoError(t, err)
+	assert.True(t, f.keep(prometheus.MustNewConstMetric(rx, prometheus.CounterValue, 1, "r1", "ether1")))
+	assert.False(t, f.keep(prometheus.MustNewConstMetric(rx, prometheus.CounterValue, 1, "r1", "bridge")))
+	assert.False(t, f.keep(prometheus.MustNewConstMetric(drop, prometheus.CounterValue, 1, "r1", "ether1")), "metric names must match the whole pattern")
+
+	_, err = newMetricFilter(config.MetricFilter{Exclude: []string{"("}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,255 @@
+package collector
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"mikrotik-exporter/config"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// mndpPort is the UDP port MNDP announcements are sent to
+	mndpPort = 5678
+	// mndpDefaultExpiry is the time after which a device which stopped
+	// announcing itself is not scraped anymore
+	mndpDefaultExpiry = 5 * time.Minute
+)
+
+// MNDP TLV types
+const (
+	mndpMACAddress  = 1
+	mndpIdentity    = 5
+	mndpSoftwareID  = 11
+	mndpIPv4Address = 17
+)
+
+// mndpNeighbor is a device announcing itself with MNDP.
+type mndpNeighbor struct {
+	mac        string
+	identity   string
+	softwareID string
+	address    net.IP
+	source     net.IP
+	seen       time.Time
+}
+
+// key identifies the device, which announces itself on each of its
+// interfaces.
+func (n *mndpNeighbor) key() string {
+	if n.softwareID != "" {
+		return n.softwareID
+	}
+
+	return n.mac
+}
+
+// mndpTable holds the announcements received by the exporter. A single
+// listener is shared by all collectors as they cannot bind the port twice.
+type mndpTable struct {
+	once      sync.Once
+	err       error
+	mu        sync.Mutex
+	neighbors map[string]*mndpNeighbor
+}
+
+var mndp = &mndpTable{
+	neighbors: make(map[string]*mndpNeighbor),
+}
+
+// start listens for announcements in the background on first use.
+func (t *mndpTable) start() error {
+	t.once.Do(func() {
+		conn, err := net.ListenUDP("udp4", &net.UDPAddr{Port: mndpPort})
+		if err != nil {
+			t.err = err
+			return
+		}
+
+		log.WithFields(log.Fields{
+			"port": mndpPort,
+		}).Info("listening for MNDP announcements")
+		go t.listen(conn)
+	})
+
+	return t.err
+}
+
+func (t *mndpTable) listen(conn *net.UDPConn) {
+	buf := make([]byte, 1500)
+	for {
+		n, src, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Error("error receiving MNDP announcement")
+			continue
+		}
+
+		nb, err := parseMNDP(buf[:n])
+		if err != nil {
+			log.WithFields(log.Fields{
+				"source": src.IP,
+				"error":  err,
+			}).Debug("ignoring invalid MNDP announcement")
+			continue
+		}
+		nb.source = src.IP
+		nb.seen = time.Now()
+
+		t.mu.Lock()
+		t.neighbors[src.IP.String()] = nb
+		t.mu.Unlock()
+	}
+}
+
+// seen returns the neighbors announcing themselves from one of the networks
+// since the expiry, one per device.
+func (t *mndpTable) seen(networks []*net.IPNet, expiry time.Duration) []*mndpNeighbor {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	latest := make(map[string]*mndpNeighbor)
+	for src, nb := range t.neighbors {
+		if time.Since(nb.seen) > expiry {
+			delete(t.neighbors, src)
+			continue
+		}
+		if !inNetworks(nb.source, networks) {
+			continue
+		}
+		if l, ok := latest[nb.key()]; !ok || nb.seen.After(l.seen) {
+			latest[nb.key()] = nb
+		}
+	}
+
+	neighbors := make([]*mndpNeighbor, 0, len(latest))
+	for _, nb := range latest {
+		neighbors = append(neighbors, nb)
+	}
+
+	return neighbors
+}
+
+// discoverMNDP returns the devices announcing themselves on the interfaces of
+// the device. The settings of the device apply to all discovered devices.
+func (c *collector) discoverMNDP(dev config.Device) []config.Device {
+	if err := mndp.start(); err != nil {
+		log.WithFields(log.Fields{
+			"device": dev.Name,
+			"error":  err,
+		}).Error("error listening for MNDP announcements")
+		return nil
+	}
+
+	networks, err := interfaceNetworks(dev.MNDP.Interfaces)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": dev.Name,
+			"error":  err,
+		}).Error("error getting networks of MNDP interfaces")
+		return nil
+	}
+
+	expiry := dev.MNDP.Expiry
+	if expiry == 0 {
+		expiry = mndpDefaultExpiry
+	}
+
+	var devices []config.Device
+	names := make(map[string]bool)
+	for _, nb := range mndp.seen(networks, expiry) {
+		d := dev
+		d.MNDP = config.MNDPDiscovery{}
+		d.Address = nb.source.String()
+		if nb.address != nil {
+			d.Address = nb.address.String()
+		}
+		d.Name = nb.identity
+		if d.Name == "" || names[d.Name] {
+			// identities are not unique, e.g. for devices with the default
+			// identity
+			d.Name = strings.TrimPrefix(d.Name+"@"+d.Address, "@")
+		}
+		names[d.Name] = true
+
+		devices = append(devices, d)
+	}
+
+	return devices
+}
+
+// parseMNDP parses an MNDP announcement, which is a 4 byte header followed by
+// type-length-value fields.
+func parseMNDP(b []byte) (*mndpNeighbor, error) {
+	if len(b) < 4 {
+		return nil, errors.New("announcement too short")
+	}
+
+	nb := &mndpNeighbor{}
+	b = b[4:]
+	for len(b) >= 4 {
+		typ := binary.BigEndian.Uint16(b[0:2])
+		length := int(binary.BigEndian.Uint16(b[2:4]))
+		if len(b) < 4+length {
+			return nil, errors.New("truncated field")
+		}
+		value := b[4 : 4+length]
+		b = b[4+length:]
+
+		switch typ {
+		case mndpMACAddress:
+			nb.mac = net.HardwareAddr(value).String()
+		case mndpIdentity:
+			nb.identity = string(value)
+		case mndpSoftwareID:
+			nb.softwareID = string(value)
+		case mndpIPv4Address:
+			if length == net.IPv4len {
+				nb.address = net.IP(value).To4()
+			}
+		}
+	}
+
+	if nb.mac == "" {
+		return nil, errors.New("missing MAC address")
+	}
+
+	return nb, nil
+}
+
+func interfaceNetworks(names []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, name := range names {
+		iface, err := net.InterfaceByName(name)
+		if err != nil {
+			return nil, err
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range addrs {
+			if n, ok := a.(*net.IPNet); ok {
+				networks = append(networks, n)
+			}
+		}
+	}
+
+	return networks, nil
+}
+
+func inNetworks(ip net.IP, networks []*net.IPNet) bool {
+	for _, n := range networks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
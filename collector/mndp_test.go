@@ -0,0 +1,32 @@
+package collector
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMNDP(t *testing.T) {
+	b := []byte{0, 0, 0, 1}
+	field := func(typ uint16, value []byte) {
+		b = binary.BigEndian.AppendUint16(b, typ)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(value)))
+		b = append(b, value...)
+	}
+	field(mndpMACAddress, []byte{0x4c, 0x5e, 0x0c, 0x01, 0x02, 0x03})
+	field(mndpIdentity, []byte("core1"))
+	field(7, []byte("7.15.3 (stable)"))
+	field(mndpSoftwareID, []byte("ABCD-1234"))
+	field(mndpIPv4Address, []byte{10, 0, 0, 1})
+
+	nb, err := parseMNDP(b)
+	assert.NoError(t, err)
+	assert.Equal(t, "4c:5e:0c:01:02:03", nb.mac)
+	assert.Equal(t, "core1", nb.identity)
+	assert.Equal(t, "ABCD-1234", nb.key())
+	assert.Equal(t, "10.0.0.1", nb.address.String())
+
+	_, err = parseMNDP(b[:len(b)-2])
+	assert.Error(t, err)
+}
@@ -38,9 +38,8 @@ func (c *monitorCollector) describe(ch chan<- *prometheus.Desc) {
 func (c *monitorCollector) collect(ctx *collectorContext) error {
 	reply, err := ctx.client.Run("/interface/ethernet/print", "=.proplist=name")
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device": ctx.device.Name,
-			"error":  err,
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
 		}).Error("error fetching ethernet interfaces")
 		return err
 	}
@@ -60,9 +59,8 @@ func (c *monitorCollector) collectForMonitor(eths []string, ctx *collectorContex
 		"=.proplist=name,"+strings.Join(c.props, ","))
 
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device": ctx.device.Name,
-			"error":  err,
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
 		}).Error("error fetching ethernet monitor info")
 		return err
 	}
@@ -0,0 +1,106 @@
+package collector
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+type mplsCollector struct {
+	neighborsDesc   *prometheus.Desc
+	operationalDesc *prometheus.Desc
+	countDescs      map[string]*prometheus.Desc
+}
+
+func newMPLSCollector() routerOSCollector {
+	c := &mplsCollector{}
+	c.init()
+	return c
+}
+
+func (c *mplsCollector) init() {
+	const prefix = "mpls"
+	labelNames := []string{"name", "address"}
+	c.neighborsDesc = description(prefix, "ldp_neighbors", "number of LDP neighbors", labelNames)
+	c.operationalDesc = description(prefix, "ldp_neighbor_operational", "whether the LDP session with the neighbor is operational", append(labelNames, "peer", "transport"))
+	c.countDescs = map[string]*prometheus.Desc{
+		"/mpls/forwarding-table/print": description(prefix, "forwarding_table_entries", "number of entries in the MPLS forwarding table", labelNames),
+		"/mpls/local-bindings/print":   description(prefix, "local_bindings", "number of local label bindings", labelNames),
+		"/mpls/remote-bindings/print":  description(prefix, "remote_bindings", "number of remote label bindings", labelNames),
+	}
+}
+
+func (c *mplsCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.neighborsDesc
+	ch <- c.operationalDesc
+	for _, d := range c.countDescs {
+		ch <- d
+	}
+}
+
+func (c *mplsCollector) collect(ctx *collectorContext) error {
+	err := c.collectNeighbors(ctx)
+	if err != nil {
+		return err
+	}
+
+	for command, desc := range c.countDescs {
+		err := c.collectCount(command, desc, ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *mplsCollector) collectNeighbors(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/mpls/ldp/neighbor/print", "=.proplist=peer,transport,operational,state")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching ldp neighbor metrics")
+		return err
+	}
+
+	ctx.ch <- prometheus.MustNewConstMetric(c.neighborsDesc, prometheus.GaugeValue, float64(len(reply.Re)), ctx.device.Name, ctx.device.Address)
+	for _, re := range reply.Re {
+		// RouterOS 6 flags operational neighbors, RouterOS 7 reports a state
+		v := 0.0
+		if re.Map["operational"] == "true" || re.Map["state"] == "operational" {
+			v = 1.0
+		}
+		ctx.ch <- prometheus.MustNewConstMetric(c.operationalDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, re.Map["peer"], re.Map["transport"])
+	}
+
+	return nil
+}
+
+func (c *mplsCollector) collectCount(command string, desc *prometheus.Desc, ctx *collectorContext) error {
+	reply, err := ctx.client.Run(command, "=count-only=")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"command": command,
+			"error":   err,
+		}).Error("error fetching mpls metrics")
+		return err
+	}
+
+	v, err := strconv.ParseFloat(reply.Done.Map["ret"], 64)
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"command": command,
+			"value":   reply.Done.Map["ret"],
+			"error":   err,
+		}).Error("error parsing mpls metric value")
+		return nil
+	}
+	ctx.ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address)
+
+	return nil
+}
+
+func (c *mplsCollector) requiredMenu() string {
+	return "/mpls/ldp/neighbor"
+}
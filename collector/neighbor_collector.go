@@ -0,0 +1,52 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+type neighborCollector struct {
+	neighborsDesc *prometheus.Desc
+	infoDesc      *prometheus.Desc
+}
+
+func newNeighborCollector() routerOSCollector {
+	c := &neighborCollector{}
+	c.init()
+	return c
+}
+
+func (c *neighborCollector) init() {
+	const prefix = "neighbor"
+	c.neighborsDesc = description(prefix, "count", "number of neighbors discovered on the interface", []string{"name", "address", "interface"})
+	c.infoDesc = description(prefix, "info", "neighbor discovered via MNDP, CDP or LLDP",
+		[]string{"name", "address", "interface", "identity", "platform", "board", "version", "neighbor_address", "mac_address"})
+}
+
+func (c *neighborCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.neighborsDesc
+	ch <- c.infoDesc
+}
+
+func (c *neighborCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/ip/neighbor/print", "=.proplist=interface,identity,platform,board,version,address,mac-address")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching neighbors")
+		return err
+	}
+
+	counts := make(map[string]float64)
+	for _, re := range reply.Re {
+		counts[re.Map["interface"]]++
+		ctx.ch <- prometheus.MustNewConstMetric(c.infoDesc, prometheus.GaugeValue, 1, ctx.device.Name, ctx.device.Address,
+			re.Map["interface"], re.Map["identity"], re.Map["platform"], re.Map["board"], re.Map["version"], re.Map["address"], re.Map["mac-address"])
+	}
+
+	for iface, v := range counts {
+		ctx.ch <- prometheus.MustNewConstMetric(c.neighborsDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, iface)
+	}
+
+	return nil
+}
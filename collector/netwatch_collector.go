@@ -2,6 +2,7 @@ package collector
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -12,8 +13,14 @@ import (
 type netwatchCollector struct {
 	props        []string
 	descriptions map[string]*prometheus.Desc
+	lossDesc     *prometheus.Desc
+	rttDescs     map[string]*prometheus.Desc
+	httpDesc     *prometheus.Desc
 }
 
+// netwatchRTTProps are the round trip times of the icmp probes of RouterOS 7
+var netwatchRTTProps = []string{"rtt-avg", "rtt-min", "rtt-max", "rtt-jitter"}
+
 func newNetwatchCollector() routerOSCollector {
 	c := &netwatchCollector{}
 	c.init()
@@ -27,12 +34,27 @@ func (c *netwatchCollector) init() {
 	for _, p := range c.props[1:] {
 		c.descriptions[p] = descriptionForPropertyName("netwatch", p, labelNames)
 	}
+
+	// the probe details of RouterOS 7, missing on RouterOS 6
+	c.props = append(c.props, "loss-percent", "http-status-code")
+	c.props = append(c.props, netwatchRTTProps...)
+	c.lossDesc = description("netwatch", "loss_ratio", "ratio of the packets of the last icmp probe without reply", labelNames)
+	c.rttDescs = make(map[string]*prometheus.Desc)
+	for _, p := range netwatchRTTProps {
+		c.rttDescs[p] = description("netwatch", strings.ReplaceAll(p, "-", "_")+"_seconds", strings.ReplaceAll(p, "-", " ")+" of the last icmp probe", labelNames)
+	}
+	c.httpDesc = description("netwatch", "http_status_code", "status code of the last http-get probe", labelNames)
 }
 
 func (c *netwatchCollector) describe(ch chan<- *prometheus.Desc) {
 	for _, d := range c.descriptions {
 		ch <- d
 	}
+	ch <- c.lossDesc
+	for _, d := range c.rttDescs {
+		ch <- d
+	}
+	ch <- c.httpDesc
 }
 
 func (c *netwatchCollector) collect(ctx *collectorContext) error {
@@ -51,9 +73,8 @@ func (c *netwatchCollector) collect(ctx *collectorContext) error {
 func (c *netwatchCollector) fetch(ctx *collectorContext) ([]*proto.Sentence, error) {
 	reply, err := ctx.client.Run("/tool/netwatch/print", "?disabled=false", "=.proplist="+strings.Join(c.props, ","))
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device": ctx.device.Name,
-			"error":  err,
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
 		}).Error("error fetching netwatch metrics")
 		return nil, err
 	}
@@ -65,8 +86,52 @@ func (c *netwatchCollector) collectForStat(re *proto.Sentence, ctx *collectorCon
 	host := re.Map["host"]
 	comment := re.Map["comment"]
 
-	for _, p := range c.props[2:] {
-		c.collectMetricForProperty(p, host, comment, re, ctx)
+	c.collectMetricForProperty("status", host, comment, re, ctx)
+	c.collectProbeDetails(host, comment, re, ctx)
+}
+
+// collectProbeDetails exports the loss, round trip times and HTTP status of
+// the probes of RouterOS 7 if present.
+func (c *netwatchCollector) collectProbeDetails(host, comment string, re *proto.Sentence, ctx *collectorContext) {
+	labelValues := []string{ctx.device.Name, ctx.device.Address, host, comment}
+	parseError := func(property, value string, err error) {
+		ctx.logger().WithFields(log.Fields{
+			"host":     host,
+			"property": property,
+			"value":    value,
+			"error":    err,
+		}).Error("error parsing netwatch metric value")
+	}
+
+	if value := re.Map["loss-percent"]; value != "" {
+		v, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		if err != nil {
+			parseError("loss-percent", value, err)
+		} else {
+			ctx.ch <- prometheus.MustNewConstMetric(c.lossDesc, prometheus.GaugeValue, v/100, labelValues...)
+		}
+	}
+
+	for _, p := range netwatchRTTProps {
+		value := re.Map[p]
+		if value == "" {
+			continue
+		}
+		v, err := parseRTT(value)
+		if err != nil {
+			parseError(p, value, err)
+			continue
+		}
+		ctx.ch <- prometheus.MustNewConstMetric(c.rttDescs[p], prometheus.GaugeValue, v, labelValues...)
+	}
+
+	if value := re.Map["http-status-code"]; value != "" {
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			parseError("http-status-code", value, err)
+		} else {
+			ctx.ch <- prometheus.MustNewConstMetric(c.httpDesc, prometheus.GaugeValue, v, labelValues...)
+		}
 	}
 }
 
@@ -82,14 +147,17 @@ func (c *netwatchCollector) collectMetricForProperty(property, host, comment str
 		case "down":
 			numericValue = -1
 		default:
-			log.WithFields(log.Fields{
-				"device":   ctx.device.Name,
+			ctx.logger().WithFields(log.Fields{
 				"host":     host,
 				"property": property,
 				"value":    value,
 				"error":    fmt.Errorf("unexpected netwatch status value"),
 			}).Error("error parsing netwatch metric value")
 		}
-		ctx.ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, numericValue, ctx.device.Name, ctx.device.Address, host, comment)
+		vtype := prometheus.GaugeValue
+		if ctx.legacyMetricTypes {
+			vtype = prometheus.CounterValue
+		}
+		ctx.ch <- prometheus.MustNewConstMetric(desc, vtype, numericValue, ctx.device.Name, ctx.device.Address, host, comment)
 	}
 }
@@ -0,0 +1,40 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/routeros.v2/proto"
+
+	"mikrotik-exporter/config"
+)
+
+func TestNetwatchProbeDetails(t *testing.T) {
+	c := newNetwatchCollector().(*netwatchCollector)
+	ch := make(chan prometheus.Metric, 10)
+	c.collectForStat(&proto.Sentence{Map: map[string]string{
+		"host":             "10.0.0.1",
+		"status":           "up",
+		"loss-percent":     "25",
+		"rtt-avg":          "1ms500us",
+		"rtt-min":          "1ms",
+		"rtt-max":          "2ms",
+		"rtt-jitter":       "800us",
+		"http-status-code": "",
+	}}, &collectorContext{ch: ch, device: &config.Device{Name: "r1"}})
+	close(ch)
+
+	values := make(map[string]float64)
+	for m := range ch {
+		var out dto.Metric
+		assert.NoError(t, m.Write(&out))
+		values[m.Desc().String()] = out.GetGauge().GetValue()
+	}
+
+	assert.Len(t, values, 6)
+	assert.Equal(t, 0.25, values[c.lossDesc.String()])
+	assert.InDelta(t, 0.0015, values[c.rttDescs["rtt-avg"].String()], 1e-9)
+	assert.InDelta(t, 0.0008, values[c.rttDescs["rtt-jitter"].String()], 1e-9)
+}
@@ -0,0 +1,110 @@
+package collector
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+type ntpCollector struct {
+	props          []string
+	syncedDesc     *prometheus.Desc
+	stratumDesc    *prometheus.Desc
+	offsetDesc     *prometheus.Desc
+	lastUpdateDesc *prometheus.Desc
+}
+
+func newNTPCollector() routerOSCollector {
+	c := &ntpCollector{}
+	c.init()
+	return c
+}
+
+func (c *ntpCollector) init() {
+	// RouterOS 6 reports last-update-*, RouterOS 7 status and synced-*
+	c.props = []string{"enabled", "status", "synced-server", "synced-stratum", "system-offset", "last-update-from", "last-update-before", "last-adjustment"}
+
+	const prefix = "ntp"
+	labelNames := []string{"name", "address", "server"}
+	c.syncedDesc = description(prefix, "synchronized", "whether the NTP client is synchronized", labelNames)
+	c.stratumDesc = description(prefix, "stratum", "stratum of the server the NTP client is synchronized to", labelNames)
+	c.offsetDesc = description(prefix, "offset_seconds", "offset of the system clock to the NTP server", labelNames)
+	c.lastUpdateDesc = description(prefix, "last_update_seconds", "time since the last update from the NTP server", labelNames)
+}
+
+func (c *ntpCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.syncedDesc
+	ch <- c.stratumDesc
+	ch <- c.offsetDesc
+	ch <- c.lastUpdateDesc
+}
+
+func (c *ntpCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/system/ntp/client/print", "=.proplist="+strings.Join(c.props, ","))
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching ntp client metrics")
+		return err
+	}
+	if len(reply.Re) == 0 || reply.Re[0].Map["enabled"] != "true" {
+		return nil
+	}
+
+	re := reply.Re[0]
+	server := re.Map["synced-server"]
+	if server == "" {
+		server = re.Map["last-update-from"]
+	}
+
+	synced := 0.0
+	if re.Map["status"] == "synchronized" || (re.Map["status"] == "" && server != "") {
+		synced = 1.0
+	}
+	ctx.ch <- prometheus.MustNewConstMetric(c.syncedDesc, prometheus.GaugeValue, synced, ctx.device.Name, ctx.device.Address, server)
+
+	if v, err := strconv.ParseFloat(re.Map["synced-stratum"], 64); err == nil {
+		ctx.ch <- prometheus.MustNewConstMetric(c.stratumDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, server)
+	}
+
+	offset := re.Map["system-offset"]
+	if offset == "" {
+		offset = re.Map["last-adjustment"]
+	}
+	if v, err := parseNTPOffset(offset); err == nil {
+		ctx.ch <- prometheus.MustNewConstMetric(c.offsetDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, server)
+	}
+
+	if before := re.Map["last-update-before"]; before != "" {
+		if v, err := parseDuration(before); err == nil {
+			ctx.ch <- prometheus.MustNewConstMetric(c.lastUpdateDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, server)
+		}
+	}
+
+	return nil
+}
+
+// parseNTPOffset parses clock offsets in seconds. RouterOS reports them in
+// milliseconds if no unit is given.
+func parseNTPOffset(value string) (float64, error) {
+	value = strings.ReplaceAll(value, " ", "")
+
+	scale := 0.001
+	switch {
+	case strings.HasSuffix(value, "us"):
+		value, scale = strings.TrimSuffix(value, "us"), 0.000001
+	case strings.HasSuffix(value, "ms"):
+		value = strings.TrimSuffix(value, "ms")
+	case strings.HasSuffix(value, "s"):
+		value, scale = strings.TrimSuffix(value, "s"), 1
+	}
+
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return v * scale, nil
+}
@@ -0,0 +1,28 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNTPOffset(t *testing.T) {
+	var testCases = []struct {
+		input    string
+		expected float64
+	}{
+		{"-1.5", -0.0015},
+		{"12ms", 0.012},
+		{"250us", 0.00025},
+		{"2 s", 2},
+	}
+
+	for _, tc := range testCases {
+		v, err := parseNTPOffset(tc.input)
+		assert.NoError(t, err)
+		assert.InDelta(t, tc.expected, v, 1e-12, tc.input)
+	}
+
+	_, err := parseNTPOffset("")
+	assert.Error(t, err)
+}
@@ -49,9 +49,8 @@ func (c *opticsCollector) describe(ch chan<- *prometheus.Desc) {
 func (c *opticsCollector) collect(ctx *collectorContext) error {
 	reply, err := ctx.client.Run("/interface/ethernet/print", "=.proplist=name")
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device": ctx.device.Name,
-			"error":  err,
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
 		}).Error("error fetching interface metrics")
 		return err
 	}
@@ -77,9 +76,8 @@ func (c *opticsCollector) collectOpticalMetricsForInterfaces(ifaces []string, ct
 		"=once=",
 		"=.proplist=name,"+strings.Join(c.props, ","))
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device": ctx.device.Name,
-			"error":  err,
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
 		}).Error("error fetching interface monitor metrics")
 		return err
 	}
@@ -105,8 +103,7 @@ func (c *opticsCollector) collectMetricsForInterface(name string, se *proto.Sent
 
 		value, err := c.valueForKey(prop, v)
 		if err != nil {
-			log.WithFields(log.Fields{
-				"device":    ctx.device.Name,
+			ctx.logger().WithFields(log.Fields{
 				"interface": name,
 				"property":  prop,
 				"error":     err,
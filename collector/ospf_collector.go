@@ -0,0 +1,153 @@
+package collector
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+type ospfCollector struct {
+	props        []string
+	descriptions map[string]*prometheus.Desc
+	adjacentDesc *prometheus.Desc
+	lsaDesc      *prometheus.Desc
+}
+
+func newOSPFCollector() routerOSCollector {
+	c := &ospfCollector{}
+	c.init()
+	return c
+}
+
+func (c *ospfCollector) init() {
+	c.props = []string{"instance", "router-id", "address", "interface", "state", "state-changes", "adjacency", "dead-time"}
+
+	const prefix = "ospf"
+	labelNames := []string{"name", "address", "instance", "router_id", "neighbor_address", "interface"}
+	c.descriptions = map[string]*prometheus.Desc{
+		"state":         description(prefix, "neighbor_full", "whether the adjacency with the neighbor is in Full state", labelNames),
+		"state-changes": description(prefix, "neighbor_state_changes", "number of state changes of the neighbor", labelNames),
+		"adjacency":     description(prefix, "neighbor_adjacency_seconds", "time since the adjacency with the neighbor was established", labelNames),
+		"dead-time":     description(prefix, "neighbor_dead_time_seconds", "time until the neighbor is declared dead without hello", labelNames),
+	}
+	c.adjacentDesc = description(prefix, "instance_full_adjacencies", "number of neighbors in Full state", []string{"name", "address", "instance"})
+	c.lsaDesc = description(prefix, "lsa_count", "number of LSAs in the database", []string{"name", "address", "instance", "type"})
+}
+
+func (c *ospfCollector) describe(ch chan<- *prometheus.Desc) {
+	for _, d := range c.descriptions {
+		ch <- d
+	}
+	ch <- c.adjacentDesc
+	ch <- c.lsaDesc
+}
+
+func (c *ospfCollector) collect(ctx *collectorContext) error {
+	stats, err := c.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	full := make(map[string]float64)
+	for _, re := range stats {
+		if _, ok := full[re.Map["instance"]]; !ok {
+			full[re.Map["instance"]] = 0
+		}
+		if strings.EqualFold(re.Map["state"], "full") {
+			full[re.Map["instance"]]++
+		}
+		c.collectForStat(re, ctx)
+	}
+
+	for instance, v := range full {
+		ctx.ch <- prometheus.MustNewConstMetric(c.adjacentDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, instance)
+	}
+
+	return c.collectLSAs(ctx)
+}
+
+func (c *ospfCollector) fetch(ctx *collectorContext) ([]*proto.Sentence, error) {
+	reply, err := ctx.client.Run("/routing/ospf/neighbor/print", "=.proplist="+strings.Join(c.props, ","))
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching ospf neighbor metrics")
+		return nil, err
+	}
+
+	return reply.Re, nil
+}
+
+func (c *ospfCollector) collectForStat(re *proto.Sentence, ctx *collectorContext) {
+	for _, p := range c.props[4:] {
+		c.collectMetricForProperty(p, re, ctx)
+	}
+}
+
+func (c *ospfCollector) collectMetricForProperty(property string, re *proto.Sentence, ctx *collectorContext) {
+	value := re.Map[property]
+	if value == "" {
+		return
+	}
+
+	var (
+		v     float64
+		vtype = prometheus.GaugeValue
+		err   error
+	)
+	switch property {
+	case "state":
+		if strings.EqualFold(value, "full") {
+			v = 1
+		}
+	case "state-changes":
+		vtype = prometheus.CounterValue
+		v, err = strconv.ParseFloat(value, 64)
+	default:
+		v, err = parseDuration(value)
+	}
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"neighbor": re.Map["router-id"],
+			"property": property,
+			"value":    value,
+			"error":    err,
+		}).Error("error parsing ospf neighbor metric value")
+		return
+	}
+
+	ctx.ch <- prometheus.MustNewConstMetric(c.descriptions[property], vtype, v, ctx.device.Name, ctx.device.Address,
+		re.Map["instance"], re.Map["router-id"], re.Map["address"], re.Map["interface"])
+}
+
+func (c *ospfCollector) collectLSAs(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/routing/ospf/lsa/print", "=.proplist=instance,type")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching ospf lsa metrics")
+		return err
+	}
+
+	type lsaKey struct {
+		instance string
+		lsaType  string
+	}
+	counts := make(map[lsaKey]float64)
+	for _, re := range reply.Re {
+		counts[lsaKey{re.Map["instance"], re.Map["type"]}]++
+	}
+
+	for k, v := range counts {
+		ctx.ch <- prometheus.MustNewConstMetric(c.lsaDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, k.instance, k.lsaType)
+	}
+
+	return nil
+}
+
+func (c *ospfCollector) requiredMenu() string {
+	return "/routing/ospf/neighbor"
+}
@@ -0,0 +1,161 @@
+package collector
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+
+	"mikrotik-exporter/config"
+)
+
+const (
+	defaultPingCount    = 3
+	defaultPingInterval = 200 * time.Millisecond
+)
+
+// pingCollector pings the targets from the devices, measuring the
+// reachability of the targets from each site.
+type pingCollector struct {
+	cfg config.PingConfig
+
+	sentDesc     *prometheus.Desc
+	receivedDesc *prometheus.Desc
+	lossDesc     *prometheus.Desc
+	rttMinDesc   *prometheus.Desc
+	rttAvgDesc   *prometheus.Desc
+	rttMaxDesc   *prometheus.Desc
+}
+
+func newPingCollector(cfg config.PingConfig) routerOSCollector {
+	if cfg.Count == 0 {
+		cfg.Count = defaultPingCount
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = defaultPingInterval
+	}
+
+	const prefix = "ping"
+
+	labelNames := []string{"name", "address", "target"}
+	return &pingCollector{
+		cfg:          cfg,
+		sentDesc:     description(prefix, "sent", "number of echo requests sent to the target", labelNames),
+		receivedDesc: description(prefix, "received", "number of echo replies received from the target", labelNames),
+		lossDesc:     description(prefix, "packet_loss_ratio", "ratio of echo requests to the target without reply", labelNames),
+		rttMinDesc:   description(prefix, "rtt_min_seconds", "minimum round trip time to the target", labelNames),
+		rttAvgDesc:   description(prefix, "rtt_avg_seconds", "average round trip time to the target", labelNames),
+		rttMaxDesc:   description(prefix, "rtt_max_seconds", "maximum round trip time to the target", labelNames),
+	}
+}
+
+func (c *pingCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.sentDesc
+	ch <- c.receivedDesc
+	ch <- c.lossDesc
+	ch <- c.rttMinDesc
+	ch <- c.rttAvgDesc
+	ch <- c.rttMaxDesc
+}
+
+func (c *pingCollector) collect(ctx *collectorContext) error {
+	for _, target := range c.cfg.Targets {
+		if err := c.collectForTarget(target, ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *pingCollector) collectForTarget(target string, ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/ping",
+		"=address="+target,
+		"=count="+strconv.Itoa(c.cfg.Count),
+		fmt.Sprintf("=interval=%dms", c.cfg.Interval.Milliseconds()))
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"target": target,
+			"error":  err,
+		}).Error("error pinging target")
+		return err
+	}
+	if len(reply.Re) == 0 {
+		return nil
+	}
+
+	// each reply carries the totals of the packets so far
+	re := reply.Re[len(reply.Re)-1]
+	labelValues := []string{ctx.device.Name, ctx.device.Address, target}
+
+	sent, err := strconv.ParseFloat(re.Map["sent"], 64)
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"target": target,
+			"value":  re.Map["sent"],
+			"error":  err,
+		}).Error("error parsing ping metric value")
+		return nil
+	}
+	received, _ := strconv.ParseFloat(re.Map["received"], 64)
+	ctx.ch <- prometheus.MustNewConstMetric(c.sentDesc, prometheus.GaugeValue, sent, labelValues...)
+	ctx.ch <- prometheus.MustNewConstMetric(c.receivedDesc, prometheus.GaugeValue, received, labelValues...)
+	if sent > 0 {
+		ctx.ch <- prometheus.MustNewConstMetric(c.lossDesc, prometheus.GaugeValue, (sent-received)/sent, labelValues...)
+	}
+
+	c.collectRTT(re, c.rttMinDesc, "min-rtt", target, labelValues, ctx)
+	c.collectRTT(re, c.rttAvgDesc, "avg-rtt", target, labelValues, ctx)
+	c.collectRTT(re, c.rttMaxDesc, "max-rtt", target, labelValues, ctx)
+
+	return nil
+}
+
+// collectRTT exports the round trip time, which is missing if no reply was
+// received.
+func (c *pingCollector) collectRTT(re *proto.Sentence, desc *prometheus.Desc, property, target string, labelValues []string, ctx *collectorContext) {
+	value := re.Map[property]
+	if value == "" {
+		return
+	}
+
+	v, err := parseRTT(value)
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"target":   target,
+			"property": property,
+			"value":    value,
+			"error":    err,
+		}).Error("error parsing ping metric value")
+		return
+	}
+
+	ctx.ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, labelValues...)
+}
+
+// parseRTT parses round trip times like 12ms on RouterOS 6 or 12ms345us on
+// RouterOS 7 into seconds.
+func parseRTT(rtt string) (float64, error) {
+	var us float64
+	ms := rtt
+	if rest, ok := strings.CutSuffix(rtt, "us"); ok {
+		i := strings.LastIndexFunc(rest, func(r rune) bool { return r < '0' || r > '9' }) + 1
+		v, err := strconv.ParseFloat(rest[i:], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid round trip time %q", rtt)
+		}
+		us = v / 1e6
+		ms = rest[:i]
+	}
+
+	v, err := parseDuration(ms)
+	if err != nil {
+		return 0, fmt.Errorf("invalid round trip time %q: %w", rtt, err)
+	}
+
+	return v + us, nil
+}
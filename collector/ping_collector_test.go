@@ -0,0 +1,30 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRTT(t *testing.T) {
+	var testCases = []struct {
+		input    string
+		expected float64
+	}{
+		{"12ms", 0.012},
+		{"12ms345us", 0.012345},
+		{"850us", 0.00085},
+		{"1s2ms", 1.002},
+	}
+
+	for _, tc := range testCases {
+		v, err := parseRTT(tc.input)
+		assert.NoError(t, err)
+		assert.InDelta(t, tc.expected, v, 1e-12, tc.input)
+	}
+
+	_, err := parseRTT("timeout")
+	assert.Error(t, err)
+	_, err = parseRTT("msus")
+	assert.Error(t, err)
+}
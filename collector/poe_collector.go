@@ -10,10 +10,13 @@ import (
 )
 
 type poeCollector struct {
-	currentDesc *prometheus.Desc
-	powerDesc   *prometheus.Desc
-	voltageDesc *prometheus.Desc
-	props       []string
+	currentDesc  *prometheus.Desc
+	powerDesc    *prometheus.Desc
+	voltageDesc  *prometheus.Desc
+	consumedDesc *prometheus.Desc
+	budgetDesc   *prometheus.Desc
+	headroomDesc *prometheus.Desc
+	props        []string
 }
 
 func newPOECollector() routerOSCollector {
@@ -25,6 +28,10 @@ func newPOECollector() routerOSCollector {
 		powerDesc:   description(prefix, "wattage", "Power in W", labelNames),
 		voltageDesc: description(prefix, "voltage", "Voltage in V", labelNames),
 		props:       []string{"poe-out-current", "poe-out-voltage", "poe-out-power"},
+
+		consumedDesc: description(prefix, "consumed_watts", "total power drawn from all PoE outputs", []string{"name", "address"}),
+		budgetDesc:   description(prefix, "budget_watts", "power budget of the PoE outputs as configured for the device", []string{"name", "address"}),
+		headroomDesc: description(prefix, "headroom_watts", "power left in the budget of the PoE outputs", []string{"name", "address"}),
 	}
 }
 
@@ -32,14 +39,16 @@ func (c *poeCollector) describe(ch chan<- *prometheus.Desc) {
 	ch <- c.currentDesc
 	ch <- c.powerDesc
 	ch <- c.voltageDesc
+	ch <- c.consumedDesc
+	ch <- c.budgetDesc
+	ch <- c.headroomDesc
 }
 
 func (c *poeCollector) collect(ctx *collectorContext) error {
 	reply, err := ctx.client.Run("/interface/ethernet/poe/print", "=.proplist=name")
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device": ctx.device.Name,
-			"error":  err,
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
 		}).Error("error fetching interface poe metrics")
 		return err
 	}
@@ -66,13 +75,13 @@ func (c *poeCollector) collectPOEMetricsForInterfaces(
 		"=once=",
 		"=.proplist=name,"+strings.Join(c.props, ","))
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device": ctx.device.Name,
-			"error":  err,
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
 		}).Error("error fetching interface poe monitor metrics")
 		return err
 	}
 
+	var consumed float64
 	for _, se := range reply.Re {
 		name, ok := se.Map["name"]
 		if !ok {
@@ -80,11 +89,28 @@ func (c *poeCollector) collectPOEMetricsForInterfaces(
 		}
 
 		c.collectMetricsForInterface(name, se, ctx)
+
+		if v, err := strconv.ParseFloat(se.Map["poe-out-power"], 64); err == nil {
+			consumed += v
+		}
 	}
 
+	c.collectBudget(consumed, ctx)
+
 	return nil
 }
 
+func (c *poeCollector) collectBudget(consumed float64, ctx *collectorContext) {
+	ctx.ch <- prometheus.MustNewConstMetric(c.consumedDesc, prometheus.GaugeValue, consumed, ctx.device.Name, ctx.device.Address)
+
+	budget := ctx.device.PoEBudget
+	if budget <= 0 {
+		return
+	}
+	ctx.ch <- prometheus.MustNewConstMetric(c.budgetDesc, prometheus.GaugeValue, budget, ctx.device.Name, ctx.device.Address)
+	ctx.ch <- prometheus.MustNewConstMetric(c.headroomDesc, prometheus.GaugeValue, budget-consumed, ctx.device.Name, ctx.device.Address)
+}
+
 func (c *poeCollector) collectMetricsForInterface(
 	name string,
 	se *proto.Sentence,
@@ -100,8 +126,7 @@ func (c *poeCollector) collectMetricsForInterface(
 		}
 		value, err := strconv.ParseFloat(v, 64)
 		if err != nil {
-			log.WithFields(log.Fields{
-				"device":    ctx.device.Name,
+			ctx.logger().WithFields(log.Fields{
 				"interface": name,
 				"property":  prop,
 				"error":     err,
@@ -125,3 +150,7 @@ func (c *poeCollector) descForKey(name string) *prometheus.Desc {
 
 	return nil
 }
+
+func (c *poeCollector) requiredMenu() string {
+	return "/interface/ethernet/poe"
+}
@@ -52,9 +52,8 @@ func (c *poolCollector) collectForIPVersion(ipVersion, topic string, ctx *collec
 func (c *poolCollector) fetchPoolNames(ipVersion, topic string, ctx *collectorContext) ([]string, error) {
 	reply, err := ctx.client.Run(fmt.Sprintf("/%s/pool/print", topic), "=.proplist=name")
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device": ctx.device.Name,
-			"error":  err,
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
 		}).Error("error fetching pool names")
 		return nil, err
 	}
@@ -70,10 +69,9 @@ func (c *poolCollector) fetchPoolNames(ipVersion, topic string, ctx *collectorCo
 func (c *poolCollector) collectForPool(ipVersion, topic, pool string, ctx *collectorContext) error {
 	reply, err := ctx.client.Run(fmt.Sprintf("/%s/pool/used/print", topic), fmt.Sprintf("?pool=%s", pool), "=count-only=")
 	if err != nil {
-		log.WithFields(log.Fields{
+		ctx.logger().WithFields(log.Fields{
 			"pool":       pool,
 			"ip_version": ipVersion,
-			"device":     ctx.device.Name,
 			"error":      err,
 		}).Error("error fetching pool counts")
 		return err
@@ -83,10 +81,9 @@ func (c *poolCollector) collectForPool(ipVersion, topic, pool string, ctx *colle
 	}
 	v, err := strconv.ParseFloat(reply.Done.Map["ret"], 32)
 	if err != nil {
-		log.WithFields(log.Fields{
+		ctx.logger().WithFields(log.Fields{
 			"pool":       pool,
 			"ip_version": ipVersion,
-			"device":     ctx.device.Name,
 			"error":      err,
 		}).Error("error parsing pool counts")
 		return err
@@ -0,0 +1,91 @@
+package collector
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+type pppCollector struct {
+	perSession   bool
+	props        []string
+	sessionsDesc *prometheus.Desc
+	uptimeDesc   *prometheus.Desc
+}
+
+func newPPPCollector(perSession bool) routerOSCollector {
+	c := &pppCollector{perSession: perSession}
+	c.init()
+	return c
+}
+
+func (c *pppCollector) init() {
+	c.props = []string{"name", "service", "caller-id", "address", "uptime"}
+
+	const prefix = "ppp"
+	c.sessionsDesc = description(prefix, "active_sessions", "number of active PPP sessions", []string{"name", "address", "service"})
+	c.uptimeDesc = description(prefix, "session_uptime_seconds", "uptime of the PPP session",
+		[]string{"name", "address", "user", "service", "caller_id", "remote_address"})
+}
+
+func (c *pppCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.sessionsDesc
+	if c.perSession {
+		ch <- c.uptimeDesc
+	}
+}
+
+func (c *pppCollector) collect(ctx *collectorContext) error {
+	stats, err := c.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	sessions := make(map[string]float64)
+	for _, re := range stats {
+		sessions[re.Map["service"]]++
+		if c.perSession {
+			c.collectForStat(re, ctx)
+		}
+	}
+
+	for service, v := range sessions {
+		ctx.ch <- prometheus.MustNewConstMetric(c.sessionsDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, service)
+	}
+
+	return nil
+}
+
+func (c *pppCollector) fetch(ctx *collectorContext) ([]*proto.Sentence, error) {
+	props := c.props[1:2]
+	if c.perSession {
+		props = c.props
+	}
+
+	reply, err := ctx.client.Run("/ppp/active/print", "=.proplist="+strings.Join(props, ","))
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching ppp session metrics")
+		return nil, err
+	}
+
+	return reply.Re, nil
+}
+
+func (c *pppCollector) collectForStat(re *proto.Sentence, ctx *collectorContext) {
+	v, err := parseDuration(re.Map["uptime"])
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"session": re.Map["name"],
+			"value":   re.Map["uptime"],
+			"error":   err,
+		}).Error("error parsing ppp session uptime")
+		return
+	}
+
+	ctx.ch <- prometheus.MustNewConstMetric(c.uptimeDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address,
+		re.Map["name"], re.Map["service"], re.Map["caller-id"], re.Map["address"])
+}
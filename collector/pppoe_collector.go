@@ -0,0 +1,133 @@
+package collector
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+type pppoeCollector struct {
+	sessionsDesc *prometheus.Desc
+	rxDesc       *prometheus.Desc
+	txDesc       *prometheus.Desc
+	uptimeDesc   *prometheus.Desc
+}
+
+func newPPPoECollector() routerOSCollector {
+	c := &pppoeCollector{}
+	c.init()
+	return c
+}
+
+func (c *pppoeCollector) init() {
+	const prefix = "pppoe_server"
+	labelNames := []string{"name", "address", "user", "service", "interface", "remote_address"}
+	c.sessionsDesc = description(prefix, "sessions", "number of active PPPoE sessions", []string{"name", "address", "service", "interface"})
+	c.rxDesc = description(prefix, "session_rx_bytes", "number of bytes received from the PPPoE session", labelNames)
+	c.txDesc = description(prefix, "session_tx_bytes", "number of bytes sent to the PPPoE session", labelNames)
+	c.uptimeDesc = description(prefix, "session_uptime_seconds", "uptime of the PPPoE session", labelNames)
+}
+
+func (c *pppoeCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.sessionsDesc
+	ch <- c.rxDesc
+	ch <- c.txDesc
+	ch <- c.uptimeDesc
+}
+
+func (c *pppoeCollector) collect(ctx *collectorContext) error {
+	interfaces, err := c.fetchServerInterfaces(ctx)
+	if err != nil {
+		return err
+	}
+
+	traffic, err := c.fetchTraffic(ctx)
+	if err != nil {
+		return err
+	}
+
+	reply, err := ctx.client.Run("/interface/pppoe-server/print", "=.proplist=name,user,service,remote-address,uptime")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching pppoe session metrics")
+		return err
+	}
+
+	sessions := make(map[string]float64)
+	for _, re := range reply.Re {
+		sessions[re.Map["service"]]++
+		c.collectForSession(re, interfaces[re.Map["service"]], traffic[re.Map["name"]], ctx)
+	}
+
+	for service, v := range sessions {
+		ctx.ch <- prometheus.MustNewConstMetric(c.sessionsDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, service, interfaces[service])
+	}
+
+	return nil
+}
+
+// fetchServerInterfaces returns the interface each PPPoE service runs on.
+func (c *pppoeCollector) fetchServerInterfaces(ctx *collectorContext) (map[string]string, error) {
+	reply, err := ctx.client.Run("/interface/pppoe-server/server/print", "=.proplist=service-name,interface")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching pppoe servers")
+		return nil, err
+	}
+
+	interfaces := make(map[string]string)
+	for _, re := range reply.Re {
+		interfaces[re.Map["service-name"]] = re.Map["interface"]
+	}
+
+	return interfaces, nil
+}
+
+// fetchTraffic returns the statistics of the dynamic PPPoE interfaces by name.
+func (c *pppoeCollector) fetchTraffic(ctx *collectorContext) (map[string]*proto.Sentence, error) {
+	reply, err := ctx.client.Run("/interface/print", "?type=pppoe-in", "=.proplist=name,rx-byte,tx-byte")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching pppoe interface metrics")
+		return nil, err
+	}
+
+	traffic := make(map[string]*proto.Sentence)
+	for _, re := range reply.Re {
+		traffic[re.Map["name"]] = re
+	}
+
+	return traffic, nil
+}
+
+func (c *pppoeCollector) collectForSession(re *proto.Sentence, iface string, traffic *proto.Sentence, ctx *collectorContext) {
+	labelValues := []string{ctx.device.Name, ctx.device.Address, re.Map["user"], re.Map["service"], iface, re.Map["remote-address"]}
+
+	if uptime, err := parseDuration(re.Map["uptime"]); err == nil {
+		ctx.ch <- prometheus.MustNewConstMetric(c.uptimeDesc, prometheus.GaugeValue, uptime, labelValues...)
+	}
+
+	if traffic == nil {
+		return
+	}
+
+	// the dynamic interface receives what the client sends
+	for desc, property := range map[*prometheus.Desc]string{c.rxDesc: "rx-byte", c.txDesc: "tx-byte"} {
+		v, err := strconv.ParseFloat(traffic.Map[property], 64)
+		if err != nil {
+			ctx.logger().WithFields(log.Fields{
+				"session":  re.Map["name"],
+				"property": property,
+				"value":    traffic.Map[property],
+				"error":    err,
+			}).Error("error parsing pppoe session metric value")
+			continue
+		}
+		ctx.ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v, labelValues...)
+	}
+}
@@ -0,0 +1,41 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"mikrotik-exporter/config"
+)
+
+// probedCollector scrapes a single device which is not one of the devices of
+// the collector, e.g. the target of a probe, sharing the connections and
+// caches of the collector.
+type probedCollector struct {
+	c      *collector
+	device config.Device
+	sel    *selection
+}
+
+// Probe returns a collector scraping only the device d with the named
+// collectors, or all but the excluded ones. It scrapes the device on each
+// collection, also with background polling.
+func (c *collector) Probe(d config.Device, collect, exclude []string) (prometheus.Collector, error) {
+	sel, err := c.newSelection(collect, exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	return &probedCollector{c: c, device: d, sel: sel}, nil
+}
+
+// Describe implements the prometheus.Collector interface.
+func (p *probedCollector) Describe(ch chan<- *prometheus.Desc) {
+	p.c.Describe(ch)
+}
+
+// Collect implements the prometheus.Collector interface.
+func (p *probedCollector) Collect(ch chan<- prometheus.Metric) {
+	s := p.c.startTrace("probe")
+	defer p.c.finishTrace(s)
+
+	p.c.collectForDevice(p.device, ch, p.sel, s)
+}
@@ -0,0 +1,23 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"mikrotik-exporter/config"
+)
+
+func TestProbe(t *testing.T) {
+	c := &collector{poller: newBackgroundPoller(0)}
+	c.add("interface", newInterfaceCollector())
+	c.add("dhcp", newDHCPCollector())
+
+	pc, err := c.Probe(config.Device{Name: "10.0.0.1", Address: "10.0.0.1"}, []string{"dhcp"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.1", pc.(*probedCollector).device.Address)
+	assert.Len(t, pc.(*probedCollector).sel.collectors, 1)
+
+	_, err = c.Probe(config.Device{Name: "10.0.0.1"}, []string{"bgp"}, nil)
+	assert.Error(t, err)
+}
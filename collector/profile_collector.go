@@ -0,0 +1,135 @@
+package collector
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mikrotik-exporter/config"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+const (
+	defaultProfileDuration = 2 * time.Second
+	defaultProfileInterval = time.Minute
+)
+
+// profileSample is the CPU usage by process of a single profile run on a
+// device.
+type profileSample struct {
+	taken time.Time
+	usage map[string]float64
+}
+
+// profileCollector runs /tool profile for a short window and exports the CPU
+// usage by process, e.g. networking, firewall or management.
+type profileCollector struct {
+	cfg         config.ProfileConfig
+	usageDesc   *prometheus.Desc
+	sampledDesc *prometheus.Desc
+
+	mu      sync.Mutex
+	samples map[string]*profileSample
+}
+
+func newProfileCollector(cfg config.ProfileConfig) routerOSCollector {
+	if cfg.Duration == 0 {
+		cfg.Duration = defaultProfileDuration
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = defaultProfileInterval
+	}
+
+	const prefix = "profile"
+
+	return &profileCollector{
+		cfg:         cfg,
+		usageDesc:   description(prefix, "cpu_usage_ratio", "share of the CPU used by the process, averaged over the profile window", []string{"name", "address", "process"}),
+		sampledDesc: description(prefix, "last_sample_timestamp_seconds", "time of the last profile sample", []string{"name", "address"}),
+		samples:     make(map[string]*profileSample),
+	}
+}
+
+func (c *profileCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.usageDesc
+	ch <- c.sampledDesc
+}
+
+func (c *profileCollector) collect(ctx *collectorContext) error {
+	s, err := c.sample(ctx)
+	if err != nil {
+		return err
+	}
+
+	for process, v := range s.usage {
+		ctx.ch <- prometheus.MustNewConstMetric(c.usageDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, process)
+	}
+	ctx.ch <- prometheus.MustNewConstMetric(c.sampledDesc, prometheus.GaugeValue, float64(s.taken.Unix()), ctx.device.Name, ctx.device.Address)
+
+	return nil
+}
+
+// sample returns the cached sample for the device, running the profile only
+// if the previous sample is older than the configured interval.
+func (c *profileCollector) sample(ctx *collectorContext) (*profileSample, error) {
+	c.mu.Lock()
+	s, ok := c.samples[ctx.device.Name]
+	c.mu.Unlock()
+	if ok && time.Since(s.taken) < c.cfg.Interval {
+		return s, nil
+	}
+
+	reply, err := ctx.client.Run("/tool/profile", fmt.Sprintf("=duration=%ds", int(c.cfg.Duration.Seconds())))
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching profile metrics")
+		return nil, err
+	}
+
+	usage, err := profileUsage(reply.Re)
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error parsing profile metric value")
+		return nil, err
+	}
+	s = &profileSample{taken: time.Now(), usage: usage}
+
+	c.mu.Lock()
+	c.samples[ctx.device.Name] = s
+	c.mu.Unlock()
+
+	return s, nil
+}
+
+// profileUsage averages the usage of each process like 12.5 (percent) over
+// the sections the profile reports every second.
+func profileUsage(entries []*proto.Sentence) (map[string]float64, error) {
+	usage := make(map[string]float64)
+	sections := make(map[string]bool)
+	for _, re := range entries {
+		process := re.Map["name"]
+		if process == "" || process == "total" {
+			continue
+		}
+
+		v, err := strconv.ParseFloat(strings.TrimSuffix(re.Map["usage"], "%"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid usage %q of process %s", re.Map["usage"], process)
+		}
+		usage[process] += v / 100
+		sections[re.Map[".section"]] = true
+	}
+
+	for process := range usage {
+		usage[process] /= float64(len(sections))
+	}
+
+	return usage, nil
+}
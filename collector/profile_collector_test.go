@@ -0,0 +1,26 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/routeros.v2/proto"
+)
+
+func TestProfileUsage(t *testing.T) {
+	row := func(section, name, usage string) *proto.Sentence {
+		return &proto.Sentence{Map: map[string]string{".section": section, "name": name, "usage": usage}}
+	}
+
+	usage, err := profileUsage([]*proto.Sentence{
+		row("0", "networking", "10"),
+		row("0", "firewall", "4%"),
+		row("0", "total", "14"),
+		row("1", "networking", "30"),
+	})
+	assert.NoError(t, err)
+	assert.InDeltaMapValues(t, map[string]float64{"networking": 0.2, "firewall": 0.02}, usage, 1e-9)
+
+	_, err = profileUsage([]*proto.Sentence{row("0", "networking", "high")})
+	assert.Error(t, err)
+}
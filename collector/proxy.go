@@ -0,0 +1,196 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"mikrotik-exporter/config"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"golang.org/x/net/proxy"
+)
+
+// dial connects to the address of the device, through its SOCKS5 proxy or
+// SSH jump host if it has one.
+func (c *collector) dial(d *config.Device, address string, timeout time.Duration) (net.Conn, error) {
+	p := c.proxy
+	if d.Proxy != (config.Proxy{}) {
+		p = d.Proxy
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	if ip := c.sourceIP(d); ip != nil {
+		dialer.LocalAddr = &net.TCPAddr{IP: ip}
+	}
+
+	switch {
+	case p.SOCKS5 != "":
+		var auth *proxy.Auth
+		if p.User != "" {
+			auth = &proxy.Auth{User: p.User, Password: p.Password}
+		}
+		socks, err := proxy.SOCKS5("tcp", p.SOCKS5, auth, dialer)
+		if err != nil {
+			return nil, err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		return socks.(proxy.ContextDialer).DialContext(ctx, "tcp", address)
+	case p.SSH != "":
+		cl, err := c.jumpHosts.client(p, dialer)
+		if err != nil {
+			return nil, err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		conn, err := cl.DialContext(ctx, "tcp", address)
+		if err != nil {
+			// reconnect with the next dial if the connection to the jump
+			// host broke rather than the device being unreachable
+			if !jumpHostAlive(cl, timeout) {
+				c.jumpHosts.discard(cl)
+			}
+			return nil, err
+		}
+
+		return conn, nil
+	default:
+		return dialer.Dial("tcp", address)
+	}
+}
+
+// jumpHostAlive checks whether the jump host answers a keepalive within the
+// timeout.
+func jumpHostAlive(cl *ssh.Client, timeout time.Duration) bool {
+	alive := make(chan bool, 1)
+	go func() {
+		_, _, err := cl.SendRequest("keepalive@openssh.com", true, nil)
+		alive <- err == nil
+	}()
+
+	select {
+	case ok := <-alive:
+		return ok
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// sourceIP returns the address connections to the device originate from, or
+// nil to leave it to the system.
+func (c *collector) sourceIP(d *config.Device) net.IP {
+	address := d.SourceAddress
+	if address == "" {
+		address = c.sourceAddress
+	}
+
+	return net.ParseIP(address)
+}
+
+// jumpHosts holds the connections to the SSH jump hosts, which are shared by
+// the connections to all devices behind them from the same source address.
+type jumpHosts struct {
+	mu      sync.Mutex
+	clients map[jumpHost]*ssh.Client
+}
+
+type jumpHost struct {
+	proxy  config.Proxy
+	source string
+}
+
+func newJumpHosts() *jumpHosts {
+	return &jumpHosts{
+		clients: make(map[jumpHost]*ssh.Client),
+	}
+}
+
+func (j *jumpHosts) client(p config.Proxy, dialer *net.Dialer) (*ssh.Client, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	host := jumpHost{proxy: p}
+	if dialer.LocalAddr != nil {
+		host.source = dialer.LocalAddr.String()
+	}
+	if cl, ok := j.clients[host]; ok {
+		return cl, nil
+	}
+
+	key, err := os.ReadFile(p.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	hostKeyCallback := ssh.InsecureIgnoreHostKey()
+	if p.KnownHosts != "" {
+		hostKeyCallback, err = knownhosts.New(p.KnownHosts)
+		if err != nil {
+			return nil, err
+		}
+	} else if !p.Insecure {
+		return nil, errors.New("missing known_hosts to verify the host key of the jump host")
+	}
+
+	conn, err := dialer.Dial("tcp", p.SSH)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = conn.SetDeadline(time.Now().Add(dialer.Timeout))
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, p.SSH, &ssh.ClientConfig{
+		User:            p.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Time{})
+	cl := ssh.NewClient(sshConn, chans, reqs)
+
+	log.WithFields(log.Fields{
+		"jump_host": p.SSH,
+	}).Debug("connected to jump host")
+	j.clients[host] = cl
+
+	return cl, nil
+}
+
+// discard closes the connection to the jump host unless it was replaced in
+// the meantime.
+func (j *jumpHosts) discard(cl *ssh.Client) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for host, c := range j.clients {
+		if c == cl {
+			delete(j.clients, host)
+			cl.Close()
+		}
+	}
+}
+
+func (j *jumpHosts) closeAll() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for host, cl := range j.clients {
+		cl.Close()
+		delete(j.clients, host)
+	}
+}
@@ -0,0 +1,123 @@
+package collector
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"mikrotik-exporter/config"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestDialThroughSOCKS5(t *testing.T) {
+	target := listen(t, func(conn net.Conn) {
+		conn.Write([]byte("hello"))
+	})
+
+	requested := make(chan string, 1)
+	socks := listen(t, func(conn net.Conn) {
+		// greeting, accept without authentication
+		buf := make([]byte, 262)
+		io.ReadFull(conn, buf[:2])
+		io.ReadFull(conn, buf[:buf[1]])
+		conn.Write([]byte{5, 0})
+
+		// connect request for an IPv4 address
+		io.ReadFull(conn, buf[:10])
+		address := net.JoinHostPort(net.IP(buf[4:8]).String(), strconv.Itoa(int(binary.BigEndian.Uint16(buf[8:10]))))
+		requested <- address
+		conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+
+		up, err := net.Dial("tcp", address)
+		if err != nil {
+			return
+		}
+		defer up.Close()
+		io.Copy(conn, up)
+	})
+
+	c := &collector{jumpHosts: newJumpHosts()}
+	d := &config.Device{Proxy: config.Proxy{SOCKS5: socks}}
+
+	conn, err := c.dial(d, target, time.Second)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	b, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+	assert.Equal(t, target, <-requested)
+}
+
+func TestDialThroughStalledJumpHost(t *testing.T) {
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	hostSigner, err := ssh.NewSignerFromKey(hostKey)
+	assert.NoError(t, err)
+	server := &ssh.ServerConfig{
+		PublicKeyCallback: func(ssh.ConnMetadata, ssh.PublicKey) (*ssh.Permissions, error) {
+			return nil, nil
+		},
+	}
+	server.AddHostKey(hostSigner)
+
+	jumpHost := listen(t, func(conn net.Conn) {
+		_, chans, reqs, err := ssh.NewServerConn(conn, server)
+		if err != nil {
+			return
+		}
+		go ssh.DiscardRequests(reqs)
+		// the forwarded connections are never answered
+		for range chans {
+		}
+	})
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	block, err := ssh.MarshalPrivateKey(key, "")
+	assert.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "id_ed25519")
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(block), 0o600))
+
+	c := &collector{jumpHosts: newJumpHosts()}
+	defer c.jumpHosts.closeAll()
+	d := &config.Device{Proxy: config.Proxy{SSH: jumpHost, User: "exporter", KeyFile: keyFile, Insecure: true}}
+
+	begin := time.Now()
+	_, err = c.dial(d, "192.0.2.1:8728", 100*time.Millisecond)
+	assert.Error(t, err)
+	assert.Less(t, time.Since(begin), time.Second)
+}
+
+// listen serves each connection to a local listener with handle and returns
+// its address.
+func listen(t *testing.T, handle func(conn net.Conn)) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+
+	return l.Addr().String()
+}
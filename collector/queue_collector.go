@@ -0,0 +1,122 @@
+package collector
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+type queueCollector struct {
+	props        []string
+	descriptions map[string]*prometheus.Desc
+}
+
+func newQueueCollector() routerOSCollector {
+	c := &queueCollector{}
+	c.init()
+	return c
+}
+
+func (c *queueCollector) init() {
+	c.props = []string{"name", "target", "comment", "bytes", "packets", "dropped", "queued-bytes", "queued-packets", "rate", "packet-rate"}
+
+	const prefix = "simple_queue"
+	labelNames := []string{"name", "address", "queue", "target", "comment", "direction"}
+	c.descriptions = map[string]*prometheus.Desc{
+		"bytes":          description(prefix, "bytes", "number of bytes passed through the queue", labelNames),
+		"packets":        description(prefix, "packets", "number of packets passed through the queue", labelNames),
+		"dropped":        description(prefix, "dropped", "number of packets dropped by the queue", labelNames),
+		"queued-bytes":   description(prefix, "queued_bytes", "number of bytes waiting in the queue", labelNames),
+		"queued-packets": description(prefix, "queued_packets", "number of packets waiting in the queue", labelNames),
+		"rate":           description(prefix, "rate_bits_per_second", "average rate of the queue", labelNames),
+		"packet-rate":    description(prefix, "rate_packets_per_second", "average packet rate of the queue", labelNames),
+	}
+}
+
+func (c *queueCollector) describe(ch chan<- *prometheus.Desc) {
+	for _, d := range c.descriptions {
+		ch <- d
+	}
+}
+
+func (c *queueCollector) collect(ctx *collectorContext) error {
+	stats, err := c.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, re := range stats {
+		c.collectForStat(re, ctx)
+	}
+
+	return nil
+}
+
+func (c *queueCollector) fetch(ctx *collectorContext) ([]*proto.Sentence, error) {
+	reply, err := ctx.client.Run("/queue/simple/print", "?disabled=false", "=.proplist="+strings.Join(c.props, ","))
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching simple queue metrics")
+		return nil, err
+	}
+
+	return reply.Re, nil
+}
+
+func (c *queueCollector) collectForStat(re *proto.Sentence, ctx *collectorContext) {
+	for _, p := range c.props[3:] {
+		c.collectMetricForProperty(p, re, ctx)
+	}
+}
+
+func (c *queueCollector) collectMetricForProperty(property string, re *proto.Sentence, ctx *collectorContext) {
+	value := re.Map[property]
+	if value == "" {
+		return
+	}
+
+	up, down, err := splitUploadDownload(value)
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"queue":    re.Map["name"],
+			"property": property,
+			"value":    value,
+			"error":    err,
+		}).Error("error parsing simple queue metric value")
+		return
+	}
+
+	vtype := prometheus.GaugeValue
+	switch property {
+	case "bytes", "packets", "dropped":
+		vtype = prometheus.CounterValue
+	}
+
+	desc := c.descriptions[property]
+	ctx.ch <- prometheus.MustNewConstMetric(desc, vtype, up, ctx.device.Name, ctx.device.Address, re.Map["name"], re.Map["target"], re.Map["comment"], "upload")
+	ctx.ch <- prometheus.MustNewConstMetric(desc, vtype, down, ctx.device.Name, ctx.device.Address, re.Map["name"], re.Map["target"], re.Map["comment"], "download")
+}
+
+// splitUploadDownload splits queue values of the form "upload/download".
+func splitUploadDownload(value string) (float64, float64, error) {
+	u, d, ok := strings.Cut(value, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("expected upload/download value")
+	}
+
+	up, err := strconv.ParseFloat(u, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	down, err := strconv.ParseFloat(d, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return up, down, nil
+}
@@ -0,0 +1,20 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitUploadDownload(t *testing.T) {
+	up, down, err := splitUploadDownload("1024/4096")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1024), up)
+	assert.Equal(t, float64(4096), down)
+
+	_, _, err = splitUploadDownload("1024")
+	assert.Error(t, err)
+
+	_, _, err = splitUploadDownload("1024/x")
+	assert.Error(t, err)
+}
@@ -0,0 +1,97 @@
+package collector
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+type radiusCollector struct {
+	props        []string
+	descriptions map[string]*prometheus.Desc
+	rttDesc      *prometheus.Desc
+}
+
+func newRADIUSCollector() routerOSCollector {
+	c := &radiusCollector{}
+	c.init()
+	return c
+}
+
+func (c *radiusCollector) init() {
+	c.props = []string{"requests", "accepts", "rejects", "resends", "timeouts", "bad-replies", "pending"}
+
+	const prefix = "radius"
+	labelNames := []string{"name", "address", "server", "service"}
+	c.descriptions = make(map[string]*prometheus.Desc)
+	for _, p := range c.props {
+		c.descriptions[p] = descriptionForPropertyName(prefix, p, labelNames)
+	}
+	c.rttDesc = description(prefix, "last_request_rtt_seconds", "round-trip time of the last request to the server", labelNames)
+}
+
+func (c *radiusCollector) describe(ch chan<- *prometheus.Desc) {
+	for _, d := range c.descriptions {
+		ch <- d
+	}
+	ch <- c.rttDesc
+}
+
+func (c *radiusCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/radius/print", "?disabled=false", "=.proplist=.id,address,service")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching radius servers")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		err := c.collectForServer(re, ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *radiusCollector) collectForServer(server *proto.Sentence, ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/radius/monitor", "=numbers="+server.Map[".id"], "=once=")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"server": server.Map["address"],
+			"error":  err,
+		}).Error("error fetching radius monitor metrics")
+		return err
+	}
+	if len(reply.Re) == 0 {
+		return nil
+	}
+
+	re := reply.Re[0]
+	labelValues := []string{ctx.device.Name, ctx.device.Address, server.Map["address"], server.Map["service"]}
+
+	for _, p := range c.props {
+		v, err := strconv.ParseFloat(re.Map[p], 64)
+		if err != nil {
+			continue
+		}
+
+		vtype := prometheus.CounterValue
+		if p == "pending" {
+			vtype = prometheus.GaugeValue
+		}
+		ctx.ch <- prometheus.MustNewConstMetric(c.descriptions[p], vtype, v, labelValues...)
+	}
+
+	// the round-trip time is reported with a unit, e.g. "12ms"
+	if rtt, err := time.ParseDuration(re.Map["last-request-rtt"]); err == nil {
+		ctx.ch <- prometheus.MustNewConstMetric(c.rttDesc, prometheus.GaugeValue, rtt.Seconds(), labelValues...)
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package collector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	rateLimitedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "api", "rate_limited_commands_total"),
+		"mikrotik_exporter: number of API commands delayed by the rate limit",
+		[]string{"device"},
+		nil,
+	)
+	rateLimitWaitDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "api", "rate_limit_wait_seconds_total"),
+		"mikrotik_exporter: time API commands spent waiting for the rate limit",
+		[]string{"device"},
+		nil,
+	)
+)
+
+// rateLimiter is a token bucket limiting the API commands per second sent to
+// a single device. Commands exceeding the limit are queued until a token is
+// available.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	created  time.Time
+	limited  float64
+	waitTime time.Duration
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &rateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		tokens:  float64(burst),
+		last:    time.Now(),
+		created: time.Now(),
+	}
+}
+
+// wait blocks until the command may be sent.
+func (l *rateLimiter) wait() {
+	d := l.reserve(time.Now())
+	if d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// reserve takes a token and returns how long the caller has to wait for it.
+func (l *rateLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+
+	d := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.limited++
+	l.waitTime += d
+
+	return d
+}
+
+func (l *rateLimiter) collect(ch chan<- prometheus.Metric, device string) {
+	l.mu.Lock()
+	limited, waitTime := l.limited, l.waitTime
+	l.mu.Unlock()
+
+	ch <- counterSince(l.created, rateLimitedDesc, limited, device)
+	ch <- counterSince(l.created, rateLimitWaitDesc, waitTime.Seconds(), device)
+}
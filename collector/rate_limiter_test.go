@@ -0,0 +1,26 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	l := newRateLimiter(2, 2)
+	now := l.last
+
+	// burst
+	assert.Equal(t, time.Duration(0), l.reserve(now))
+	assert.Equal(t, time.Duration(0), l.reserve(now))
+	// limit bites, next token in 500ms
+	assert.Equal(t, 500*time.Millisecond, l.reserve(now))
+	// queued behind the previous command
+	assert.Equal(t, time.Second, l.reserve(now))
+	// tokens refilled
+	assert.Equal(t, time.Duration(0), l.reserve(now.Add(2*time.Second)))
+
+	assert.Equal(t, float64(2), l.limited)
+	assert.Equal(t, 1500*time.Millisecond, l.waitTime)
+}
@@ -0,0 +1,169 @@
+package collector
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"mikrotik-exporter/config"
+)
+
+// relabelRule is a compiled relabeling rule.
+type relabelRule struct {
+	sourceLabels []string
+	separator    string
+	regex        *regexp.Regexp
+	targetLabel  string
+	replacement  string
+	action       string
+}
+
+func newRelabelRules(rules []config.RelabelRule) ([]relabelRule, error) {
+	compiled := make([]relabelRule, 0, len(rules))
+	for i, r := range rules {
+		rule := relabelRule{
+			sourceLabels: r.SourceLabels,
+			separator:    ";",
+			targetLabel:  r.TargetLabel,
+			replacement:  "$1",
+			action:       "replace",
+		}
+		if r.Separator != "" {
+			rule.separator = r.Separator
+		}
+		if r.Replacement != nil {
+			rule.replacement = *r.Replacement
+		}
+		if r.Action != "" {
+			rule.action = r.Action
+		}
+
+		regex := "(.*)"
+		if r.Regex != "" {
+			regex = r.Regex
+		}
+		re, err := regexp.Compile("^(?:" + regex + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("relabel rule %d: invalid regex %q: %w", i+1, regex, err)
+		}
+		rule.regex = re
+
+		switch rule.action {
+		case "replace":
+			if rule.targetLabel == "" {
+				return nil, fmt.Errorf("relabel rule %d: replace requires a target label", i+1)
+			}
+		case "keep", "drop", "labeldrop":
+		default:
+			return nil, fmt.Errorf("relabel rule %d: unknown action %q", i+1, rule.action)
+		}
+
+		compiled = append(compiled, rule)
+	}
+
+	return compiled, nil
+}
+
+// relabel applies the rules to the labels of the metric with the given name,
+// returning false if it is dropped. Labels set to an empty value are removed.
+func relabel(rules []relabelRule, name string, labels map[string]string) bool {
+	for _, r := range rules {
+		values := make([]string, len(r.sourceLabels))
+		for i, l := range r.sourceLabels {
+			if l == "__name__" {
+				values[i] = name
+				continue
+			}
+			values[i] = labels[l]
+		}
+		value := strings.Join(values, r.separator)
+
+		switch r.action {
+		case "replace":
+			match := r.regex.FindStringSubmatchIndex(value)
+			if match == nil {
+				continue
+			}
+			labels[r.targetLabel] = string(r.regex.ExpandString(nil, r.replacement, value, match))
+		case "keep":
+			if !r.regex.MatchString(value) {
+				return false
+			}
+		case "drop":
+			if r.regex.MatchString(value) {
+				return false
+			}
+		case "labeldrop":
+			for l := range labels {
+				if r.regex.MatchString(l) {
+					delete(labels, l)
+				}
+			}
+		}
+	}
+
+	return true
+}
+
+// relabeledMetric is a metric with rewritten labels.
+type relabeledMetric struct {
+	prometheus.Metric
+	labels []*dto.LabelPair
+}
+
+func (m *relabeledMetric) Write(out *dto.Metric) error {
+	if err := m.Metric.Write(out); err != nil {
+		return err
+	}
+	out.Label = m.labels
+
+	return nil
+}
+
+// withRelabeling returns a channel relabeling the metrics sent to ch and a
+// function to call once all metrics have been sent.
+func withRelabeling(ch chan<- prometheus.Metric, rules []relabelRule) (chan<- prometheus.Metric, func()) {
+	relabeled := make(chan prometheus.Metric)
+	done := make(chan struct{})
+
+	go func() {
+		for m := range relabeled {
+			var out dto.Metric
+			if err := m.Write(&out); err != nil {
+				ch <- m
+				continue
+			}
+
+			labels := make(map[string]string, len(out.Label))
+			for _, l := range out.Label {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if !relabel(rules, metricName(m.Desc()), labels) {
+				continue
+			}
+
+			pairs := make([]*dto.LabelPair, 0, len(labels))
+			for name, value := range labels {
+				if value == "" {
+					continue
+				}
+				pairs = append(pairs, &dto.LabelPair{Name: &name, Value: &value})
+			}
+			sort.Slice(pairs, func(i, j int) bool {
+				return pairs[i].GetName() < pairs[j].GetName()
+			})
+
+			ch <- &relabeledMetric{Metric: m, labels: pairs}
+		}
+		close(done)
+	}()
+
+	return relabeled, func() {
+		close(relabeled)
+		<-done
+	}
+}
@@ -0,0 +1,58 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+
+	"mikrotik-exporter/config"
+)
+
+func TestRelabeling(t *testing.T) {
+	wan := "wan"
+	rules, err := newRelabelRules([]config.RelabelRule{
+		{SourceLabels: []string{"name", "interface"}, Regex: "r1;ether1", TargetLabel: "interface", Replacement: &wan},
+		{SourceLabels: []string{"__name__", "interface"}, Regex: "mikrotik_interface_rx_drop;.*", Action: "drop"},
+		{Regex: "comment", Action: "labeldrop"},
+	})
+	assert.NoError(t, err)
+
+	labels := []string{"name", "interface", "comment"}
+	rx := prometheus.NewDesc("mikrotik_interface_rx_byte", "test", labels, nil)
+	drop := prometheus.NewDesc("mikrotik_interface_rx_drop", "test", labels, nil)
+
+	metrics, err := collectBuffered(func(ch chan<- prometheus.Metric) error {
+		ch, flush := withRelabeling(ch, rules)
+		defer flush()
+
+		ch <- prometheus.MustNewConstMetric(rx, prometheus.CounterValue, 1, "r1", "ether1", "uplink")
+		ch <- prometheus.MustNewConstMetric(rx, prometheus.CounterValue, 1, "r2", "ether1", "")
+		ch <- prometheus.MustNewConstMetric(drop, prometheus.CounterValue, 1, "r1", "ether1", "")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 2)
+
+	var out dto.Metric
+	assert.NoError(t, metrics[0].Write(&out))
+	assert.Equal(t, map[string]string{"interface": "wan", "name": "r1"}, labelMap(out.Label))
+	assert.Equal(t, 1.0, out.GetCounter().GetValue())
+
+	out = dto.Metric{}
+	assert.NoError(t, metrics[1].Write(&out))
+	assert.Equal(t, map[string]string{"interface": "ether1", "name": "r2"}, labelMap(out.Label))
+
+	_, err = newRelabelRules([]config.RelabelRule{{Action: "replace"}})
+	assert.Error(t, err, "replace requires a target label")
+}
+
+func labelMap(pairs []*dto.LabelPair) map[string]string {
+	labels := make(map[string]string, len(pairs))
+	for _, l := range pairs {
+		labels[l.GetName()] = l.GetValue()
+	}
+
+	return labels
+}
@@ -5,6 +5,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -20,13 +21,30 @@ func init() {
 	uptimeParts = [5]time.Duration{time.Hour * 168, time.Hour * 24, time.Hour, time.Minute, time.Second}
 }
 
+// routerboardTTL is the time after which the routerboard of a device is
+// fetched again.
+const routerboardTTL = time.Hour
+
 type resourceCollector struct {
 	props        []string
 	descriptions map[string]*prometheus.Desc
+	infoDesc     *prometheus.Desc
+
+	routerboardsMu sync.Mutex
+	routerboards   map[string]routerboard
+}
+
+// routerboard is the hardware of a device, which is empty for CHR and x86
+// installations.
+type routerboard struct {
+	model        string
+	serialNumber string
+	firmwareType string
+	fetched      time.Time
 }
 
 func newResourceCollector() routerOSCollector {
-	c := &resourceCollector{}
+	c := &resourceCollector{routerboards: make(map[string]routerboard)}
 	c.init()
 	return c
 }
@@ -39,12 +57,17 @@ func (c *resourceCollector) init() {
 	for _, p := range c.props {
 		c.descriptions[p] = descriptionForPropertyName("system", p, labelNames)
 	}
+	c.props = append(c.props, "architecture-name")
+
+	c.infoDesc = description("device", "info", "hardware and RouterOS version of the device",
+		[]string{"name", "address", "board_name", "model", "serial_number", "ros_version", "firmware_type", "architecture"})
 }
 
 func (c *resourceCollector) describe(ch chan<- *prometheus.Desc) {
 	for _, d := range c.descriptions {
 		ch <- d
 	}
+	ch <- c.infoDesc
 }
 
 func (c *resourceCollector) collect(ctx *collectorContext) error {
@@ -55,17 +78,54 @@ func (c *resourceCollector) collect(ctx *collectorContext) error {
 
 	for _, re := range stats {
 		c.collectForStat(re, ctx)
+		c.collectInfo(re, ctx)
 	}
 
 	return nil
 }
 
+func (c *resourceCollector) collectInfo(re *proto.Sentence, ctx *collectorContext) {
+	rb := c.routerboard(ctx)
+	ctx.ch <- prometheus.MustNewConstMetric(c.infoDesc, prometheus.GaugeValue, 1, ctx.device.Name, ctx.device.Address,
+		re.Map["board-name"], rb.model, rb.serialNumber, re.Map["version"], rb.firmwareType, re.Map["architecture-name"])
+}
+
+// routerboard returns the routerboard of the device, which is fetched at
+// most once per routerboardTTL.
+func (c *resourceCollector) routerboard(ctx *collectorContext) routerboard {
+	c.routerboardsMu.Lock()
+	rb, ok := c.routerboards[ctx.device.Name]
+	c.routerboardsMu.Unlock()
+	if ok && time.Since(rb.fetched) < routerboardTTL {
+		return rb
+	}
+
+	reply, err := ctx.client.Run("/system/routerboard/print", "=.proplist=model,serial-number,firmware-type")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Debug("error fetching routerboard")
+		return rb
+	}
+	rb = routerboard{fetched: time.Now()}
+	for _, re := range reply.Re {
+		rb.model = re.Map["model"]
+		rb.serialNumber = re.Map["serial-number"]
+		rb.firmwareType = re.Map["firmware-type"]
+	}
+
+	c.routerboardsMu.Lock()
+	c.routerboards[ctx.device.Name] = rb
+	c.routerboardsMu.Unlock()
+
+	return rb
+}
+
 func (c *resourceCollector) fetch(ctx *collectorContext) ([]*proto.Sentence, error) {
 	reply, err := ctx.client.Run("/system/resource/print", "=.proplist="+strings.Join(c.props, ","))
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device": ctx.device.Name,
-			"error":  err,
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
 		}).Error("error fetching system resource metrics")
 		return nil, err
 	}
@@ -101,8 +161,7 @@ func (c *resourceCollector) collectMetricForProperty(property string, re *proto.
 	}
 
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device":   ctx.device.Name,
+		ctx.logger().WithFields(log.Fields{
 			"property": property,
 			"value":    re.Map[property],
 			"error":    err,
@@ -111,6 +170,10 @@ func (c *resourceCollector) collectMetricForProperty(property string, re *proto.
 	}
 
 	desc := c.descriptions[property]
+	if vtype == prometheus.CounterValue {
+		ctx.ch <- ctx.counter(desc, v, ctx.device.Name, ctx.device.Address, boardname, version)
+		return
+	}
 	ctx.ch <- prometheus.MustNewConstMetric(desc, vtype, v, ctx.device.Name, ctx.device.Address, boardname, version)
 }
 
@@ -0,0 +1,52 @@
+package collector
+
+import (
+	"time"
+)
+
+const (
+	// DefaultRetryAttempts is the default number of reconnects per scrape
+	DefaultRetryAttempts = 1
+	// DefaultRetryBackoff is the default wait before the first reconnect
+	DefaultRetryBackoff = 100 * time.Millisecond
+
+	// maxRetryBackoff limits the wait between reconnects
+	maxRetryBackoff = 10 * time.Second
+)
+
+// retryPolicy decides how often connecting to a device and the commands
+// failing due to a broken connection are retried during a scrape, waiting
+// twice as long before each further attempt.
+type retryPolicy struct {
+	attempts int
+	backoff  time.Duration
+}
+
+// delay returns the wait before the retry, starting at 1.
+func (p retryPolicy) delay(retry int) time.Duration {
+	d := p.backoff
+	for i := 1; i < retry && d < maxRetryBackoff; i++ {
+		d *= 2
+	}
+
+	return min(d, maxRetryBackoff)
+}
+
+// wait waits before the retry. It returns false without waiting if the retry
+// would not start before the deadline, and early once done is closed.
+func (p retryPolicy) wait(retry int, deadline time.Time, done <-chan struct{}) bool {
+	d := p.delay(retry)
+	if !deadline.IsZero() && time.Now().Add(d).After(deadline) {
+		return false
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-done:
+		return false
+	}
+}
@@ -0,0 +1,27 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryDelay(t *testing.T) {
+	p := retryPolicy{attempts: 8, backoff: 100 * time.Millisecond}
+
+	assert.Equal(t, 100*time.Millisecond, p.delay(1))
+	assert.Equal(t, 200*time.Millisecond, p.delay(2))
+	assert.Equal(t, 800*time.Millisecond, p.delay(4))
+	assert.Equal(t, maxRetryBackoff, p.delay(8))
+}
+
+func TestRetryWaitHonoursDeadline(t *testing.T) {
+	p := retryPolicy{attempts: 1, backoff: time.Second}
+
+	assert.False(t, p.wait(1, time.Now().Add(100*time.Millisecond), nil))
+
+	done := make(chan struct{})
+	close(done)
+	assert.False(t, p.wait(1, time.Time{}, done))
+}
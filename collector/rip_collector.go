@@ -0,0 +1,77 @@
+package collector
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+type ripCollector struct {
+	neighborsDesc *prometheus.Desc
+	routesDesc    *prometheus.Desc
+}
+
+func newRIPCollector() routerOSCollector {
+	c := &ripCollector{}
+	c.init()
+	return c
+}
+
+func (c *ripCollector) init() {
+	const prefix = "rip"
+	labelNames := []string{"name", "address"}
+	c.neighborsDesc = description(prefix, "neighbors", "number of RIP neighbors", labelNames)
+	c.routesDesc = description(prefix, "routes", "number of routes learned via RIP", append(labelNames, "active"))
+}
+
+func (c *ripCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.neighborsDesc
+	ch <- c.routesDesc
+}
+
+func (c *ripCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/routing/rip/neighbor/print", "=.proplist=address")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching rip neighbors")
+		return err
+	}
+	ctx.ch <- prometheus.MustNewConstMetric(c.neighborsDesc, prometheus.GaugeValue, float64(len(reply.Re)), ctx.device.Name, ctx.device.Address)
+
+	for _, active := range []string{"true", "false"} {
+		err := c.collectRoutes(active, ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *ripCollector) collectRoutes(active string, ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/ip/route/print", "?rip=true", "?active="+active, "=count-only=")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching rip routes")
+		return err
+	}
+
+	v, err := strconv.ParseFloat(reply.Done.Map["ret"], 64)
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"value": reply.Done.Map["ret"],
+			"error": err,
+		}).Error("error parsing rip routes")
+		return nil
+	}
+	ctx.ch <- prometheus.MustNewConstMetric(c.routesDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, active)
+
+	return nil
+}
+
+func (c *ripCollector) requiredMenu() string {
+	return "/routing/rip/neighbor"
+}
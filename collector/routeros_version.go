@@ -0,0 +1,215 @@
+package collector
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/routeros.v2/proto"
+)
+
+// routerOSVersion is the RouterOS release running on a device.
+type routerOSVersion struct {
+	major int
+	minor int
+}
+
+func (v routerOSVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.major, v.minor)
+}
+
+// parseRouterOSVersion parses version strings like "6.49.10 (long-term)" or
+// "7.1beta6".
+func parseRouterOSVersion(s string) (routerOSVersion, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return routerOSVersion{}, fmt.Errorf("empty RouterOS version")
+	}
+
+	parts := strings.SplitN(fields[0], ".", 3)
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return routerOSVersion{}, fmt.Errorf("invalid RouterOS version %q: %w", s, err)
+	}
+
+	v := routerOSVersion{major: major}
+	if len(parts) > 1 {
+		minor := parts[1]
+		if i := strings.IndexFunc(minor, func(r rune) bool { return r < '0' || r > '9' }); i > -1 {
+			minor = minor[:i]
+		}
+		v.minor, _ = strconv.Atoi(minor)
+	}
+
+	return v, nil
+}
+
+// commandMapping describes how a RouterOS 6 command is issued on RouterOS 7.
+// Collectors are written against the RouterOS 6 paths and field names.
+type commandMapping struct {
+	path string
+	// fields maps RouterOS 6 attribute names to their RouterOS 7 names
+	fields map[string]string
+	// fixup converts the values of a translated reply sentence
+	fixup func(re *proto.Sentence)
+	// query is appended to the translated command, e.g. to select the
+	// entries of a RouterOS 6 menu merged into another menu
+	query []string
+}
+
+var v7Commands = map[string]commandMapping{
+	"/routing/bgp/peer/print": {
+		path: "/routing/bgp/session/print",
+		fields: map[string]string{
+			"remote-as":         "remote.as",
+			"state":             "established",
+			"messages-sent":     "local.messages",
+			"messages-received": "remote.messages",
+			"address-families":  "remote.afi",
+		},
+		fixup: func(re *proto.Sentence) {
+			if re.Map["state"] == "true" {
+				re.Map["state"] = "established"
+			} else {
+				re.Map["state"] = "idle"
+			}
+		},
+	},
+	"/ip/route/print": {
+		path: "/ip/route/print",
+		fields: map[string]string{
+			"received-from": "bgp.session",
+		},
+	},
+	"/ipv6/route/print": {
+		path: "/ipv6/route/print",
+		fields: map[string]string{
+			"received-from": "bgp.session",
+		},
+	},
+	"/routing/bgp/vpnv4-route/print": {
+		path: "/routing/route/print",
+		fields: map[string]string{
+			"received-from": "bgp.session",
+		},
+		query: []string{"?afi=vpn4"},
+	},
+	"/routing/ospf/neighbor/print": {
+		path: "/routing/ospf/neighbor/print",
+		fields: map[string]string{
+			"dead-time": "timeout",
+		},
+	},
+	"/mpls/local-bindings/print": {
+		path: "/mpls/ldp/local-mapping/print",
+	},
+	"/mpls/remote-bindings/print": {
+		path: "/mpls/ldp/remote-mapping/print",
+	},
+	"/interface/bonding/monitor": {
+		path: "/interface/bonding/monitor",
+		fields: map[string]string{
+			"active-slaves":   "active-ports",
+			"inactive-slaves": "inactive-ports",
+		},
+	},
+	"/interface/lte/info": {
+		path: "/interface/lte/monitor",
+		fields: map[string]string{
+			"number": "numbers",
+		},
+	},
+}
+
+// wifiPackages maps the packages replacing the legacy wireless package on
+// RouterOS 7 to their menu.
+var wifiPackages = map[string]string{
+	"wifiwave2":     "/interface/wifiwave2",
+	"wifi-qcom":     "/interface/wifi",
+	"wifi-qcom-ac":  "/interface/wifi",
+	"wifi-mediatek": "/interface/wifi",
+}
+
+// wifiCommands maps the legacy wireless and CAPsMAN commands to their
+// counterparts relative to the menu of the installed wifi package.
+var wifiCommands = map[string]commandMapping{
+	"/interface/wireless/print": {
+		path: "/print",
+	},
+	"/interface/wireless/monitor": {
+		path: "/monitor",
+		fields: map[string]string{
+			"registered-clients": "registered-peers",
+		},
+	},
+	"/interface/wireless/registration-table/print": {
+		path: "/registration-table/print",
+		fields: map[string]string{
+			"signal-strength": "signal",
+		},
+	},
+	"/caps-man/registration-table/print": {
+		path: "/registration-table/print",
+		fields: map[string]string{
+			"rx-signal": "signal",
+		},
+	},
+	"/caps-man/remote-cap/print": {
+		path: "/capsman/remote-cap/print",
+		fields: map[string]string{
+			"board": "board-name",
+		},
+	},
+}
+
+// translate returns the RouterOS 7 form of a RouterOS 6 command sentence.
+func (m commandMapping) translate(sentence []string) []string {
+	out := make([]string, len(sentence), len(sentence)+len(m.query))
+	out[0] = m.path
+
+	for i, w := range sentence[1:] {
+		out[i+1] = m.translateWord(w)
+	}
+
+	return append(out, m.query...)
+}
+
+func (m commandMapping) translateWord(w string) string {
+	if len(w) < 2 || (w[0] != '=' && w[0] != '?') {
+		return w
+	}
+
+	name, value, hasValue := strings.Cut(w[1:], "=")
+	if name == ".proplist" {
+		props := strings.Split(value, ",")
+		for i, p := range props {
+			if n, ok := m.fields[p]; ok {
+				props[i] = n
+			}
+		}
+		return w[:1] + name + "=" + strings.Join(props, ",")
+	}
+
+	if n, ok := m.fields[name]; ok {
+		name = n
+	}
+	if !hasValue {
+		return w[:1] + name
+	}
+
+	return w[:1] + name + "=" + value
+}
+
+// translateReply renames the RouterOS 7 attributes of a reply back to their
+// RouterOS 6 names.
+func (m commandMapping) translateReply(re *proto.Sentence) {
+	for v6, v7 := range m.fields {
+		if v, ok := re.Map[v7]; ok {
+			re.Map[v6] = v
+		}
+	}
+
+	if m.fixup != nil {
+		m.fixup(re)
+	}
+}
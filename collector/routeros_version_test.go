@@ -0,0 +1,109 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/routeros.v2/proto"
+
+	"mikrotik-exporter/config"
+)
+
+func TestParseRouterOSVersion(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected routerOSVersion
+		hasError bool
+	}{
+		{"6.49.10 (long-term)", routerOSVersion{6, 49}, false},
+		{"7.14.3 (stable)", routerOSVersion{7, 14}, false},
+		{"7.1beta6", routerOSVersion{7, 1}, false},
+		{"7", routerOSVersion{7, 0}, false},
+		{"", routerOSVersion{}, true},
+		{"foo", routerOSVersion{}, true},
+	}
+
+	for _, testCase := range testCases {
+		v, err := parseRouterOSVersion(testCase.input)
+		if testCase.hasError {
+			assert.Error(t, err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, testCase.expected, v)
+	}
+}
+
+func TestCommandMappingTranslate(t *testing.T) {
+	m := v7Commands["/routing/bgp/peer/print"]
+
+	sentence := m.translate([]string{"/routing/bgp/peer/print", "=.proplist=name,remote-as,state", "?remote-as=65000"})
+	assert.Equal(t, []string{"/routing/bgp/session/print", "=.proplist=name,remote.as,established", "?remote.as=65000"}, sentence)
+
+	re := proto.NewSentence()
+	re.Map["name"] = "peer1"
+	re.Map["remote.as"] = "65000"
+	re.Map["established"] = "true"
+	m.translateReply(re)
+
+	assert.Equal(t, "65000", re.Map["remote-as"])
+	assert.Equal(t, "established", re.Map["state"])
+}
+
+func TestRunAdaptsToVersion(t *testing.T) {
+	for release, path := range map[string]string{
+		"6.49.10 (long-term)": "/routing/bgp/peer/print",
+		"7.14.3 (stable)":     "/routing/bgp/session/print",
+	} {
+		cl, commands := fakeDevice(t, func(command []string) [][]string {
+			switch command[0] {
+			case "/system/resource/print":
+				return [][]string{{"!re", "=version=" + release, "=uptime=1d"}, {"!done"}}
+			case "/routing/bgp/peer/print":
+				return [][]string{{"!re", "=name=peer1", "=remote-as=65000", "=state=established"}, {"!done"}}
+			case "/routing/bgp/session/print":
+				return [][]string{{"!re", "=name=peer1", "=remote.as=65000", "=established=true"}, {"!done"}}
+			}
+			return [][]string{{"!done"}}
+		})
+
+		// the version is detected when connecting
+		c := newAPIClient(cl, &config.Device{Name: "r1"}, nil, retryPolicy{}, nil)
+		reply, err := c.Run("/routing/bgp/peer/print", "=.proplist=name,remote-as,state")
+		assert.NoError(t, err, release)
+		assert.Equal(t, release, c.release)
+
+		sent := commands()
+		assert.Equal(t, path, sent[len(sent)-1][0], release)
+		// collectors see the RouterOS 6 attributes on either version
+		if assert.Len(t, reply.Re, 1, release) {
+			assert.Equal(t, "65000", reply.Re[0].Map["remote-as"], release)
+			assert.Equal(t, "established", reply.Re[0].Map["state"], release)
+		}
+	}
+}
+
+func TestCommandMappingQuery(t *testing.T) {
+	m := v7Commands["/routing/bgp/vpnv4-route/print"]
+
+	sentence := m.translate([]string{"/routing/bgp/vpnv4-route/print", "?received-from=peer1", "=count-only="})
+	assert.Equal(t, []string{"/routing/route/print", "?bgp.session=peer1", "=count-only=", "?afi=vpn4"}, sentence)
+}
+
+func TestWifiCommandMapping(t *testing.T) {
+	c := &apiClient{
+		version:          routerOSVersion{7, 13},
+		wirelessTree:     "/interface/wifi",
+		wirelessDetected: true,
+	}
+
+	m, ok := c.mapping("/interface/wireless/registration-table/print")
+	assert.True(t, ok)
+	assert.Equal(t, "/interface/wifi/registration-table/print", m.path)
+	// the shared mapping must not be modified
+	assert.Equal(t, "/registration-table/print", wifiCommands["/interface/wireless/registration-table/print"].path)
+
+	c.wirelessTree = ""
+	_, ok = c.mapping("/interface/wireless/registration-table/print")
+	assert.False(t, ok)
+}
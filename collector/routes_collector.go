@@ -12,6 +12,8 @@ type routesCollector struct {
 	protocols         []string
 	countDesc         *prometheus.Desc
 	countProtocolDesc *prometheus.Desc
+	tableCountDesc    *prometheus.Desc
+	summaryDesc       *prometheus.Desc
 }
 
 func newRoutesCollector() routerOSCollector {
@@ -25,6 +27,8 @@ func (c *routesCollector) init() {
 	labelNames := []string{"name", "address", "ip_version"}
 	c.countDesc = description(prefix, "total_count", "number of routes in RIB", labelNames)
 	c.countProtocolDesc = description(prefix, "protocol_count", "number of routes per protocol in RIB", append(labelNames, "protocol"))
+	c.tableCountDesc = description(prefix, "table_count", "number of routes per routing table in RIB, e.g. of a VRF", append(labelNames, "routing_table"))
+	c.summaryDesc = description(prefix, "summary_count", "number of routes per routing table, protocol and state in RIB", append(labelNames, "table", "protocol", "active"))
 
 	c.protocols = []string{"bgp", "static", "ospf", "dynamic", "connect", "rip"}
 }
@@ -32,15 +36,160 @@ func (c *routesCollector) init() {
 func (c *routesCollector) describe(ch chan<- *prometheus.Desc) {
 	ch <- c.countDesc
 	ch <- c.countProtocolDesc
+	ch <- c.tableCountDesc
+	ch <- c.summaryDesc
 }
 
 func (c *routesCollector) collect(ctx *collectorContext) error {
+	if ctx.device.RoutesSummary {
+		return c.collectSummary(ctx)
+	}
+
 	err := c.colllectForIPVersion("4", "ip", ctx)
 	if err != nil {
 		return err
 	}
 
-	return c.colllectForIPVersion("6", "ip", ctx)
+	err = c.colllectForIPVersion("6", "ipv6", ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.collectTables(ctx)
+}
+
+// collectTables counts the routes per routing table of RouterOS 7, whose VRFs
+// each have a table named after them.
+func (c *routesCollector) collectTables(ctx *collectorContext) error {
+	if ctx.client.version.major < 7 {
+		return nil
+	}
+
+	tables, err := c.fetchTables(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range []struct{ ipVersion, topic string }{{"4", "ip"}, {"6", "ipv6"}} {
+		for _, table := range tables {
+			err := c.collectTableCount(v.ipVersion, v.topic, table, ctx)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *routesCollector) collectTableCount(ipVersion, topic, table string, ctx *collectorContext) error {
+	reply, err := ctx.client.Run(fmt.Sprintf("/%s/route/print", topic), "?disabled=false", "?routing-table="+table, "=count-only=")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"ip_version": ipVersion,
+			"table":      table,
+			"error":      err,
+		}).Error("error fetching routes metrics")
+		return err
+	}
+	if reply.Done.Map["ret"] == "" {
+		return nil
+	}
+	v, err := strconv.ParseFloat(reply.Done.Map["ret"], 64)
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"ip_version": ipVersion,
+			"table":      table,
+			"error":      err,
+		}).Error("error parsing routes metrics")
+		return err
+	}
+
+	ctx.ch <- prometheus.MustNewConstMetric(c.tableCountDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, ipVersion, table)
+	return nil
+}
+
+// collectSummary counts the routes per routing table, protocol and state.
+// Routing tables are only listed on RouterOS 7, on RouterOS 6 all routes are
+// counted as part of the main table.
+func (c *routesCollector) collectSummary(ctx *collectorContext) error {
+	tables, err := c.fetchTables(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range []struct{ ipVersion, topic string }{{"4", "ip"}, {"6", "ipv6"}} {
+		for _, table := range tables {
+			for _, p := range c.protocols {
+				for _, active := range []string{"true", "false"} {
+					err := c.collectSummaryCount(v.ipVersion, v.topic, table, p, active, ctx)
+					if err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *routesCollector) fetchTables(ctx *collectorContext) ([]string, error) {
+	if ctx.client.version.major < 7 {
+		return []string{""}, nil
+	}
+
+	reply, err := ctx.client.Run("/routing/table/print", "?disabled=false", "=.proplist=name")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching routing tables")
+		return nil, err
+	}
+
+	tables := []string{}
+	for _, re := range reply.Re {
+		tables = append(tables, re.Map["name"])
+	}
+
+	return tables, nil
+}
+
+func (c *routesCollector) collectSummaryCount(ipVersion, topic, table, protocol, active string, ctx *collectorContext) error {
+	sentence := []string{fmt.Sprintf("/%s/route/print", topic), "?disabled=false", fmt.Sprintf("?%s", protocol), "?active=" + active}
+	if table != "" {
+		sentence = append(sentence, "?routing-table="+table)
+	}
+
+	reply, err := ctx.client.Run(append(sentence, "=count-only=")...)
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"ip_version": ipVersion,
+			"table":      table,
+			"protocol":   protocol,
+			"error":      err,
+		}).Error("error fetching routes metrics")
+		return err
+	}
+	if reply.Done.Map["ret"] == "" {
+		return nil
+	}
+	v, err := strconv.ParseFloat(reply.Done.Map["ret"], 64)
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"ip_version": ipVersion,
+			"table":      table,
+			"protocol":   protocol,
+			"error":      err,
+		}).Error("error parsing routes metrics")
+		return err
+	}
+
+	if table == "" {
+		table = "main"
+	}
+	ctx.ch <- prometheus.MustNewConstMetric(c.summaryDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, ipVersion, table, protocol, active)
+	return nil
 }
 
 func (c *routesCollector) colllectForIPVersion(ipVersion, topic string, ctx *collectorContext) error {
@@ -62,9 +211,8 @@ func (c *routesCollector) colllectForIPVersion(ipVersion, topic string, ctx *col
 func (c *routesCollector) colllectCount(ipVersion, topic string, ctx *collectorContext) error {
 	reply, err := ctx.client.Run(fmt.Sprintf("/%s/route/print", topic), "?disabled=false", "=count-only=")
 	if err != nil {
-		log.WithFields(log.Fields{
+		ctx.logger().WithFields(log.Fields{
 			"ip_version": ipVersion,
-			"device":     ctx.device.Name,
 			"topic":      topic,
 			"error":      err,
 		}).Error("error fetching routes metrics")
@@ -75,9 +223,8 @@ func (c *routesCollector) colllectCount(ipVersion, topic string, ctx *collectorC
 	}
 	v, err := strconv.ParseFloat(reply.Done.Map["ret"], 32)
 	if err != nil {
-		log.WithFields(log.Fields{
+		ctx.logger().WithFields(log.Fields{
 			"ip_version": ipVersion,
-			"device":     ctx.device.Name,
 			"error":      err,
 		}).Error("error parsing routes metrics")
 		return err
@@ -90,10 +237,9 @@ func (c *routesCollector) colllectCount(ipVersion, topic string, ctx *collectorC
 func (c *routesCollector) colllectCountProtcol(ipVersion, topic, protocol string, ctx *collectorContext) error {
 	reply, err := ctx.client.Run(fmt.Sprintf("/%s/route/print", topic), "?disabled=false", fmt.Sprintf("?%s", protocol), "=count-only=")
 	if err != nil {
-		log.WithFields(log.Fields{
+		ctx.logger().WithFields(log.Fields{
 			"ip_version": ipVersion,
 			"protocol":   protocol,
-			"device":     ctx.device.Name,
 			"error":      err,
 		}).Error("error fetching routes metrics")
 		return err
@@ -103,10 +249,9 @@ func (c *routesCollector) colllectCountProtcol(ipVersion, topic, protocol string
 	}
 	v, err := strconv.ParseFloat(reply.Done.Map["ret"], 32)
 	if err != nil {
-		log.WithFields(log.Fields{
+		ctx.logger().WithFields(log.Fields{
 			"ip_version": ipVersion,
 			"protocol":   protocol,
-			"device":     ctx.device.Name,
 			"error":      err,
 		}).Error("error parsing routes metrics")
 		return err
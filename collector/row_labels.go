@@ -0,0 +1,73 @@
+package collector
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// rowLabels are the label pairs of the metrics of a row of a reply, e.g. of an
+// interface, which are built once per row instead of once per property. The
+// descriptors of the metrics must have the same variable labels and no
+// constant labels.
+type rowLabels struct {
+	pairs   []*dto.LabelPair
+	created time.Time
+}
+
+// rowLabels validates the label values against the descriptor and returns the
+// labels of the metrics of the row. Counters are created at the time the
+// device booted.
+func (ctx *collectorContext) rowLabels(desc *prometheus.Desc, labelValues ...string) *rowLabels {
+	m := prometheus.MustNewConstMetric(desc, prometheus.UntypedValue, 0, labelValues...)
+
+	var out dto.Metric
+	if err := m.Write(&out); err != nil {
+		panic(err)
+	}
+
+	return &rowLabels{pairs: out.Label, created: ctx.client.bootTime}
+}
+
+func (r *rowLabels) counter(desc *prometheus.Desc, v float64) prometheus.Metric {
+	return &rowMetric{desc: desc, valueType: prometheus.CounterValue, value: v, labels: r}
+}
+
+func (r *rowLabels) gauge(desc *prometheus.Desc, v float64) prometheus.Metric {
+	return &rowMetric{desc: desc, valueType: prometheus.GaugeValue, value: v, labels: r}
+}
+
+// rowMetric is a constant metric sharing the label pairs of its row.
+type rowMetric struct {
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+	value     float64
+	labels    *rowLabels
+}
+
+func (m *rowMetric) Desc() *prometheus.Desc {
+	return m.desc
+}
+
+func (m *rowMetric) Write(out *dto.Metric) error {
+	// the full slice expression keeps appends of wrappers from writing to the
+	// shared pairs
+	out.Label = m.labels.pairs[:len(m.labels.pairs):len(m.labels.pairs)]
+
+	switch m.valueType {
+	case prometheus.CounterValue:
+		out.Counter = &dto.Counter{Value: proto.Float64(m.value)}
+		if !m.labels.created.IsZero() {
+			out.Counter.CreatedTimestamp = timestamppb.New(m.labels.created)
+		}
+	case prometheus.GaugeValue:
+		out.Gauge = &dto.Gauge{Value: proto.Float64(m.value)}
+	default:
+		out.Untyped = &dto.Untyped{Value: proto.Float64(m.value)}
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+
+	"mikrotik-exporter/config"
+)
+
+func TestRowLabels(t *testing.T) {
+	bytes := prometheus.NewDesc("test_bytes", "test", []string{"name", "address", "interface"}, nil)
+	mtu := prometheus.NewDesc("test_mtu", "test", []string{"name", "address", "interface"}, nil)
+	boot := time.Unix(1700000000, 0)
+	ctx := &collectorContext{device: &config.Device{Name: "r1"}, client: &apiClient{bootTime: boot}}
+
+	labels := ctx.rowLabels(bytes, "r1", "10.0.0.1", "ether1")
+	rows := []struct {
+		got, want prometheus.Metric
+	}{
+		{labels.counter(bytes, 42), ctx.counter(bytes, 42, "r1", "10.0.0.1", "ether1")},
+		{labels.gauge(mtu, 1500), prometheus.MustNewConstMetric(mtu, prometheus.GaugeValue, 1500, "r1", "10.0.0.1", "ether1")},
+	}
+	for _, r := range rows {
+		var got, want dto.Metric
+		assert.NoError(t, r.got.Write(&got))
+		assert.NoError(t, r.want.Write(&want))
+		assert.Equal(t, want.String(), got.String())
+		assert.Equal(t, r.want.Desc(), r.got.Desc())
+	}
+
+	// wrappers adding labels must not change the pairs shared by the row
+	m := &labelledMetric{Metric: labels.counter(bytes, 1), labels: map[string]string{"site": "fra1"}}
+	var out dto.Metric
+	assert.NoError(t, m.Write(&out))
+	assert.Len(t, out.Label, 4)
+	assert.Len(t, labels.pairs, 3)
+
+	assert.Panics(t, func() { ctx.rowLabels(bytes, "r1") })
+}
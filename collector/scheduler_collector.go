@@ -0,0 +1,190 @@
+package collector
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+// deviceTimeLayouts are the layouts of timestamps on RouterOS 7 and 6.
+var deviceTimeLayouts = []string{"2006-01-02 15:04:05", "Jan/02/2006 15:04:05"}
+
+// runState is the last seen run count of a scheduler entry and the time the
+// exporter has seen it increase.
+type runState struct {
+	count float64
+	last  time.Time
+}
+
+type schedulerCollector struct {
+	runCountDesc  *prometheus.Desc
+	nextRunDesc   *prometheus.Desc
+	lastRunDesc   *prometheus.Desc
+	enabledDesc   *prometheus.Desc
+	scriptRunDesc *prometheus.Desc
+
+	mu   sync.Mutex
+	runs map[string]*runState
+}
+
+func newSchedulerCollector() routerOSCollector {
+	c := &schedulerCollector{
+		runs: make(map[string]*runState),
+	}
+	c.init()
+	return c
+}
+
+func (c *schedulerCollector) init() {
+	labelNames := []string{"name", "address", "scheduler"}
+	c.runCountDesc = description("scheduler", "run_count", "number of times the scheduler entry has run", labelNames)
+	c.nextRunDesc = description("scheduler", "next_run_timestamp_seconds", "time of the next run of the scheduler entry", labelNames)
+	c.lastRunDesc = description("scheduler", "last_run_timestamp_seconds", "time the exporter has last seen the run count of the scheduler entry increase", labelNames)
+	c.enabledDesc = description("scheduler", "enabled", "whether the scheduler entry is enabled", labelNames)
+	c.scriptRunDesc = description("script", "run_count", "number of times the script has run", []string{"name", "address", "script"})
+}
+
+func (c *schedulerCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.runCountDesc
+	ch <- c.nextRunDesc
+	ch <- c.lastRunDesc
+	ch <- c.enabledDesc
+	ch <- c.scriptRunDesc
+}
+
+func (c *schedulerCollector) collect(ctx *collectorContext) error {
+	offset, err := fetchClockOffset(ctx)
+	if err != nil {
+		return err
+	}
+
+	reply, err := ctx.client.Run("/system/scheduler/print", "=.proplist=name,disabled,run-count,next-run")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching scheduler metrics")
+		return err
+	}
+
+	now := time.Now()
+	for _, re := range reply.Re {
+		c.collectForStat(re, offset, now, ctx)
+	}
+
+	return c.collectScripts(ctx)
+}
+
+// fetchClockOffset returns the offset of the device clock to UTC, which
+// timestamps reported by the device are in.
+func fetchClockOffset(ctx *collectorContext) (string, error) {
+	reply, err := ctx.client.Run("/system/clock/print", "=.proplist=gmt-offset")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching clock settings")
+		return "", err
+	}
+	if len(reply.Re) == 0 {
+		return "", nil
+	}
+
+	return reply.Re[0].Map["gmt-offset"], nil
+}
+
+func (c *schedulerCollector) collectForStat(re *proto.Sentence, offset string, now time.Time, ctx *collectorContext) {
+	name := re.Map["name"]
+	labelValues := []string{ctx.device.Name, ctx.device.Address, name}
+
+	enabled := 1.0
+	if re.Map["disabled"] == "true" {
+		enabled = 0.0
+	}
+	ctx.ch <- prometheus.MustNewConstMetric(c.enabledDesc, prometheus.GaugeValue, enabled, labelValues...)
+
+	if v, err := strconv.ParseFloat(re.Map["run-count"], 64); err == nil {
+		ctx.ch <- prometheus.MustNewConstMetric(c.runCountDesc, prometheus.CounterValue, v, labelValues...)
+
+		if last := c.run(ctx.device.Name+"/"+name, v, now); !last.IsZero() {
+			ctx.ch <- prometheus.MustNewConstMetric(c.lastRunDesc, prometheus.GaugeValue, float64(last.Unix()), labelValues...)
+		}
+	}
+
+	if next := re.Map["next-run"]; next != "" {
+		t, err := parseDeviceTime(next, offset)
+		if err != nil {
+			ctx.logger().WithFields(log.Fields{
+				"scheduler": name,
+				"value":     next,
+				"error":     err,
+			}).Error("error parsing scheduler next run")
+			return
+		}
+		ctx.ch <- prometheus.MustNewConstMetric(c.nextRunDesc, prometheus.GaugeValue, float64(t.Unix()), labelValues...)
+	}
+}
+
+func (c *schedulerCollector) collectScripts(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/system/script/print", "=.proplist=name,run-count")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching script metrics")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		if v, err := strconv.ParseFloat(re.Map["run-count"], 64); err == nil {
+			ctx.ch <- prometheus.MustNewConstMetric(c.scriptRunDesc, prometheus.CounterValue, v, ctx.device.Name, ctx.device.Address, re.Map["name"])
+		}
+	}
+
+	return nil
+}
+
+// run records the run count of the scheduler entry and returns the time the
+// exporter has last seen it increase.
+func (c *schedulerCollector) run(key string, count float64, now time.Time) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.runs[key]
+	if !ok {
+		c.runs[key] = &runState{count: count}
+		return time.Time{}
+	}
+
+	if count > s.count {
+		s.last = now
+	}
+	s.count = count
+
+	return s.last
+}
+
+// parseDeviceTime parses a timestamp of the device, e.g. "2024-10-15 10:00:00"
+// or "oct/15/2024 10:00:00", in the time zone given by the offset to UTC,
+// e.g. "+02:00".
+func parseDeviceTime(value, offset string) (time.Time, error) {
+	loc := time.UTC
+	if offset != "" {
+		o, err := time.Parse("-07:00", offset)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid offset %q: %w", offset, err)
+		}
+		_, seconds := o.Zone()
+		loc = time.FixedZone(offset, seconds)
+	}
+
+	for _, layout := range deviceTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q", value)
+}
@@ -0,0 +1,33 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDeviceTime(t *testing.T) {
+	testCases := []struct {
+		value    string
+		offset   string
+		expected time.Time
+		isError  bool
+	}{
+		{"2024-10-15 10:00:00", "", time.Date(2024, 10, 15, 10, 0, 0, 0, time.UTC), false},
+		{"2024-10-15 10:00:00", "+02:00", time.Date(2024, 10, 15, 8, 0, 0, 0, time.UTC), false},
+		{"oct/15/2024 10:00:00", "-05:30", time.Date(2024, 10, 15, 15, 30, 0, 0, time.UTC), false},
+		{"10:00:00", "", time.Time{}, true},
+		{"2024-10-15 10:00:00", "CET", time.Time{}, true},
+	}
+
+	for _, tc := range testCases {
+		v, err := parseDeviceTime(tc.value, tc.offset)
+		if tc.isError {
+			assert.Error(t, err, tc.value)
+			continue
+		}
+		assert.NoError(t, err, tc.value)
+		assert.True(t, tc.expected.Equal(v), "%s: expected %s, got %s", tc.value, tc.expected, v)
+	}
+}
@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// scrapeCacheEntry holds the metrics of a scrape of a device, done is closed
+// once the scrape finished.
+type scrapeCacheEntry struct {
+	metrics []prometheus.Metric
+	begin   time.Time
+	done    chan struct{}
+}
+
+// scrapeCache serves the metrics of a device scraped within the TTL from
+// memory, e.g. to the scrapes of an HA pair of Prometheus servers, and lets
+// concurrent scrapes of a device wait for a single scrape.
+type scrapeCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*scrapeCacheEntry
+}
+
+func newScrapeCache(ttl time.Duration) *scrapeCache {
+	return &scrapeCache{
+		ttl:     ttl,
+		entries: make(map[string]*scrapeCacheEntry),
+	}
+}
+
+// get returns the metrics of the device, scraping it with collect unless it
+// is being scraped or was scraped within the TTL.
+func (s *scrapeCache) get(device string, collect func(ch chan<- prometheus.Metric)) []prometheus.Metric {
+	s.mu.Lock()
+	e, ok := s.entries[device]
+	if ok && (e.scraping() || time.Since(e.begin) < s.ttl) {
+		s.mu.Unlock()
+		<-e.done
+		return e.metrics
+	}
+
+	e = &scrapeCacheEntry{begin: time.Now(), done: make(chan struct{})}
+	s.entries[device] = e
+	s.mu.Unlock()
+
+	e.metrics, _ = collectBuffered(func(ch chan<- prometheus.Metric) error {
+		collect(ch)
+		return nil
+	})
+	close(e.done)
+
+	return e.metrics
+}
+
+func (e *scrapeCacheEntry) scraping() bool {
+	select {
+	case <-e.done:
+		return false
+	default:
+		return true
+	}
+}
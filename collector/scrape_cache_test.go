@@ -0,0 +1,44 @@
+package collector
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScrapeCache(t *testing.T) {
+	s := newScrapeCache(time.Minute)
+	desc := prometheus.NewDesc("test_metric", "test", nil, nil)
+
+	var scrapes atomic.Int32
+	release := make(chan struct{})
+	collect := func(ch chan<- prometheus.Metric) {
+		scrapes.Add(1)
+		<-release
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Len(t, s.get("r1", collect), 1)
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), scrapes.Load(), "concurrent scrapes must poll the device once")
+
+	assert.Len(t, s.get("r1", collect), 1)
+	assert.Equal(t, int32(1), scrapes.Load(), "scrapes within the TTL must be served from memory")
+
+	s.ttl = 0
+	s.get("r1", collect)
+	assert.Equal(t, int32(2), scrapes.Load(), "expired scrapes must poll the device again")
+}
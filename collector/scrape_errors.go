@@ -0,0 +1,205 @@
+package collector
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2"
+)
+
+var (
+	scrapeErrorsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "scrape", "errors_total"),
+		"mikrotik_exporter: number of failed scrapes of the device",
+		[]string{"device"},
+		nil,
+	)
+	collectorErrorsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "scrape", "collector_errors_total"),
+		"mikrotik_exporter: number of failed runs of a device collector",
+		[]string{"device", "collector"},
+		nil,
+	)
+	parseErrorsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "scrape", "parse_errors_total"),
+		"mikrotik_exporter: number of values of the device which could not be parsed and were skipped",
+		[]string{"device"},
+		nil,
+	)
+	lastErrorDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "scrape", "last_error_info"),
+		"mikrotik_exporter: class of the last error scraping the device",
+		[]string{"device", "class"},
+		nil,
+	)
+)
+
+// errScrapeTimeout is returned once the scrape timeout of a device passed
+var errScrapeTimeout = errors.New("scrape timed out")
+
+// connectError is returned if the device could not be connected to, as
+// opposed to errors of single collectors
+type connectError struct {
+	err error
+}
+
+func (e *connectError) Error() string {
+	return e.err.Error()
+}
+
+func (e *connectError) Unwrap() error {
+	return e.err
+}
+
+// Classes of errors scraping a device
+const (
+	errorClassAuth        = "auth"
+	errorClassTimeout     = "timeout"
+	errorClassConnection  = "connection"
+	errorClassDevice      = "device"
+	errorClassParse       = "parse"
+	errorClassCircuitOpen = "circuit_open"
+	errorClassOther       = "other"
+)
+
+// errorClass returns the class of an error scraping a device.
+func errorClass(err error) string {
+	var (
+		deviceErr *routeros.DeviceError
+		numErr    *strconv.NumError
+		netErr    net.Error
+	)
+
+	switch {
+	case errors.Is(err, errCircuitOpen):
+		return errorClassCircuitOpen
+	case errors.Is(err, errScrapeTimeout), errors.As(err, &netErr) && netErr.Timeout():
+		return errorClassTimeout
+	case errors.As(err, &deviceErr):
+		msg := deviceErr.Sentence.Map["message"]
+		if strings.Contains(msg, "invalid user name or password") || strings.Contains(msg, "cannot log in") {
+			return errorClassAuth
+		}
+		return errorClassDevice
+	case errors.As(err, &numErr):
+		return errorClassParse
+	case isConnectionError(err):
+		return errorClassConnection
+	default:
+		return errorClassOther
+	}
+}
+
+type collectorError struct {
+	device    string
+	collector string
+}
+
+// scrapeErrors counts the errors scraping each device and running each of its
+// collectors, and remembers the class of the last one.
+type scrapeErrors struct {
+	mu         sync.Mutex
+	devices    map[string]float64
+	collectors map[collectorError]float64
+	parse      map[string]float64
+	last       map[string]string
+}
+
+func newScrapeErrors() *scrapeErrors {
+	return &scrapeErrors{
+		devices:    make(map[string]float64),
+		collectors: make(map[collectorError]float64),
+		parse:      make(map[string]float64),
+		last:       make(map[string]string),
+	}
+}
+
+// recordScrape counts the result of a scrape of the device, which parsed had
+// values in it that failed to parse.
+func (s *scrapeErrors) recordScrape(device string, err error, parsed float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.devices[device]; !ok {
+		s.devices[device] = 0
+	}
+	if err != nil {
+		s.devices[device]++
+		s.last[device] = errorClass(err)
+	} else if parsed > s.parse[device] {
+		s.last[device] = errorClassParse
+	}
+	s.parse[device] = parsed
+}
+
+// recordCollector counts the result of running the collector against the
+// device.
+func (s *scrapeErrors) recordCollector(device, collector string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := collectorError{device: device, collector: collector}
+	if _, ok := s.collectors[key]; !ok {
+		s.collectors[key] = 0
+	}
+	if err != nil {
+		s.collectors[key]++
+	}
+}
+
+func (s *scrapeErrors) collect(ch chan<- prometheus.Metric, device string, lastError bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch <- prometheus.MustNewConstMetric(scrapeErrorsDesc, prometheus.CounterValue, s.devices[device], device)
+	ch <- prometheus.MustNewConstMetric(parseErrorsDesc, prometheus.CounterValue, s.parse[device], device)
+	for key, v := range s.collectors {
+		if key.device == device {
+			ch <- prometheus.MustNewConstMetric(collectorErrorsDesc, prometheus.CounterValue, v, device, key.collector)
+		}
+	}
+	if class, ok := s.last[device]; ok && lastError {
+		ch <- prometheus.MustNewConstMetric(lastErrorDesc, prometheus.GaugeValue, 1, device, class)
+	}
+}
+
+// parseErrors counts the values which failed to parse per device. Collectors
+// log and skip those values rather than failing, so they are counted from the
+// log entries.
+var parseErrors = &parseErrorHook{counts: make(map[string]float64)}
+
+var addParseErrorHook sync.Once
+
+type parseErrorHook struct {
+	mu     sync.Mutex
+	counts map[string]float64
+}
+
+func (h *parseErrorHook) Levels() []log.Level {
+	return []log.Level{log.ErrorLevel, log.WarnLevel}
+}
+
+func (h *parseErrorHook) Fire(e *log.Entry) error {
+	device, ok := e.Data["device"].(string)
+	if !ok || !strings.HasPrefix(e.Message, "error parsing") {
+		return nil
+	}
+
+	h.mu.Lock()
+	h.counts[device]++
+	h.mu.Unlock()
+
+	return nil
+}
+
+func (h *parseErrorHook) count(device string) float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.counts[device]
+}
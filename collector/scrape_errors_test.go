@@ -0,0 +1,43 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/routeros.v2"
+	"gopkg.in/routeros.v2/proto"
+)
+
+func TestErrorClass(t *testing.T) {
+	login := proto.NewSentence()
+	login.Map["message"] = "invalid user name or password (6)"
+	rejected := proto.NewSentence()
+	rejected.Map["message"] = "no such command prefix"
+	_, parseErr := strconv.ParseFloat("1.5dBm", 64)
+
+	assert.Equal(t, errorClassAuth, errorClass(&routeros.DeviceError{Sentence: login}))
+	assert.Equal(t, errorClassDevice, errorClass(&routeros.DeviceError{Sentence: rejected}))
+	assert.Equal(t, errorClassTimeout, errorClass(fmt.Errorf("%w after 5s", errScrapeTimeout)))
+	assert.Equal(t, errorClassConnection, errorClass(io.EOF))
+	assert.Equal(t, errorClassParse, errorClass(parseErr))
+	assert.Equal(t, errorClassCircuitOpen, errorClass(errCircuitOpen))
+	assert.Equal(t, errorClassOther, errorClass(errors.New("boom")))
+}
+
+func TestParseErrorHook(t *testing.T) {
+	h := &parseErrorHook{counts: make(map[string]float64)}
+
+	for _, msg := range []string{"error parsing interface metric value", "error fetching interface metrics"} {
+		e := log.WithFields(log.Fields{"device": "r1"})
+		e.Message = msg
+		assert.NoError(t, h.Fire(e))
+	}
+
+	assert.Equal(t, 1.0, h.count("r1"))
+	assert.Equal(t, 0.0, h.count("r2"))
+}
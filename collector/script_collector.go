@@ -0,0 +1,130 @@
+package collector
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"mikrotik-exporter/config"
+)
+
+// scriptQuoter escapes the characters special in RouterOS strings
+var scriptQuoter = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`)
+
+// script is a script run on the devices with the source to execute.
+type script struct {
+	name   string
+	source string
+}
+
+// scriptCollector runs the configured scripts on the device and exports the
+// lines like key=value they print, e.g. for values only computable on the
+// device.
+type scriptCollector struct {
+	scripts   []script
+	valueDesc *prometheus.Desc
+}
+
+func newScriptCollector(scripts []config.Script) (routerOSCollector, error) {
+	c := &scriptCollector{
+		valueDesc: description("script", "value", "value printed by the script", []string{"name", "address", "script", "key"}),
+	}
+
+	names := make(map[string]bool)
+	for _, s := range scripts {
+		if s.Name == "" {
+			return nil, fmt.Errorf("missing name of script")
+		}
+		if names[s.Name] {
+			return nil, fmt.Errorf("duplicate script %s", s.Name)
+		}
+		names[s.Name] = true
+
+		switch {
+		case s.Script != "" && s.Source != "":
+			return nil, fmt.Errorf("script %s sets both script and source", s.Name)
+		case s.Script != "":
+			c.scripts = append(c.scripts, script{name: s.Name, source: `/system script run "` + scriptQuoter.Replace(s.Script) + `"`})
+		case s.Source != "":
+			c.scripts = append(c.scripts, script{name: s.Name, source: s.Source})
+		default:
+			return nil, fmt.Errorf("missing script or source of script %s", s.Name)
+		}
+	}
+
+	return c, nil
+}
+
+func (c *scriptCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.valueDesc
+}
+
+func (c *scriptCollector) collect(ctx *collectorContext) error {
+	for _, s := range c.scripts {
+		if err := c.collectScript(s, ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *scriptCollector) collectScript(s script, ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/execute", "=script="+s.source, "=as-string=")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"script": s.name,
+			"error":  err,
+		}).Error("error running script")
+		return err
+	}
+
+	values, errs := parseScriptOutput(reply.Done.Map["ret"])
+	for _, err := range errs {
+		ctx.logger().WithFields(log.Fields{
+			"script": s.name,
+			"error":  err,
+		}).Warn("ignoring script output")
+	}
+	for key, v := range values {
+		ctx.ch <- prometheus.MustNewConstMetric(c.valueDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, s.name, key)
+	}
+
+	return nil
+}
+
+// parseScriptOutput parses the lines like key=value printed by a script,
+// skipping empty lines and comments starting with #. Lines which cannot be
+// parsed are returned as errors.
+func parseScriptOutput(output string) (map[string]float64, []error) {
+	values := make(map[string]float64)
+	var errs []error
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" {
+			errs = append(errs, fmt.Errorf("line %q is not like key=value", line))
+			continue
+		}
+		if _, ok := values[key]; ok {
+			errs = append(errs, fmt.Errorf("duplicate key %s", key))
+			continue
+		}
+
+		v, err := parseCustomValue(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid value of key %s: %w", key, err))
+			continue
+		}
+		values[key] = v
+	}
+
+	return values, errs
+}
@@ -0,0 +1,39 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"mikrotik-exporter/config"
+)
+
+func TestNewScriptCollector(t *testing.T) {
+	co, err := newScriptCollector([]config.Script{
+		{Name: "queues", Script: `queue "math"`},
+		{Name: "wan", Source: `:put "rx=1"`},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []script{
+		{name: "queues", source: `/system script run "queue \"math\""`},
+		{name: "wan", source: `:put "rx=1"`},
+	}, co.(*scriptCollector).scripts)
+
+	invalid := [][]config.Script{
+		{{Script: "queues"}},
+		{{Name: "queues"}},
+		{{Name: "queues", Script: "queues", Source: ":put 1"}},
+		{{Name: "queues", Script: "queues"}, {Name: "queues", Script: "other"}},
+	}
+	for _, scripts := range invalid {
+		_, err := newScriptCollector(scripts)
+		assert.Error(t, err, "%+v", scripts)
+	}
+}
+
+func TestParseScriptOutput(t *testing.T) {
+	values, errs := parseScriptOutput("# queue math\r\nused=42\r\nratio = 0.5\r\n\r\nup=true\r\nuptime=1h\r\nused=43\r\nbroken\r\nname=ether1\r\n")
+
+	assert.Equal(t, map[string]float64{"used": 42, "ratio": 0.5, "up": 1, "uptime": 3600}, values)
+	assert.Len(t, errs, 3)
+}
@@ -0,0 +1,91 @@
+package collector
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// selection is the subset of the collectors run by a scrape.
+type selection struct {
+	collectors []namedCollector
+	// key tells the cached metrics of the subset apart from those of other
+	// subsets, it is empty for all collectors
+	key string
+}
+
+// cacheKey returns the key of the metrics of the device scraped with the
+// selection in the scrape and stale caches.
+func (s *selection) cacheKey(device string) string {
+	if s.key == "" {
+		return device
+	}
+
+	return device + "?" + s.key
+}
+
+// selectedCollector scrapes the devices with a subset of the collectors,
+// sharing the connections and caches of the collector.
+type selectedCollector struct {
+	c   *collector
+	sel *selection
+}
+
+// Select returns a collector scraping the devices only with the named
+// collectors, or with all but the excluded ones, e.g. for the collect[] and
+// exclude[] parameters of a scrape.
+func (c *collector) Select(collect, exclude []string) (prometheus.Collector, error) {
+	if c.poller != nil {
+		return nil, fmt.Errorf("selecting collectors is not supported with background polling")
+	}
+
+	sel, err := c.newSelection(collect, exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	return &selectedCollector{c: c, sel: sel}, nil
+}
+
+// newSelection returns the named collectors, or all but the excluded ones.
+// Without names all collectors are selected.
+func (c *collector) newSelection(collect, exclude []string) (*selection, error) {
+	if len(collect) > 0 && len(exclude) > 0 {
+		return nil, fmt.Errorf("collectors cannot be both collected and excluded")
+	}
+	for _, name := range slices.Concat(collect, exclude) {
+		if !c.hasCollector(name) {
+			return nil, fmt.Errorf("unknown or disabled collector %s", name)
+		}
+	}
+
+	sel := &selection{}
+	for _, co := range c.collectors {
+		if len(collect) > 0 && !slices.Contains(collect, co.name) || slices.Contains(exclude, co.name) {
+			continue
+		}
+		sel.collectors = append(sel.collectors, co)
+	}
+
+	if len(sel.collectors) < len(c.collectors) {
+		names := make([]string, len(sel.collectors))
+		for i, co := range sel.collectors {
+			names[i] = co.name
+		}
+		sel.key = strings.Join(names, ",")
+	}
+
+	return sel, nil
+}
+
+// Describe implements the prometheus.Collector interface.
+func (s *selectedCollector) Describe(ch chan<- *prometheus.Desc) {
+	s.c.Describe(ch)
+}
+
+// Collect implements the prometheus.Collector interface.
+func (s *selectedCollector) Collect(ch chan<- prometheus.Metric) {
+	s.c.collect(ch, s.sel)
+}
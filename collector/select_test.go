@@ -0,0 +1,42 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelect(t *testing.T) {
+	c := &collector{}
+	c.add("interface", newInterfaceCollector())
+	c.add("dhcp", newDHCPCollector())
+	c.add("health", newhealthCollector())
+
+	names := func(sc prometheus.Collector) []string {
+		var names []string
+		for _, co := range sc.(*selectedCollector).sel.collectors {
+			names = append(names, co.name)
+		}
+		return names
+	}
+
+	sc, err := c.Select([]string{"health", "dhcp"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dhcp", "health"}, names(sc))
+	assert.Equal(t, "r1?dhcp,health", sc.(*selectedCollector).sel.cacheKey("r1"))
+
+	sc, err = c.Select(nil, []string{"dhcp"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"interface", "health"}, names(sc))
+
+	sc, err = c.Select(nil, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "r1", sc.(*selectedCollector).sel.cacheKey("r1"))
+
+	_, err = c.Select([]string{"dhcp"}, []string{"health"})
+	assert.Error(t, err)
+
+	_, err = c.Select([]string{"bgp"}, nil)
+	assert.Error(t, err)
+}
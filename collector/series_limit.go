@@ -0,0 +1,111 @@
+package collector
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	cardinalityLimitedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "scrape", "cardinality_limited_total"),
+		"mikrotik_exporter: number of runs of a device collector whose series were truncated at a series limit",
+		[]string{"device", "collector"},
+		nil,
+	)
+	seriesDroppedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "scrape", "collector_series_dropped"),
+		"mikrotik_exporter: number of series of a device collector dropped at a series limit in the last run",
+		[]string{"device", "collector"},
+		nil,
+	)
+)
+
+// seriesBudget is the number of series a device or collector may still
+// export in a scrape. Collectors running in parallel share the budget of the
+// device.
+type seriesBudget struct {
+	left atomic.Int64
+}
+
+// newSeriesBudget returns a budget of limit series, nil if unlimited.
+func newSeriesBudget(limit int) *seriesBudget {
+	if limit <= 0 {
+		return nil
+	}
+
+	b := &seriesBudget{}
+	b.left.Store(int64(limit))
+	return b
+}
+
+// take returns whether a series is left in the budget.
+func (b *seriesBudget) take() bool {
+	return b == nil || b.left.Add(-1) >= 0
+}
+
+// withSeriesLimit returns a channel forwarding the metrics sent to ch within
+// the budgets and a function to call once all metrics have been sent, which
+// returns the number of metrics dropped.
+func withSeriesLimit(ch chan<- prometheus.Metric, budgets ...*seriesBudget) (chan<- prometheus.Metric, func() int) {
+	limited := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	dropped := 0
+
+	go func() {
+		for m := range limited {
+			if takeAll(budgets) {
+				ch <- m
+			} else {
+				dropped++
+			}
+		}
+		close(done)
+	}()
+
+	return limited, func() int {
+		close(limited)
+		<-done
+		return dropped
+	}
+}
+
+func takeAll(budgets []*seriesBudget) bool {
+	for _, b := range budgets {
+		if !b.take() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// seriesLimited counts the runs of the collectors of each device which were
+// truncated.
+type seriesLimited struct {
+	mu     sync.Mutex
+	counts map[collectorError]float64
+}
+
+func newSeriesLimited() *seriesLimited {
+	return &seriesLimited{counts: make(map[collectorError]float64)}
+}
+
+func (s *seriesLimited) record(device, collector string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.counts[collectorError{device: device, collector: collector}]++
+}
+
+func (s *seriesLimited) collect(ch chan<- prometheus.Metric, device string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, v := range s.counts {
+		if key.device == device {
+			ch <- prometheus.MustNewConstMetric(cardinalityLimitedDesc, prometheus.CounterValue, v, device, key.collector)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithSeriesLimit(t *testing.T) {
+	desc := prometheus.NewDesc("test_metric", "test", []string{"n"}, nil)
+	device := newSeriesBudget(5)
+
+	run := func(limit, n int) (int, int) {
+		ch := make(chan prometheus.Metric, n)
+		out, flush := withSeriesLimit(ch, newSeriesBudget(limit), device)
+		for i := 0; i < n; i++ {
+			out <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, string(rune('a'+i)))
+		}
+		dropped := flush()
+		return len(ch), dropped
+	}
+
+	sent, dropped := run(2, 3)
+	assert.Equal(t, 2, sent, "the collector limit must truncate the series")
+	assert.Equal(t, 1, dropped)
+
+	sent, dropped = run(0, 4)
+	assert.Equal(t, 3, sent, "the device limit must be shared by its collectors")
+	assert.Equal(t, 1, dropped)
+
+	assert.True(t, (*seriesBudget)(nil).take(), "nil budgets must be unlimited")
+}
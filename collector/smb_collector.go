@@ -0,0 +1,69 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+// smbCollector exports the SMB service status. RouterOS does not expose the
+// active SMB sessions through the API.
+type smbCollector struct {
+	enabledDesc *prometheus.Desc
+	sharesDesc  *prometheus.Desc
+}
+
+func newSMBCollector() routerOSCollector {
+	c := &smbCollector{}
+	c.init()
+	return c
+}
+
+func (c *smbCollector) init() {
+	const prefix = "smb"
+	labelNames := []string{"name", "address"}
+	c.enabledDesc = description(prefix, "enabled", "whether the SMB service is enabled", labelNames)
+	c.sharesDesc = description(prefix, "shares", "number of enabled SMB shares", labelNames)
+}
+
+func (c *smbCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.enabledDesc
+	ch <- c.sharesDesc
+}
+
+func (c *smbCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/ip/smb/print")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching smb settings")
+		return err
+	}
+	if len(reply.Re) == 0 {
+		return nil
+	}
+
+	shares, err := ctx.client.Run("/ip/smb/shares/print", "?disabled=false", "=.proplist=name")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching smb shares")
+		return err
+	}
+
+	// recent RouterOS 7 releases enable the service automatically once a
+	// share exists
+	enabled := 0.0
+	switch reply.Re[0].Map["enabled"] {
+	case "true", "yes":
+		enabled = 1.0
+	case "auto":
+		if len(shares.Re) > 0 {
+			enabled = 1.0
+		}
+	}
+
+	ctx.ch <- prometheus.MustNewConstMetric(c.enabledDesc, prometheus.GaugeValue, enabled, ctx.device.Name, ctx.device.Address)
+	ctx.ch <- prometheus.MustNewConstMetric(c.sharesDesc, prometheus.GaugeValue, float64(len(shares.Re)), ctx.device.Name, ctx.device.Address)
+
+	return nil
+}
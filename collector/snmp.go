@@ -0,0 +1,140 @@
+package collector
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// snmpBulkRepetitions is the number of table rows requested at once
+const snmpBulkRepetitions = 25
+
+// snmpVar is a variable binding of an SNMP response.
+type snmpVar struct {
+	oid string
+	typ gosnmp.Asn1BER
+	num uint64
+	str string
+}
+
+func newSNMPVar(pdu gosnmp.SnmpPDU) snmpVar {
+	v := snmpVar{oid: strings.TrimPrefix(pdu.Name, "."), typ: pdu.Type}
+
+	switch pdu.Type {
+	case gosnmp.OctetString:
+		b, _ := pdu.Value.([]byte)
+		v.str = string(b)
+	case gosnmp.IPAddress:
+		v.str, _ = pdu.Value.(string)
+	case gosnmp.Integer:
+		v.num = uint64(gosnmp.ToBigInt(pdu.Value).Int64())
+	case gosnmp.Counter32, gosnmp.Gauge32, gosnmp.TimeTicks, gosnmp.Counter64, gosnmp.Uinteger32:
+		v.num = gosnmp.ToBigInt(pdu.Value).Uint64()
+	}
+
+	return v
+}
+
+// exists returns whether the agent has the requested variable.
+func (v snmpVar) exists() bool {
+	return v.typ != gosnmp.NoSuchObject && v.typ != gosnmp.NoSuchInstance && v.typ != gosnmp.EndOfMibView
+}
+
+// text returns the value formatted the way RouterOS prints it.
+func (v snmpVar) text() string {
+	switch v.typ {
+	case gosnmp.OctetString, gosnmp.IPAddress:
+		return v.str
+	case gosnmp.Integer:
+		return strconv.FormatInt(int64(v.num), 10)
+	default:
+		return strconv.FormatUint(v.num, 10)
+	}
+}
+
+// snmpClient queries an SNMPv2c agent.
+type snmpClient struct {
+	g *gosnmp.GoSNMP
+}
+
+func dialSNMP(source *net.UDPAddr, address, community string, timeout time.Duration, attempts int) (*snmpClient, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snmp port %q: %w", port, err)
+	}
+
+	g := &gosnmp.GoSNMP{
+		Target:         host,
+		Port:           uint16(p),
+		Transport:      "udp",
+		Community:      community,
+		Version:        gosnmp.Version2c,
+		Timeout:        timeout,
+		Retries:        attempts,
+		MaxRepetitions: snmpBulkRepetitions,
+		MaxOids:        gosnmp.MaxOids,
+	}
+	if source != nil {
+		g.LocalAddr = source.String()
+	}
+	if err := g.Connect(); err != nil {
+		return nil, err
+	}
+
+	return &snmpClient{g: g}, nil
+}
+
+func (s *snmpClient) close() error {
+	return s.g.Close()
+}
+
+// get returns the variables, which exist() is false for if the agent lacks
+// them.
+func (s *snmpClient) get(oids ...string) ([]snmpVar, error) {
+	packet, err := s.g.Get(oids)
+	if err != nil {
+		return nil, err
+	}
+	if packet.Error != gosnmp.NoError {
+		return nil, fmt.Errorf("snmp error %s", packet.Error)
+	}
+	if len(packet.Variables) != len(oids) {
+		return nil, fmt.Errorf("got %d values for %d oids", len(packet.Variables), len(oids))
+	}
+
+	vars := make([]snmpVar, len(packet.Variables))
+	for i, pdu := range packet.Variables {
+		vars[i] = newSNMPVar(pdu)
+	}
+
+	return vars, nil
+}
+
+// walk returns the values in the subtree of the OID keyed by the OID suffix,
+// e.g. the index of a table row.
+func (s *snmpClient) walk(root string) (map[string]snmpVar, error) {
+	pdus, err := s.g.BulkWalkAll(root)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]snmpVar, len(pdus))
+	prefix := root + "."
+	for _, pdu := range pdus {
+		v := newSNMPVar(pdu)
+		if !v.exists() || !strings.HasPrefix(v.oid, prefix) {
+			continue
+		}
+		values[strings.TrimPrefix(v.oid, prefix)] = v
+	}
+
+	return values, nil
+}
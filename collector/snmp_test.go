@@ -0,0 +1,119 @@
+package collector
+
+import (
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSNMPWalk(t *testing.T) {
+	addr := fakeSNMPAgent(t, []gosnmp.SnmpPDU{
+		{Name: ".1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(12345)},
+		{Name: ".1.3.6.1.2.1.31.1.1.1.1.1", Type: gosnmp.OctetString, Value: []byte("ether1")},
+		{Name: ".1.3.6.1.2.1.31.1.1.1.1.2", Type: gosnmp.OctetString, Value: []byte("bridge")},
+		{Name: ".1.3.6.1.2.1.31.1.1.1.6.1", Type: gosnmp.Counter64, Value: uint64(1 << 40)},
+	})
+
+	s, err := dialSNMP(nil, addr, "public", time.Second, 0)
+	assert.NoError(t, err)
+	defer s.close()
+
+	vars, err := s.get(oidSysUpTime, oidMtxrLicVersion)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(12345), vars[0].num)
+	assert.False(t, vars[1].exists())
+
+	names, err := s.walk(oidIfName)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(names))
+	assert.Equal(t, "bridge", names["2"].text())
+
+	octets, err := s.walk(oidIfHCInOctets)
+	assert.NoError(t, err)
+	assert.Equal(t, "1099511627776", octets["1"].text())
+}
+
+func TestSNMPVarText(t *testing.T) {
+	assert.Equal(t, "-5", newSNMPVar(gosnmp.SnmpPDU{Type: gosnmp.Integer, Value: -5}).text())
+	assert.Equal(t, "10.0.0.1", newSNMPVar(gosnmp.SnmpPDU{Type: gosnmp.IPAddress, Value: "10.0.0.1"}).text())
+	assert.Equal(t, "42", newSNMPVar(gosnmp.SnmpPDU{Type: gosnmp.Gauge32, Value: uint(42)}).text())
+}
+
+// fakeSNMPAgent answers get and get-bulk requests for the variables, which
+// must be sorted by OID.
+func fakeSNMPAgent(t *testing.T, vars []gosnmp.SnmpPDU) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		decoder := &gosnmp.GoSNMP{}
+		buf := make([]byte, 65535)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			req, err := decoder.SnmpDecodePacket(buf[:n])
+			if err != nil {
+				continue
+			}
+
+			var reply []gosnmp.SnmpPDU
+			for _, r := range req.Variables {
+				if req.PDUType == gosnmp.GetRequest {
+					i := slices.IndexFunc(vars, func(v gosnmp.SnmpPDU) bool { return v.Name == r.Name })
+					v := gosnmp.SnmpPDU{Name: r.Name, Type: gosnmp.NoSuchObject}
+					if i >= 0 {
+						v = vars[i]
+					}
+					reply = append(reply, v)
+					continue
+				}
+
+				i := slices.IndexFunc(vars, func(v gosnmp.SnmpPDU) bool { return compareOIDs(v.Name, r.Name) > 0 })
+				if i < 0 {
+					reply = append(reply, gosnmp.SnmpPDU{Name: r.Name, Type: gosnmp.EndOfMibView})
+					continue
+				}
+				reply = append(reply, vars[i:min(i+2, len(vars))]...)
+			}
+
+			resp := &gosnmp.SnmpPacket{
+				Version:   gosnmp.Version2c,
+				Community: req.Community,
+				PDUType:   gosnmp.GetResponse,
+				RequestID: req.RequestID,
+				Variables: reply,
+			}
+			b, err := resp.MarshalMsg()
+			if err != nil {
+				continue
+			}
+			conn.WriteTo(b, addr)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func compareOIDs(a, b string) int {
+	pa := strings.Split(strings.TrimPrefix(a, "."), ".")
+	pb := strings.Split(strings.TrimPrefix(b, "."), ".")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		x, _ := strconv.Atoi(pa[i])
+		y, _ := strconv.Atoi(pb[i])
+		if x != y {
+			return x - y
+		}
+	}
+
+	return len(pa) - len(pb)
+}
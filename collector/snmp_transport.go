@@ -0,0 +1,303 @@
+package collector
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"mikrotik-exporter/config"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+const (
+	snmpPort             = "161"
+	snmpDefaultCommunity = "public"
+
+	oidSysDescr  = "1.3.6.1.2.1.1.1.0"
+	oidSysUpTime = "1.3.6.1.2.1.1.3.0"
+
+	oidIfType        = "1.3.6.1.2.1.2.2.1.3"
+	oidIfMtu         = "1.3.6.1.2.1.2.2.1.4"
+	oidIfAdminStatus = "1.3.6.1.2.1.2.2.1.7"
+	oidIfOperStatus  = "1.3.6.1.2.1.2.2.1.8"
+	oidIfInDiscards  = "1.3.6.1.2.1.2.2.1.13"
+	oidIfInErrors    = "1.3.6.1.2.1.2.2.1.14"
+	oidIfOutDiscards = "1.3.6.1.2.1.2.2.1.19"
+	oidIfOutErrors   = "1.3.6.1.2.1.2.2.1.20"
+
+	oidIfName              = "1.3.6.1.2.1.31.1.1.1.1"
+	oidIfHCInOctets        = "1.3.6.1.2.1.31.1.1.1.6"
+	oidIfHCInUcastPkts     = "1.3.6.1.2.1.31.1.1.1.7"
+	oidIfHCInMulticastPkts = "1.3.6.1.2.1.31.1.1.1.8"
+	oidIfHCInBroadcastPkts = "1.3.6.1.2.1.31.1.1.1.9"
+	oidIfHCOutOctets       = "1.3.6.1.2.1.31.1.1.1.10"
+	oidIfHCOutUcastPkts    = "1.3.6.1.2.1.31.1.1.1.11"
+	oidIfHCOutMulticastPkt = "1.3.6.1.2.1.31.1.1.1.12"
+	oidIfHCOutBroadcastPkt = "1.3.6.1.2.1.31.1.1.1.13"
+	oidIfAlias             = "1.3.6.1.2.1.31.1.1.1.18"
+
+	oidHrStorageDescr  = "1.3.6.1.2.1.25.2.3.1.3"
+	oidHrStorageUnits  = "1.3.6.1.2.1.25.2.3.1.4"
+	oidHrStorageSize   = "1.3.6.1.2.1.25.2.3.1.5"
+	oidHrStorageUsed   = "1.3.6.1.2.1.25.2.3.1.6"
+	oidHrProcessorLoad = "1.3.6.1.2.1.25.3.3.1.2"
+
+	oidMtxrLicVersion = "1.3.6.1.4.1.14988.1.1.4.4.0"
+	oidMtxrHlVoltage  = "1.3.6.1.4.1.14988.1.1.3.8.0"
+	oidMtxrHlTemp     = "1.3.6.1.4.1.14988.1.1.3.10.0"
+	oidMtxrHlCPUTemp  = "1.3.6.1.4.1.14988.1.1.3.11.0"
+	oidMtxrGaugeName  = "1.3.6.1.4.1.14988.1.1.3.100.1.2"
+	oidMtxrGaugeValue = "1.3.6.1.4.1.14988.1.1.3.100.1.3"
+	oidMtxrGaugeUnit  = "1.3.6.1.4.1.14988.1.1.3.100.1.4"
+
+	// units of gauges in tenths of volts, amperes and watts
+	mtxrGaugeDeciVolts = 3
+	mtxrGaugeDeciWatts = 5
+)
+
+// interface types of the IANAifType-MIB named like RouterOS does
+var snmpInterfaceTypes = map[string]string{
+	"6":   "ether",
+	"23":  "ppp",
+	"24":  "loopback",
+	"71":  "wlan",
+	"131": "tunnel",
+	"135": "vlan",
+	"209": "bridge",
+}
+
+// statCollector is implemented by collectors which export the metrics of a
+// sentence, whether it was fetched with the API or built from SNMP values.
+type statCollector interface {
+	collectForStat(re *proto.Sentence, ctx *collectorContext)
+}
+
+// snmpFetchers build the sentences of the collectors supported over SNMP
+var snmpFetchers = map[string]func(s *snmpClient) ([]*proto.Sentence, error){
+	"interface": snmpInterfaces,
+	"resource":  snmpResource,
+	"health":    snmpHealth,
+}
+
+// collectSNMP collects the interface, resource and health metrics of a device
+// which is only reachable over SNMP, traced in child spans of parent.
+func (c *collector) collectSNMP(d *config.Device, ch chan<- prometheus.Metric, sel *selection, parent *span) error {
+	s, err := c.dialSNMP(d)
+	if err != nil {
+		return &connectError{err}
+	}
+	defer s.close()
+
+	vars, err := s.get(oidSysUpTime)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"device": d.Name,
+			"error":  err,
+		}).Error("error querying device over snmp")
+		return &connectError{err}
+	}
+	// the uptime is in hundredths of a second
+	client := &apiClient{device: d}
+	if vars[0].exists() {
+		client.bootTime = c.statuses.bootTime(d.Name, time.Now().Add(-time.Duration(vars[0].num)*10*time.Millisecond))
+	}
+
+	for _, co := range sel.collectors {
+		fetch, ok := snmpFetchers[co.name]
+		if !ok || !c.runsOn(co, d) {
+			continue
+		}
+		sc, ok := co.routerOSCollector.(statCollector)
+		if !ok {
+			continue
+		}
+
+		fs := parent.child(co.name)
+		fs.setAttribute("collector", co.name)
+		stats, err := fetch(s)
+		fs.end(err)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"device":    d.Name,
+				"collector": co.name,
+				"error":     err,
+			}).Error("error fetching metrics over snmp")
+			return err
+		}
+		ctx := &collectorContext{ch, d, client, co.name, c.legacyMetricTypes}
+		for _, re := range stats {
+			sc.collectForStat(re, ctx)
+		}
+	}
+
+	return nil
+}
+
+func (c *collector) dialSNMP(d *config.Device) (*snmpClient, error) {
+	port := d.SNMP.Port
+	if port == "" {
+		port = snmpPort
+	}
+	community := d.SNMP.Community
+	if community == "" {
+		community = snmpDefaultCommunity
+	}
+	var source *net.UDPAddr
+	if ip := c.sourceIP(d); ip != nil {
+		source = &net.UDPAddr{IP: ip}
+	}
+	return dialSNMP(source, net.JoinHostPort(d.Address, port), community, c.deviceTimeout(d), c.retry.attempts)
+}
+
+// snmpInterfaces returns the interfaces like /interface/print.
+func snmpInterfaces(s *snmpClient) ([]*proto.Sentence, error) {
+	columns := []string{
+		oidIfName, oidIfType, oidIfMtu, oidIfAdminStatus, oidIfOperStatus, oidIfAlias,
+		oidIfHCInOctets, oidIfHCOutOctets,
+		oidIfHCInUcastPkts, oidIfHCInMulticastPkts, oidIfHCInBroadcastPkts,
+		oidIfHCOutUcastPkts, oidIfHCOutMulticastPkt, oidIfHCOutBroadcastPkt,
+		oidIfInErrors, oidIfOutErrors, oidIfInDiscards, oidIfOutDiscards,
+	}
+	table := make(map[string]map[string]snmpVar, len(columns))
+	for _, col := range columns {
+		values, err := s.walk(col)
+		if err != nil {
+			return nil, err
+		}
+		table[col] = values
+	}
+
+	sum := func(index string, cols ...string) string {
+		var total uint64
+		for _, col := range cols {
+			total += table[col][index].num
+		}
+		return strconv.FormatUint(total, 10)
+	}
+
+	var stats []*proto.Sentence
+	for index, name := range table[oidIfName] {
+		re := proto.NewSentence()
+		re.Map["name"] = name.text()
+		re.Map["type"] = table[oidIfType][index].text()
+		if t, ok := snmpInterfaceTypes[re.Map["type"]]; ok {
+			re.Map["type"] = t
+		}
+		re.Map["disabled"] = strconv.FormatBool(table[oidIfAdminStatus][index].num == 2)
+		re.Map["running"] = strconv.FormatBool(table[oidIfOperStatus][index].num == 1)
+		re.Map["comment"] = table[oidIfAlias][index].text()
+		re.Map["slave"] = "false"
+		re.Map["actual-mtu"] = table[oidIfMtu][index].text()
+		re.Map["rx-byte"] = table[oidIfHCInOctets][index].text()
+		re.Map["tx-byte"] = table[oidIfHCOutOctets][index].text()
+		re.Map["rx-packet"] = sum(index, oidIfHCInUcastPkts, oidIfHCInMulticastPkts, oidIfHCInBroadcastPkts)
+		re.Map["tx-packet"] = sum(index, oidIfHCOutUcastPkts, oidIfHCOutMulticastPkt, oidIfHCOutBroadcastPkt)
+		re.Map["rx-error"] = table[oidIfInErrors][index].text()
+		re.Map["tx-error"] = table[oidIfOutErrors][index].text()
+		re.Map["rx-drop"] = table[oidIfInDiscards][index].text()
+		re.Map["tx-drop"] = table[oidIfOutDiscards][index].text()
+		stats = append(stats, re)
+	}
+
+	return stats, nil
+}
+
+// snmpResource returns the system resources like /system/resource/print.
+func snmpResource(s *snmpClient) ([]*proto.Sentence, error) {
+	vars, err := s.get(oidSysDescr, oidSysUpTime, oidMtxrLicVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	re := proto.NewSentence()
+	re.Map["board-name"] = strings.TrimPrefix(vars[0].text(), "RouterOS ")
+	re.Map["uptime"] = fmt.Sprintf("%ds", vars[1].num/100)
+	if vars[2].exists() {
+		re.Map["version"] = vars[2].text()
+	}
+
+	load, err := s.walk(oidHrProcessorLoad)
+	if err != nil {
+		return nil, err
+	}
+	if len(load) > 0 {
+		var total uint64
+		for _, v := range load {
+			total += v.num
+		}
+		re.Map["cpu-load"] = strconv.FormatUint(total/uint64(len(load)), 10)
+	}
+
+	storage := make(map[string]map[string]snmpVar)
+	for _, col := range []string{oidHrStorageDescr, oidHrStorageUnits, oidHrStorageSize, oidHrStorageUsed} {
+		values, err := s.walk(col)
+		if err != nil {
+			return nil, err
+		}
+		storage[col] = values
+	}
+	for index, descr := range storage[oidHrStorageDescr] {
+		units := storage[oidHrStorageUnits][index].num
+		size := storage[oidHrStorageSize][index].num * units
+		free := size - storage[oidHrStorageUsed][index].num*units
+
+		switch descr.text() {
+		case "main memory":
+			re.Map["total-memory"] = strconv.FormatUint(size, 10)
+			re.Map["free-memory"] = strconv.FormatUint(free, 10)
+		case "system disk":
+			re.Map["total-hdd-space"] = strconv.FormatUint(size, 10)
+			re.Map["free-hdd-space"] = strconv.FormatUint(free, 10)
+		}
+	}
+
+	return []*proto.Sentence{re}, nil
+}
+
+// snmpHealth returns the health values like /system/health/print on RouterOS
+// 6. RouterOS 7 exports them in a table of gauges.
+func snmpHealth(s *snmpClient) ([]*proto.Sentence, error) {
+	re := proto.NewSentence()
+
+	names, err := s.walk(oidMtxrGaugeName)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) > 0 {
+		values, err := s.walk(oidMtxrGaugeValue)
+		if err != nil {
+			return nil, err
+		}
+		units, err := s.walk(oidMtxrGaugeUnit)
+		if err != nil {
+			return nil, err
+		}
+		for index, name := range names {
+			v := float64(int64(values[index].num))
+			if u := units[index].num; u >= mtxrGaugeDeciVolts && u <= mtxrGaugeDeciWatts {
+				v /= 10
+			}
+			re.Map[name.text()] = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+
+		return []*proto.Sentence{re}, nil
+	}
+
+	vars, err := s.get(oidMtxrHlVoltage, oidMtxrHlTemp, oidMtxrHlCPUTemp)
+	if err != nil {
+		return nil, err
+	}
+	for i, name := range []string{"voltage", "temperature", "cpu-temperature"} {
+		if vars[i].exists() {
+			// in tenths of volts and degrees
+			re.Map[name] = strconv.FormatFloat(float64(int64(vars[i].num))/10, 'f', -1, 64)
+		}
+	}
+
+	return []*proto.Sentence{re}, nil
+}
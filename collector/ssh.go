@@ -0,0 +1,253 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mikrotik-exporter/config"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"gopkg.in/routeros.v2"
+	"gopkg.in/routeros.v2/proto"
+)
+
+const sshPort = "22"
+
+// terseProperty matches the start of a property in the terse output of print
+var terseProperty = regexp.MustCompile(`(?:^|\s)([a-z0-9.-]+)=`)
+
+// measurement matches the values monitor commands print with a unit the API
+// omits
+var measurement = regexp.MustCompile(`^(-?[0-9]+(?:\.[0-9]+)?)(?:C|V|W|mA|dBm|nm|m|%)$`)
+
+// sshRunner runs the commands of a device which are not reachable or broken
+// through the API on the command line instead. The connection is established
+// with the first command.
+type sshRunner struct {
+	device  *config.Device
+	timeout time.Duration
+	dial    func(address string) (net.Conn, error)
+
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+func newSSHRunner(d *config.Device, timeout time.Duration, dial func(address string) (net.Conn, error)) *sshRunner {
+	return &sshRunner{device: d, timeout: timeout, dial: dial}
+}
+
+// handles returns whether the command is configured to run over SSH.
+func (r *sshRunner) handles(command string) bool {
+	return slices.Contains(r.device.SSH.Commands, command)
+}
+
+// run runs the API command on the command line and parses the output into
+// the reply the API would have sent.
+func (r *sshRunner) run(sentence ...string) (*routeros.Reply, error) {
+	cl, err := r.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := cl.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	out, err := session.Output(commandLine(sentence))
+	if err != nil {
+		return nil, fmt.Errorf("error running %s over ssh: %w", sentence[0], err)
+	}
+
+	reply := &routeros.Reply{}
+	if strings.HasSuffix(sentence[0], "/print") {
+		reply.Re = parseTerse(string(out))
+	} else {
+		reply.Re = parseMonitor(string(out), items(sentence))
+	}
+
+	return reply, nil
+}
+
+func (r *sshRunner) connect() (*ssh.Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.client != nil {
+		return r.client, nil
+	}
+
+	user, password, err := r.device.Credentials()
+	if err != nil {
+		return nil, err
+	}
+
+	hostKeyCallback := ssh.InsecureIgnoreHostKey()
+	if r.device.SSH.KnownHosts != "" {
+		hostKeyCallback, err = knownhosts.New(r.device.SSH.KnownHosts)
+		if err != nil {
+			return nil, err
+		}
+	} else if !r.device.SSH.Insecure {
+		return nil, errors.New("missing known_hosts to verify the ssh host key")
+	}
+
+	port := r.device.SSH.Port
+	if port == "" {
+		port = sshPort
+	}
+
+	address := net.JoinHostPort(r.device.Address, port)
+	conn, err := r.dial(address)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = conn.SetDeadline(time.Now().Add(r.timeout))
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, address, &ssh.ClientConfig{
+		User:            user,
+		Auth:            []ssh.AuthMethod{ssh.Password(password)},
+		HostKeyCallback: hostKeyCallback,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Time{})
+	r.client = ssh.NewClient(sshConn, chans, reqs)
+
+	return r.client, nil
+}
+
+func (r *sshRunner) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.client != nil {
+		r.client.Close()
+		r.client = nil
+	}
+}
+
+// commandLine translates an API sentence into a console command, e.g.
+// /interface/ethernet/monitor =numbers=ether1 =once= into
+// /interface ethernet monitor numbers="ether1" once.
+func commandLine(sentence []string) string {
+	path := strings.Split(strings.TrimPrefix(sentence[0], "/"), "/")
+	words := []string{"/" + path[0]}
+	words = append(words, path[1:]...)
+
+	isPrint := path[len(path)-1] == "print"
+	if isPrint {
+		words = append(words, "terse", "without-paging")
+	}
+
+	var where []string
+	for _, w := range sentence[1:] {
+		switch {
+		case strings.HasPrefix(w, "=.proplist="):
+			if isPrint {
+				words = append(words, "proplist="+strings.TrimPrefix(w, "=.proplist="))
+			}
+		case strings.HasPrefix(w, "="):
+			key, value, _ := strings.Cut(w[1:], "=")
+			if value == "" {
+				words = append(words, key)
+			} else {
+				words = append(words, key+"="+strconv.Quote(value))
+			}
+		case strings.HasPrefix(w, "?"):
+			key, value, _ := strings.Cut(w[1:], "=")
+			where = append(where, key+"="+strconv.Quote(value))
+		}
+	}
+	if len(where) > 0 {
+		words = append(words, "where")
+		words = append(words, strings.Join(where, " and "))
+	}
+
+	return strings.Join(words, " ")
+}
+
+// items returns the number of items a monitor command is run for.
+func items(sentence []string) int {
+	for _, w := range sentence[1:] {
+		if numbers, ok := strings.CutPrefix(w, "=numbers="); ok {
+			return len(strings.Split(numbers, ","))
+		}
+	}
+
+	return 1
+}
+
+// parseTerse parses the output of print terse, a line per item with the
+// number, the flags and the properties.
+func parseTerse(out string) []*proto.Sentence {
+	var sentences []*proto.Sentence
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimRight(line, "\r")
+		matches := terseProperty.FindAllStringSubmatchIndex(line, -1)
+		if len(matches) == 0 {
+			continue
+		}
+
+		re := proto.NewSentence()
+		for i, m := range matches {
+			end := len(line)
+			if i+1 < len(matches) {
+				end = matches[i+1][0]
+			}
+			re.Map[line[m[2]:m[3]]] = strings.TrimSpace(line[m[1]:end])
+		}
+		sentences = append(sentences, re)
+	}
+
+	return sentences
+}
+
+// parseMonitor parses the output of a monitor command, a line per property
+// with a column per item.
+func parseMonitor(out string, items int) []*proto.Sentence {
+	sentences := make([]*proto.Sentence, items)
+	for i := range sentences {
+		sentences[i] = proto.NewSentence()
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		if items == 1 {
+			sentences[0].Map[key] = withoutUnit(value)
+			continue
+		}
+		for i, v := range strings.Fields(value) {
+			if i < items {
+				sentences[i].Map[key] = withoutUnit(v)
+			}
+		}
+	}
+
+	return sentences
+}
+
+func withoutUnit(value string) string {
+	if m := measurement.FindStringSubmatch(value); m != nil {
+		return m[1]
+	}
+
+	return value
+}
@@ -0,0 +1,39 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandLine(t *testing.T) {
+	assert.Equal(t, `/interface ethernet monitor numbers="sfp1,sfp2" once`,
+		commandLine([]string{"/interface/ethernet/monitor", "=numbers=sfp1,sfp2", "=once=", "=.proplist=name,sfp-temperature"}))
+	assert.Equal(t, `/tool netwatch print terse without-paging proplist=host,status where disabled="false"`,
+		commandLine([]string{"/tool/netwatch/print", "?disabled=false", "=.proplist=host,status"}))
+}
+
+func TestParseTerse(t *testing.T) {
+	out := " 0   host=10.0.0.1 status=up comment=core router\r\n" +
+		" 1 X host=10.0.0.2 status=unknown\r\n\r\n"
+
+	sentences := parseTerse(out)
+	assert.Equal(t, 2, len(sentences))
+	assert.Equal(t, "10.0.0.1", sentences[0].Map["host"])
+	assert.Equal(t, "core router", sentences[0].Map["comment"])
+	assert.Equal(t, "unknown", sentences[1].Map["status"])
+}
+
+func TestParseMonitor(t *testing.T) {
+	out := "                 name: sfp1   sfp2\n" +
+		"               status: link-ok no-link\n" +
+		"      sfp-temperature: 41C    38C\n"
+
+	sentences := parseMonitor(out, 2)
+	assert.Equal(t, "sfp2", sentences[1].Map["name"])
+	assert.Equal(t, "link-ok", sentences[0].Map["status"])
+	assert.Equal(t, "38", sentences[1].Map["sfp-temperature"])
+
+	sentences = parseMonitor("  status: link ok\n", 1)
+	assert.Equal(t, "link ok", sentences[0].Map["status"])
+}
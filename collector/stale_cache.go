@@ -0,0 +1,82 @@
+package collector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var staleDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "scrape", "stale_seconds"),
+	"mikrotik_exporter: age of the metrics served for a device, non-zero if the last scrape failed",
+	[]string{"device"},
+	nil,
+)
+
+// staleEntry holds the metrics of the last successful scrape of a device.
+type staleEntry struct {
+	metrics []prometheus.Metric
+	taken   time.Time
+}
+
+// staleCache keeps the metrics of the last successful scrape per device to be
+// served for a grace period when the device is unreachable.
+type staleCache struct {
+	mu      sync.Mutex
+	grace   time.Duration
+	entries map[string]staleEntry
+}
+
+func newStaleCache(grace time.Duration) *staleCache {
+	return &staleCache{
+		grace:   grace,
+		entries: make(map[string]staleEntry),
+	}
+}
+
+func (s *staleCache) store(device string, metrics []prometheus.Metric) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[device] = staleEntry{metrics: metrics, taken: time.Now()}
+}
+
+// load returns the last metrics of the device if they are within the grace
+// period.
+func (s *staleCache) load(device string) (staleEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[device]
+	if !ok {
+		return staleEntry{}, false
+	}
+	if time.Since(e.taken) > s.grace {
+		delete(s.entries, device)
+		return staleEntry{}, false
+	}
+
+	return e, true
+}
+
+// collectBuffered runs collect with a buffering channel and returns the
+// metrics it sent.
+func collectBuffered(collect func(ch chan<- prometheus.Metric) error) ([]prometheus.Metric, error) {
+	buf := make(chan prometheus.Metric)
+	done := make(chan struct{})
+
+	var metrics []prometheus.Metric
+	go func() {
+		for m := range buf {
+			metrics = append(metrics, m)
+		}
+		close(done)
+	}()
+
+	err := collect(buf)
+	close(buf)
+	<-done
+
+	return metrics, err
+}
@@ -0,0 +1,69 @@
+package collector
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+
+	"mikrotik-exporter/config"
+)
+
+var testStaleDesc = prometheus.NewDesc("test_value", "test", []string{"device"}, nil)
+
+func staleTestMetric(v float64) prometheus.Metric {
+	return prometheus.MustNewConstMetric(testStaleDesc, prometheus.GaugeValue, v, "r1")
+}
+
+// serveStale returns the test values and the stale age served.
+func serveStale(t *testing.T, c *collector, metrics []prometheus.Metric, err error) ([]float64, float64) {
+	ch := make(chan prometheus.Metric, 10)
+	_ = c.serveWithStaleFallback(&config.Device{Name: "r1"}, "r1", ch, metrics, err)
+	close(ch)
+
+	var (
+		values []float64
+		age    float64
+	)
+	for m := range ch {
+		var out dto.Metric
+		assert.NoError(t, m.Write(&out))
+		if m.Desc() == staleDesc {
+			age = out.GetGauge().GetValue()
+			continue
+		}
+		values = append(values, out.GetGauge().GetValue())
+	}
+
+	return values, age
+}
+
+func TestStaleFallbackWithoutEntry(t *testing.T) {
+	c := &collector{stale: newStaleCache(time.Minute)}
+
+	// the partial metrics are served if there is nothing stale to serve
+	values, age := serveStale(t, c, []prometheus.Metric{staleTestMetric(1)}, &connectError{io.EOF})
+	assert.Equal(t, []float64{1}, values)
+	assert.Equal(t, 0.0, age)
+}
+
+func TestStaleFallbackCollectorError(t *testing.T) {
+	c := &collector{stale: newStaleCache(time.Minute)}
+	serveStale(t, c, []prometheus.Metric{staleTestMetric(1)}, nil)
+
+	// a failing collector of a reachable device keeps the fresh metrics
+	values, age := serveStale(t, c, []prometheus.Metric{staleTestMetric(2)}, errors.New("no such command"))
+	assert.Equal(t, []float64{2}, values)
+	assert.Equal(t, 0.0, age)
+
+	// the stale metrics are served for unreachable devices
+	values, _ = serveStale(t, c, nil, &connectError{io.EOF})
+	assert.Equal(t, []float64{1}, values)
+
+	values, _ = serveStale(t, c, nil, errCircuitOpen)
+	assert.Equal(t, []float64{1}, values)
+}
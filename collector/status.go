@@ -0,0 +1,170 @@
+package collector
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"mikrotik-exporter/config"
+)
+
+// DeviceStatus is the result of the last scrape of a device.
+type DeviceStatus struct {
+	Name    string
+	Address string
+	// Scraped is the time the last scrape started, zero if the device was
+	// not scraped yet
+	Scraped  time.Time
+	Duration time.Duration
+	// Error is the error of the last scrape, empty if it succeeded
+	Error      string
+	Collectors []string
+	// Version is the RouterOS version of the device, empty if unknown
+	Version string
+}
+
+// deviceStatuses holds the results of the last scrape of each device.
+type deviceStatuses struct {
+	mu        sync.Mutex
+	devices   map[string]DeviceStatus
+	versions  map[string]string
+	bootTimes map[string]time.Time
+}
+
+// bootTimeTolerance is the difference between the boot times computed from
+// the uptime of a device, which has a resolution of a second and is read with
+// some latency, within which the device is taken not to have rebooted.
+const bootTimeTolerance = 5 * time.Second
+
+func newDeviceStatuses() *deviceStatuses {
+	return &deviceStatuses{
+		devices:   make(map[string]DeviceStatus),
+		versions:  make(map[string]string),
+		bootTimes: make(map[string]time.Time),
+	}
+}
+
+// bootTime returns the boot time of the device computed on an earlier scrape
+// unless the device rebooted since, so that the created timestamps of its
+// counters don't drift between scrapes.
+func (s *deviceStatuses) bootTime(device string, booted time.Time) time.Time {
+	if booted.IsZero() {
+		return booted
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if last, ok := s.bootTimes[device]; ok {
+		if d := booted.Sub(last); d > -bootTimeTolerance && d < bootTimeTolerance {
+			return last
+		}
+	}
+	booted = booted.Truncate(time.Second)
+	s.bootTimes[device] = booted
+
+	return booted
+}
+
+func (s *deviceStatuses) setVersion(device, version string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.versions[device] = version
+}
+
+func (s *deviceStatuses) record(d *config.Device, begin time.Time, duration time.Duration, err error, collectors []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := DeviceStatus{
+		Name:       d.Name,
+		Address:    d.Address,
+		Scraped:    begin,
+		Duration:   duration,
+		Collectors: collectors,
+		Version:    s.versions[d.Name],
+	}
+	if err != nil {
+		status.Error = err.Error()
+	}
+	s.devices[d.Name] = status
+}
+
+// Status returns the result of the last scrape of each device, including the
+// configured devices which were not scraped yet, ordered by name.
+func (c *collector) Status() []DeviceStatus {
+	c.statuses.mu.Lock()
+	defer c.statuses.mu.Unlock()
+
+	statuses := make([]DeviceStatus, 0, len(c.statuses.devices))
+	for _, s := range c.statuses.devices {
+		statuses = append(statuses, s)
+	}
+	for _, d := range c.devices {
+		if _, ok := c.statuses.devices[d.Name]; !ok && d.Address != "" {
+			statuses = append(statuses, DeviceStatus{Name: d.Name, Address: d.Address})
+		}
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+
+	return statuses
+}
+
+// Reachable returns the number of devices which were reachable and the
+// number of devices. Devices are reachable if their last scrape succeeded, or
+// if they can be connected to if they were not scraped yet.
+func (c *collector) Reachable() (int, int) {
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		reachable int
+		total     int
+	)
+
+	for _, d := range c.Devices() {
+		if d.Address == "" {
+			continue
+		}
+		total++
+
+		c.statuses.mu.Lock()
+		s, scraped := c.statuses.devices[d.Name]
+		c.statuses.mu.Unlock()
+		if scraped {
+			if s.Error == "" {
+				reachable++
+			}
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if c.probe(&d) == nil {
+				mu.Lock()
+				reachable++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return reachable, total
+}
+
+// enabledCollectors returns the names of the collectors run against the
+// device.
+func (c *collector) enabledCollectors(d *config.Device) []string {
+	var names []string
+	for _, co := range c.collectors {
+		if c.runsOn(co, d) {
+			names = append(names, co.name)
+		}
+	}
+
+	return names
+}
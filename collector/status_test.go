@@ -0,0 +1,27 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBootTime(t *testing.T) {
+	s := newDeviceStatuses()
+	booted := time.Date(2024, 1, 1, 0, 0, 0, 400000000, time.UTC)
+
+	first := s.bootTime("r1", booted)
+	assert.Equal(t, booted.Truncate(time.Second), first)
+	// the uptime resolution and latency don't move the boot time
+	assert.Equal(t, first, s.bootTime("r1", booted.Add(900*time.Millisecond)))
+	assert.Equal(t, first, s.bootTime("r1", booted.Add(-time.Second)))
+	// other devices are tracked independently
+	assert.Equal(t, booted.Add(time.Hour).Truncate(time.Second), s.bootTime("r2", booted.Add(time.Hour)))
+
+	// a reboot does
+	rebooted := booted.Add(time.Hour)
+	assert.Equal(t, rebooted.Truncate(time.Second), s.bootTime("r1", rebooted))
+
+	assert.True(t, s.bootTime("r1", time.Time{}).IsZero())
+}
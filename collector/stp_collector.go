@@ -0,0 +1,163 @@
+package collector
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+// stpPortRoles are the roles a bridge port can have in the spanning tree.
+var stpPortRoles = []string{"root-port", "designated-port", "alternate-port", "backup-port", "disabled-port"}
+
+type stpCollector struct {
+	portProps       []string
+	roleDesc        *prometheus.Desc
+	forwardingDesc  *prometheus.Desc
+	learningDesc    *prometheus.Desc
+	pathCostDesc    *prometheus.Desc
+	designatedDesc  *prometheus.Desc
+	rootBridgeDesc  *prometheus.Desc
+	rootInfoDesc    *prometheus.Desc
+	rootCostDesc    *prometheus.Desc
+	topologyChanges *prometheus.Desc
+}
+
+func newSTPCollector() routerOSCollector {
+	c := &stpCollector{}
+	c.init()
+	return c
+}
+
+func (c *stpCollector) init() {
+	c.portProps = []string{"interface", "bridge", "role", "forwarding", "learning", "path-cost", "designated-bridge"}
+
+	const prefix = "bridge"
+	portLabels := []string{"name", "address", "bridge", "port"}
+	bridgeLabels := []string{"name", "address", "bridge"}
+	c.roleDesc = description(prefix, "port_role", "spanning tree role of the bridge port, 1 for the current role", append(portLabels, "role"))
+	c.forwardingDesc = description(prefix, "port_forwarding", "whether the bridge port is forwarding", portLabels)
+	c.learningDesc = description(prefix, "port_learning", "whether the bridge port is learning", portLabels)
+	c.pathCostDesc = description(prefix, "port_path_cost", "spanning tree path cost of the bridge port", portLabels)
+	c.designatedDesc = description(prefix, "port_designated_bridge_info", "designated bridge of the bridge port", append(portLabels, "designated_bridge"))
+	c.rootBridgeDesc = description(prefix, "root_bridge", "whether the bridge is the spanning tree root", bridgeLabels)
+	c.rootInfoDesc = description(prefix, "root_bridge_info", "spanning tree root bridge seen by the bridge", append(bridgeLabels, "root_bridge_id"))
+	c.rootCostDesc = description(prefix, "root_path_cost", "spanning tree path cost to the root bridge", bridgeLabels)
+	c.topologyChanges = description(prefix, "topology_changes", "number of spanning tree topology changes", bridgeLabels)
+}
+
+func (c *stpCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.roleDesc
+	ch <- c.forwardingDesc
+	ch <- c.learningDesc
+	ch <- c.pathCostDesc
+	ch <- c.designatedDesc
+	ch <- c.rootBridgeDesc
+	ch <- c.rootInfoDesc
+	ch <- c.rootCostDesc
+	ch <- c.topologyChanges
+}
+
+func (c *stpCollector) collect(ctx *collectorContext) error {
+	err := c.collectPorts(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.collectBridges(ctx)
+}
+
+func (c *stpCollector) collectPorts(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/interface/bridge/port/print", "?disabled=false", "=.proplist="+strings.Join(c.portProps, ","))
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching bridge port metrics")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		c.collectForPort(re, ctx)
+	}
+
+	return nil
+}
+
+func (c *stpCollector) collectForPort(re *proto.Sentence, ctx *collectorContext) {
+	labelValues := []string{ctx.device.Name, ctx.device.Address, re.Map["bridge"], re.Map["interface"]}
+
+	if role := re.Map["role"]; role != "" {
+		for _, r := range stpPortRoles {
+			v := 0.0
+			if r == role {
+				v = 1.0
+			}
+			ctx.ch <- prometheus.MustNewConstMetric(c.roleDesc, prometheus.GaugeValue, v, append(labelValues, r)...)
+		}
+	}
+
+	ctx.ch <- prometheus.MustNewConstMetric(c.forwardingDesc, prometheus.GaugeValue, boolToFloat(re.Map["forwarding"]), labelValues...)
+	ctx.ch <- prometheus.MustNewConstMetric(c.learningDesc, prometheus.GaugeValue, boolToFloat(re.Map["learning"]), labelValues...)
+
+	if cost, err := strconv.ParseFloat(re.Map["path-cost"], 64); err == nil {
+		ctx.ch <- prometheus.MustNewConstMetric(c.pathCostDesc, prometheus.GaugeValue, cost, labelValues...)
+	}
+
+	if b := re.Map["designated-bridge"]; b != "" {
+		ctx.ch <- prometheus.MustNewConstMetric(c.designatedDesc, prometheus.GaugeValue, 1, append(labelValues, b)...)
+	}
+}
+
+func (c *stpCollector) collectBridges(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/interface/bridge/print", "?disabled=false", "=.proplist=name")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching bridges")
+		return err
+	}
+	if len(reply.Re) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(reply.Re))
+	for _, re := range reply.Re {
+		names = append(names, re.Map["name"])
+	}
+
+	reply, err = ctx.client.Run("/interface/bridge/monitor", "=numbers="+strings.Join(names, ","), "=once=")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching bridge monitor metrics")
+		return err
+	}
+
+	for i, re := range reply.Re {
+		if i >= len(names) {
+			break
+		}
+		c.collectForBridge(names[i], re, ctx)
+	}
+
+	return nil
+}
+
+func (c *stpCollector) collectForBridge(bridge string, re *proto.Sentence, ctx *collectorContext) {
+	labelValues := []string{ctx.device.Name, ctx.device.Address, bridge}
+
+	if re.Map["root-bridge"] != "" {
+		ctx.ch <- prometheus.MustNewConstMetric(c.rootBridgeDesc, prometheus.GaugeValue, boolToFloat(re.Map["root-bridge"]), labelValues...)
+	}
+	if id := re.Map["root-bridge-id"]; id != "" {
+		ctx.ch <- prometheus.MustNewConstMetric(c.rootInfoDesc, prometheus.GaugeValue, 1, append(labelValues, id)...)
+	}
+	if cost, err := strconv.ParseFloat(re.Map["root-path-cost"], 64); err == nil {
+		ctx.ch <- prometheus.MustNewConstMetric(c.rootCostDesc, prometheus.GaugeValue, cost, labelValues...)
+	}
+	if changes, err := strconv.ParseFloat(re.Map["topology-change-count"], 64); err == nil {
+		ctx.ch <- prometheus.MustNewConstMetric(c.topologyChanges, prometheus.CounterValue, changes, labelValues...)
+	}
+}
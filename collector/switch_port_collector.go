@@ -0,0 +1,93 @@
+package collector
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+type switchPortCollector struct {
+	props        []string
+	descriptions map[string]*prometheus.Desc
+}
+
+func newSwitchPortCollector() routerOSCollector {
+	c := &switchPortCollector{}
+	c.init()
+	return c
+}
+
+func (c *switchPortCollector) init() {
+	c.props = []string{"name", "switch",
+		"rx-bytes", "tx-bytes", "rx-broadcast", "tx-broadcast", "rx-multicast", "tx-multicast",
+		"rx-drop", "tx-drop", "rx-pause", "tx-pause", "rx-fcs-error", "rx-align-error", "rx-fragment",
+		"rx-overflow", "rx-too-short", "rx-too-long", "tx-collision", "tx-excessive-collision", "tx-late-collision"}
+	labelNames := []string{"name", "address", "switch", "port"}
+	c.descriptions = make(map[string]*prometheus.Desc)
+	for _, p := range c.props[2:] {
+		c.descriptions[p] = descriptionForPropertyName("switch_port", p, labelNames)
+	}
+}
+
+func (c *switchPortCollector) describe(ch chan<- *prometheus.Desc) {
+	for _, d := range c.descriptions {
+		ch <- d
+	}
+}
+
+func (c *switchPortCollector) collect(ctx *collectorContext) error {
+	stats, err := c.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, re := range stats {
+		c.collectForStat(re, ctx)
+	}
+
+	return nil
+}
+
+func (c *switchPortCollector) fetch(ctx *collectorContext) ([]*proto.Sentence, error) {
+	reply, err := ctx.client.Run("/interface/ethernet/switch/port/print", "=stats=", "=.proplist="+strings.Join(c.props, ","))
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching switch port metrics")
+		return nil, err
+	}
+
+	return reply.Re, nil
+}
+
+func (c *switchPortCollector) collectForStat(re *proto.Sentence, ctx *collectorContext) {
+	labels := ctx.rowLabels(c.descriptions[c.props[2]], ctx.device.Name, ctx.device.Address, re.Map["switch"], re.Map["name"])
+	for _, p := range c.props[2:] {
+		c.collectMetricForProperty(p, re, labels, ctx)
+	}
+}
+
+// collectMetricForProperty exports a single counter. Switch chips differ in
+// the counters they keep, missing ones are skipped.
+func (c *switchPortCollector) collectMetricForProperty(property string, re *proto.Sentence, labels *rowLabels, ctx *collectorContext) {
+	value := re.Map[property]
+	if value == "" {
+		return
+	}
+
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"port":     re.Map["name"],
+			"property": property,
+			"value":    value,
+			"error":    err,
+		}).Error("error parsing switch port metric value")
+		return
+	}
+
+	ctx.ch <- labels.counter(c.descriptions[property], v)
+}
@@ -0,0 +1,236 @@
+package collector
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"mikrotik-exporter/config"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+const (
+	defaultTorchDuration   = 3 * time.Second
+	defaultTorchInterval   = 5 * time.Minute
+	defaultTorchTop        = 10
+	defaultTorchIPv4Prefix = 32
+	defaultTorchIPv6Prefix = 64
+)
+
+// talker is the throughput of an aggregated address prefix in bits per second.
+type talker struct {
+	prefix string
+	rate   float64
+}
+
+// torchSample is the result of a single torch run on a device.
+type torchSample struct {
+	taken   time.Time
+	sources []talker
+	dests   []talker
+}
+
+type torchCollector struct {
+	cfg         config.TorchConfig
+	sourceDesc  *prometheus.Desc
+	destDesc    *prometheus.Desc
+	sampledDesc *prometheus.Desc
+
+	mu      sync.Mutex
+	samples map[string]*torchSample
+}
+
+func newTorchCollector(cfg config.TorchConfig) routerOSCollector {
+	if cfg.Duration == 0 {
+		cfg.Duration = defaultTorchDuration
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = defaultTorchInterval
+	}
+	if cfg.Top == 0 {
+		cfg.Top = defaultTorchTop
+	}
+	if cfg.IPv4Prefix == 0 {
+		cfg.IPv4Prefix = defaultTorchIPv4Prefix
+	}
+	if cfg.IPv6Prefix == 0 {
+		cfg.IPv6Prefix = defaultTorchIPv6Prefix
+	}
+
+	const prefix = "torch"
+
+	labelNames := []string{"name", "address", "interface", "prefix"}
+	return &torchCollector{
+		cfg:         cfg,
+		sourceDesc:  description(prefix, "source_bits_per_second", "throughput of the top source prefixes seen by torch", labelNames),
+		destDesc:    description(prefix, "destination_bits_per_second", "throughput of the top destination prefixes seen by torch", labelNames),
+		sampledDesc: description(prefix, "last_sample_timestamp_seconds", "time of the last torch sample", []string{"name", "address", "interface"}),
+		samples:     make(map[string]*torchSample),
+	}
+}
+
+func (c *torchCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.sourceDesc
+	ch <- c.destDesc
+	ch <- c.sampledDesc
+}
+
+func (c *torchCollector) collect(ctx *collectorContext) error {
+	if c.cfg.Interface == "" {
+		return nil
+	}
+
+	s, err := c.sample(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range s.sources {
+		ctx.ch <- prometheus.MustNewConstMetric(c.sourceDesc, prometheus.GaugeValue, t.rate, ctx.device.Name, ctx.device.Address, c.cfg.Interface, t.prefix)
+	}
+	for _, t := range s.dests {
+		ctx.ch <- prometheus.MustNewConstMetric(c.destDesc, prometheus.GaugeValue, t.rate, ctx.device.Name, ctx.device.Address, c.cfg.Interface, t.prefix)
+	}
+	ctx.ch <- prometheus.MustNewConstMetric(c.sampledDesc, prometheus.GaugeValue, float64(s.taken.Unix()), ctx.device.Name, ctx.device.Address, c.cfg.Interface)
+
+	return nil
+}
+
+// sample returns the cached sample for the device, running torch only if the
+// previous sample is older than the configured interval.
+func (c *torchCollector) sample(ctx *collectorContext) (*torchSample, error) {
+	c.mu.Lock()
+	s, ok := c.samples[ctx.device.Name]
+	c.mu.Unlock()
+	if ok && time.Since(s.taken) < c.cfg.Interval {
+		return s, nil
+	}
+
+	entries, err := c.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s = &torchSample{taken: time.Now()}
+	s.sources, s.dests = c.aggregate(ctx, entries)
+
+	c.mu.Lock()
+	c.samples[ctx.device.Name] = s
+	c.mu.Unlock()
+
+	return s, nil
+}
+
+func (c *torchCollector) fetch(ctx *collectorContext) ([]*proto.Sentence, error) {
+	reply, err := ctx.client.Run("/tool/torch",
+		"=interface="+c.cfg.Interface,
+		"=src-address=0.0.0.0/0",
+		"=dst-address=0.0.0.0/0",
+		"=src-address6=::/0",
+		"=dst-address6=::/0",
+		fmt.Sprintf("=duration=%ds", int(c.cfg.Duration.Seconds())))
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"interface": c.cfg.Interface,
+			"error":     err,
+		}).Error("error fetching torch metrics")
+		return nil, err
+	}
+
+	// torch reports a new section every second, only the last one is
+	// representative for the current throughput
+	last := 0
+	for _, re := range reply.Re {
+		if sec, err := strconv.Atoi(re.Map[".section"]); err == nil && sec > last {
+			last = sec
+		}
+	}
+	entries := make([]*proto.Sentence, 0, len(reply.Re))
+	for _, re := range reply.Re {
+		if sec, _ := strconv.Atoi(re.Map[".section"]); sec == last {
+			entries = append(entries, re)
+		}
+	}
+
+	return entries, nil
+}
+
+func (c *torchCollector) aggregate(ctx *collectorContext, entries []*proto.Sentence) ([]talker, []talker) {
+	sources := make(map[string]float64)
+	dests := make(map[string]float64)
+
+	for _, re := range entries {
+		rate, err := torchRate(re)
+		if err != nil {
+			ctx.logger().WithFields(log.Fields{
+				"interface": c.cfg.Interface,
+				"error":     err,
+			}).Error("error parsing torch metric value")
+			continue
+		}
+
+		if p := c.prefixFor(re.Map["src-address"] + re.Map["src-address6"]); p != "" {
+			sources[p] += rate
+		}
+		if p := c.prefixFor(re.Map["dst-address"] + re.Map["dst-address6"]); p != "" {
+			dests[p] += rate
+		}
+	}
+
+	return topTalkers(sources, c.cfg.Top), topTalkers(dests, c.cfg.Top)
+}
+
+func (c *torchCollector) prefixFor(address string) string {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return ""
+	}
+
+	if ip4 := ip.To4(); ip4 != nil {
+		return fmt.Sprintf("%s/%d", ip4.Mask(net.CIDRMask(c.cfg.IPv4Prefix, 32)), c.cfg.IPv4Prefix)
+	}
+
+	return fmt.Sprintf("%s/%d", ip.Mask(net.CIDRMask(c.cfg.IPv6Prefix, 128)), c.cfg.IPv6Prefix)
+}
+
+func torchRate(re *proto.Sentence) (float64, error) {
+	var rate float64
+	for _, p := range []string{"tx", "rx"} {
+		if re.Map[p] == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(re.Map[p], 64)
+		if err != nil {
+			return 0, err
+		}
+		rate += v
+	}
+
+	return rate, nil
+}
+
+func topTalkers(rates map[string]float64, n int) []talker {
+	talkers := make([]talker, 0, len(rates))
+	for p, r := range rates {
+		talkers = append(talkers, talker{prefix: p, rate: r})
+	}
+
+	sort.Slice(talkers, func(i, j int) bool {
+		if talkers[i].rate == talkers[j].rate {
+			return talkers[i].prefix < talkers[j].prefix
+		}
+		return talkers[i].rate > talkers[j].rate
+	})
+
+	if len(talkers) > n {
+		talkers = talkers[:n]
+	}
+
+	return talkers
+}
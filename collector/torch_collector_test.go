@@ -0,0 +1,29 @@
+package collector
+
+import (
+	"testing"
+
+	"mikrotik-exporter/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTorchPrefixFor(t *testing.T) {
+	c := newTorchCollector(config.TorchConfig{IPv4Prefix: 24, IPv6Prefix: 48}).(*torchCollector)
+
+	assert.Equal(t, "192.168.88.0/24", c.prefixFor("192.168.88.17"))
+	assert.Equal(t, "2001:db8:1::/48", c.prefixFor("2001:db8:1:2::1"))
+	assert.Equal(t, "", c.prefixFor(""))
+}
+
+func TestTopTalkers(t *testing.T) {
+	rates := map[string]float64{
+		"10.0.0.0/24": 100,
+		"10.0.1.0/24": 300,
+		"10.0.2.0/24": 200,
+	}
+
+	top := topTalkers(rates, 2)
+
+	assert.Equal(t, []talker{{"10.0.1.0/24", 300}, {"10.0.2.0/24", 200}}, top)
+}
@@ -0,0 +1,128 @@
+package collector
+
+import (
+	"encoding/binary"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Tracer exports the spans of a scrape, e.g. to an OpenTelemetry collector.
+// Export is called once the scrape finished and must not block it.
+type Tracer interface {
+	Export(spans []Span)
+}
+
+// Span is a timed step of a scrape: the scrape itself, the scrape of a
+// device, a collector or a command sent to the device.
+type Span struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	// ParentID is zero for the span of the scrape
+	ParentID   [8]byte
+	Name       string
+	Start, End time.Time
+	// Attributes hold string, bool and int64 values
+	Attributes map[string]any
+	Err        error
+}
+
+// WithTracer exports a trace of each scrape with a span per device, collector
+// and command.
+func WithTracer(t Tracer) Option {
+	return func(c *collector) {
+		c.tracer = t
+	}
+}
+
+// trace collects the finished spans of a scrape.
+type trace struct {
+	mu    sync.Mutex
+	spans []Span
+}
+
+// span is a running span of a trace. All methods are no-ops on a nil span,
+// which is used when tracing is disabled.
+type span struct {
+	trace *trace
+
+	mu sync.Mutex
+	Span
+}
+
+// startTrace starts the span of a scrape, nil without a tracer.
+func (c *collector) startTrace(name string) *span {
+	if c.tracer == nil {
+		return nil
+	}
+
+	s := &span{trace: &trace{}, Span: Span{Name: name, Start: time.Now()}}
+	binary.BigEndian.PutUint64(s.TraceID[:8], rand.Uint64())
+	binary.BigEndian.PutUint64(s.TraceID[8:], rand.Uint64())
+	binary.BigEndian.PutUint64(s.SpanID[:], rand.Uint64())
+
+	return s
+}
+
+// finishTrace ends the span of the scrape and exports its trace.
+func (c *collector) finishTrace(s *span) {
+	if s == nil {
+		return
+	}
+
+	s.end(nil)
+
+	s.trace.mu.Lock()
+	spans := s.trace.spans
+	s.trace.mu.Unlock()
+
+	c.tracer.Export(spans)
+}
+
+// child starts a span within s.
+func (s *span) child(name string) *span {
+	if s == nil {
+		return nil
+	}
+
+	child := &span{trace: s.trace, Span: Span{
+		TraceID:  s.TraceID,
+		ParentID: s.SpanID,
+		Name:     name,
+		Start:    time.Now(),
+	}}
+	binary.BigEndian.PutUint64(child.SpanID[:], rand.Uint64())
+
+	return child
+}
+
+func (s *span) setAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Attributes == nil {
+		s.Attributes = make(map[string]any)
+	}
+	s.Attributes[key] = value
+}
+
+// end finishes the span, failed if err is set.
+func (s *span) end(err error) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.End = time.Now()
+	s.Err = err
+	finished := s.Span
+	s.mu.Unlock()
+
+	s.trace.mu.Lock()
+	s.trace.spans = append(s.trace.spans, finished)
+	s.trace.mu.Unlock()
+}
@@ -0,0 +1,58 @@
+package collector
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingTracer struct {
+	spans []Span
+}
+
+func (t *recordingTracer) Export(spans []Span) {
+	t.spans = append(t.spans, spans...)
+}
+
+func TestTrace(t *testing.T) {
+	tracer := &recordingTracer{}
+	c := &collector{tracer: tracer}
+
+	scrape := c.startTrace("scrape")
+	device := scrape.child("device")
+	device.setAttribute("device", "r1")
+	co := device.child("interface")
+	co.setAttribute("commands", int64(2))
+	co.end(errors.New("timeout"))
+	device.end(nil)
+	c.finishTrace(scrape)
+
+	assert.Len(t, tracer.spans, 3)
+	assert.Equal(t, "interface", tracer.spans[0].Name)
+	assert.Equal(t, "device", tracer.spans[1].Name)
+	assert.Equal(t, "scrape", tracer.spans[2].Name)
+
+	for _, s := range tracer.spans {
+		assert.Equal(t, scrape.TraceID, s.TraceID)
+	}
+	assert.Equal(t, [8]byte{}, tracer.spans[2].ParentID)
+	assert.Equal(t, tracer.spans[2].SpanID, tracer.spans[1].ParentID)
+	assert.Equal(t, tracer.spans[1].SpanID, tracer.spans[0].ParentID)
+
+	assert.EqualError(t, tracer.spans[0].Err, "timeout")
+	assert.Equal(t, int64(2), tracer.spans[0].Attributes["commands"])
+	assert.Equal(t, "r1", tracer.spans[1].Attributes["device"])
+}
+
+func TestTraceDisabled(t *testing.T) {
+	c := &collector{}
+
+	s := c.startTrace("scrape")
+	assert.Nil(t, s)
+
+	child := s.child("device")
+	child.setAttribute("device", "r1")
+	child.end(nil)
+	c.finishTrace(s)
+}
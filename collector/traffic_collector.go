@@ -0,0 +1,209 @@
+package collector
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mikrotik-exporter/config"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	routeros "gopkg.in/routeros.v2"
+	"gopkg.in/routeros.v2/proto"
+)
+
+const (
+	// trafficRetryInterval is the time to wait before resubscribing after the
+	// monitor-traffic stream of a device broke
+	trafficRetryInterval = 10 * time.Second
+	// trafficMaxAge is the age after which rates received from the stream are
+	// not exported anymore
+	trafficMaxAge = time.Minute
+)
+
+// trafficRate is the latest rate reported by monitor-traffic for an interface
+// and its peak since the last scrape.
+type trafficRate struct {
+	current  map[string]float64
+	peak     map[string]float64
+	received time.Time
+}
+
+// trafficStream holds the rates received from the monitor-traffic
+// subscription of a device.
+type trafficStream struct {
+	mu    sync.Mutex
+	rates map[string]*trafficRate
+}
+
+type trafficCollector struct {
+	interfaces []string
+	dial       func(d *config.Device) (*routeros.Client, error)
+	props      []string
+	currDescs  map[string]*prometheus.Desc
+	peakDescs  map[string]*prometheus.Desc
+
+	mu      sync.Mutex
+	streams map[string]*trafficStream
+	done    chan struct{}
+}
+
+func newTrafficCollector(interfaces []string, dial func(d *config.Device) (*routeros.Client, error)) routerOSCollector {
+	c := &trafficCollector{
+		interfaces: interfaces,
+		dial:       dial,
+		streams:    make(map[string]*trafficStream),
+		done:       make(chan struct{}),
+	}
+	c.init()
+	return c
+}
+
+func (c *trafficCollector) init() {
+	c.props = []string{"rx-bits-per-second", "tx-bits-per-second", "rx-packets-per-second", "tx-packets-per-second"}
+
+	const prefix = "traffic"
+	labelNames := []string{"name", "address", "interface"}
+	c.currDescs = make(map[string]*prometheus.Desc)
+	c.peakDescs = make(map[string]*prometheus.Desc)
+	for _, p := range c.props {
+		c.currDescs[p] = descriptionForPropertyNameHelpText(prefix, p, labelNames, "latest "+strings.ReplaceAll(p, "-", " ")+" reported by monitor-traffic")
+		c.peakDescs[p] = descriptionForPropertyNameHelpText(prefix, p+"-max", labelNames, "highest "+strings.ReplaceAll(p, "-", " ")+" reported by monitor-traffic since the last scrape")
+	}
+}
+
+func (c *trafficCollector) describe(ch chan<- *prometheus.Desc) {
+	for _, p := range c.props {
+		ch <- c.currDescs[p]
+		ch <- c.peakDescs[p]
+	}
+}
+
+func (c *trafficCollector) collect(ctx *collectorContext) error {
+	if len(c.interfaces) == 0 {
+		return nil
+	}
+
+	s := c.stream(ctx.device)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for iface, r := range s.rates {
+		if time.Since(r.received) > trafficMaxAge {
+			continue
+		}
+
+		for _, p := range c.props {
+			ctx.ch <- prometheus.MustNewConstMetric(c.currDescs[p], prometheus.GaugeValue, r.current[p], ctx.device.Name, ctx.device.Address, iface)
+			ctx.ch <- prometheus.MustNewConstMetric(c.peakDescs[p], prometheus.GaugeValue, r.peak[p], ctx.device.Name, ctx.device.Address, iface)
+		}
+		r.peak = make(map[string]float64)
+		for p, v := range r.current {
+			r.peak[p] = v
+		}
+	}
+
+	return nil
+}
+
+// stream returns the stream of the device, subscribing on first use.
+func (c *trafficCollector) stream(d *config.Device) *trafficStream {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.streams[d.Name]
+	if !ok {
+		s = &trafficStream{rates: make(map[string]*trafficRate)}
+		c.streams[d.Name] = s
+		go c.subscribe(*d, s)
+	}
+
+	return s
+}
+
+// subscribe keeps a monitor-traffic subscription open on a dedicated
+// connection, resubscribing if it breaks, until the collector is stopped.
+func (c *trafficCollector) subscribe(d config.Device, s *trafficStream) {
+	for {
+		err := c.listen(&d, s)
+
+		select {
+		case <-c.done:
+			return
+		default:
+		}
+
+		log.WithFields(log.Fields{
+			"device": d.Name,
+			"error":  err,
+		}).Warn("monitor-traffic stream ended, resubscribing")
+
+		select {
+		case <-c.done:
+			return
+		case <-time.After(trafficRetryInterval):
+		}
+	}
+}
+
+// stop ends all subscriptions.
+func (c *trafficCollector) stop() {
+	close(c.done)
+}
+
+func (c *trafficCollector) listen(d *config.Device, s *trafficStream) error {
+	cl, err := c.dial(d)
+	if err != nil {
+		return err
+	}
+	defer cl.Close()
+
+	ended := make(chan struct{})
+	defer close(ended)
+	go func() {
+		select {
+		case <-c.done:
+			cl.Close()
+		case <-ended:
+		}
+	}()
+
+	l, err := cl.Listen("/interface/monitor-traffic", "=interface="+strings.Join(c.interfaces, ","))
+	if err != nil {
+		return err
+	}
+
+	for re := range l.Chan() {
+		s.update(re, c.props)
+	}
+
+	return l.Err()
+}
+
+func (s *trafficStream) update(re *proto.Sentence, props []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	iface := re.Map["name"]
+	r, ok := s.rates[iface]
+	if !ok {
+		r = &trafficRate{peak: make(map[string]float64)}
+		s.rates[iface] = r
+	}
+
+	r.current = make(map[string]float64)
+	for _, p := range props {
+		v, err := strconv.ParseFloat(re.Map[p], 64)
+		if err != nil {
+			continue
+		}
+		r.current[p] = v
+		if v > r.peak[p] {
+			r.peak[p] = v
+		}
+	}
+	r.received = time.Now()
+}
@@ -0,0 +1,20 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/routeros.v2/proto"
+)
+
+func TestTrafficStreamUpdate(t *testing.T) {
+	props := []string{"rx-bits-per-second", "tx-bits-per-second"}
+	s := &trafficStream{rates: make(map[string]*trafficRate)}
+
+	s.update(&proto.Sentence{Map: map[string]string{"name": "ether1", "rx-bits-per-second": "1000", "tx-bits-per-second": "500"}}, props)
+	s.update(&proto.Sentence{Map: map[string]string{"name": "ether1", "rx-bits-per-second": "200", "tx-bits-per-second": "800"}}, props)
+
+	r := s.rates["ether1"]
+	assert.Equal(t, map[string]float64{"rx-bits-per-second": 200, "tx-bits-per-second": 800}, r.current)
+	assert.Equal(t, map[string]float64{"rx-bits-per-second": 1000, "tx-bits-per-second": 800}, r.peak)
+}
@@ -0,0 +1,89 @@
+package collector
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+type trafficFlowCollector struct {
+	enabledDesc *prometheus.Desc
+	cacheDesc   *prometheus.Desc
+	targetDesc  *prometheus.Desc
+}
+
+func newTrafficFlowCollector() routerOSCollector {
+	c := &trafficFlowCollector{}
+	c.init()
+	return c
+}
+
+func (c *trafficFlowCollector) init() {
+	const prefix = "traffic_flow"
+	labelNames := []string{"name", "address"}
+	c.enabledDesc = description(prefix, "enabled", "whether traffic flow export is enabled", labelNames)
+	c.cacheDesc = description(prefix, "cache_entries", "maximum number of flows kept in the flow cache", labelNames)
+	c.targetDesc = description(prefix, "target_info", "enabled traffic flow targets", append(labelNames, "target", "port", "version"))
+}
+
+func (c *trafficFlowCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.enabledDesc
+	ch <- c.cacheDesc
+	ch <- c.targetDesc
+}
+
+func (c *trafficFlowCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/ip/traffic-flow/print")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching traffic flow settings")
+		return err
+	}
+	if len(reply.Re) == 0 {
+		return nil
+	}
+
+	re := reply.Re[0]
+	ctx.ch <- prometheus.MustNewConstMetric(c.enabledDesc, prometheus.GaugeValue, boolToFloat(re.Map["enabled"]), ctx.device.Name, ctx.device.Address)
+
+	// the cache size is configured in entries, e.g. "4k"
+	if v, err := parseCacheEntries(re.Map["cache-entries"]); err == nil {
+		ctx.ch <- prometheus.MustNewConstMetric(c.cacheDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address)
+	}
+
+	return c.collectTargets(ctx)
+}
+
+func (c *trafficFlowCollector) collectTargets(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/ip/traffic-flow/target/print", "?disabled=false", "=.proplist=dst-address,address,port,version")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching traffic flow targets")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		// RouterOS 6 uses address with the port appended
+		target := re.Map["dst-address"]
+		if target == "" {
+			target = re.Map["address"]
+		}
+		ctx.ch <- prometheus.MustNewConstMetric(c.targetDesc, prometheus.GaugeValue, 1, ctx.device.Name, ctx.device.Address, target, re.Map["port"], re.Map["version"])
+	}
+
+	return nil
+}
+
+// parseCacheEntries parses the size of the flow cache, e.g. "4k" or "256k".
+func parseCacheEntries(value string) (float64, error) {
+	if v, ok := strings.CutSuffix(value, "k"); ok {
+		f, err := strconv.ParseFloat(v, 64)
+		return f * 1024, err
+	}
+
+	return strconv.ParseFloat(value, 64)
+}
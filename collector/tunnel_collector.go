@@ -0,0 +1,93 @@
+package collector
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+// tunnelTypes are the menus below /interface of the tunnel types.
+var tunnelTypes = []string{"eoip", "gre", "ipip", "eoipv6", "gre6", "ipipv6"}
+
+type tunnelCollector struct {
+	props             []string
+	runningDesc       *prometheus.Desc
+	mtuDesc           *prometheus.Desc
+	keepaliveDesc     *prometheus.Desc
+	keepaliveRetries  *prometheus.Desc
+	keepaliveInterval *prometheus.Desc
+}
+
+func newTunnelCollector() routerOSCollector {
+	c := &tunnelCollector{}
+	c.init()
+	return c
+}
+
+func (c *tunnelCollector) init() {
+	c.props = []string{"name", "remote-address", "running", "actual-mtu", "keepalive"}
+
+	const prefix = "tunnel"
+	labelNames := []string{"name", "address", "interface", "type", "remote_address"}
+	c.runningDesc = description(prefix, "running", "whether the tunnel is running, tunnels with keepalive stop running on keepalive timeout", labelNames)
+	c.mtuDesc = description(prefix, "actual_mtu", "actual MTU of the tunnel", labelNames)
+	c.keepaliveDesc = description(prefix, "keepalive_enabled", "whether keepalive is enabled on the tunnel", labelNames)
+	c.keepaliveInterval = description(prefix, "keepalive_interval_seconds", "interval between keepalive packets", labelNames)
+	c.keepaliveRetries = description(prefix, "keepalive_retries", "number of lost keepalive packets until the tunnel goes down", labelNames)
+}
+
+func (c *tunnelCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.runningDesc
+	ch <- c.mtuDesc
+	ch <- c.keepaliveDesc
+	ch <- c.keepaliveInterval
+	ch <- c.keepaliveRetries
+}
+
+func (c *tunnelCollector) collect(ctx *collectorContext) error {
+	for _, t := range tunnelTypes {
+		reply, err := ctx.client.Run("/interface/"+t+"/print", "?disabled=false", "=.proplist="+strings.Join(c.props, ","))
+		if err != nil {
+			ctx.logger().WithFields(log.Fields{
+				"type":  t,
+				"error": err,
+			}).Error("error fetching tunnel metrics")
+			return err
+		}
+
+		for _, re := range reply.Re {
+			c.collectForStat(t, re, ctx)
+		}
+	}
+
+	return nil
+}
+
+func (c *tunnelCollector) collectForStat(tunnelType string, re *proto.Sentence, ctx *collectorContext) {
+	labelValues := []string{ctx.device.Name, ctx.device.Address, re.Map["name"], tunnelType, re.Map["remote-address"]}
+
+	ctx.ch <- prometheus.MustNewConstMetric(c.runningDesc, prometheus.GaugeValue, boolToFloat(re.Map["running"]), labelValues...)
+
+	if v, err := strconv.ParseFloat(re.Map["actual-mtu"], 64); err == nil {
+		ctx.ch <- prometheus.MustNewConstMetric(c.mtuDesc, prometheus.GaugeValue, v, labelValues...)
+	}
+
+	// keepalive is reported as "interval,retries", e.g. "10s,10"
+	keepalive := re.Map["keepalive"]
+	if keepalive == "" {
+		ctx.ch <- prometheus.MustNewConstMetric(c.keepaliveDesc, prometheus.GaugeValue, 0, labelValues...)
+		return
+	}
+	ctx.ch <- prometheus.MustNewConstMetric(c.keepaliveDesc, prometheus.GaugeValue, 1, labelValues...)
+
+	interval, retries, _ := strings.Cut(keepalive, ",")
+	if v, err := parseDuration(interval); err == nil {
+		ctx.ch <- prometheus.MustNewConstMetric(c.keepaliveInterval, prometheus.GaugeValue, v, labelValues...)
+	}
+	if v, err := strconv.ParseFloat(retries, 64); err == nil {
+		ctx.ch <- prometheus.MustNewConstMetric(c.keepaliveRetries, prometheus.GaugeValue, v, labelValues...)
+	}
+}
@@ -0,0 +1,101 @@
+package collector
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+type upnpCollector struct {
+	enabledDesc    *prometheus.Desc
+	interfacesDesc *prometheus.Desc
+	mappingsDesc   *prometheus.Desc
+}
+
+func newUPnPCollector() routerOSCollector {
+	c := &upnpCollector{}
+	c.init()
+	return c
+}
+
+func (c *upnpCollector) init() {
+	const prefix = "upnp"
+	labelNames := []string{"name", "address"}
+	c.enabledDesc = description(prefix, "enabled", "whether UPnP is enabled", labelNames)
+	c.interfacesDesc = description(prefix, "interface_info", "interfaces UPnP is enabled on", append(labelNames, "interface", "type"))
+	c.mappingsDesc = description(prefix, "mappings", "number of port mappings created via UPnP", append(labelNames, "protocol"))
+}
+
+func (c *upnpCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.enabledDesc
+	ch <- c.interfacesDesc
+	ch <- c.mappingsDesc
+}
+
+func (c *upnpCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/ip/upnp/print")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching upnp settings")
+		return err
+	}
+	if len(reply.Re) == 0 {
+		return nil
+	}
+
+	enabled := boolToFloat(reply.Re[0].Map["enabled"])
+	ctx.ch <- prometheus.MustNewConstMetric(c.enabledDesc, prometheus.GaugeValue, enabled, ctx.device.Name, ctx.device.Address)
+	if enabled == 0 {
+		return nil
+	}
+
+	err = c.collectInterfaces(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.collectMappings(ctx)
+}
+
+func (c *upnpCollector) collectInterfaces(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/ip/upnp/interfaces/print", "?disabled=false", "=.proplist=interface,type")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching upnp interfaces")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		ctx.ch <- prometheus.MustNewConstMetric(c.interfacesDesc, prometheus.GaugeValue, 1, ctx.device.Name, ctx.device.Address, re.Map["interface"], re.Map["type"])
+	}
+
+	return nil
+}
+
+// collectMappings counts the dynamic NAT rules created by UPnP, which are
+// commented with "upnp" followed by the client address.
+func (c *upnpCollector) collectMappings(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/ip/firewall/nat/print", "?dynamic=true", "=.proplist=protocol,comment")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching upnp mappings")
+		return err
+	}
+
+	counts := map[string]float64{"tcp": 0, "udp": 0}
+	for _, re := range reply.Re {
+		if strings.HasPrefix(re.Map["comment"], "upnp ") {
+			counts[re.Map["protocol"]]++
+		}
+	}
+
+	for protocol, v := range counts {
+		ctx.ch <- prometheus.MustNewConstMetric(c.mappingsDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, protocol)
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+type userManagerCollector struct {
+	usersDesc          *prometheus.Desc
+	sessionsDesc       *prometheus.Desc
+	profileSessionDesc *prometheus.Desc
+}
+
+func newUserManagerCollector() routerOSCollector {
+	c := &userManagerCollector{}
+	c.init()
+	return c
+}
+
+func (c *userManagerCollector) init() {
+	const prefix = "usermanager"
+	labelNames := []string{"name", "address"}
+	c.usersDesc = description(prefix, "users", "number of User Manager users", append(labelNames, "disabled"))
+	c.sessionsDesc = description(prefix, "active_sessions", "number of active User Manager sessions", labelNames)
+	c.profileSessionDesc = description(prefix, "profile_active_sessions", "number of active User Manager sessions by profile of the user", append(labelNames, "profile"))
+}
+
+func (c *userManagerCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.usersDesc
+	ch <- c.sessionsDesc
+	ch <- c.profileSessionDesc
+}
+
+func (c *userManagerCollector) collect(ctx *collectorContext) error {
+	err := c.collectUsers(ctx)
+	if err != nil {
+		return err
+	}
+
+	profiles, err := c.fetchProfiles(ctx)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := c.fetch(ctx, "/user-manager/session/print", "?active=true", "=.proplist=user")
+	if err != nil {
+		return err
+	}
+
+	perProfile := make(map[string]float64)
+	for _, re := range sessions {
+		for _, p := range profiles[re.Map["user"]] {
+			perProfile[p]++
+		}
+	}
+
+	ctx.ch <- prometheus.MustNewConstMetric(c.sessionsDesc, prometheus.GaugeValue, float64(len(sessions)), ctx.device.Name, ctx.device.Address)
+	for profile, v := range perProfile {
+		ctx.ch <- prometheus.MustNewConstMetric(c.profileSessionDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, profile)
+	}
+
+	return nil
+}
+
+func (c *userManagerCollector) collectUsers(ctx *collectorContext) error {
+	users, err := c.fetch(ctx, "/user-manager/user/print", "=.proplist=disabled")
+	if err != nil {
+		return err
+	}
+
+	counts := map[string]float64{"true": 0, "false": 0}
+	for _, re := range users {
+		if re.Map["disabled"] == "true" {
+			counts["true"]++
+		} else {
+			counts["false"]++
+		}
+	}
+
+	for disabled, v := range counts {
+		ctx.ch <- prometheus.MustNewConstMetric(c.usersDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, disabled)
+	}
+
+	return nil
+}
+
+// fetchProfiles returns the profiles currently in use by each user.
+func (c *userManagerCollector) fetchProfiles(ctx *collectorContext) (map[string][]string, error) {
+	userProfiles, err := c.fetch(ctx, "/user-manager/user-profile/print", "=.proplist=user,profile,state")
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := make(map[string][]string)
+	for _, re := range userProfiles {
+		switch re.Map["state"] {
+		case "running", "running-active":
+			profiles[re.Map["user"]] = append(profiles[re.Map["user"]], re.Map["profile"])
+		}
+	}
+
+	return profiles, nil
+}
+
+func (c *userManagerCollector) fetch(ctx *collectorContext, sentence ...string) ([]*proto.Sentence, error) {
+	reply, err := ctx.client.Run(sentence...)
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"command": sentence[0],
+			"error":   err,
+		}).Error("error fetching user manager metrics")
+		return nil, err
+	}
+
+	return reply.Re, nil
+}
+
+func (c *userManagerCollector) requiredMenu() string {
+	return "/user-manager/user"
+}
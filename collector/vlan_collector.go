@@ -0,0 +1,123 @@
+package collector
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+type vlanCollector struct {
+	trafficProps    []string
+	runningDesc     *prometheus.Desc
+	descriptions    map[string]*prometheus.Desc
+	bridgeVLANsDesc *prometheus.Desc
+}
+
+func newVLANCollector() routerOSCollector {
+	c := &vlanCollector{}
+	c.init()
+	return c
+}
+
+func (c *vlanCollector) init() {
+	c.trafficProps = []string{"rx-byte", "tx-byte", "rx-packet", "tx-packet", "rx-drop", "tx-drop"}
+
+	const prefix = "vlan"
+	labelNames := []string{"name", "address", "interface", "vlan_id", "parent"}
+	c.runningDesc = description(prefix, "running", "whether the VLAN interface is running", labelNames)
+	c.descriptions = make(map[string]*prometheus.Desc)
+	for _, p := range c.trafficProps {
+		c.descriptions[p] = descriptionForPropertyName(prefix, p, labelNames)
+	}
+	c.bridgeVLANsDesc = description("bridge", "vlan_entries", "number of entries in the VLAN table of the bridge", []string{"name", "address", "bridge"})
+}
+
+func (c *vlanCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.runningDesc
+	for _, d := range c.descriptions {
+		ch <- d
+	}
+	ch <- c.bridgeVLANsDesc
+}
+
+func (c *vlanCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/interface/vlan/print", "?disabled=false", "=.proplist=name,vlan-id,interface,running")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching vlan interfaces")
+		return err
+	}
+
+	if len(reply.Re) > 0 {
+		traffic, err := c.fetchTraffic(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, re := range reply.Re {
+			c.collectForStat(re, traffic[re.Map["name"]], ctx)
+		}
+	}
+
+	return c.collectBridgeVLANs(ctx)
+}
+
+// fetchTraffic returns the statistics of the VLAN interfaces by name.
+func (c *vlanCollector) fetchTraffic(ctx *collectorContext) (map[string]*proto.Sentence, error) {
+	reply, err := ctx.client.Run("/interface/print", "?type=vlan", "=.proplist=name,rx-byte,tx-byte,rx-packet,tx-packet,rx-drop,tx-drop")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching vlan interface metrics")
+		return nil, err
+	}
+
+	traffic := make(map[string]*proto.Sentence)
+	for _, re := range reply.Re {
+		traffic[re.Map["name"]] = re
+	}
+
+	return traffic, nil
+}
+
+func (c *vlanCollector) collectForStat(re *proto.Sentence, traffic *proto.Sentence, ctx *collectorContext) {
+	labelValues := []string{ctx.device.Name, ctx.device.Address, re.Map["name"], re.Map["vlan-id"], re.Map["interface"]}
+
+	ctx.ch <- prometheus.MustNewConstMetric(c.runningDesc, prometheus.GaugeValue, boolToFloat(re.Map["running"]), labelValues...)
+
+	if traffic == nil {
+		return
+	}
+
+	for _, p := range c.trafficProps {
+		v, err := strconv.ParseFloat(traffic.Map[p], 64)
+		if err != nil {
+			continue
+		}
+		ctx.ch <- ctx.counter(c.descriptions[p], v, labelValues...)
+	}
+}
+
+func (c *vlanCollector) collectBridgeVLANs(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/interface/bridge/vlan/print", "=.proplist=bridge")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching bridge vlan table")
+		return err
+	}
+
+	counts := make(map[string]float64)
+	for _, re := range reply.Re {
+		counts[re.Map["bridge"]]++
+	}
+
+	for bridge, v := range counts {
+		ctx.ch <- prometheus.MustNewConstMetric(c.bridgeVLANsDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, bridge)
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package collector
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultVRF is the VRF of the interfaces not assigned to another, which uses
+// the main routing table
+const defaultVRF = "main"
+
+// fetchInterfaceVRFs returns the VRFs of the interfaces by name. VRFs are
+// only listed on RouterOS 7, on RouterOS 6 all interfaces are in the main
+// VRF.
+func fetchInterfaceVRFs(ctx *collectorContext) (map[string]string, error) {
+	vrfs := make(map[string]string)
+	if ctx.client.version.major < 7 {
+		return vrfs, nil
+	}
+
+	reply, err := ctx.client.Run("/ip/vrf/print", "?disabled=false", "=.proplist=name,interfaces")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching VRFs")
+		return nil, err
+	}
+
+	for _, re := range reply.Re {
+		for _, iface := range strings.Split(re.Map["interfaces"], ",") {
+			if iface != "" {
+				vrfs[iface] = re.Map["name"]
+			}
+		}
+	}
+
+	return vrfs, nil
+}
+
+// interfaceVRF returns the VRF of the interface.
+func interfaceVRF(vrfs map[string]string, iface string) string {
+	if vrf, ok := vrfs[iface]; ok {
+		return vrf
+	}
+
+	return defaultVRF
+}
@@ -0,0 +1,15 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterfaceVRF(t *testing.T) {
+	vrfs := map[string]string{"ether2": "customer-a", "vlan100": "customer-b"}
+
+	assert.Equal(t, "customer-a", interfaceVRF(vrfs, "ether2"))
+	assert.Equal(t, "main", interfaceVRF(vrfs, "ether1"))
+	assert.Equal(t, "main", interfaceVRF(nil, "ether1"))
+}
@@ -0,0 +1,135 @@
+package collector
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+// vrrpStates are the states a VRRP interface can be in.
+var vrrpStates = []string{"master", "backup", "init"}
+
+// vrrpHistory is the last seen state of a VRRP interface and the number of
+// transitions seen by the exporter.
+type vrrpHistory struct {
+	state       string
+	transitions float64
+}
+
+type vrrpCollector struct {
+	props           []string
+	stateDesc       *prometheus.Desc
+	priorityDesc    *prometheus.Desc
+	transitionsDesc *prometheus.Desc
+
+	mu      sync.Mutex
+	history map[string]*vrrpHistory
+}
+
+func newVRRPCollector() routerOSCollector {
+	c := &vrrpCollector{
+		history: make(map[string]*vrrpHistory),
+	}
+	c.init()
+	return c
+}
+
+func (c *vrrpCollector) init() {
+	c.props = []string{"name", "interface", "vrid", "priority", "master", "backup"}
+
+	const prefix = "vrrp"
+	labelNames := []string{"name", "address", "vrrp", "interface", "vrid"}
+	c.stateDesc = description(prefix, "state", "state of the VRRP interface, 1 for the current state", append(labelNames, "state"))
+	c.priorityDesc = description(prefix, "priority", "priority of the VRRP interface", labelNames)
+	c.transitionsDesc = description(prefix, "transitions", "number of state transitions seen by the exporter", labelNames)
+}
+
+func (c *vrrpCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.stateDesc
+	ch <- c.priorityDesc
+	ch <- c.transitionsDesc
+}
+
+func (c *vrrpCollector) collect(ctx *collectorContext) error {
+	stats, err := c.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, re := range stats {
+		c.collectForStat(re, ctx)
+	}
+
+	return nil
+}
+
+func (c *vrrpCollector) fetch(ctx *collectorContext) ([]*proto.Sentence, error) {
+	reply, err := ctx.client.Run("/interface/vrrp/print", "?disabled=false", "=.proplist="+strings.Join(c.props, ","))
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching vrrp metrics")
+		return nil, err
+	}
+
+	return reply.Re, nil
+}
+
+func (c *vrrpCollector) collectForStat(re *proto.Sentence, ctx *collectorContext) {
+	labelValues := []string{ctx.device.Name, ctx.device.Address, re.Map["name"], re.Map["interface"], re.Map["vrid"]}
+
+	state := vrrpState(re)
+	for _, s := range vrrpStates {
+		v := 0.0
+		if s == state {
+			v = 1.0
+		}
+		ctx.ch <- prometheus.MustNewConstMetric(c.stateDesc, prometheus.GaugeValue, v, append(labelValues, s)...)
+	}
+
+	if priority, err := strconv.ParseFloat(re.Map["priority"], 64); err == nil {
+		ctx.ch <- prometheus.MustNewConstMetric(c.priorityDesc, prometheus.GaugeValue, priority, labelValues...)
+	}
+
+	transitions := c.transition(ctx.device.Name+"/"+re.Map["name"], state)
+	ctx.ch <- prometheus.MustNewConstMetric(c.transitionsDesc, prometheus.CounterValue, transitions, labelValues...)
+}
+
+// transition records the state of the VRRP interface and returns the number
+// of transitions seen so far.
+func (c *vrrpCollector) transition(key, state string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h, ok := c.history[key]
+	if !ok {
+		c.history[key] = &vrrpHistory{state: state}
+		return 0
+	}
+
+	if h.state != state {
+		h.state = state
+		h.transitions++
+	}
+
+	return h.transitions
+}
+
+func vrrpState(re *proto.Sentence) string {
+	switch {
+	case re.Map["master"] == "true":
+		return "master"
+	case re.Map["backup"] == "true":
+		return "backup"
+	default:
+		return "init"
+	}
+}
+
+func (c *vrrpCollector) requiredMenu() string {
+	return "/interface/vrrp"
+}
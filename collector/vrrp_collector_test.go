@@ -0,0 +1,17 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVRRPTransitions(t *testing.T) {
+	c := newVRRPCollector().(*vrrpCollector)
+
+	assert.Equal(t, float64(0), c.transition("router1/vrrp1", "backup"))
+	assert.Equal(t, float64(0), c.transition("router1/vrrp1", "backup"))
+	assert.Equal(t, float64(1), c.transition("router1/vrrp1", "master"))
+	assert.Equal(t, float64(2), c.transition("router1/vrrp1", "backup"))
+	assert.Equal(t, float64(0), c.transition("router2/vrrp1", "master"))
+}
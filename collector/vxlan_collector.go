@@ -0,0 +1,99 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+type vxlanCollector struct {
+	runningDesc *prometheus.Desc
+	vtepDesc    *prometheus.Desc
+	fdbDesc     *prometheus.Desc
+}
+
+func newVXLANCollector() routerOSCollector {
+	c := &vxlanCollector{}
+	c.init()
+	return c
+}
+
+func (c *vxlanCollector) init() {
+	const prefix = "vxlan"
+	c.runningDesc = description(prefix, "running", "whether the VXLAN interface is running", []string{"name", "address", "interface", "vni"})
+	c.vtepDesc = description(prefix, "vtep_info", "VTEPs configured on the VXLAN interface", []string{"name", "address", "interface", "remote_ip"})
+	c.fdbDesc = description(prefix, "fdb_entries", "number of MAC addresses learned behind the VTEP", []string{"name", "address", "interface", "remote_ip"})
+}
+
+func (c *vxlanCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.runningDesc
+	ch <- c.vtepDesc
+	ch <- c.fdbDesc
+}
+
+func (c *vxlanCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/interface/vxlan/print", "?disabled=false", "=.proplist=name,vni,running")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching vxlan metrics")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		ctx.ch <- prometheus.MustNewConstMetric(c.runningDesc, prometheus.GaugeValue, boolToFloat(re.Map["running"]), ctx.device.Name, ctx.device.Address, re.Map["name"], re.Map["vni"])
+	}
+
+	err = c.collectVTEPs(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.collectFDB(ctx)
+
+	return nil
+}
+
+func (c *vxlanCollector) collectVTEPs(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/interface/vxlan/vteps/print", "=.proplist=interface,remote-ip")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching vxlan vteps")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		ctx.ch <- prometheus.MustNewConstMetric(c.vtepDesc, prometheus.GaugeValue, 1, ctx.device.Name, ctx.device.Address, re.Map["interface"], re.Map["remote-ip"])
+	}
+
+	return nil
+}
+
+// collectFDB counts the learned MAC addresses per VTEP. The FDB menu is only
+// available on recent RouterOS 7 releases, so errors are not fatal.
+func (c *vxlanCollector) collectFDB(ctx *collectorContext) {
+	reply, err := ctx.client.Run("/interface/vxlan/fdb/print", "=.proplist=interface,remote-ip")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Debug("error fetching vxlan fdb")
+		return
+	}
+
+	type fdbKey struct {
+		iface    string
+		remoteIP string
+	}
+	counts := make(map[fdbKey]float64)
+	for _, re := range reply.Re {
+		counts[fdbKey{re.Map["interface"], re.Map["remote-ip"]}]++
+	}
+
+	for k, v := range counts {
+		ctx.ch <- prometheus.MustNewConstMetric(c.fdbDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, k.iface, k.remoteIP)
+	}
+}
+
+func (c *vxlanCollector) requiredMenu() string {
+	return "/interface/vxlan"
+}
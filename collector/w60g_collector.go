@@ -35,9 +35,8 @@ func (c *w60gInterfaceCollector) describe(ch chan<- *prometheus.Desc) {
 func (c *w60gInterfaceCollector) collect(ctx *collectorContext) error {
 	reply, err := ctx.client.Run("/interface/w60g/print", "=.proplist=name")
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device": ctx.device.Name,
-			"error":  err,
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
 		}).Error("error fetching w60g interface metrics")
 		return err
 	}
@@ -64,9 +63,8 @@ func (c *w60gInterfaceCollector) collectw60gMetricsForInterfaces(
 		"=once=",
 		"=.proplist=name,"+strings.Join(c.props, ","))
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device": ctx.device.Name,
-			"error":  err,
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
 		}).Error("error fetching w60g interface monitor metrics")
 		return err
 	}
@@ -97,8 +95,7 @@ func (c *w60gInterfaceCollector) collectMetricsForw60gInterface(
 		}
 		value, err := strconv.ParseFloat(v, 64)
 		if err != nil {
-			log.WithFields(log.Fields{
-				"device":    ctx.device.Name,
+			ctx.logger().WithFields(log.Fields{
 				"interface": name,
 				"property":  prop,
 				"error":     err,
@@ -168,3 +165,7 @@ func (c *w60gInterfaceCollector) descForKey(name string) *prometheus.Desc {
 
 	return nil
 }
+
+func (c *w60gInterfaceCollector) requiredMenu() string {
+	return "/interface/w60g"
+}
@@ -0,0 +1,197 @@
+package collector
+
+import (
+	"sync"
+	"time"
+
+	"mikrotik-exporter/config"
+
+	log "github.com/sirupsen/logrus"
+	routeros "gopkg.in/routeros.v2"
+)
+
+const (
+	// warmUpConcurrency limits the logins running at the same time during
+	// warm-up
+	warmUpConcurrency = 10
+	// warmConnectionMaxAge is the time after which a warm connection is not
+	// used anymore as the device might have closed it in the meantime, unless
+	// the idle timeout of the connection pool is set
+	warmConnectionMaxAge = time.Minute
+)
+
+type warmConnection struct {
+	client    *routeros.Client
+	idleSince time.Time
+}
+
+// warmConnections holds the connections established during warm-up or kept
+// open by the connection pool until they are picked up by the next scrape of
+// the device.
+type warmConnections struct {
+	mu     sync.Mutex
+	conns  map[string]warmConnection
+	maxAge time.Duration
+	closed bool
+	done   chan struct{}
+}
+
+func newWarmConnections(maxAge time.Duration) *warmConnections {
+	w := &warmConnections{
+		conns:  make(map[string]warmConnection),
+		maxAge: maxAge,
+		done:   make(chan struct{}),
+	}
+	go w.closeIdle()
+
+	return w
+}
+
+// closeIdle closes the connections idle for longer than the max age until
+// closeAll is called, so that they don't linger on the devices until the next
+// scrape.
+func (w *warmConnections) closeIdle() {
+	ticker := time.NewTicker(w.maxAge)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			w.expire()
+		}
+	}
+}
+
+func (w *warmConnections) expire() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for device, wc := range w.conns {
+		if time.Since(wc.idleSince) > w.maxAge {
+			wc.client.Close()
+			delete(w.conns, device)
+		}
+	}
+}
+
+func (w *warmConnections) put(device string, cl *routeros.Client) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		cl.Close()
+		return
+	}
+
+	// concurrent scrapes of the device return a connection each
+	if wc, ok := w.conns[device]; ok {
+		wc.client.Close()
+	}
+
+	w.conns[device] = warmConnection{client: cl, idleSince: time.Now()}
+}
+
+// closeAll closes the connections not picked up yet and all connections put
+// afterwards.
+func (w *warmConnections) closeAll() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return
+	}
+
+	for device, wc := range w.conns {
+		wc.client.Close()
+		delete(w.conns, device)
+	}
+	w.closed = true
+	close(w.done)
+}
+
+func (w *warmConnections) take(device string) *routeros.Client {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	wc, ok := w.conns[device]
+	if !ok {
+		return nil
+	}
+	delete(w.conns, device)
+
+	if time.Since(wc.idleSince) > w.maxAge {
+		wc.client.Close()
+		return nil
+	}
+
+	return wc.client
+}
+
+// warmUp resolves all devices and logs in to them in the background.
+func (c *collector) warmUp() {
+	devices := c.resolveDevices()
+
+	log.WithFields(log.Fields{
+		"numDevices": len(devices),
+	}).Info("warming up device connections")
+
+	sem := make(chan struct{}, warmUpConcurrency)
+	wg := sync.WaitGroup{}
+	wg.Add(len(devices))
+
+	for _, dev := range devices {
+		if dev.Transport == config.TransportSNMP {
+			wg.Done()
+			continue
+		}
+
+		sem <- struct{}{}
+		go func(d config.Device) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			cl, err := c.connect(&d)
+			if err != nil {
+				log.WithFields(log.Fields{
+					"device": d.Name,
+					"error":  err,
+				}).Warn("error warming up device connection")
+				return
+			}
+			c.warm.put(d.Name, cl)
+		}(dev)
+	}
+
+	wg.Wait()
+	log.Info("done warming up device connections")
+}
+
+// warmOrConnect returns the warm connection of the device if there is one,
+// the credentials did not change since and the device still answers on it,
+// otherwise it connects to the device.
+func (c *collector) warmOrConnect(d *config.Device) (*routeros.Client, error) {
+	if c.warm != nil {
+		if cl := c.warm.take(d.Name); cl != nil {
+			if !c.credentialsChanged(d) && alive(cl, c.deviceTimeout(d)) {
+				return cl, nil
+			}
+			cl.Close()
+		}
+	}
+
+	return c.connect(d)
+}
+
+// alive reports whether the device answers a cheap command on the connection
+// within the timeout, as it might have been closed by the device or a
+// firewall while idle.
+func alive(cl *routeros.Client, timeout time.Duration) bool {
+	t := time.AfterFunc(timeout, cl.Close)
+	_, err := cl.Run("/system/identity/print")
+
+	return t.Stop() && err == nil
+}
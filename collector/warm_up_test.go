@@ -0,0 +1,62 @@
+package collector
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	routeros "gopkg.in/routeros.v2"
+)
+
+func TestWarmConnectionsCloseIdle(t *testing.T) {
+	w := newWarmConnections(10 * time.Millisecond)
+	defer w.closeAll()
+
+	conn, server := net.Pipe()
+	defer server.Close()
+
+	cl, err := routeros.NewClient(conn)
+	assert.NoError(t, err)
+	w.put("r1", cl)
+
+	assert.Eventually(t, func() bool {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		return len(w.conns) == 0
+	}, time.Second, 5*time.Millisecond)
+
+	_, err = server.Read(make([]byte, 1))
+	assert.Error(t, err, "the idle connection must be closed")
+}
+
+func TestAliveClosedConnection(t *testing.T) {
+	conn, server := net.Pipe()
+	server.Close()
+
+	cl, err := routeros.NewClient(conn)
+	assert.NoError(t, err)
+
+	assert.False(t, alive(cl, time.Second))
+}
+
+func TestAliveTimeout(t *testing.T) {
+	conn, server := net.Pipe()
+	defer server.Close()
+
+	// the server never answers
+	go func() {
+		b := make([]byte, 1024)
+		for {
+			if _, err := server.Read(b); err != nil {
+				return
+			}
+		}
+	}()
+
+	cl, err := routeros.NewClient(conn)
+	assert.NoError(t, err)
+
+	assert.False(t, alive(cl, 10*time.Millisecond))
+}
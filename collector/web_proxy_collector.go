@@ -0,0 +1,94 @@
+package collector
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+type webProxyCollector struct {
+	runningDesc  *prometheus.Desc
+	uptimeDesc   *prometheus.Desc
+	clientsDesc  *prometheus.Desc
+	descriptions map[string]*prometheus.Desc
+	cacheDesc    *prometheus.Desc
+	ramDesc      *prometheus.Desc
+}
+
+func newWebProxyCollector() routerOSCollector {
+	c := &webProxyCollector{}
+	c.init()
+	return c
+}
+
+func (c *webProxyCollector) init() {
+	const prefix = "proxy"
+	labelNames := []string{"name", "address"}
+	c.runningDesc = description(prefix, "running", "whether the web proxy is running", labelNames)
+	c.uptimeDesc = description(prefix, "uptime_seconds", "uptime of the web proxy", labelNames)
+	c.clientsDesc = description(prefix, "clients", "number of clients of the web proxy", labelNames)
+	c.descriptions = map[string]*prometheus.Desc{
+		"requests":              description(prefix, "requests", "number of requests to the web proxy", labelNames),
+		"hits":                  description(prefix, "hits", "number of requests served from the cache", labelNames),
+		"received-from-servers": description(prefix, "received_from_servers_bytes", "number of bytes received from servers", labelNames),
+		"sent-to-clients":       description(prefix, "sent_to_clients_bytes", "number of bytes sent to clients", labelNames),
+		"hits-sent-to-clients":  description(prefix, "hits_sent_to_clients_bytes", "number of bytes sent to clients from the cache", labelNames),
+	}
+	c.cacheDesc = description(prefix, "cache_used_bytes", "size of the cache", labelNames)
+	c.ramDesc = description(prefix, "ram_used_bytes", "memory used by the web proxy", labelNames)
+}
+
+func (c *webProxyCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.runningDesc
+	ch <- c.uptimeDesc
+	ch <- c.clientsDesc
+	for _, d := range c.descriptions {
+		ch <- d
+	}
+	ch <- c.cacheDesc
+	ch <- c.ramDesc
+}
+
+func (c *webProxyCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/ip/proxy/monitor", "=once=")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching web proxy metrics")
+		return err
+	}
+	if len(reply.Re) == 0 {
+		return nil
+	}
+
+	re := reply.Re[0]
+	running := 0.0
+	if re.Map["status"] == "running" {
+		running = 1.0
+	}
+	ctx.ch <- prometheus.MustNewConstMetric(c.runningDesc, prometheus.GaugeValue, running, ctx.device.Name, ctx.device.Address)
+	if running == 0 {
+		return nil
+	}
+
+	if v, err := parseDuration(re.Map["uptime"]); err == nil {
+		ctx.ch <- prometheus.MustNewConstMetric(c.uptimeDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address)
+	}
+	if v, err := strconv.ParseFloat(re.Map["clients"], 64); err == nil {
+		ctx.ch <- prometheus.MustNewConstMetric(c.clientsDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address)
+	}
+	for property, desc := range c.descriptions {
+		if v, err := strconv.ParseFloat(re.Map[property], 64); err == nil {
+			ctx.ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v, ctx.device.Name, ctx.device.Address)
+		}
+	}
+	if v, err := parseKiB(re.Map["cache-size"]); err == nil {
+		ctx.ch <- prometheus.MustNewConstMetric(c.cacheDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address)
+	}
+	if v, err := parseKiB(re.Map["total-ram-used"]); err == nil {
+		ctx.ch <- prometheus.MustNewConstMetric(c.ramDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address)
+	}
+
+	return nil
+}
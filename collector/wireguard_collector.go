@@ -0,0 +1,159 @@
+package collector
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+type wireguardCollector struct {
+	peerProps     []string
+	descriptions  map[string]*prometheus.Desc
+	peerInfoDesc  *prometheus.Desc
+	interfaceDesc *prometheus.Desc
+}
+
+func newWireguardCollector() routerOSCollector {
+	c := &wireguardCollector{}
+	c.init()
+	return c
+}
+
+func (c *wireguardCollector) init() {
+	c.peerProps = []string{"interface", "public-key", "comment", "current-endpoint-address", "current-endpoint-port", "endpoint-address", "endpoint-port", "rx", "tx", "last-handshake"}
+
+	const prefix = "wireguard"
+	labelNames := []string{"name", "address", "interface", "public_key", "comment"}
+	c.descriptions = map[string]*prometheus.Desc{
+		"rx":             description(prefix, "peer_rx_bytes", "number of bytes received from the peer", labelNames),
+		"tx":             description(prefix, "peer_tx_bytes", "number of bytes sent to the peer", labelNames),
+		"last-handshake": description(prefix, "peer_last_handshake_seconds", "time since the last handshake with the peer", labelNames),
+	}
+	// the endpoint of roaming peers changes, so it is kept off the counters
+	c.peerInfoDesc = description(prefix, "peer_info", "endpoint the peer is connected from", append(labelNames, "endpoint"))
+	c.interfaceDesc = description(prefix, "interface_up", "whether the wireguard interface is running", []string{"name", "address", "interface"})
+}
+
+func (c *wireguardCollector) describe(ch chan<- *prometheus.Desc) {
+	for _, d := range c.descriptions {
+		ch <- d
+	}
+	ch <- c.peerInfoDesc
+	ch <- c.interfaceDesc
+}
+
+func (c *wireguardCollector) collect(ctx *collectorContext) error {
+	err := c.collectInterfaces(ctx)
+	if err != nil {
+		return err
+	}
+
+	stats, err := c.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, re := range stats {
+		c.collectForStat(re, ctx)
+	}
+
+	return nil
+}
+
+func (c *wireguardCollector) collectInterfaces(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/interface/wireguard/print", "?disabled=false", "=.proplist=name,running")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching wireguard interfaces")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		v := 0.0
+		if re.Map["running"] == "true" {
+			v = 1.0
+		}
+		ctx.ch <- prometheus.MustNewConstMetric(c.interfaceDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, re.Map["name"])
+	}
+
+	return nil
+}
+
+func (c *wireguardCollector) fetch(ctx *collectorContext) ([]*proto.Sentence, error) {
+	reply, err := ctx.client.Run("/interface/wireguard/peers/print", "?disabled=false", "=.proplist="+strings.Join(c.peerProps, ","))
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching wireguard peer metrics")
+		return nil, err
+	}
+
+	return reply.Re, nil
+}
+
+func (c *wireguardCollector) collectForStat(re *proto.Sentence, ctx *collectorContext) {
+	for _, p := range []string{"rx", "tx", "last-handshake"} {
+		c.collectMetricForProperty(p, re, ctx)
+	}
+
+	ctx.ch <- prometheus.MustNewConstMetric(c.peerInfoDesc, prometheus.GaugeValue, 1, ctx.device.Name, ctx.device.Address,
+		re.Map["interface"], re.Map["public-key"], re.Map["comment"], wireguardEndpoint(re))
+}
+
+func (c *wireguardCollector) collectMetricForProperty(property string, re *proto.Sentence, ctx *collectorContext) {
+	value := re.Map[property]
+	if value == "" {
+		// peers which never completed a handshake have no last-handshake
+		return
+	}
+
+	var (
+		v     float64
+		vtype = prometheus.CounterValue
+		err   error
+	)
+	if property == "last-handshake" {
+		vtype = prometheus.GaugeValue
+		v, err = parseDuration(value)
+	} else {
+		v, err = strconv.ParseFloat(value, 64)
+	}
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"peer":     re.Map["public-key"],
+			"property": property,
+			"value":    value,
+			"error":    err,
+		}).Error("error parsing wireguard peer metric value")
+		return
+	}
+
+	ctx.ch <- prometheus.MustNewConstMetric(c.descriptions[property], vtype, v, ctx.device.Name, ctx.device.Address,
+		re.Map["interface"], re.Map["public-key"], re.Map["comment"])
+}
+
+func (c *wireguardCollector) requiredMenu() string {
+	return "/interface/wireguard"
+}
+
+// wireguardEndpoint returns the endpoint the peer is currently connected
+// from, falling back to the configured endpoint.
+func wireguardEndpoint(re *proto.Sentence) string {
+	addr, port := re.Map["current-endpoint-address"], re.Map["current-endpoint-port"]
+	if addr == "" {
+		addr, port = re.Map["endpoint-address"], re.Map["endpoint-port"]
+	}
+	if addr == "" {
+		return ""
+	}
+	if port == "" || port == "0" {
+		return addr
+	}
+
+	return net.JoinHostPort(addr, port)
+}
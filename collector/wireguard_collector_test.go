@@ -0,0 +1,56 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/routeros.v2/proto"
+
+	"mikrotik-exporter/config"
+)
+
+func TestWireguardEndpoint(t *testing.T) {
+	assert.Equal(t, "198.51.100.7:51820", wireguardEndpoint(&proto.Sentence{Map: map[string]string{
+		"current-endpoint-address": "198.51.100.7", "current-endpoint-port": "51820",
+		"endpoint-address": "vpn.example.com", "endpoint-port": "13231",
+	}}))
+	assert.Equal(t, "vpn.example.com:13231", wireguardEndpoint(&proto.Sentence{Map: map[string]string{
+		"endpoint-address": "vpn.example.com", "endpoint-port": "13231",
+	}}))
+	assert.Equal(t, "[2001:db8::1]:51820", wireguardEndpoint(&proto.Sentence{Map: map[string]string{
+		"current-endpoint-address": "2001:db8::1", "current-endpoint-port": "51820",
+	}}))
+	assert.Equal(t, "", wireguardEndpoint(&proto.Sentence{Map: map[string]string{}}))
+}
+
+func TestWireguardPeerLabels(t *testing.T) {
+	c := newWireguardCollector().(*wireguardCollector)
+	ch := make(chan prometheus.Metric, 10)
+	ctx := &collectorContext{ch: ch, device: &config.Device{Name: "r1"}, client: &apiClient{}}
+
+	c.collectForStat(&proto.Sentence{Map: map[string]string{
+		"interface": "wg0", "public-key": "key", "rx": "100", "tx": "200",
+		"current-endpoint-address": "198.51.100.7", "current-endpoint-port": "51820",
+	}}, ctx)
+	close(ch)
+
+	for m := range ch {
+		var out dto.Metric
+		assert.NoError(t, m.Write(&out))
+
+		endpoint := ""
+		for _, l := range out.GetLabel() {
+			if l.GetName() == "endpoint" {
+				endpoint = l.GetValue()
+			}
+		}
+		if m.Desc() == c.peerInfoDesc {
+			assert.Equal(t, "198.51.100.7:51820", endpoint)
+		} else {
+			// roaming peers must not start new counter series
+			assert.Empty(t, endpoint)
+		}
+	}
+}
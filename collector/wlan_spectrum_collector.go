@@ -0,0 +1,107 @@
+package collector
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+type wlanSpectrumCollector struct {
+	frequencyDesc *prometheus.Desc
+	noiseDesc     *prometheus.Desc
+	ccqDesc       *prometheus.Desc
+}
+
+func newWlanSpectrumCollector() routerOSCollector {
+	c := &wlanSpectrumCollector{}
+	c.init()
+	return c
+}
+
+func (c *wlanSpectrumCollector) init() {
+	const prefix = "wlan_spectrum"
+	labelNames := []string{"name", "address", "interface", "channel"}
+	c.frequencyDesc = description(prefix, "frequency_mhz", "center frequency of the channel the interface operates on", labelNames)
+	c.noiseDesc = description(prefix, "noise_floor_dbm", "noise floor on the channel", labelNames)
+	c.ccqDesc = description(prefix, "overall_tx_ccq_percent", "client connection quality of transmissions on the interface", labelNames)
+}
+
+func (c *wlanSpectrumCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.frequencyDesc
+	ch <- c.noiseDesc
+	ch <- c.ccqDesc
+}
+
+func (c *wlanSpectrumCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/interface/wireless/print", "?disabled=false", "=.proplist=name")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching wireless interface names")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		err := c.collectForInterface(re.Map["name"], ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *wlanSpectrumCollector) collectForInterface(iface string, ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/interface/wireless/monitor", "=numbers="+iface, "=once=", "=.proplist=channel,noise-floor,overall-tx-ccq")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"interface": iface,
+			"error":     err,
+		}).Error("error fetching wireless spectrum metrics")
+		return err
+	}
+	if len(reply.Re) == 0 {
+		return nil
+	}
+
+	c.collectForStat(iface, reply.Re[0], ctx)
+
+	return nil
+}
+
+func (c *wlanSpectrumCollector) collectForStat(iface string, re *proto.Sentence, ctx *collectorContext) {
+	channel := re.Map["channel"]
+	labelValues := []string{ctx.device.Name, ctx.device.Address, iface, channel}
+
+	if v, err := parseChannelFrequency(channel); err == nil {
+		ctx.ch <- prometheus.MustNewConstMetric(c.frequencyDesc, prometheus.GaugeValue, v, labelValues...)
+	}
+
+	// the wifi packages report neither the noise floor nor the CCQ
+	if v, err := strconv.ParseFloat(re.Map["noise-floor"], 64); err == nil {
+		ctx.ch <- prometheus.MustNewConstMetric(c.noiseDesc, prometheus.GaugeValue, v, labelValues...)
+	}
+	if v, err := strconv.ParseFloat(re.Map["overall-tx-ccq"], 64); err == nil {
+		ctx.ch <- prometheus.MustNewConstMetric(c.ccqDesc, prometheus.GaugeValue, v, labelValues...)
+	}
+}
+
+func (c *wlanSpectrumCollector) requiredMenu() string {
+	return "/interface/wireless"
+}
+
+// parseChannelFrequency parses the frequency from channels reported by the
+// legacy wireless package ("5180/20-Ce/ac") and the wifi packages
+// ("5180/ax/Ceee").
+func parseChannelFrequency(channel string) (float64, error) {
+	frequency, _, _ := strings.Cut(channel, "/")
+	if frequency == "" {
+		return 0, fmt.Errorf("invalid channel %q", channel)
+	}
+
+	return strconv.ParseFloat(frequency, 64)
+}
@@ -0,0 +1,31 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseChannelFrequency(t *testing.T) {
+	testCases := []struct {
+		channel  string
+		expected float64
+		isError  bool
+	}{
+		{"5180/20-Ce/ac", 5180, false},
+		{"2412/20/gn", 2412, false},
+		{"5180/ax/Ceee", 5180, false},
+		{"5745", 5745, false},
+		{"", 0, true},
+	}
+
+	for _, tc := range testCases {
+		v, err := parseChannelFrequency(tc.channel)
+		if tc.isError {
+			assert.Error(t, err, tc.channel)
+			continue
+		}
+		assert.NoError(t, err, tc.channel)
+		assert.Equal(t, tc.expected, v, tc.channel)
+	}
+}
@@ -13,6 +13,7 @@ import (
 type wlanIFCollector struct {
 	props        []string
 	descriptions map[string]*prometheus.Desc
+	radioDesc    *prometheus.Desc
 }
 
 func newWlanIFCollector() routerOSCollector {
@@ -28,12 +29,14 @@ func (c *wlanIFCollector) init() {
 	for _, p := range c.props {
 		c.descriptions[p] = descriptionForPropertyName("wlan_interface", p, labelNames)
 	}
+	c.radioDesc = description("wlan", "radio_info", "radios of the wifi package, including those of CAPs managed by CAPsMAN", []string{"name", "address", "radio_mac", "cap", "bands"})
 }
 
 func (c *wlanIFCollector) describe(ch chan<- *prometheus.Desc) {
 	for _, d := range c.descriptions {
 		ch <- d
 	}
+	ch <- c.radioDesc
 }
 
 func (c *wlanIFCollector) collect(ctx *collectorContext) error {
@@ -49,15 +52,36 @@ func (c *wlanIFCollector) collect(ctx *collectorContext) error {
 		}
 	}
 
+	if tree := ctx.client.wifiTree(); tree != "" {
+		return c.collectRadios(tree, ctx)
+	}
+
+	return nil
+}
+
+// collectRadios exports the radios of the wifi packages, which have no
+// counterpart in the legacy wireless package.
+func (c *wlanIFCollector) collectRadios(tree string, ctx *collectorContext) error {
+	reply, err := ctx.client.Run(tree+"/radio/print", "=.proplist=radio-mac,cap,bands")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching wifi radios")
+		return err
+	}
+
+	for _, re := range reply.Re {
+		ctx.ch <- prometheus.MustNewConstMetric(c.radioDesc, prometheus.GaugeValue, 1, ctx.device.Name, ctx.device.Address, re.Map["radio-mac"], re.Map["cap"], re.Map["bands"])
+	}
+
 	return nil
 }
 
 func (c *wlanIFCollector) fetchInterfaceNames(ctx *collectorContext) ([]string, error) {
 	reply, err := ctx.client.Run("/interface/wireless/print", "?disabled=false", "=.proplist=name")
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device": ctx.device.Name,
-			"error":  err,
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
 		}).Error("error fetching wireless interface names")
 		return nil, err
 	}
@@ -73,9 +97,8 @@ func (c *wlanIFCollector) fetchInterfaceNames(ctx *collectorContext) ([]string,
 func (c *wlanIFCollector) collectForInterface(iface string, ctx *collectorContext) error {
 	reply, err := ctx.client.Run("/interface/wireless/monitor", fmt.Sprintf("=numbers=%s", iface), "=once=", "=.proplist="+strings.Join(c.props, ","))
 	if err != nil {
-		log.WithFields(log.Fields{
+		ctx.logger().WithFields(log.Fields{
 			"interface": iface,
-			"device":    ctx.device.Name,
 			"error":     err,
 		}).Error("error fetching interface statistics")
 		return err
@@ -98,10 +121,9 @@ func (c *wlanIFCollector) collectMetricForProperty(property, iface string, re *p
 	}
 	v, err := strconv.ParseFloat(re.Map[property], 64)
 	if err != nil {
-		log.WithFields(log.Fields{
+		ctx.logger().WithFields(log.Fields{
 			"property":  property,
 			"interface": iface,
-			"device":    ctx.device.Name,
 			"error":     err,
 		}).Error("error parsing interface metric value")
 		return
@@ -109,3 +131,7 @@ func (c *wlanIFCollector) collectMetricForProperty(property, iface string, re *p
 
 	ctx.ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, iface, channel)
 }
+
+func (c *wlanIFCollector) requiredMenu() string {
+	return "/interface/wireless"
+}
@@ -55,9 +55,8 @@ func (c *wlanSTACollector) collect(ctx *collectorContext) error {
 func (c *wlanSTACollector) fetch(ctx *collectorContext) ([]*proto.Sentence, error) {
 	reply, err := ctx.client.Run("/interface/wireless/registration-table/print", "=.proplist="+strings.Join(c.props, ","))
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device": ctx.device.Name,
-			"error":  err,
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
 		}).Error("error fetching wlan station metrics")
 		return nil, err
 	}
@@ -88,8 +87,7 @@ func (c *wlanSTACollector) collectMetricForProperty(property, iface, mac string,
 	}
 	v, err := strconv.ParseFloat(p, 64)
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device":   ctx.device.Name,
+		ctx.logger().WithFields(log.Fields{
 			"property": property,
 			"value":    re.Map[property],
 			"error":    err,
@@ -104,8 +102,7 @@ func (c *wlanSTACollector) collectMetricForProperty(property, iface, mac string,
 func (c *wlanSTACollector) collectMetricForTXRXCounters(property, iface, mac string, re *proto.Sentence, ctx *collectorContext) {
 	tx, rx, err := splitStringToFloats(re.Map[property])
 	if err != nil {
-		log.WithFields(log.Fields{
-			"device":   ctx.device.Name,
+		ctx.logger().WithFields(log.Fields{
 			"property": property,
 			"value":    re.Map[property],
 			"error":    err,
@@ -117,3 +114,7 @@ func (c *wlanSTACollector) collectMetricForTXRXCounters(property, iface, mac str
 	ctx.ch <- prometheus.MustNewConstMetric(desc_tx, prometheus.CounterValue, tx, ctx.device.Name, ctx.device.Address, iface, mac)
 	ctx.ch <- prometheus.MustNewConstMetric(desc_rx, prometheus.CounterValue, rx, ctx.device.Name, ctx.device.Address, iface, mac)
 }
+
+func (c *wlanSTACollector) requiredMenu() string {
+	return "/interface/wireless"
+}
@@ -0,0 +1,128 @@
+package collector
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/routeros.v2/proto"
+)
+
+type zerotierCollector struct {
+	onlineDesc *prometheus.Desc
+	statusDesc *prometheus.Desc
+	peersDesc  *prometheus.Desc
+	rxDesc     *prometheus.Desc
+	txDesc     *prometheus.Desc
+}
+
+func newZeroTierCollector() routerOSCollector {
+	c := &zerotierCollector{}
+	c.init()
+	return c
+}
+
+func (c *zerotierCollector) init() {
+	const prefix = "zerotier"
+	labelNames := []string{"name", "address", "interface", "network"}
+	c.onlineDesc = description(prefix, "network_online", "whether the ZeroTier network is joined and the interface is running", labelNames)
+	c.statusDesc = description(prefix, "network_status", "status of the ZeroTier network, 1 for the current status", append(labelNames, "status"))
+	c.peersDesc = description(prefix, "peers", "number of ZeroTier peers", []string{"name", "address", "role"})
+	c.rxDesc = description(prefix, "rx_bytes", "number of bytes received on the ZeroTier interface", labelNames)
+	c.txDesc = description(prefix, "tx_bytes", "number of bytes sent on the ZeroTier interface", labelNames)
+}
+
+func (c *zerotierCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- c.onlineDesc
+	ch <- c.statusDesc
+	ch <- c.peersDesc
+	ch <- c.rxDesc
+	ch <- c.txDesc
+}
+
+func (c *zerotierCollector) collect(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/zerotier/interface/print", "?disabled=false", "=.proplist=name,network,status,running")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching zerotier interfaces")
+		return err
+	}
+
+	if len(reply.Re) > 0 {
+		traffic, err := c.fetchTraffic(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, re := range reply.Re {
+			c.collectForStat(re, traffic[re.Map["name"]], ctx)
+		}
+	}
+
+	return c.collectPeers(ctx)
+}
+
+// fetchTraffic returns the statistics of the ZeroTier interfaces by name.
+func (c *zerotierCollector) fetchTraffic(ctx *collectorContext) (map[string]*proto.Sentence, error) {
+	reply, err := ctx.client.Run("/interface/print", "?type=zerotier", "=.proplist=name,rx-byte,tx-byte")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching zerotier interface metrics")
+		return nil, err
+	}
+
+	traffic := make(map[string]*proto.Sentence)
+	for _, re := range reply.Re {
+		traffic[re.Map["name"]] = re
+	}
+
+	return traffic, nil
+}
+
+func (c *zerotierCollector) collectForStat(re *proto.Sentence, traffic *proto.Sentence, ctx *collectorContext) {
+	labelValues := []string{ctx.device.Name, ctx.device.Address, re.Map["name"], re.Map["network"]}
+
+	online := 0.0
+	if re.Map["status"] == "OK" && re.Map["running"] == "true" {
+		online = 1.0
+	}
+	ctx.ch <- prometheus.MustNewConstMetric(c.onlineDesc, prometheus.GaugeValue, online, labelValues...)
+	ctx.ch <- prometheus.MustNewConstMetric(c.statusDesc, prometheus.GaugeValue, 1, append(labelValues, re.Map["status"])...)
+
+	if traffic == nil {
+		return
+	}
+
+	for desc, property := range map[*prometheus.Desc]string{c.rxDesc: "rx-byte", c.txDesc: "tx-byte"} {
+		if v, err := strconv.ParseFloat(traffic.Map[property], 64); err == nil {
+			ctx.ch <- ctx.counter(desc, v, labelValues...)
+		}
+	}
+}
+
+func (c *zerotierCollector) collectPeers(ctx *collectorContext) error {
+	reply, err := ctx.client.Run("/zerotier/peer/print", "=.proplist=role")
+	if err != nil {
+		ctx.logger().WithFields(log.Fields{
+			"error": err,
+		}).Error("error fetching zerotier peers")
+		return err
+	}
+
+	counts := make(map[string]float64)
+	for _, re := range reply.Re {
+		counts[re.Map["role"]]++
+	}
+
+	for role, v := range counts {
+		ctx.ch <- prometheus.MustNewConstMetric(c.peersDesc, prometheus.GaugeValue, v, ctx.device.Name, ctx.device.Address, role)
+	}
+
+	return nil
+}
+
+func (c *zerotierCollector) requiredMenu() string {
+	return "/zerotier"
+}
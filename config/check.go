@@ -0,0 +1,177 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Check loads the config like Load, but rejects unknown keys, and returns all
+// problems found in it.
+func Check(r io.Reader) []error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return []error{err}
+	}
+
+	if errs := checkKeys(b); len(errs) > 0 {
+		return errs
+	}
+
+	c, err := Load(bytes.NewReader(b))
+	if err != nil {
+		return []error{err}
+	}
+
+	return c.validate()
+}
+
+// CheckFiles checks the config files like Check and the config merged from
+// them.
+func CheckFiles(paths []string) []error {
+	files, err := Files(paths)
+	if err != nil {
+		return []error{err}
+	}
+
+	var errs []error
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return []error{err}
+		}
+		for _, err := range checkKeys(b) {
+			errs = append(errs, fmt.Errorf("%s: %w", f, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	c, err := LoadFiles(paths)
+	if err != nil {
+		return []error{err}
+	}
+
+	return c.validate()
+}
+
+// checkKeys returns the problems decoding the config, including unknown keys.
+func checkKeys(b []byte) []error {
+	b, err := expandEnv(b)
+	if err != nil {
+		return []error{err}
+	}
+
+	err = yaml.UnmarshalStrict(b, &Config{})
+	if te, ok := err.(*yaml.TypeError); ok {
+		return typeErrors(te)
+	}
+	if err != nil {
+		return []error{err}
+	}
+
+	return nil
+}
+
+// typeErrors returns an error per problem found while decoding, reporting
+// unknown keys without the type they were not found in.
+func typeErrors(te *yaml.TypeError) []error {
+	errs := make([]error, 0, len(te.Errors))
+	for _, msg := range te.Errors {
+		if before, _, ok := strings.Cut(msg, " not found in type "); ok {
+			msg = strings.Replace(before, "field ", "unknown key ", 1)
+		}
+		errs = append(errs, errors.New(msg))
+	}
+
+	return errs
+}
+
+// validate returns the problems of the devices, profiles and features.
+func (c *Config) validate() []error {
+	var errs []error
+	features := FeatureNames()
+	checkFeatures := func(owner string, names []string) {
+		for _, f := range names {
+			if !slices.Contains(features, f) {
+				errs = append(errs, fmt.Errorf("unknown feature %s of %s", f, owner))
+			}
+		}
+	}
+
+	tenants := make(map[string]bool)
+	for _, t := range c.Tenants {
+		tenants[t.Name] = true
+	}
+
+	names := make(map[string]bool)
+	for i, d := range c.Devices {
+		if d.Name == "" {
+			errs = append(errs, fmt.Errorf("missing name of device %d", i+1))
+		} else if names[d.Name] {
+			errs = append(errs, fmt.Errorf("duplicate device %s", d.Name))
+		}
+		names[d.Name] = true
+
+		if d.Address == "" && d.Srv.Record == "" && d.Consul.Service == "" && len(d.MNDP.Interfaces) == 0 {
+			errs = append(errs, fmt.Errorf("missing address, srv, consul or mndp of device %s", d.Name))
+		}
+		switch d.Transport {
+		case "", TransportAPI:
+			if d.User == "" && d.UserFile == "" {
+				errs = append(errs, fmt.Errorf("missing user of device %s", d.Name))
+			}
+			if d.Password == "" && d.PasswordFile == "" && d.CertFile == "" {
+				errs = append(errs, fmt.Errorf("missing password of device %s", d.Name))
+			}
+		case TransportSNMP:
+		default:
+			errs = append(errs, fmt.Errorf("unknown transport %s of device %s", d.Transport, d.Name))
+		}
+		if d.Tenant != "" && len(c.Tenants) > 0 && !tenants[d.Tenant] {
+			errs = append(errs, fmt.Errorf("unknown tenant %s of device %s", d.Tenant, d.Name))
+		}
+		if (d.CertFile == "") != (d.KeyFile == "") {
+			errs = append(errs, fmt.Errorf("cert_file and key_file of device %s must be set together", d.Name))
+		}
+		if d.SourceAddress != "" && net.ParseIP(d.SourceAddress) == nil {
+			errs = append(errs, fmt.Errorf("invalid source_address %s of device %s", d.SourceAddress, d.Name))
+		}
+		checkFeatures("device "+d.Name, d.Features)
+	}
+	if c.SourceAddress != "" && net.ParseIP(c.SourceAddress) == nil {
+		errs = append(errs, fmt.Errorf("invalid source_address %s", c.SourceAddress))
+	}
+
+	for name, p := range c.Profiles {
+		checkFeatures("profile "+name, p.Features)
+	}
+	checkFeatures("features.exclude", c.Features.Exclude)
+
+	return errs
+}
+
+// FeatureNames returns the names of all features which can be enabled
+func FeatureNames() []string {
+	var names []string
+
+	t := reflect.TypeOf(Config{}.Features)
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name == "all" || name == "exclude" {
+			continue
+		}
+		names = append(names, name)
+	}
+
+	return names
+}
@@ -1,7 +1,15 @@
 package config
 
 import (
+	"fmt"
 	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	yaml "gopkg.in/yaml.v2"
 )
@@ -10,36 +18,456 @@ import (
 type Config struct {
 	Devices  []Device `yaml:"devices"`
 	Features struct {
-		BGP       bool `yaml:"bgp,omitempty"`
-		Conntrack bool `yaml:"conntrack,omitempty"`
-		DHCP      bool `yaml:"dhcp,omitempty"`
-		DHCPL     bool `yaml:"dhcpl,omitempty"`
-		DHCPv6    bool `yaml:"dhcpv6,omitempty"`
-		Firmware  bool `yaml:"firmware,omitempty"`
-		Health    bool `yaml:"health,omitempty"`
-		Routes    bool `yaml:"routes,omitempty"`
-		POE       bool `yaml:"poe,omitempty"`
-		Pools     bool `yaml:"pools,omitempty"`
-		Optics    bool `yaml:"optics,omitempty"`
-		W60G      bool `yaml:"w60g,omitempty"`
-		WlanSTA   bool `yaml:"wlansta,omitempty"`
-		Capsman   bool `yaml:"capsman,omitempty"`
-		WlanIF    bool `yaml:"wlanif,omitempty"`
-		Monitor   bool `yaml:"monitor,omitempty"`
-		Ipsec     bool `yaml:"ipsec,omitempty"`
-		Lte       bool `yaml:"lte,omitempty"`
-		Netwatch  bool `yaml:"netwatch,omitempty"`
+		BGP             bool `yaml:"bgp,omitempty"`
+		Conntrack       bool `yaml:"conntrack,omitempty"`
+		DHCP            bool `yaml:"dhcp,omitempty"`
+		DHCPL           bool `yaml:"dhcpl,omitempty"`
+		DHCPv6          bool `yaml:"dhcpv6,omitempty"`
+		Firmware        bool `yaml:"firmware,omitempty"`
+		Health          bool `yaml:"health,omitempty"`
+		Routes          bool `yaml:"routes,omitempty"`
+		POE             bool `yaml:"poe,omitempty"`
+		Pools           bool `yaml:"pools,omitempty"`
+		Optics          bool `yaml:"optics,omitempty"`
+		W60G            bool `yaml:"w60g,omitempty"`
+		WlanSTA         bool `yaml:"wlansta,omitempty"`
+		Capsman         bool `yaml:"capsman,omitempty"`
+		WlanIF          bool `yaml:"wlanif,omitempty"`
+		Monitor         bool `yaml:"monitor,omitempty"`
+		Ipsec           bool `yaml:"ipsec,omitempty"`
+		Lte             bool `yaml:"lte,omitempty"`
+		Netwatch        bool `yaml:"netwatch,omitempty"`
+		Torch           bool `yaml:"torch,omitempty"`
+		Traffic         bool `yaml:"traffic,omitempty"`
+		History         bool `yaml:"history,omitempty"`
+		Wireguard       bool `yaml:"wireguard,omitempty"`
+		OSPF            bool `yaml:"ospf,omitempty"`
+		Queues          bool `yaml:"queues,omitempty"`
+		NAT             bool `yaml:"nat,omitempty"`
+		Mangle          bool `yaml:"mangle,omitempty"`
+		BridgeHosts     bool `yaml:"bridge_hosts,omitempty"`
+		STP             bool `yaml:"stp,omitempty"`
+		VRRP            bool `yaml:"vrrp,omitempty"`
+		PPP             bool `yaml:"ppp,omitempty"`
+		PPPoE           bool `yaml:"pppoe,omitempty"`
+		Hotspot         bool `yaml:"hotspot,omitempty"`
+		UserManager     bool `yaml:"usermanager,omitempty"`
+		DNS             bool `yaml:"dns,omitempty"`
+		NTP             bool `yaml:"ntp,omitempty"`
+		Disk            bool `yaml:"disk,omitempty"`
+		Container       bool `yaml:"container,omitempty"`
+		GPS             bool `yaml:"gps,omitempty"`
+		MPLS            bool `yaml:"mpls,omitempty"`
+		Tunnels         bool `yaml:"tunnels,omitempty"`
+		VXLAN           bool `yaml:"vxlan,omitempty"`
+		Bonding         bool `yaml:"bonding,omitempty"`
+		SwitchPorts     bool `yaml:"switch_ports,omitempty"`
+		CPU             bool `yaml:"cpu,omitempty"`
+		Dot1x           bool `yaml:"dot1x,omitempty"`
+		IPServices      bool `yaml:"ip_services,omitempty"`
+		Log             bool `yaml:"log,omitempty"`
+		Scheduler       bool `yaml:"scheduler,omitempty"`
+		InterfaceQueues bool `yaml:"interface_queues,omitempty"`
+		VLAN            bool `yaml:"vlan,omitempty"`
+		Neighbors       bool `yaml:"neighbors,omitempty"`
+		RIP             bool `yaml:"rip,omitempty"`
+		KidControl      bool `yaml:"kid_control,omitempty"`
+		WebProxy        bool `yaml:"web_proxy,omitempty"`
+		UPnP            bool `yaml:"upnp,omitempty"`
+		DHCPClient      bool `yaml:"dhcp_client,omitempty"`
+		DHCPv6Client    bool `yaml:"dhcpv6_client,omitempty"`
+		RADIUS          bool `yaml:"radius,omitempty"`
+		ZeroTier        bool `yaml:"zerotier,omitempty"`
+		SMB             bool `yaml:"smb,omitempty"`
+		WlanSpectrum    bool `yaml:"wlan_spectrum,omitempty"`
+		TrafficFlow     bool `yaml:"traffic_flow,omitempty"`
+		Ping            bool `yaml:"ping,omitempty"`
+		BackToHome      bool `yaml:"back_to_home,omitempty"`
+		Profile         bool `yaml:"profile,omitempty"`
+		ARP             bool `yaml:"arp,omitempty"`
+
+		All     bool     `yaml:"all,omitempty"`
+		Exclude []string `yaml:"exclude,omitempty"`
 	} `yaml:"features,omitempty"`
+	PPP                   PPPConfig           `yaml:"ppp,omitempty"`
+	Traffic               TrafficConfig       `yaml:"traffic,omitempty"`
+	Torch                 TorchConfig         `yaml:"torch,omitempty"`
+	Ping                  PingConfig          `yaml:"ping,omitempty"`
+	Profile               ProfileConfig       `yaml:"profile,omitempty"`
+	CounterWrapCorrection bool                `yaml:"counter_wrap_correction,omitempty"`
+	LegacyMetricTypes     bool                `yaml:"legacy_metric_types,omitempty"`
+	CommentLabels         bool                `yaml:"comment_labels,omitempty"`
+	HA                    HAConfig            `yaml:"ha,omitempty"`
+	Graphite              GraphiteConfig      `yaml:"graphite,omitempty"`
+	Pushgateway           PushgatewayConfig   `yaml:"pushgateway,omitempty"`
+	InfluxDB              InfluxDBConfig      `yaml:"influxdb,omitempty"`
+	OTLP                  OTLPConfig          `yaml:"otlp,omitempty"`
+	RateLimit             RateLimit           `yaml:"rate_limit,omitempty"`
+	StaleGracePeriod      time.Duration       `yaml:"stale_grace_period,omitempty"`
+	ScrapeCacheTTL        time.Duration       `yaml:"scrape_cache_ttl,omitempty"`
+	PollInterval          time.Duration       `yaml:"poll_interval,omitempty"`
+	WarmUp                bool                `yaml:"warm_up,omitempty"`
+	PoolIdleTimeout       time.Duration       `yaml:"pool_idle_timeout,omitempty"`
+	ScrapeTimeout         time.Duration       `yaml:"scrape_timeout,omitempty"`
+	MaxConcurrentScrapes  int                 `yaml:"max_concurrent_scrapes,omitempty"`
+	Parallelism           int                 `yaml:"parallelism,omitempty"`
+	SeriesLimit           int                 `yaml:"series_limit,omitempty"`
+	Retry                 Retry               `yaml:"retry,omitempty"`
+	CircuitBreaker        CircuitBreaker      `yaml:"circuit_breaker,omitempty"`
+	Proxy                 Proxy               `yaml:"proxy,omitempty"`
+	SourceAddress         string              `yaml:"source_address,omitempty"`
+	LastErrorInfo         bool                `yaml:"last_error_info,omitempty"`
+	IdentityLabels        string              `yaml:"identity_labels,omitempty"`
+	HealthQuorum          int                 `yaml:"health_quorum,omitempty"`
+	Tenants               []Tenant            `yaml:"tenants,omitempty"`
+	Maintenance           []MaintenanceWindow `yaml:"maintenance,omitempty"`
+	ReloadToken           string              `yaml:"reload_token,omitempty"`
+	ConfigEndpoint        bool                `yaml:"config_endpoint,omitempty"`
+	ProbeToken            string              `yaml:"probe_token,omitempty"`
+	Profiles              map[string]Profile  `yaml:"profiles,omitempty"`
+	Defaults              Profile             `yaml:"defaults,omitempty"`
+
+	// ProbeTargets are the addresses, hostnames and CIDR prefixes which may
+	// be probed besides the configured devices
+	ProbeTargets []string `yaml:"probe_targets,omitempty"`
+
+	// CollectorIntervals runs the named collectors at most once per
+	// interval, e.g. firmware every 30m
+	CollectorIntervals map[string]time.Duration `yaml:"collector_intervals,omitempty"`
+	// MetricFilters drops metrics of the named collectors before they are
+	// exported
+	MetricFilters map[string]MetricFilter `yaml:"metric_filters,omitempty"`
+	// Relabel rewrites the labels of the metrics of the devices in order
+	Relabel []RelabelRule `yaml:"relabel,omitempty"`
+	// CollectorSeriesLimits truncates the series of the named collectors
+	// per device and scrape, e.g. routes on full-table edge routers
+	CollectorSeriesLimits map[string]int `yaml:"collector_series_limits,omitempty"`
+	// CustomCollectors export the replies of API commands without a
+	// dedicated collector
+	CustomCollectors []CustomCollector `yaml:"custom_collectors,omitempty"`
+	// Scripts run on the devices during a scrape to export the values they
+	// print
+	Scripts []Script `yaml:"scripts,omitempty"`
+	// BandwidthTests run /tool bandwidth-test from the devices on a schedule
+	// to measure the capacity of their links
+	BandwidthTests []BandwidthTest `yaml:"bandwidth_tests,omitempty"`
 }
 
 // Device represents a target device
 type Device struct {
-	Name     string    `yaml:"name"`
-	Address  string    `yaml:"address,omitempty"`
-	Srv      SrvRecord `yaml:"srv,omitempty"`
-	User     string    `yaml:"user"`
-	Password string    `yaml:"password"`
-	Port     string    `yaml:"port"`
+	Name          string            `yaml:"name"`
+	Address       string            `yaml:"address,omitempty"`
+	Srv           SrvRecord         `yaml:"srv,omitempty"`
+	Consul        ConsulService     `yaml:"consul,omitempty"`
+	MNDP          MNDPDiscovery     `yaml:"mndp,omitempty"`
+	User          string            `yaml:"user"`
+	Password      string            `yaml:"password"`
+	UserFile      string            `yaml:"user_file,omitempty"`
+	PasswordFile  string            `yaml:"password_file,omitempty"`
+	Port          string            `yaml:"port"`
+	Tenant        string            `yaml:"tenant,omitempty"`
+	CAFile        string            `yaml:"ca_file,omitempty"`
+	CertFile      string            `yaml:"cert_file,omitempty"`
+	KeyFile       string            `yaml:"key_file,omitempty"`
+	PoEBudget     float64           `yaml:"poe_budget,omitempty"`
+	RoutesSummary bool              `yaml:"routes_summary,omitempty"`
+	Profile       string            `yaml:"profile,omitempty"`
+	TLS           *bool             `yaml:"tls,omitempty"`
+	Insecure      *bool             `yaml:"insecure,omitempty"`
+	ServerName    string            `yaml:"server_name,omitempty"`
+	Timeout       time.Duration     `yaml:"timeout,omitempty"`
+	ScrapeTimeout time.Duration     `yaml:"scrape_timeout,omitempty"`
+	Parallelism   int               `yaml:"parallelism,omitempty"`
+	SeriesLimit   int               `yaml:"series_limit,omitempty"`
+	RateLimit     RateLimit         `yaml:"rate_limit,omitempty"`
+	Transport     string            `yaml:"transport,omitempty"`
+	SNMP          SNMP              `yaml:"snmp,omitempty"`
+	SSH           SSH               `yaml:"ssh,omitempty"`
+	Proxy         Proxy             `yaml:"proxy,omitempty"`
+	SourceAddress string            `yaml:"source_address,omitempty"`
+	Features      []string          `yaml:"features,omitempty"`
+	Labels        map[string]string `yaml:"labels,omitempty"`
+}
+
+// Profile holds the settings shared by the devices referencing it. Settings
+// of a device take precedence over the settings of its profile.
+type Profile struct {
+	User          string            `yaml:"user,omitempty"`
+	Password      string            `yaml:"password,omitempty"`
+	UserFile      string            `yaml:"user_file,omitempty"`
+	PasswordFile  string            `yaml:"password_file,omitempty"`
+	Port          string            `yaml:"port,omitempty"`
+	TLS           *bool             `yaml:"tls,omitempty"`
+	Insecure      *bool             `yaml:"insecure,omitempty"`
+	ServerName    string            `yaml:"server_name,omitempty"`
+	CAFile        string            `yaml:"ca_file,omitempty"`
+	CertFile      string            `yaml:"cert_file,omitempty"`
+	KeyFile       string            `yaml:"key_file,omitempty"`
+	Timeout       time.Duration     `yaml:"timeout,omitempty"`
+	ScrapeTimeout time.Duration     `yaml:"scrape_timeout,omitempty"`
+	Parallelism   int               `yaml:"parallelism,omitempty"`
+	SeriesLimit   int               `yaml:"series_limit,omitempty"`
+	RateLimit     RateLimit         `yaml:"rate_limit,omitempty"`
+	Transport     string            `yaml:"transport,omitempty"`
+	SNMP          SNMP              `yaml:"snmp,omitempty"`
+	SSH           SSH               `yaml:"ssh,omitempty"`
+	Proxy         Proxy             `yaml:"proxy,omitempty"`
+	SourceAddress string            `yaml:"source_address,omitempty"`
+	Features      []string          `yaml:"features,omitempty"`
+	Labels        map[string]string `yaml:"labels,omitempty"`
+}
+
+// Transports devices are scraped with
+const (
+	TransportAPI  = "api"
+	TransportSNMP = "snmp"
+)
+
+// SNMP configures scraping a device with SNMPv2c instead of the API
+type SNMP struct {
+	Community string `yaml:"community,omitempty"`
+	Port      string `yaml:"port,omitempty"`
+}
+
+// SSH configures API commands which are run on the command line over SSH
+// instead, e.g. as they are broken in the API of some RouterOS versions
+type SSH struct {
+	Commands   []string `yaml:"commands,omitempty"`
+	Port       string   `yaml:"port,omitempty"`
+	KnownHosts string   `yaml:"known_hosts,omitempty"`
+	Insecure   bool     `yaml:"insecure,omitempty"`
+}
+
+// Proxy configures connecting to devices through a SOCKS5 proxy or an SSH jump
+// host, which the user logs in to with the key file
+type Proxy struct {
+	SOCKS5     string `yaml:"socks5,omitempty"`
+	SSH        string `yaml:"ssh,omitempty"`
+	User       string `yaml:"user,omitempty"`
+	Password   string `yaml:"password,omitempty"`
+	KeyFile    string `yaml:"key_file,omitempty"`
+	KnownHosts string `yaml:"known_hosts,omitempty"`
+	Insecure   bool   `yaml:"insecure,omitempty"`
+}
+
+// MaintenanceWindow is a period during which devices are not scraped. It
+// either starts once at Start or repeatedly following the cron Schedule.
+type MaintenanceWindow struct {
+	Devices  []string      `yaml:"devices,omitempty"`
+	Schedule string        `yaml:"schedule,omitempty"`
+	Start    time.Time     `yaml:"start,omitempty"`
+	Duration time.Duration `yaml:"duration"`
+}
+
+// MetricFilter selects the metrics of a collector by regular expressions
+// matching the whole metric name or label value. Metrics are exported if they
+// match an include pattern, if any, and no exclude pattern.
+type MetricFilter struct {
+	Include       []string          `yaml:"include,omitempty"`
+	Exclude       []string          `yaml:"exclude,omitempty"`
+	IncludeLabels map[string]string `yaml:"include_labels,omitempty"`
+	ExcludeLabels map[string]string `yaml:"exclude_labels,omitempty"`
+}
+
+// RelabelRule is a relabeling rule like those of Prometheus. The values of the
+// source labels joined by the separator are matched against the regular
+// expression, which must match the whole value.
+type RelabelRule struct {
+	SourceLabels []string `yaml:"source_labels,omitempty"`
+	// Separator defaults to ;
+	Separator string `yaml:"separator,omitempty"`
+	// Regex defaults to (.*)
+	Regex       string `yaml:"regex,omitempty"`
+	TargetLabel string `yaml:"target_label,omitempty"`
+	// Replacement defaults to $1
+	Replacement *string `yaml:"replacement,omitempty"`
+	// Action is replace, keep, drop or labeldrop and defaults to replace
+	Action string `yaml:"action,omitempty"`
+}
+
+// CustomCollector exports the fields of the entries an API command returns as
+// metrics named mikrotik_custom_<name>_<field>, labelled with the label
+// fields.
+type CustomCollector struct {
+	Name    string `yaml:"name"`
+	Command string `yaml:"command"`
+	// Query words filter the entries, e.g. ?disabled=false
+	Query  []string      `yaml:"query,omitempty"`
+	Labels []string      `yaml:"labels,omitempty"`
+	Values []CustomValue `yaml:"values"`
+}
+
+// CustomValue is a field of a custom collector exported as a metric
+type CustomValue struct {
+	Field string `yaml:"field"`
+	// Type is gauge or counter and defaults to gauge
+	Type string `yaml:"type,omitempty"`
+	Help string `yaml:"help,omitempty"`
+}
+
+// Script runs on the devices during a scrape, printing lines like key=value
+// exported as mikrotik_script_value. It either runs the script of the
+// /system script entry named by Script or the Source.
+type Script struct {
+	Name   string `yaml:"name"`
+	Script string `yaml:"script,omitempty"`
+	Source string `yaml:"source,omitempty"`
+}
+
+// BandwidthTest runs /tool bandwidth-test from the device to the bandwidth
+// test server at the target on the cron schedule, e.g. off-peak. The tx
+// speeds limit the rates of the test like 100M.
+type BandwidthTest struct {
+	Device        string        `yaml:"device"`
+	Target        string        `yaml:"target"`
+	Schedule      string        `yaml:"schedule"`
+	Duration      time.Duration `yaml:"duration,omitempty"`
+	Protocol      string        `yaml:"protocol,omitempty"`
+	Direction     string        `yaml:"direction,omitempty"`
+	LocalTxSpeed  string        `yaml:"local_tx_speed,omitempty"`
+	RemoteTxSpeed string        `yaml:"remote_tx_speed,omitempty"`
+	User          string        `yaml:"user,omitempty"`
+	Password      string        `yaml:"password,omitempty"`
+}
+
+// Tenant is a customer whose devices are served to requests bearing its token
+type Tenant struct {
+	Name  string `yaml:"name"`
+	Token string `yaml:"token"`
+}
+
+// TorchConfig configures the torch top talkers sampling
+type TorchConfig struct {
+	Interface  string        `yaml:"interface"`
+	Duration   time.Duration `yaml:"duration,omitempty"`
+	Interval   time.Duration `yaml:"interval,omitempty"`
+	Top        int           `yaml:"top,omitempty"`
+	IPv4Prefix int           `yaml:"ipv4_prefix,omitempty"`
+	IPv6Prefix int           `yaml:"ipv6_prefix,omitempty"`
+}
+
+// PingConfig configures the targets the devices ping
+type PingConfig struct {
+	Targets  []string      `yaml:"targets"`
+	Count    int           `yaml:"count,omitempty"`
+	Interval time.Duration `yaml:"interval,omitempty"`
+}
+
+// ProfileConfig configures the sampling of the CPU usage by process
+type ProfileConfig struct {
+	Duration time.Duration `yaml:"duration,omitempty"`
+	Interval time.Duration `yaml:"interval,omitempty"`
+}
+
+// PPPConfig configures the PPP session metrics
+type PPPConfig struct {
+	Sessions bool `yaml:"sessions"`
+}
+
+// TrafficConfig configures the interfaces monitored with monitor-traffic
+type TrafficConfig struct {
+	Interfaces []string `yaml:"interfaces"`
+}
+
+// HAConfig configures the leader election between exporter instances
+type HAConfig struct {
+	LeaseFile     string        `yaml:"lease_file"`
+	ID            string        `yaml:"id,omitempty"`
+	LeaseDuration time.Duration `yaml:"lease_duration,omitempty"`
+}
+
+// GraphiteConfig configures pushing metrics to a graphite server
+type GraphiteConfig struct {
+	Address  string        `yaml:"address"`
+	Prefix   string        `yaml:"prefix,omitempty"`
+	Interval time.Duration `yaml:"interval,omitempty"`
+	// Protocol is plaintext or pickle, defaults to plaintext
+	Protocol string `yaml:"protocol,omitempty"`
+}
+
+// Protocols metrics are pushed to graphite with
+const (
+	GraphiteProtocolPlaintext = "plaintext"
+	GraphiteProtocolPickle    = "pickle"
+)
+
+// PushgatewayConfig configures pushing metrics to a Pushgateway, grouped by
+// device
+type PushgatewayConfig struct {
+	URL      string        `yaml:"url"`
+	Job      string        `yaml:"job,omitempty"`
+	Interval time.Duration `yaml:"interval,omitempty"`
+}
+
+// InfluxDBConfig configures writing metrics to an InfluxDB v2 bucket in the
+// line protocol
+type InfluxDBConfig struct {
+	URL      string        `yaml:"url"`
+	Org      string        `yaml:"org"`
+	Bucket   string        `yaml:"bucket"`
+	Token    string        `yaml:"token,omitempty"`
+	Interval time.Duration `yaml:"interval,omitempty"`
+}
+
+// OTLPConfig configures exporting metrics to an OpenTelemetry collector
+type OTLPConfig struct {
+	Endpoint string `yaml:"endpoint"`
+	// Protocol is grpc or http, defaults to grpc
+	Protocol string `yaml:"protocol,omitempty"`
+	Insecure bool   `yaml:"insecure,omitempty"`
+	// Headers are sent with each export, e.g. to authenticate
+	Headers  map[string]string `yaml:"headers,omitempty"`
+	Interval time.Duration     `yaml:"interval,omitempty"`
+	// Traces exports a trace of each scrape to the same collector
+	Traces bool `yaml:"traces,omitempty"`
+}
+
+// RateLimit limits the API commands per second sent to each device
+type RateLimit struct {
+	Rate  float64       `yaml:"rate,omitempty"`
+	Burst int           `yaml:"burst,omitempty"`
+	Delay time.Duration `yaml:"delay,omitempty"`
+}
+
+// Limit returns the commands per second and the burst of the rate limit. A
+// delay between commands is a rate without burst.
+func (r RateLimit) Limit() (float64, int) {
+	if r.Delay > 0 {
+		return 1 / r.Delay.Seconds(), 1
+	}
+
+	return r.Rate, r.Burst
+}
+
+// Retry configures retrying to connect and commands failing due to a dropped
+// connection during a scrape. Attempts is nil for the default, 0 disables
+// retries.
+type Retry struct {
+	Attempts *int          `yaml:"attempts,omitempty"`
+	Backoff  time.Duration `yaml:"backoff,omitempty"`
+}
+
+// CircuitBreaker configures skipping devices after consecutive failed scrapes
+type CircuitBreaker struct {
+	Failures      int           `yaml:"failures"`
+	ProbeInterval time.Duration `yaml:"probe_interval,omitempty"`
+}
+
+// ConsulService discovers the devices registered as a service in the Consul
+// catalog
+type ConsulService struct {
+	Address    string   `yaml:"address,omitempty"`
+	Service    string   `yaml:"service"`
+	Tags       []string `yaml:"tags,omitempty"`
+	Datacenter string   `yaml:"datacenter,omitempty"`
+	Token      string   `yaml:"token,omitempty"`
+}
+
+// MNDPDiscovery discovers the devices announcing themselves with the MikroTik
+// Neighbor Discovery Protocol on the interfaces of the exporter
+type MNDPDiscovery struct {
+	Interfaces []string      `yaml:"interfaces"`
+	Expiry     time.Duration `yaml:"expiry,omitempty"`
 }
 
 type SrvRecord struct {
@@ -51,6 +479,268 @@ type DnsServer struct {
 	Port    int    `yaml:"port"`
 }
 
+// Credentials returns the user and password of the device, reading them from
+// the user and password files if set, e.g. secrets mounted by Docker or
+// Kubernetes.
+func (d *Device) Credentials() (string, string, error) {
+	user, err := readSecret(d.User, d.UserFile)
+	if err != nil {
+		return "", "", err
+	}
+
+	password, err := readSecret(d.Password, d.PasswordFile)
+	if err != nil {
+		return "", "", err
+	}
+
+	return user, password, nil
+}
+
+func readSecret(value, file string) (string, error) {
+	if file == "" {
+		return value, nil
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// Apply fills the settings not set on the device from the profile.
+func (p Profile) Apply(d *Device) {
+	if d.User == "" && d.UserFile == "" {
+		d.User = p.User
+		d.UserFile = p.UserFile
+	}
+	if d.Password == "" && d.PasswordFile == "" {
+		d.Password = p.Password
+		d.PasswordFile = p.PasswordFile
+	}
+	if d.Port == "" {
+		d.Port = p.Port
+	}
+	if d.TLS == nil {
+		d.TLS = p.TLS
+	}
+	if d.Insecure == nil {
+		d.Insecure = p.Insecure
+	}
+	if d.ServerName == "" {
+		d.ServerName = p.ServerName
+	}
+	if d.CAFile == "" {
+		d.CAFile = p.CAFile
+	}
+	if d.CertFile == "" {
+		d.CertFile, d.KeyFile = p.CertFile, p.KeyFile
+	}
+	if d.Timeout == 0 {
+		d.Timeout = p.Timeout
+	}
+	if d.ScrapeTimeout == 0 {
+		d.ScrapeTimeout = p.ScrapeTimeout
+	}
+	if d.Parallelism == 0 {
+		d.Parallelism = p.Parallelism
+	}
+	if d.SeriesLimit == 0 {
+		d.SeriesLimit = p.SeriesLimit
+	}
+	if d.RateLimit == (RateLimit{}) {
+		d.RateLimit = p.RateLimit
+	}
+	if d.Transport == "" {
+		d.Transport = p.Transport
+	}
+	if d.SNMP == (SNMP{}) {
+		d.SNMP = p.SNMP
+	}
+	if len(d.SSH.Commands) == 0 {
+		d.SSH = p.SSH
+	}
+	if d.Proxy == (Proxy{}) {
+		d.Proxy = p.Proxy
+	}
+	if d.SourceAddress == "" {
+		d.SourceAddress = p.SourceAddress
+	}
+	if len(d.Features) == 0 {
+		d.Features = p.Features
+	}
+	if len(p.Labels) > 0 {
+		labels := make(map[string]string, len(p.Labels)+len(d.Labels))
+		for k, v := range p.Labels {
+			labels[k] = v
+		}
+		for k, v := range d.Labels {
+			labels[k] = v
+		}
+		d.Labels = labels
+	}
+}
+
+// applyProfiles fills the settings not set on the devices from their profiles
+// and then from the defaults. Devices discovered in Consul get the defaults
+// once their profile is known.
+func (c *Config) applyProfiles() error {
+	for i := range c.Devices {
+		d := &c.Devices[i]
+		if d.Profile != "" {
+			p, ok := c.Profiles[d.Profile]
+			if !ok {
+				return fmt.Errorf("unknown profile %s of device %s", d.Profile, d.Name)
+			}
+			p.Apply(d)
+		}
+
+		if d.Consul.Service == "" {
+			c.Defaults.Apply(d)
+		}
+	}
+
+	return nil
+}
+
+// DeviceFeature returns whether a device or profile enables the feature on
+// its own
+func (c *Config) DeviceFeature(feature string) bool {
+	for _, d := range c.Devices {
+		if slices.Contains(d.Features, feature) {
+			return true
+		}
+	}
+	for _, p := range c.Profiles {
+		if slices.Contains(p.Features, feature) {
+			return true
+		}
+	}
+
+	return slices.Contains(c.Defaults.Features, feature)
+}
+
+// DeviceLabels returns whether a device or profile sets static labels
+func (c *Config) DeviceLabels() bool {
+	for _, d := range c.Devices {
+		if len(d.Labels) > 0 {
+			return true
+		}
+	}
+	for _, p := range c.Profiles {
+		if len(p.Labels) > 0 {
+			return true
+		}
+	}
+
+	return len(c.Defaults.Labels) > 0
+}
+
+// NormalizeAddress removes the brackets around an IPv6 address, taking the port
+// from the address if given like [2001:db8::1]:8729.
+func (d *Device) NormalizeAddress() {
+	if !strings.HasPrefix(d.Address, "[") {
+		return
+	}
+
+	if host, port, err := net.SplitHostPort(d.Address); err == nil {
+		d.Address = host
+		if d.Port == "" {
+			d.Port = port
+		}
+		return
+	}
+
+	d.Address = strings.TrimSuffix(strings.TrimPrefix(d.Address, "["), "]")
+}
+
+// DeviceRateLimits returns whether devices, their profiles or the defaults set
+// their own rate limit
+func (c *Config) DeviceRateLimits() bool {
+	for _, d := range c.Devices {
+		if d.RateLimit != (RateLimit{}) {
+			return true
+		}
+	}
+	for _, p := range c.Profiles {
+		if p.RateLimit != (RateLimit{}) {
+			return true
+		}
+	}
+
+	return c.Defaults.RateLimit != (RateLimit{})
+}
+
+// TenantDevices returns the devices assigned to the tenant
+func (c *Config) TenantDevices(tenant string) []Device {
+	var devices []Device
+	for _, d := range c.Devices {
+		if d.Tenant == tenant {
+			devices = append(devices, d)
+		}
+	}
+
+	return devices
+}
+
+// ForTenant returns the parts of the config limited to the tenant: its
+// devices, the profiles they use, the bandwidth tests and maintenance windows
+// of its devices and its tenant entry. The global sections, e.g. the sinks,
+// relabel rules and custom collectors, are left out.
+func (c *Config) ForTenant(tenant string) *Config {
+	r := Config{Devices: c.TenantDevices(tenant)}
+
+	names := make(map[string]bool, len(r.Devices))
+	for _, d := range r.Devices {
+		names[d.Name] = true
+		if p, ok := c.Profiles[d.Profile]; ok {
+			if r.Profiles == nil {
+				r.Profiles = make(map[string]Profile)
+			}
+			r.Profiles[d.Profile] = p
+		}
+	}
+
+	for _, t := range c.Tenants {
+		if t.Name == tenant {
+			r.Tenants = append(r.Tenants, t)
+		}
+	}
+
+	for _, t := range c.BandwidthTests {
+		if names[t.Device] {
+			r.BandwidthTests = append(r.BandwidthTests, t)
+		}
+	}
+
+	for _, w := range c.Maintenance {
+		if len(w.Devices) == 0 {
+			r.Maintenance = append(r.Maintenance, w)
+			continue
+		}
+		var devices []string
+		for _, d := range w.Devices {
+			if names[d] {
+				devices = append(devices, d)
+			}
+		}
+		if len(devices) > 0 {
+			w.Devices = devices
+			r.Maintenance = append(r.Maintenance, w)
+		}
+	}
+
+	return &r
+}
+
+// envReference matches references to environment variables, e.g.
+// "${CORE1_PASSWORD}". A reference is escaped by doubling the dollar sign.
+var envReference = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// labelName matches valid Prometheus label names
+var labelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
 // Load reads YAML from reader and unmashals in Config
 func Load(r io.Reader) (*Config, error) {
 	b, err := io.ReadAll(r)
@@ -58,6 +748,130 @@ func Load(r io.Reader) (*Config, error) {
 		return nil, err
 	}
 
+	c, err := parse(b)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.prepare()
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// LoadFiles loads the config files, including all YAML files of directories,
+// and merges them.
+func LoadFiles(paths []string) (*Config, error) {
+	files, err := Files(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Config{}
+	for i, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+
+		fc, err := parse(b)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+
+		if i == 0 {
+			c = fc
+			continue
+		}
+		err = c.merge(fc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+	}
+
+	err = c.prepare()
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Files returns the paths, replacing directories by the YAML files in
+// them.
+func Files(paths []string) ([]string, error) {
+	var files []string
+	for _, p := range paths {
+		fi, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			files = append(files, p)
+			continue
+		}
+
+		entries, err := os.ReadDir(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			name := e.Name()
+			ext := filepath.Ext(name)
+			if strings.HasPrefix(name, ".") || (ext != ".yml" && ext != ".yaml") {
+				continue
+			}
+			files = append(files, filepath.Join(p, name))
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no config files found in %s", strings.Join(paths, ", "))
+	}
+
+	return files, nil
+}
+
+// merge adds the devices, profiles, tenants and maintenance windows of other.
+// Other settings are kept.
+func (c *Config) merge(other *Config) error {
+	for _, d := range other.Devices {
+		if slices.ContainsFunc(c.Devices, func(e Device) bool { return e.Name == d.Name }) {
+			return fmt.Errorf("duplicate device %s", d.Name)
+		}
+		c.Devices = append(c.Devices, d)
+	}
+
+	for name, p := range other.Profiles {
+		if _, ok := c.Profiles[name]; ok {
+			return fmt.Errorf("duplicate profile %s", name)
+		}
+		if c.Profiles == nil {
+			c.Profiles = make(map[string]Profile)
+		}
+		c.Profiles[name] = p
+	}
+
+	for _, t := range other.Tenants {
+		if slices.ContainsFunc(c.Tenants, func(e Tenant) bool { return e.Name == t.Name }) {
+			return fmt.Errorf("duplicate tenant %s", t.Name)
+		}
+		c.Tenants = append(c.Tenants, t)
+	}
+
+	c.Maintenance = append(c.Maintenance, other.Maintenance...)
+
+	return nil
+}
+
+func parse(b []byte) (*Config, error) {
+	b, err := expandEnv(b)
+	if err != nil {
+		return nil, err
+	}
+
 	c := &Config{}
 	err = yaml.Unmarshal(b, c)
 	if err != nil {
@@ -66,3 +880,66 @@ func Load(r io.Reader) (*Config, error) {
 
 	return c, nil
 }
+
+// prepare applies the profiles to the devices and validates their labels, the
+// tenants and the graphite protocol.
+func (c *Config) prepare() error {
+	for i := range c.Devices {
+		c.Devices[i].NormalizeAddress()
+	}
+
+	err := c.applyProfiles()
+	if err != nil {
+		return err
+	}
+
+	for _, d := range c.Devices {
+		for k := range d.Labels {
+			if !labelName.MatchString(k) {
+				return fmt.Errorf("invalid label name %q of device %s", k, d.Name)
+			}
+		}
+	}
+
+	switch c.Graphite.Protocol {
+	case "", GraphiteProtocolPlaintext, GraphiteProtocolPickle:
+	default:
+		return fmt.Errorf("unknown graphite protocol %q, expected plaintext or pickle", c.Graphite.Protocol)
+	}
+
+	// a token must identify a single tenant
+	names := make(map[string]bool)
+	tokens := make(map[string]bool)
+	for _, t := range c.Tenants {
+		if names[t.Name] {
+			return fmt.Errorf("duplicate tenant %s", t.Name)
+		}
+		if t.Token != "" && tokens[t.Token] {
+			return fmt.Errorf("duplicate token of tenant %s", t.Name)
+		}
+		names[t.Name] = true
+		tokens[t.Token] = true
+	}
+
+	return nil
+}
+
+// expandEnv replaces references to environment variables with their values.
+// Unset variables are an error, so that missing credentials are noticed.
+func expandEnv(b []byte) ([]byte, error) {
+	var err error
+	expanded := envReference.ReplaceAllFunc(b, func(ref []byte) []byte {
+		if ref[1] == '$' {
+			return ref[1:]
+		}
+
+		name := string(envReference.FindSubmatch(ref)[1])
+		v, ok := os.LookupEnv(name)
+		if !ok && err == nil {
+			err = fmt.Errorf("environment variable %s is not set", name)
+		}
+		return []byte(v)
+	})
+
+	return expanded, err
+}
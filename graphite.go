@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultGraphiteInterval = 15 * time.Second
+
+	// graphitePickleBatch is the number of samples per pickled message, which
+	// keeps the messages below the 1 MiB carbon accepts
+	graphitePickleBatch = 500
+)
+
+// graphitePickleWriter pushes the gathered metrics to a carbon pickle
+// listener, named like the plaintext bridge of client_golang does.
+type graphitePickleWriter struct {
+	address  string
+	prefix   string
+	interval time.Duration
+}
+
+func (w *graphitePickleWriter) write(ctx context.Context, g prometheus.Gatherer) {
+	now := model.Now()
+	mfs, err := g.Gather()
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Warn("error gathering metrics to push, pushing the gathered ones")
+	}
+
+	samples, err := expfmt.ExtractSamples(&expfmt.DecodeOptions{Timestamp: now}, mfs...)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Warn("error extracting samples to push, pushing the extracted ones")
+	}
+	if len(samples) == 0 {
+		return
+	}
+
+	if err := w.push(ctx, samples); err != nil && ctx.Err() == nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("error pushing metrics to graphite")
+	}
+}
+
+func (w *graphitePickleWriter) push(ctx context.Context, samples model.Vector) error {
+	dialer := net.Dialer{Timeout: w.interval}
+	conn, err := dialer.DialContext(ctx, "tcp", w.address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_ = conn.SetWriteDeadline(time.Now().Add(w.interval))
+
+	for len(samples) > 0 {
+		batch := samples[:min(graphitePickleBatch, len(samples))]
+		samples = samples[len(batch):]
+
+		if _, err := conn.Write(encodeGraphitePickle(w.prefix, batch)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encodeGraphitePickle encodes the samples as a length-prefixed message of
+// the pickle protocol, a list of (path, (timestamp, value)) tuples.
+func encodeGraphitePickle(prefix string, samples model.Vector) []byte {
+	var b bytes.Buffer
+	b.Write([]byte{0, 0, 0, 0}) // length, set below
+
+	b.Write([]byte{0x80, 2}) // PROTO 2
+	b.WriteByte(']')         // EMPTY_LIST
+	b.WriteByte('(')         // MARK
+	for _, s := range samples {
+		path := graphitePath(prefix, s.Metric)
+		b.WriteByte('X') // BINUNICODE
+		_ = binary.Write(&b, binary.LittleEndian, uint32(len(path)))
+		b.WriteString(path)
+
+		b.WriteByte('J') // BININT
+		_ = binary.Write(&b, binary.LittleEndian, int32(s.Timestamp.Unix()))
+		b.WriteByte('G') // BINFLOAT
+		_ = binary.Write(&b, binary.BigEndian, math.Float64bits(float64(s.Value)))
+		b.WriteByte(0x86) // TUPLE2 of timestamp and value
+		b.WriteByte(0x86) // TUPLE2 of path and the above
+	}
+	b.WriteByte('e') // APPENDS
+	b.WriteByte('.') // STOP
+
+	msg := b.Bytes()
+	binary.BigEndian.PutUint32(msg, uint32(len(msg)-4))
+
+	return msg
+}
+
+// graphitePath names the metric like the plaintext bridge: the sanitized
+// metric name followed by the sorted "label.value" pairs.
+func graphitePath(prefix string, m model.Metric) string {
+	var b strings.Builder
+	if prefix != "" {
+		b.WriteString(prefix)
+		b.WriteByte('.')
+	}
+	writeGraphiteSanitized(&b, string(m[model.MetricNameLabel]))
+
+	labels := make([]string, 0, len(m))
+	for name, value := range m {
+		if name != model.MetricNameLabel {
+			labels = append(labels, string(name)+" "+string(value))
+		}
+	}
+	sort.Strings(labels)
+	for _, l := range labels {
+		b.WriteByte('.')
+		writeGraphiteSanitized(&b, l)
+	}
+
+	return b.String()
+}
+
+func writeGraphiteSanitized(b *strings.Builder, s string) {
+	prevUnderscore := false
+	for _, c := range s {
+		switch {
+		case c == ' ':
+			c = '.'
+		case !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == ':' || c == '-'):
+			c = '_'
+		}
+
+		if c == '_' {
+			if prevUnderscore {
+				continue
+			}
+			prevUnderscore = true
+		} else {
+			prevUnderscore = false
+		}
+		b.WriteRune(c)
+	}
+}
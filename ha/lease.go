@@ -0,0 +1,147 @@
+// Package ha coordinates multiple exporter instances so only one of them
+// polls the devices at a time.
+package ha
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// lease is the content of the lease file.
+type lease struct {
+	Holder  string    `json:"holder"`
+	Expires time.Time `json:"expires"`
+}
+
+// FileElector elects a leader among exporter instances sharing a lease file,
+// e.g. on a shared volume. The leader renews the lease periodically, a
+// standby takes over once the lease expired.
+type FileElector struct {
+	path     string
+	id       string
+	duration time.Duration
+	leader   atomic.Bool
+}
+
+// NewFileElector creates an elector for the instance id using the lease file
+// at path.
+func NewFileElector(path, id string, duration time.Duration) *FileElector {
+	return &FileElector{
+		path:     path,
+		id:       id,
+		duration: duration,
+	}
+}
+
+// IsLeader reports whether this instance currently holds the lease.
+func (e *FileElector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Run tries to acquire or renew the lease until stop is closed.
+func (e *FileElector) Run(stop <-chan struct{}) {
+	t := time.NewTicker(e.duration / 3)
+	defer t.Stop()
+
+	for {
+		e.update(time.Now())
+
+		select {
+		case <-stop:
+			e.release()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (e *FileElector) update(now time.Time) {
+	l, err := e.read()
+	if err != nil && !os.IsNotExist(err) {
+		log.WithFields(log.Fields{
+			"file":  e.path,
+			"error": err,
+		}).Error("error reading lease file")
+	}
+
+	if l != nil && l.Holder != e.id && now.Before(l.Expires) {
+		e.setLeader(false, l.Holder)
+		return
+	}
+
+	err = e.write(&lease{Holder: e.id, Expires: now.Add(e.duration)})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"file":  e.path,
+			"error": err,
+		}).Error("error writing lease file")
+		e.setLeader(false, "")
+		return
+	}
+
+	// another instance might have written the lease at the same time
+	l, err = e.read()
+	e.setLeader(err == nil && l.Holder == e.id, e.id)
+}
+
+func (e *FileElector) setLeader(leader bool, holder string) {
+	if e.leader.Swap(leader) != leader {
+		log.WithFields(log.Fields{
+			"id":     e.id,
+			"holder": holder,
+			"leader": leader,
+		}).Info("leadership changed")
+	}
+}
+
+func (e *FileElector) release() {
+	if !e.leader.Load() {
+		return
+	}
+
+	e.leader.Store(false)
+	_ = e.write(&lease{Holder: e.id, Expires: time.Now()})
+}
+
+func (e *FileElector) read() (*lease, error) {
+	b, err := os.ReadFile(e.path)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &lease{}
+	err = json.Unmarshal(b, l)
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (e *FileElector) write(l *lease) error {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(e.path), ".lease-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(b)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), e.path)
+}
@@ -0,0 +1,41 @@
+package ha
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileElector(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lease")
+	a := NewFileElector(path, "a", 15*time.Second)
+	b := NewFileElector(path, "b", 15*time.Second)
+
+	now := time.Now()
+	a.update(now)
+	b.update(now)
+
+	if !a.IsLeader() {
+		t.Fatalf("expected a to be leader")
+	}
+	if b.IsLeader() {
+		t.Fatalf("expected b to be standby")
+	}
+
+	// a stopped renewing its lease
+	b.update(now.Add(20 * time.Second))
+	if !b.IsLeader() {
+		t.Fatalf("expected b to take over expired lease")
+	}
+
+	a.update(now.Add(21 * time.Second))
+	if a.IsLeader() {
+		t.Fatalf("expected a to be standby after b took over")
+	}
+
+	b.release()
+	a.update(now.Add(22 * time.Second))
+	if !a.IsLeader() {
+		t.Fatalf("expected a to take over released lease")
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	cryptotls "crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Environment variables holding the basic auth credentials of the health
+// check if the web config requires them
+const (
+	healthcheckUserEnv     = "MIKROTIK_EXPORTER_HEALTHCHECK_USER"
+	healthcheckPasswordEnv = "MIKROTIK_EXPORTER_HEALTHCHECK_PASSWORD"
+)
+
+const healthcheckTimeout = 5 * time.Second
+
+// runHealthcheck checks /healthz of the exporter listening on -port and
+// returns the exit code, e.g. for the HEALTHCHECK of a container image
+// without curl.
+func runHealthcheck() int {
+	u, err := healthcheckURL()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if user := os.Getenv(healthcheckUserEnv); user != "" {
+		req.SetBasicAuth(user, os.Getenv(healthcheckPasswordEnv))
+	}
+
+	client := &http.Client{
+		Timeout: healthcheckTimeout,
+		// the certificate is issued for the name the exporter is scraped
+		// by, not the loopback address
+		Transport: &http.Transport{TLSClientConfig: &cryptotls.Config{InsecureSkipVerify: true}},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintf(os.Stderr, "%s returned %s\n", u, resp.Status)
+		return 1
+	}
+
+	return 0
+}
+
+// healthcheckURL returns the URL of /healthz on the address of -port, with
+// https if the web config enables TLS.
+func healthcheckURL() (string, error) {
+	host, listenPort, err := net.SplitHostPort(*port)
+	if err != nil {
+		return "", fmt.Errorf("invalid -port %q: %w", *port, err)
+	}
+	if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+		host = "localhost"
+	}
+
+	scheme := "http"
+	if *webConfigFile != "" {
+		b, err := os.ReadFile(*webConfigFile)
+		if err != nil {
+			return "", err
+		}
+		var webConfig struct {
+			TLSServerConfig map[string]interface{} `yaml:"tls_server_config"`
+		}
+		if err := yaml.Unmarshal(b, &webConfig); err != nil {
+			return "", fmt.Errorf("invalid web config %s: %w", *webConfigFile, err)
+		}
+		if len(webConfig.TLSServerConfig) > 0 {
+			scheme = "https"
+		}
+	}
+
+	u := url.URL{Scheme: scheme, Host: net.JoinHostPort(host, listenPort), Path: "/healthz"}
+	return u.String(), nil
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultInfluxDBInterval = 15 * time.Second
+
+func startInfluxDB(ctx context.Context, g prometheus.Gatherer) {
+	ic := cfg.InfluxDB
+	if *influxDBURL != "" {
+		ic.URL = *influxDBURL
+	}
+	if *influxDBOrg != "" {
+		ic.Org = *influxDBOrg
+	}
+	if *influxDBBucket != "" {
+		ic.Bucket = *influxDBBucket
+	}
+	if *influxDBToken != "" {
+		ic.Token = *influxDBToken
+	}
+	if *influxDBInterval != 0 {
+		ic.Interval = *influxDBInterval
+	}
+	if ic.URL == "" {
+		return
+	}
+	if ic.Org == "" || ic.Bucket == "" {
+		log.Fatal("writing metrics to InfluxDB requires an org and a bucket")
+	}
+	if ic.Interval <= 0 {
+		ic.Interval = defaultInfluxDBInterval
+	}
+
+	w := &influxDBWriter{
+		url:    strings.TrimSuffix(ic.URL, "/") + "/api/v2/write?" + url.Values{"org": {ic.Org}, "bucket": {ic.Bucket}, "precision": {"ns"}}.Encode(),
+		token:  ic.Token,
+		client: &http.Client{Timeout: ic.Interval},
+	}
+
+	log.WithFields(log.Fields{
+		"url":      ic.URL,
+		"org":      ic.Org,
+		"bucket":   ic.Bucket,
+		"interval": ic.Interval,
+	}).Info("writing metrics to influxdb")
+
+	go every(ctx, ic.Interval, func() {
+		w.write(ctx, g)
+	})
+}
+
+// influxDBWriter writes the gathered metrics to an InfluxDB v2 bucket.
+type influxDBWriter struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func (w *influxDBWriter) write(ctx context.Context, g prometheus.Gatherer) {
+	now := time.Now()
+	mfs, err := g.Gather()
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Warn("error gathering metrics to write, writing the gathered ones")
+	}
+
+	var b bytes.Buffer
+	for _, mf := range mfs {
+		writeLineProtocol(&b, mf, now)
+	}
+	if b.Len() == 0 {
+		return
+	}
+
+	if err := w.post(ctx, &b); err != nil && ctx.Err() == nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("error writing metrics to influxdb")
+	}
+}
+
+func (w *influxDBWriter) post(ctx context.Context, body io.Reader) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if w.token != "" {
+		req.Header.Set("Authorization", "Token "+w.token)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	return nil
+}
+
+// writeLineProtocol writes the metrics of the family as points in the line
+// protocol, named like in the text exposition format: the measurement is the
+// metric name, the labels are the tags and the sample is the value field.
+// Histograms and summaries are written as their _sum, _count and _bucket or
+// quantile series. Metrics without a timestamp get the time they were
+// gathered.
+func writeLineProtocol(b *bytes.Buffer, mf *dto.MetricFamily, gathered time.Time) {
+	name := mf.GetName()
+	for _, m := range mf.GetMetric() {
+		ts := gathered.UnixNano()
+		if m.TimestampMs != nil {
+			ts = m.GetTimestampMs() * int64(time.Millisecond)
+		}
+
+		switch mf.GetType() {
+		case dto.MetricType_COUNTER:
+			writePoint(b, name, m.GetLabel(), "", "", m.GetCounter().GetValue(), ts)
+		case dto.MetricType_GAUGE:
+			writePoint(b, name, m.GetLabel(), "", "", m.GetGauge().GetValue(), ts)
+		case dto.MetricType_UNTYPED:
+			writePoint(b, name, m.GetLabel(), "", "", m.GetUntyped().GetValue(), ts)
+		case dto.MetricType_SUMMARY:
+			s := m.GetSummary()
+			for _, q := range s.GetQuantile() {
+				writePoint(b, name, m.GetLabel(), "quantile", strconv.FormatFloat(q.GetQuantile(), 'g', -1, 64), q.GetValue(), ts)
+			}
+			writePoint(b, name+"_sum", m.GetLabel(), "", "", s.GetSampleSum(), ts)
+			writePoint(b, name+"_count", m.GetLabel(), "", "", float64(s.GetSampleCount()), ts)
+		case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+			h := m.GetHistogram()
+			for _, bucket := range h.GetBucket() {
+				if math.IsInf(bucket.GetUpperBound(), 1) {
+					continue
+				}
+				writePoint(b, name+"_bucket", m.GetLabel(), "le", strconv.FormatFloat(bucket.GetUpperBound(), 'g', -1, 64), float64(bucket.GetCumulativeCount()), ts)
+			}
+			writePoint(b, name+"_bucket", m.GetLabel(), "le", "+Inf", float64(h.GetSampleCount()), ts)
+			writePoint(b, name+"_sum", m.GetLabel(), "", "", h.GetSampleSum(), ts)
+			writePoint(b, name+"_count", m.GetLabel(), "", "", float64(h.GetSampleCount()), ts)
+		}
+	}
+}
+
+// writePoint writes a point with the labels and the extra tag, if any, as its
+// tags. Points with values the line protocol can't represent, NaN and
+// infinities, are skipped.
+func writePoint(b *bytes.Buffer, measurement string, labels []*dto.LabelPair, extraTag, extraValue string, value float64, ts int64) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return
+	}
+
+	b.WriteString(measurementEscaper.Replace(measurement))
+	for _, l := range labels {
+		writeTag(b, l.GetName(), l.GetValue())
+	}
+	if extraTag != "" {
+		writeTag(b, extraTag, extraValue)
+	}
+	b.WriteString(" value=")
+	b.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatInt(ts, 10))
+	b.WriteByte('\n')
+}
+
+// writeTag writes the tag, skipping empty values the line protocol doesn't
+// allow.
+func writeTag(b *bytes.Buffer, key, value string) {
+	if value == "" {
+		return
+	}
+
+	b.WriteByte(',')
+	b.WriteString(tagEscaper.Replace(key))
+	b.WriteByte('=')
+	b.WriteString(tagEscaper.Replace(value))
+}
+
+var (
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "\n", `\n`)
+	tagEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", `\n`)
+)
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// journaldSocket is the socket journald receives entries in its native
+// protocol on
+const journaldSocket = "/run/systemd/journal/socket"
+
+// journaldHook sends the log entries to journald with their fields as journal
+// fields, e.g. the device field as DEVICE, so they can be filtered with
+// journalctl DEVICE=router1.
+type journaldHook struct {
+	conn *net.UnixConn
+}
+
+func newJournaldHook() (*journaldHook, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journaldSocket, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+
+	return &journaldHook{conn: conn}, nil
+}
+
+func (h *journaldHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (h *journaldHook) Fire(e *log.Entry) error {
+	var b bytes.Buffer
+	writeJournalField(&b, "MESSAGE", e.Message)
+	writeJournalField(&b, "PRIORITY", strconv.Itoa(journalPriority(e.Level)))
+	writeJournalField(&b, "SYSLOG_IDENTIFIER", "mikrotik-exporter")
+	for k, v := range e.Data {
+		writeJournalField(&b, journalFieldName(k), fmt.Sprint(v))
+	}
+
+	_, err := h.conn.Write(b.Bytes())
+	return err
+}
+
+// journalPriority returns the syslog priority of the level.
+func journalPriority(l log.Level) int {
+	switch l {
+	case log.PanicLevel:
+		return 0
+	case log.FatalLevel:
+		return 2
+	case log.ErrorLevel:
+		return 3
+	case log.WarnLevel:
+		return 4
+	case log.InfoLevel:
+		return 6
+	default:
+		return 7
+	}
+}
+
+// journalFieldName returns the field name in upper case with the characters
+// journald doesn't allow replaced.
+func journalFieldName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+
+	// fields starting with an underscore are reserved for journald
+	if name == "" || name[0] == '_' || (name[0] >= '0' && name[0] <= '9') {
+		name = "F" + name
+	}
+
+	return name
+}
+
+// writeJournalField writes a field in the native protocol, values spanning
+// lines prefixed with their length.
+func writeJournalField(b *bytes.Buffer, name, value string) {
+	b.WriteString(name)
+	if !strings.Contains(value, "\n") {
+		b.WriteByte('=')
+		b.WriteString(value)
+		b.WriteByte('\n')
+		return
+	}
+
+	b.WriteByte('\n')
+	_ = binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"mikrotik-exporter/collector"
+	"mikrotik-exporter/config"
+)
+
+// collectorListing describes a collector in the output of list-collectors.
+type collectorListing struct {
+	collector.CollectorInfo
+	// Flag and Feature enable the collector, empty if it is always enabled
+	Flag    string `json:"flag,omitempty"`
+	Feature string `json:"feature,omitempty"`
+}
+
+// runListCollectors prints the collectors, how to enable them, the commands
+// they run and the metrics they export and returns the exit code.
+func runListCollectors(args []string) int {
+	fs := flag.NewFlagSet("list-collectors", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format, text or json")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "unknown format %q\n", *format)
+		return 2
+	}
+
+	// the collectors fail on the empty replies of the recorded commands
+	log.SetLevel(log.FatalLevel)
+
+	// enable all collectors without reading the config
+	cfg = &config.Config{}
+	*withAll = true
+	*exclude = ""
+
+	var listings []collectorListing
+	for _, info := range collector.Inventory(collectorOptions(cfg)...) {
+		l := collectorListing{CollectorInfo: info}
+		if info.Name != "interface" && info.Name != "resource" {
+			l.Flag = "-with-" + strings.ReplaceAll(info.Name, "_", "-")
+			l.Feature = info.Name
+		}
+		listings = append(listings, l)
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(listings); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	for _, l := range listings {
+		if l.Flag == "" {
+			fmt.Printf("%s (always enabled)\n", l.Name)
+		} else {
+			fmt.Printf("%s (%s, features: %s)\n", l.Name, l.Flag, l.Feature)
+		}
+		fmt.Printf("  commands: %s\n", strings.Join(l.Commands, ", "))
+		fmt.Printf("  metrics:  %s\n", strings.Join(l.Metrics, ", "))
+	}
+
+	return 0
+}
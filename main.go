@@ -4,73 +4,253 @@
 package main
 
 import (
-	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"expvar"
 	"flag"
 	"fmt"
+	"html/template"
+	"io"
+	"log/slog"
 	"mikrotik-exporter/collector"
 	"mikrotik-exporter/config"
+	"mikrotik-exporter/ha"
+	"net"
 	"net/http"
+	"net/http/pprof"
+	"net/netip"
 	"os"
+	"os/signal"
 	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
+	"github.com/coreos/go-systemd/v22/daemon"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/collectors/version"
+	"github.com/prometheus/client_golang/prometheus/graphite"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/exporter-toolkit/web"
 	log "github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
 )
 
+// configEnv is the environment variable holding the config if no config file
+// is given
+const configEnv = "MIKROTIK_EXPORTER_CONFIG"
+
 // single device can be defined via CLI flags, multiple via config file.
 var (
-	address    = flag.String("address", "", "address of the device to monitor")
-	configFile = flag.String("config-file", "", "config file to load")
-	device     = flag.String("device", "", "single device to monitor")
-	insecure   = flag.Bool(
+	address  = flag.String("address", "", "address of the device to monitor")
+	device   = flag.String("device", "", "single device to monitor")
+	insecure = flag.Bool(
 		"insecure",
 		false,
 		"skips verification of server certificate when using TLS (not recommended)",
 	)
 	logFormat   = flag.String("log-format", "json", "logformat text or json (default json)")
 	logLevel    = flag.String("log-level", "info", "log level")
+	logOutput   = flag.String("log-output", "stderr", "where to log to: stderr, journald or the path of a file")
 	metricsPath = flag.String("path", "/metrics", "path to answer requests on")
+	reloadToken = flag.String("reload-token", "", "bearer token required to reload the config with POST /-/reload")
+	probeToken  = flag.String("probe-token", "", "bearer token required to probe targets at /probe")
+	configWatch = flag.Duration("config-watch-interval", 0, "interval to check the config file for changes and reload it (0 = disabled)")
 	password    = flag.String("password", "", "password for authentication for single device")
 	deviceport  = flag.String("deviceport", "8728", "port for single device")
 	port        = flag.String("port", ":9436", "port number to listen on")
+	debugServer = flag.Bool("debug", false, "serves pprof profiles and expvar variables at /debug/")
+	debugListen = flag.String("debug-address", "", "address to serve the debug endpoints on instead of the port of the metrics")
+	drainTime   = flag.Duration("shutdown-timeout", 30*time.Second, "time to let running scrapes finish on SIGTERM or SIGINT")
 	timeout     = flag.Duration(
 		"timeout",
 		collector.DefaultTimeout,
 		"timeout when connecting to devices",
 	)
-	tls  = flag.Bool("tls", false, "use tls to connect to routers")
-	user = flag.String("user", "", "user for authentication with single device")
-	ver  = flag.Bool("version", false, "find the version of binary")
-
-	withBgp       = flag.Bool("with-bgp", false, "retrieves BGP routing infrormation")
-	withConntrack = flag.Bool("with-conntrack", false, "retrieves connection tracking metrics")
-	withRoutes    = flag.Bool("with-routes", false, "retrieves routing table information")
-	withDHCP      = flag.Bool("with-dhcp", false, "retrieves DHCP server metrics")
-	withDHCPL     = flag.Bool("with-dhcpl", false, "retrieves DHCP server lease metrics")
-	withDHCPv6    = flag.Bool("with-dhcpv6", false, "retrieves DHCPv6 server metrics")
-	withFirmware  = flag.Bool("with-firmware", false, "retrieves firmware versions")
-	withHealth    = flag.Bool("with-health", false, "retrieves board Health metrics")
-	withPOE       = flag.Bool("with-poe", false, "retrieves PoE metrics")
-	withPools     = flag.Bool("with-pools", false, "retrieves IP(v6) pool metrics")
-	withOptics    = flag.Bool("with-optics", false, "retrieves optical diagnostic metrics")
-	withW60G      = flag.Bool("with-w60g", false, "retrieves w60g interface metrics")
-	withWlanSTA   = flag.Bool("with-wlansta", false, "retrieves connected wlan station metrics")
-	withWlanIF    = flag.Bool("with-wlanif", false, "retrieves wlan interface metrics")
-	withCapsman   = flag.Bool("with-capsman", false, "retrieves capsman station metrics")
-	withMonitor   = flag.Bool("with-monitor", false, "retrieves ethernet interface monitor info")
-	withIpsec     = flag.Bool("with-ipsec", false, "retrieves ipsec metrics")
-	withLte       = flag.Bool("with-lte", false, "retrieves lte metrics")
-	withNetwatch  = flag.Bool("with-netwatch", false, "retrieves netwatch metrics")
-
-	cfg *config.Config
+	tls   = flag.Bool("tls", false, "use tls to connect to routers")
+	tlsCA = flag.String("tls-ca", "", "file with the CA certificates to verify the routers' certificates")
+	user  = flag.String("user", "", "user for authentication with single device")
+	ver   = flag.Bool("version", false, "find the version of binary")
+
+	checkConfig    = flag.Bool("check-config", false, "validates the config file and exits")
+	printConfig    = flag.Bool("print-config", false, "prints the effective config with secrets redacted and exits")
+	configEndpoint = flag.Bool("config-endpoint", false, "serves the effective config with secrets redacted at /config")
+
+	once       = flag.Bool("once", false, "scrapes the devices once, writes the metrics to stdout or -once.output and exits")
+	onceOutput = flag.String("once.output", "", "file to write the metrics of -once to, e.g. for the textfile collector of the node exporter")
+
+	healthQuorum = flag.Int("health-quorum", 0, "devices which must be reachable for /healthz?deep=true to succeed (default 1)")
+
+	webConfigFile = flag.String("web.config.file", "", "path of an exporter-toolkit web config enabling TLS and basic auth for the HTTP endpoints")
+	systemdSocket = flag.Bool("web.systemd-socket", false, "listens on the socket passed by systemd socket activation instead of -port")
+
+	withBgp             = flag.Bool("with-bgp", false, "retrieves BGP routing infrormation")
+	withConntrack       = flag.Bool("with-conntrack", false, "retrieves connection tracking metrics")
+	withRoutes          = flag.Bool("with-routes", false, "retrieves routing table information")
+	withDHCP            = flag.Bool("with-dhcp", false, "retrieves DHCP server metrics")
+	withDHCPL           = flag.Bool("with-dhcpl", false, "retrieves DHCP server lease metrics")
+	withDHCPv6          = flag.Bool("with-dhcpv6", false, "retrieves DHCPv6 server metrics")
+	withFirmware        = flag.Bool("with-firmware", false, "retrieves firmware versions")
+	withHealth          = flag.Bool("with-health", false, "retrieves board Health metrics")
+	withPOE             = flag.Bool("with-poe", false, "retrieves PoE metrics")
+	withPools           = flag.Bool("with-pools", false, "retrieves IP(v6) pool metrics")
+	withOptics          = flag.Bool("with-optics", false, "retrieves optical diagnostic metrics")
+	withW60G            = flag.Bool("with-w60g", false, "retrieves w60g interface metrics")
+	withWlanSTA         = flag.Bool("with-wlansta", false, "retrieves connected wlan station metrics")
+	withWlanIF          = flag.Bool("with-wlanif", false, "retrieves wlan interface metrics")
+	withCapsman         = flag.Bool("with-capsman", false, "retrieves capsman station metrics")
+	withMonitor         = flag.Bool("with-monitor", false, "retrieves ethernet interface monitor info")
+	withIpsec           = flag.Bool("with-ipsec", false, "retrieves ipsec metrics")
+	withLte             = flag.Bool("with-lte", false, "retrieves lte metrics")
+	withNetwatch        = flag.Bool("with-netwatch", false, "retrieves netwatch metrics")
+	withTorch           = flag.Bool("with-torch", false, "retrieves top talkers sampled with torch (rate limited)")
+	withTraffic         = flag.Bool("with-traffic", false, "streams interface rates with monitor-traffic")
+	withHistory         = flag.Bool("with-history", false, "retrieves configuration change metrics")
+	withWireguard       = flag.Bool("with-wireguard", false, "retrieves wireguard peer metrics")
+	withOSPF            = flag.Bool("with-ospf", false, "retrieves OSPF neighbor and LSA metrics")
+	withQueues          = flag.Bool("with-queues", false, "retrieves simple queue metrics")
+	withNAT             = flag.Bool("with-nat", false, "retrieves NAT rule counters")
+	withMangle          = flag.Bool("with-mangle", false, "retrieves mangle rule counters")
+	withBridgeHosts     = flag.Bool("with-bridge-hosts", false, "retrieves bridge host table metrics")
+	withSTP             = flag.Bool("with-stp", false, "retrieves bridge spanning tree metrics")
+	withVRRP            = flag.Bool("with-vrrp", false, "retrieves VRRP state metrics")
+	withPPP             = flag.Bool("with-ppp", false, "retrieves active PPP session metrics")
+	withPPPoE           = flag.Bool("with-pppoe", false, "retrieves PPPoE server session metrics")
+	withHotspot         = flag.Bool("with-hotspot", false, "retrieves hotspot user metrics")
+	withUserManager     = flag.Bool("with-usermanager", false, "retrieves User Manager (RouterOS 7) session metrics")
+	withDNS             = flag.Bool("with-dns", false, "retrieves DNS cache metrics")
+	withNTP             = flag.Bool("with-ntp", false, "retrieves NTP client synchronization metrics")
+	withDisk            = flag.Bool("with-disk", false, "retrieves disk and storage metrics")
+	withContainer       = flag.Bool("with-container", false, "retrieves container metrics (RouterOS 7)")
+	withGPS             = flag.Bool("with-gps", false, "retrieves GPS position metrics")
+	withMPLS            = flag.Bool("with-mpls", false, "retrieves MPLS and LDP metrics")
+	withTunnels         = flag.Bool("with-tunnels", false, "retrieves EoIP, GRE and IPIP tunnel metrics")
+	withVXLAN           = flag.Bool("with-vxlan", false, "retrieves VXLAN interface and VTEP metrics (RouterOS 7)")
+	withBonding         = flag.Bool("with-bonding", false, "retrieves bonding slave and LACP metrics")
+	withSwitchPorts     = flag.Bool("with-switch-ports", false, "retrieves switch chip port counters")
+	withCPU             = flag.Bool("with-cpu", false, "retrieves per-core CPU load")
+	withDot1x           = flag.Bool("with-dot1x", false, "retrieves 802.1X session metrics")
+	withIPServices      = flag.Bool("with-ip-services", false, "retrieves management service status")
+	withLog             = flag.Bool("with-log", false, "counts log entries by topic and severity")
+	withScheduler       = flag.Bool("with-scheduler", false, "retrieves scheduler and script run metrics")
+	withInterfaceQueues = flag.Bool("with-interface-queues", false, "retrieves interface queue backlog and drops")
+	withVLAN            = flag.Bool("with-vlan", false, "retrieves per-VLAN traffic metrics")
+	withNeighbors       = flag.Bool("with-neighbors", false, "retrieves MNDP, CDP and LLDP neighbors")
+	withRIP             = flag.Bool("with-rip", false, "retrieves RIP neighbor and route metrics")
+	withKidControl      = flag.Bool("with-kid-control", false, "retrieves kid control device metrics")
+	withWebProxy        = flag.Bool("with-web-proxy", false, "retrieves web proxy metrics")
+	withUPnP            = flag.Bool("with-upnp", false, "retrieves UPnP port mapping metrics")
+	withDHCPClient      = flag.Bool("with-dhcp-client", false, "retrieves DHCP client lease status")
+	withDHCPv6Client    = flag.Bool("with-dhcpv6-client", false, "retrieves DHCPv6 client and prefix delegation metrics")
+	withRADIUS          = flag.Bool("with-radius", false, "retrieves RADIUS client statistics")
+	withZeroTier        = flag.Bool("with-zerotier", false, "retrieves ZeroTier network metrics")
+	withSMB             = flag.Bool("with-smb", false, "retrieves SMB service status")
+	withWlanSpectrum    = flag.Bool("with-wlan-spectrum", false, "retrieves wireless frequency, noise floor and CCQ")
+	withTrafficFlow     = flag.Bool("with-traffic-flow", false, "retrieves traffic flow (NetFlow) export status")
+	withPing            = flag.Bool("with-ping", false, "pings the targets from the devices")
+	withBackToHome      = flag.Bool("with-back-to-home", false, "retrieves Back To Home VPN user metrics")
+	withARP             = flag.Bool("with-arp", false, "retrieves ARP and IPv6 neighbor table metrics")
+	withProfile         = flag.Bool("with-profile", false, "retrieves CPU usage by process sampled with /tool profile (rate limited)")
+	withAll             = flag.Bool("with-all", false, "enables all collectors not listed in -exclude")
+	exclude             = flag.String("exclude", "", "comma separated list of collectors not enabled by -with-all")
+
+	torchInterface    = flag.String("torch-interface", "", "interface to sample top talkers on")
+	pppSessions       = flag.Bool("ppp-sessions", false, "exports metrics per PPP session instead of per service only")
+	trafficInterfaces = flag.String("traffic-interfaces", "", "comma separated list of interfaces to stream rates of")
+	pingTargets       = flag.String("ping-targets", "", "comma separated list of addresses the devices ping")
+	counterWraps      = flag.Bool("counter-wrap-correction", false, "corrects wrapping 32-bit interface counters")
+	legacyTypes       = flag.Bool("legacy-metric-types", false, "exports metrics with the metric types of earlier releases")
+	commentLabels     = flag.Bool("comment-labels", false, "labels DHCP lease metrics with the comments of the leases")
+	haLeaseFile       = flag.String("ha-lease-file", "", "shared lease file to elect the instance polling the devices")
+	haID              = flag.String("ha-id", "", "identity of this instance in the leader election (default hostname)")
+
+	rateLimit = flag.Float64("rate-limit", 0, "maximum API commands per second sent to each device (0 = unlimited)")
+	rateBurst = flag.Int("rate-burst", 0, "API commands sent to a device before the rate limit applies")
+
+	warmUp           = flag.Bool("warm-up", false, "connects to all devices in the background on startup")
+	staleGracePeriod = flag.Duration("stale-grace-period", 0, "serves the last metrics of unreachable devices for this period")
+	scrapeCacheTTL   = flag.Duration("scrape-cache-ttl", 0, "serves the metrics of devices scraped within this period from memory (0 = disabled)")
+	pollInterval     = flag.Duration("poll-interval", 0, "polls the devices in the background in this interval and serves the last poll (0 = disabled)")
+	poolIdleTimeout  = flag.Duration("pool-idle-timeout", 0, "keeps connections to the devices open between scrapes until idle for this period (0 = disabled)")
+	scrapeTimeout    = flag.Duration("scrape-timeout", 0, "time after which scraping a device is aborted (0 = unlimited)")
+	maxConcurrent    = flag.Int("max-concurrent-scrapes", 0, "maximum number of devices scraped at the same time (0 = unlimited)")
+	parallelism      = flag.Int("parallelism", 0, "number of collectors run at the same time per device (default 1)")
+
+	collectorIntervals = flag.String("collector-intervals", "", "comma separated list of collector=interval pairs to run collectors at most once per interval, e.g. firmware=30m")
+	seriesLimit        = flag.Int("series-limit", 0, "maximum series exported per device and scrape, further series are dropped (0 = unlimited)")
+
+	retryAttempts = flag.Int("retry-attempts", -1, "reconnects per scrape if the connection to a device drops, 0 disables them, -1 for the default of 1")
+	retryBackoff  = flag.Duration("retry-backoff", 0, "wait before the first reconnect, doubled for each further one (default 100ms)")
+
+	lastErrorInfo = flag.Bool("last-error-info", false, "exports the class of the last error scraping each device")
+	sourceAddress = flag.String("source-address", "", "local address the connections to the devices originate from")
+
+	identityLabels = flag.String("identity-labels", "", "labels the metrics with the system identity of the devices: replace (the name) or add (an identity label)")
+
+	circuitFailures      = flag.Int("circuit-breaker-failures", 0, "consecutive failed scrapes after which a device is skipped until it is reachable again (0 = disabled)")
+	circuitProbeInterval = flag.Duration("circuit-breaker-probe-interval", 0, "interval skipped devices are probed in (default 30s)")
+
+	graphiteAddress  = flag.String("graphite-address", "", "host:port of a graphite server to push metrics to")
+	graphitePrefix   = flag.String("graphite-prefix", "", "prefix of the metrics pushed to graphite")
+	graphiteInterval = flag.Duration("graphite-interval", 0, "interval to push metrics to graphite (default 15s)")
+	graphiteProtocol = flag.String("graphite-protocol", "", "protocol to push metrics to graphite with: plaintext or pickle (default plaintext)")
+
+	pushgatewayURL      = flag.String("pushgateway-url", "", "URL of a Pushgateway to push metrics to")
+	pushgatewayJob      = flag.String("pushgateway-job", "", "job the metrics are pushed to the Pushgateway as (default mikrotik)")
+	pushgatewayInterval = flag.Duration("pushgateway-interval", 0, "interval to push metrics to the Pushgateway (default 15s)")
+
+	influxDBURL      = flag.String("influxdb-url", "", "URL of an InfluxDB v2 server to write metrics to")
+	influxDBOrg      = flag.String("influxdb-org", "", "InfluxDB organization the bucket belongs to")
+	influxDBBucket   = flag.String("influxdb-bucket", "", "InfluxDB bucket to write metrics to")
+	influxDBToken    = flag.String("influxdb-token", "", "InfluxDB API token with write access to the bucket")
+	influxDBInterval = flag.Duration("influxdb-interval", 0, "interval to write metrics to InfluxDB (default 15s)")
+
+	otlpEndpoint = flag.String("otlp-endpoint", "", "host:port (grpc) or URL (http) of an OpenTelemetry collector to export metrics to")
+	otlpProtocol = flag.String("otlp-protocol", "", "protocol to export metrics with, grpc or http (default grpc)")
+	otlpInsecure = flag.Bool("otlp-insecure", false, "exports metrics without TLS")
+	otlpInterval = flag.Duration("otlp-interval", 0, "interval to export metrics to the OpenTelemetry collector (default 15s)")
+	otlpTraces   = flag.Bool("otlp-traces", false, "exports a trace of each scrape to the OpenTelemetry collector")
+
+	goCollector      = flag.Bool("go-collector", true, "exports Go runtime metrics of the exporter")
+	processCollector = flag.Bool("process-collector", true, "exports process metrics (CPU, memory, file descriptors) of the exporter")
+
+	configFiles stringList
+	targets     stringList
+
+	// cfg is the config loaded on startup, reloaded configs are kept by
+	// their exporter only
+	cfg     *config.Config
+	elector *ha.FileElector
+	// electorStopped is closed once the lease was released on shutdown
+	electorStopped chan struct{}
 
 	vcsRevision = "0xDEADBEEF"
 )
 
+// stringList is a flag which can be repeated
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
 func init() {
+	flag.Var(&configFiles, "config-file", "config file or directory of config files to load, can be repeated")
+	flag.Var(&targets, "target", "device to monitor as name=address[:port] with -user and -password, can be repeated")
+
 	bi, ok := debug.ReadBuildInfo()
 	if ok {
 		for _, s := range bi.Settings {
@@ -79,27 +259,91 @@ func init() {
 			}
 		}
 	}
-	prometheus.MustRegister(version.NewCollector("mikrotik_exporter"))
 }
 
 func main() {
 	flag.Parse()
 
+	if flag.Arg(0) == "healthcheck" {
+		// flags may follow the subcommand
+		_ = flag.CommandLine.Parse(flag.Args()[1:])
+		os.Exit(runHealthcheck())
+	}
+	if flag.Arg(0) == "service" {
+		os.Exit(runServiceCommand(flag.Args()[1:]))
+	}
+	if flag.Arg(0) == "list-collectors" {
+		os.Exit(runListCollectors(flag.Args()[1:]))
+	}
+
 	if *ver {
 		fmt.Printf("Version: %s\nSHA: %s\n", appVersion, vcsRevision)
 		os.Exit(0)
 	}
 
+	if *checkConfig {
+		os.Exit(runCheckConfig())
+	}
+
 	configureLog()
 
 	c, err := loadConfig()
 	if err != nil {
-		log.Errorf("Could not load config: %v", err)
+		log.WithError(err).Error("could not load config")
 		os.Exit(3)
 	}
 	cfg = c
 
-	startServer()
+	if *printConfig {
+		os.Exit(runPrintConfig())
+	}
+
+	if *once {
+		os.Exit(runOnce())
+	}
+
+	if runningAsService() {
+		if err := runService(run); err != nil {
+			log.WithError(err).Fatal("error running as service")
+		}
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	run(ctx)
+}
+
+// run serves the metrics until ctx is done.
+func run(ctx context.Context) {
+	startLeaderElection(ctx.Done())
+	startServer(ctx)
+}
+
+// runCheckConfig prints the problems of the config file and returns the exit
+// code.
+func runCheckConfig() int {
+	var errs []error
+	switch {
+	case len(configFiles) > 0:
+		errs = config.CheckFiles(configFiles)
+	case os.Getenv(configEnv) != "":
+		errs = config.Check(strings.NewReader(os.Getenv(configEnv)))
+	default:
+		fmt.Fprintln(os.Stderr, "-check-config requires -config-file or "+configEnv)
+		return 2
+	}
+
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	if len(errs) > 0 {
+		return 1
+	}
+
+	fmt.Println("config OK")
+	return 0
 }
 
 func configureLog() {
@@ -115,23 +359,45 @@ func configureLog() {
 	} else {
 		log.SetFormatter(&log.JSONFormatter{})
 	}
+
+	switch *logOutput {
+	case "", "stderr":
+	case "journald":
+		hook, err := newJournaldHook()
+		if err != nil {
+			log.WithError(err).Fatal("could not connect to journald")
+		}
+		log.AddHook(hook)
+		log.SetOutput(io.Discard)
+	default:
+		f, err := os.OpenFile(*logOutput, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			log.WithError(err).Fatal("could not open log file")
+		}
+		log.SetOutput(f)
+	}
 }
 
 func loadConfig() (*config.Config, error) {
-	if *configFile != "" {
+	switch *graphiteProtocol {
+	case "", config.GraphiteProtocolPlaintext, config.GraphiteProtocolPickle:
+	default:
+		return nil, fmt.Errorf("unknown -graphite-protocol %q, expected plaintext or pickle", *graphiteProtocol)
+	}
+
+	if len(configFiles) > 0 {
 		return loadConfigFromFile()
 	}
 
+	if env := os.Getenv(configEnv); env != "" {
+		return config.Load(strings.NewReader(env))
+	}
+
 	return loadConfigFromFlags()
 }
 
 func loadConfigFromFile() (*config.Config, error) {
-	b, err := os.ReadFile(*configFile)
-	if err != nil {
-		return nil, err
-	}
-
-	return config.Load(bytes.NewReader(b))
+	return config.LoadFiles(configFiles)
 }
 
 func loadConfigFromFlags() (*config.Config, error) {
@@ -142,160 +408,1585 @@ func loadConfigFromFlags() (*config.Config, error) {
 	if *password == "" {
 		*password = os.Getenv("MIKROTIK_PASSWORD")
 	}
-	if *device == "" || *address == "" || *user == "" || *password == "" {
+	if *device == "" && len(targets) == 0 || *user == "" || *password == "" {
 		return nil, fmt.Errorf("missing required param for single device configuration")
 	}
 
+	var devices []config.Device
+	if *device != "" {
+		if *address == "" {
+			return nil, fmt.Errorf("missing required param for single device configuration")
+		}
+		devices = append(devices, config.Device{
+			Name:    *device,
+			Address: *address,
+			Port:    *deviceport,
+		})
+	}
+	for _, t := range targets {
+		d, err := parseTarget(t)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, d)
+	}
+
+	names := make(map[string]bool, len(devices))
+	for i := range devices {
+		d := &devices[i]
+		if names[d.Name] {
+			return nil, fmt.Errorf("duplicate device %s", d.Name)
+		}
+		names[d.Name] = true
+
+		d.User = *user
+		d.Password = *password
+		d.NormalizeAddress()
+	}
+
 	return &config.Config{
-		Devices: []config.Device{
-			{
-				Name:     *device,
-				Address:  *address,
-				User:     *user,
-				Password: *password,
-				Port:     *deviceport,
-			},
-		},
+		Devices: devices,
 	}, nil
 }
 
-func startServer() {
-	h, err := createMetricsHandler()
+// parseTarget parses a device of the -target flag like
+// router1=10.0.0.1:8729. Without a port the port of -deviceport is used.
+func parseTarget(t string) (config.Device, error) {
+	name, address, ok := strings.Cut(t, "=")
+	if !ok || name == "" || address == "" {
+		return config.Device{}, fmt.Errorf("invalid -target %q, expected name=address[:port]", t)
+	}
+
+	d := config.Device{Name: name, Address: address, Port: *deviceport}
+	if host, port, err := net.SplitHostPort(address); err == nil {
+		d.Address = host
+		d.Port = port
+	}
+
+	return d, nil
+}
+
+func startLeaderElection(stop <-chan struct{}) {
+	h := cfg.HA
+	if *haLeaseFile != "" {
+		h.LeaseFile = *haLeaseFile
+	}
+	if *haID != "" {
+		h.ID = *haID
+	}
+	if h.LeaseFile == "" {
+		return
+	}
+	if h.ID == "" {
+		h.ID, _ = os.Hostname()
+	}
+	if h.LeaseDuration == 0 {
+		h.LeaseDuration = 15 * time.Second
+	}
+
+	log.WithFields(log.Fields{
+		"file": h.LeaseFile,
+		"id":   h.ID,
+	}).Info("starting leader election")
+
+	elector = ha.NewFileElector(h.LeaseFile, h.ID, h.LeaseDuration)
+	electorStopped = make(chan struct{})
+	go func() {
+		elector.Run(stop)
+		close(electorStopped)
+	}()
+}
+
+// startServer serves the metrics until ctx is done, then lets the running
+// scrapes finish within the shutdown timeout and closes the connections to
+// the devices.
+func startServer(ctx context.Context) {
+	startOTLPTraces(ctx)
+	e, err := createExporter(cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
-	http.Handle(*metricsPath, h)
+	server := &reloadableExporter{current: e}
+
+	// net/http/pprof and expvar register themselves with the default mux,
+	// which must not serve them unless enabled
+	mux := http.NewServeMux()
+	mux.Handle(*metricsPath, server)
+	startGraphite(ctx, server)
+	startPushgateway(ctx, server)
+	startInfluxDB(ctx, server)
+	startOTLP(ctx, server)
+
+	go reloadOnSignal(server)
+	if *configWatch > 0 && len(configFiles) > 0 {
+		go reloadOnChange(server, *configWatch)
+	}
+	mux.Handle("/-/reload", reloadHandler(server))
+	mux.Handle("/probe", probeHandler(server))
+	mux.Handle("/config", configHandler(server))
+	mux.Handle("/api/v1/devices/{name}/metrics", snapshotHandler(server))
+
+	if *debugListen != "" {
+		go func() {
+			log.WithField("address", *debugListen).Info("serving debug endpoints")
+			log.Fatal(http.ListenAndServe(*debugListen, debugHandler()))
+		}()
+	} else if *debugServer {
+		mux.Handle("/debug/", debugHandler())
+	}
+
+	mux.Handle("/healthz", healthHandler(server))
 
-	http.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+	var ready atomic.Bool
+	mux.HandleFunc("/livez", func(w http.ResponseWriter, r *http.Request) {
 		_, _ = w.Write([]byte("ok"))
 	})
-
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		_, _ = w.Write([]byte(`<html>
-			<head><title>Mikrotik Exporter</title></head>
-			<body>
-			<h1>Mikrotik Exporter</h1>
-			<p><a href="` + *metricsPath + `">Metrics</a></p>
-			</body>
-			</html>`))
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !ready.Load() {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
 	})
 
-	log.Info("Listening on ", *port)
-	log.Fatal(http.ListenAndServe(*port, nil))
-}
+	mux.Handle("/", landingPage(server))
 
-func createMetricsHandler() (http.Handler, error) {
-	opts := collectorOptions()
-	nc, err := collector.NewCollector(cfg, opts...)
+	var l net.Listener
+	if *systemdSocket {
+		l, err = systemdListener()
+	} else {
+		l, err = net.Listen("tcp", *port)
+	}
 	if err != nil {
-		return nil, err
+		log.Fatal(err)
 	}
+	srv := &http.Server{Handler: mux}
+	go func() {
+		log.WithField("address", l.Addr().String()).Info("listening")
+		flags := &web.FlagConfig{WebConfigFile: webConfigFile}
+		logger := slog.New(&logrusHandler{})
+		if err := web.Serve(l, srv, flags, logger); err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
 
-	promhttp.Handler()
+	// the config is loaded and the socket is listening
+	notifySystemd(daemon.SdNotifyReady)
+	go watchdog(ctx)
 
-	registry := prometheus.NewRegistry()
-	err = registry.Register(collectors.NewGoCollector())
-	if err != nil {
-		return nil, err
+	// ready once the devices configured by SRV records, Consul or MNDP are
+	// known
+	go func() {
+		for _, c := range server.get().collectors {
+			if r, ok := c.(resolver); ok {
+				r.Resolve()
+			}
+		}
+		ready.Store(true)
+		log.Info("ready")
+	}()
+
+	<-ctx.Done()
+	ready.Store(false)
+	notifySystemd(daemon.SdNotifyStopping)
+	// a second signal terminates right away
+	signal.Reset(syscall.SIGTERM, os.Interrupt)
+	log.WithField("timeout", *drainTime).Info("shutting down, waiting for running scrapes")
+
+	drain, cancel := context.WithTimeout(context.Background(), *drainTime)
+	defer cancel()
+	if err := srv.Shutdown(drain); err != nil {
+		log.WithError(err).Warn("scrapes still running after shutdown timeout")
 	}
-	err = registry.Register(nc)
-	if err != nil {
-		return nil, err
+
+	server.get().close()
+	if electorStopped != nil {
+		<-electorStopped
 	}
+	log.Info("shut down")
+}
+
+var landingTemplate = template.Must(template.New("landing").Parse(`<html>
+<head><title>Mikrotik Exporter</title></head>
+<body>
+<h1>Mikrotik Exporter</h1>
+<p><a href="{{.MetricsPath}}">Metrics</a></p>
+<h2>Devices</h2>
+{{if .Aggregate}}<p>{{.Up}} of {{.Total}} devices up. The devices of a tenant are listed for requests bearing its token.</p>
+{{else}}<table border="1" cellpadding="4">
+<tr><th>Device</th><th>Address</th><th>RouterOS</th><th>Last scrape</th><th>Result</th><th>Duration</th><th>Collectors</th></tr>
+{{range .Devices}}<tr>
+<td>{{.Name}}</td>
+<td>{{.Address}}</td>
+<td>{{.Version}}</td>
+{{if .Scraped.IsZero}}<td>never</td><td></td><td></td>{{else}}<td>{{.Scraped.Format "2006-01-02 15:04:05 MST"}}</td>
+<td>{{if .Error}}{{.Error}}{{else}}ok{{end}}</td>
+<td>{{printf "%.3fs" .Duration.Seconds}}</td>{{end}}
+<td>{{range $i, $c := .Collectors}}{{if $i}}, {{end}}{{$c}}{{end}}</td>
+</tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
+
+// healthHandler reports the exporter as healthy. With the deep parameter it
+// is only healthy if the quorum of devices is reachable.
+func healthHandler(r *reloadableExporter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if deep, _ := strconv.ParseBool(req.URL.Query().Get("deep")); !deep {
+			_, _ = w.Write([]byte("ok"))
+			return
+		}
+
+		e := r.get()
+		quorum := *healthQuorum
+		if quorum == 0 {
+			quorum = e.cfg.HealthQuorum
+		}
+		if quorum == 0 {
+			quorum = 1
+		}
+
+		var reachable, total int
+		for _, c := range e.collectors {
+			if h, ok := c.(reachabilityReporter); ok {
+				up, n := h.Reachable()
+				reachable += up
+				total += n
+			}
+		}
 
-	return promhttp.HandlerFor(registry,
-		promhttp.HandlerOpts{
-			ErrorLog:      log.New(),
-			ErrorHandling: promhttp.ContinueOnError,
-		}), nil
+		msg := fmt.Sprintf("%d of %d devices reachable, quorum %d", reachable, total, quorum)
+		if reachable < quorum {
+			http.Error(w, msg, http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(msg))
+	})
 }
 
-func collectorOptions() []collector.Option {
-	opts := []collector.Option{}
+// resolver is implemented by the device collectors
+type resolver interface {
+	Resolve() []config.Device
+}
 
-	if *withBgp || cfg.Features.BGP {
-		opts = append(opts, collector.WithBGP())
-	}
+// reachabilityReporter is implemented by the device collectors
+type reachabilityReporter interface {
+	Reachable() (int, int)
+}
 
-	if *withRoutes || cfg.Features.Routes {
-		opts = append(opts, collector.WithRoutes())
-	}
+// statusReporter is implemented by the device collectors
+type statusReporter interface {
+	Status() []collector.DeviceStatus
+}
 
-	if *withDHCP || cfg.Features.DHCP {
-		opts = append(opts, collector.WithDHCP())
-	}
+// landingPage lists the devices with the result of their last scrape. With
+// tenants only the devices of the tenant whose token the request bears are
+// listed, otherwise only the number of devices up.
+func landingPage(r *reloadableExporter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		e := r.get()
+		collectors, aggregate := e.collectors, false
+		if len(e.cfg.Tenants) > 0 {
+			if _, nc, ok := e.tenant(req); ok {
+				collectors = []prometheus.Collector{nc}
+			} else {
+				aggregate = true
+			}
+		}
 
-	if *withDHCPL || cfg.Features.DHCPL {
-		opts = append(opts, collector.WithDHCPL())
-	}
+		var devices []collector.DeviceStatus
+		for _, c := range collectors {
+			if s, ok := c.(statusReporter); ok {
+				devices = append(devices, s.Status()...)
+			}
+		}
 
-	if *withDHCPv6 || cfg.Features.DHCPv6 {
-		opts = append(opts, collector.WithDHCPv6())
-	}
+		up := 0
+		for _, d := range devices {
+			if !d.Scraped.IsZero() && d.Error == "" {
+				up++
+			}
+		}
+		total := len(devices)
+		if aggregate {
+			devices = nil
+		}
 
-	if *withFirmware || cfg.Features.Firmware {
-		opts = append(opts, collector.WithFirmware())
-	}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		err := landingTemplate.Execute(w, struct {
+			MetricsPath string
+			Devices     []collector.DeviceStatus
+			Aggregate   bool
+			Up, Total   int
+		}{*metricsPath, devices, aggregate, up, total})
+		if err != nil {
+			log.WithError(err).Error("error rendering landing page")
+		}
+	})
+}
 
-	if *withHealth || cfg.Features.Health {
-		opts = append(opts, collector.WithHealth())
-	}
+// debugHandler serves the pprof profiles and the expvar variables.
+func debugHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
 
-	if *withPOE || cfg.Features.POE {
-		opts = append(opts, collector.WithPOE())
+	return mux
+}
+
+// exporter serves the metrics of the devices of a config.
+type exporter struct {
+	cfg        *config.Config
+	options    []collector.Option
+	handler    http.Handler
+	gatherer   prometheus.Gatherer
+	collectors []prometheus.Collector
+	// snapshots keeps the last collection of each tenant by its token, or
+	// of all devices by the empty token without tenants
+	snapshots map[string]*snapshotGatherer
+	// tenants keeps the collector of each tenant by its token
+	tenants map[string]prometheus.Collector
+
+	// probes is the collector probing targets, set up on the first probe
+	// and shared by all probes as the features of the module apply per
+	// device
+	probesMu sync.Mutex
+	probes   prometheus.Collector
+
+	// inflight counts the requests scraping the devices, which must finish
+	// before the exporter is closed after a reload
+	inflight sync.WaitGroup
+}
+
+// close stops the background work of the device collectors.
+func (e *exporter) close() {
+	for _, c := range e.collectors {
+		if cl, ok := c.(io.Closer); ok {
+			_ = cl.Close()
+		}
 	}
 
-	if *withPools || cfg.Features.Pools {
-		opts = append(opts, collector.WithPools())
+	e.probesMu.Lock()
+	defer e.probesMu.Unlock()
+	if cl, ok := e.probes.(io.Closer); ok {
+		_ = cl.Close()
 	}
+}
 
-	if *withOptics || cfg.Features.Optics {
-		opts = append(opts, collector.WithOptics())
+// tenant returns the tenant whose token the request bears and its collector,
+// false if it bears none.
+func (e *exporter) tenant(req *http.Request) (config.Tenant, prometheus.Collector, bool) {
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return config.Tenant{}, nil, false
 	}
 
-	if *withW60G || cfg.Features.W60G {
-		opts = append(opts, collector.WithW60G())
+	for _, t := range e.cfg.Tenants {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t.Token)) == 1 {
+			nc, ok := e.tenants[t.Token]
+			return t, nc, ok
+		}
 	}
 
-	if *withWlanSTA || cfg.Features.WlanSTA {
-		opts = append(opts, collector.WithWlanSTA())
+	return config.Tenant{}, nil, false
+}
+
+// prober returns the collector probing targets, which has no devices of its
+// own.
+func (e *exporter) prober() (prometheus.Collector, error) {
+	e.probesMu.Lock()
+	defer e.probesMu.Unlock()
+
+	if e.probes != nil {
+		return e.probes, nil
 	}
 
-	if *withCapsman || cfg.Features.Capsman {
-		opts = append(opts, collector.WithCapsman())
+	pc := *e.cfg
+	pc.Devices = nil
+	nc, err := collector.NewCollector(&pc, e.options...)
+	if err != nil {
+		return nil, err
 	}
+	e.probes = nc
 
-	if *withWlanIF || cfg.Features.WlanIF {
-		opts = append(opts, collector.WithWlanIF())
+	return nc, nil
+}
+
+// reloadableExporter serves the current exporter, which is replaced when the
+// config is reloaded.
+type reloadableExporter struct {
+	mu      sync.RWMutex
+	current *exporter
+	// reloadMu serializes reloads
+	reloadMu sync.Mutex
+}
+
+func (r *reloadableExporter) get() *exporter {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.current
+}
+
+// acquire returns the current exporter to scrape the devices with, which is
+// not closed before the returned func released it.
+func (r *reloadableExporter) acquire() (*exporter, func()) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	e := r.current
+	e.inflight.Add(1)
+
+	return e, e.inflight.Done
+}
+
+func (r *reloadableExporter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	e, release := r.acquire()
+	defer release()
+
+	e.handler.ServeHTTP(w, req)
+}
+
+// Gather implements the prometheus.Gatherer interface.
+func (r *reloadableExporter) Gather() ([]*dto.MetricFamily, error) {
+	e, release := r.acquire()
+	defer release()
+
+	return e.gatherer.Gather()
+}
+
+// reload loads the config file again and replaces the exporter. The current
+// exporter keeps serving if the config or the collectors cannot be set up.
+func (r *reloadableExporter) reload() error {
+	if len(configFiles) == 0 {
+		return fmt.Errorf("no config file to reload")
 	}
 
-	if *withMonitor || cfg.Features.Monitor {
-		opts = append(opts, collector.Monitor())
+	c, err := loadConfigFromFile()
+	if err != nil {
+		return err
 	}
 
-	if *withIpsec || cfg.Features.Ipsec {
-		opts = append(opts, collector.WithIpsec())
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+
+	e, err := createExporter(c)
+	if err != nil {
+		return err
 	}
 
-	if *withConntrack || cfg.Features.Conntrack {
-		opts = append(opts, collector.WithConntrack())
+	r.mu.Lock()
+	old := r.current
+	r.current = e
+	r.mu.Unlock()
+
+	// let the scrapes running on the previous exporter finish
+	go func() {
+		old.inflight.Wait()
+		old.close()
+	}()
+
+	log.WithFields(log.Fields{
+		"numDevices": len(c.Devices),
+	}).Info("reloaded config")
+
+	return nil
+}
+
+func reloadOnSignal(r *reloadableExporter) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+
+	for range ch {
+		if err := r.reload(); err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Error("error reloading config")
+		}
 	}
+}
 
-	if *withLte || cfg.Features.Lte {
-		opts = append(opts, collector.WithLte())
+// reloadOnChange reloads the config whenever the content of the config file
+// changed, e.g. after Kubernetes updated a mounted ConfigMap or Secret.
+func reloadOnChange(r *reloadableExporter, interval time.Duration) {
+	last, _ := configHash()
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for range t.C {
+		h, err := configHash()
+		if err != nil {
+			log.WithFields(log.Fields{
+				"files": configFiles.String(),
+				"error": err,
+			}).Warn("error reading config file")
+			continue
+		}
+		if h == last {
+			continue
+		}
+		last = h
+
+		log.WithFields(log.Fields{
+			"files": configFiles.String(),
+		}).Info("config file changed")
+		if err := r.reload(); err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Error("error reloading config")
+		}
 	}
+}
 
-	if *withNetwatch || cfg.Features.Netwatch {
-		opts = append(opts, collector.WithNetwatch())
+// configHash hashes the names and contents of the config files, so that
+// files added to a config directory are noticed as well.
+func configHash() ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+
+	files, err := config.Files(configFiles)
+	if err != nil {
+		return sum, err
 	}
 
-	if *timeout != collector.DefaultTimeout {
-		opts = append(opts, collector.WithTimeout(*timeout))
+	h := sha256.New()
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return sum, err
+		}
+		h.Write([]byte(f))
+		h.Write(b)
 	}
+	copy(sum[:], h.Sum(nil))
 
-	if *tls {
-		opts = append(opts, collector.WithTLS(*insecure))
+	return sum, nil
+}
+
+// reloadHandler reloads the config on POST requests bearing the reload token.
+// Without a token the endpoint is disabled.
+func reloadHandler(r *reloadableExporter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		expected := *reloadToken
+		if expected == "" {
+			expected = r.get().cfg.ReloadToken
+		}
+		if expected == "" {
+			http.Error(w, "reloading is disabled", http.StatusForbidden)
+			return
+		}
+
+		token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if err := r.reload(); err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Error("error reloading config")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		_, _ = w.Write([]byte("ok"))
+	})
+}
+
+// configHandler serves the effective config with the secrets redacted and the
+// devices discovered by the last scrape, if enabled. With tenants the config
+// is limited to the tenant whose token the request bears.
+func configHandler(r *reloadableExporter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		e := r.get()
+		if !*configEndpoint && !e.cfg.ConfigEndpoint {
+			http.Error(w, "the config endpoint is disabled", http.StatusForbidden)
+			return
+		}
+
+		c, collectors := e.cfg, e.collectors
+		if len(e.cfg.Tenants) > 0 {
+			t, nc, ok := e.tenant(req)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			c, collectors = c.ForTenant(t.Name), []prometheus.Collector{nc}
+		}
+		c = c.Redacted()
+
+		var devices []config.Device
+		for _, nc := range collectors {
+			if l, ok := nc.(deviceLister); ok {
+				devices = append(devices, l.Devices()...)
+			}
+		}
+		c.Devices = config.RedactDevices(devices)
+
+		b, err := yaml.Marshal(c)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = w.Write(b)
+	})
+}
+
+// deviceLister is implemented by the device collectors
+type deviceLister interface {
+	Devices() []config.Device
+}
+
+// runPrintConfig prints the effective config with the secrets redacted and
+// returns the exit code.
+func runPrintConfig() int {
+	b, err := yaml.Marshal(cfg.Redacted())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
 	}
 
+	_, _ = os.Stdout.Write(b)
+	return 0
+}
+
+// probeHandler scrapes the target of the request on demand using the
+// credentials and features of the profile named by the module parameter.
+// Requests must bear the probe token, which allows probing the configured
+// devices and the probe targets, or the token of a tenant, which allows
+// probing the devices of the tenant.
+func probeHandler(r *reloadableExporter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		target := req.URL.Query().Get("target")
+		if target == "" {
+			http.Error(w, "missing target", http.StatusBadRequest)
+			return
+		}
+
+		e, release := r.acquire()
+		defer release()
+
+		expected := *probeToken
+		if expected == "" {
+			expected = e.cfg.ProbeToken
+		}
+
+		var (
+			allowed []config.Device
+			targets []string
+		)
+		token, _ := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if _, nc, ok := e.tenant(req); ok {
+			allowed = nc.(deviceLister).Devices()
+		} else if expected != "" && subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1 {
+			allowed = slices.Clone(e.cfg.Devices)
+			for _, nc := range e.collectors {
+				allowed = append(allowed, nc.(deviceLister).Devices()...)
+			}
+			targets = e.cfg.ProbeTargets
+		} else if expected == "" && len(e.cfg.Tenants) == 0 {
+			http.Error(w, "probing is disabled", http.StatusForbidden)
+			return
+		} else {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		module := req.URL.Query().Get("module")
+		p, ok := e.cfg.Profiles[module]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown module %q", module), http.StatusBadRequest)
+			return
+		}
+
+		d := config.Device{Name: target, Address: target, Profile: module}
+		if host, port, err := net.SplitHostPort(target); err == nil {
+			d.Address = host
+			d.Port = port
+		}
+		d.NormalizeAddress()
+		if !probeAllowed(d.Address, allowed, targets) {
+			http.Error(w, fmt.Sprintf("target %q is not allowed", target), http.StatusForbidden)
+			return
+		}
+		p.Apply(&d)
+		e.cfg.Defaults.Apply(&d)
+
+		nc, err := e.prober()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		pc, err := nc.(prober).Probe(d, req.URL.Query()["collect[]"], req.URL.Query()["exclude[]"])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		registry := prometheus.NewRegistry()
+		if err := registry.Register(pc); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		createMetricsHandler(registry).ServeHTTP(w, req)
+	})
+}
+
+// prober is implemented by the device collectors to scrape devices which are
+// not configured.
+type prober interface {
+	Probe(d config.Device, collect, exclude []string) (prometheus.Collector, error)
+}
+
+// probeAllowed returns whether the address is the address of one of the
+// devices or one of the targets, which are addresses, hostnames or CIDR
+// prefixes.
+func probeAllowed(address string, devices []config.Device, targets []string) bool {
+	for _, d := range devices {
+		if strings.EqualFold(d.Address, address) {
+			return true
+		}
+	}
+
+	addr, addrErr := netip.ParseAddr(address)
+	for _, t := range targets {
+		if p, err := netip.ParsePrefix(t); err == nil {
+			if addrErr == nil && p.Contains(addr.Unmap()) {
+				return true
+			}
+			continue
+		}
+		if a, err := netip.ParseAddr(t); err == nil {
+			if addrErr == nil && a == addr.Unmap() {
+				return true
+			}
+			continue
+		}
+		if strings.EqualFold(t, address) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// createExporter sets up the collectors for the devices of the config.
+func createExporter(cfg *config.Config) (*exporter, error) {
+	opts := collectorOptions(cfg)
+	if len(cfg.Tenants) > 0 {
+		return createTenantsExporter(cfg, opts)
+	}
+
+	registry, nc, err := createRegistry(cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &snapshotGatherer{Gatherer: registry}
+
+	return &exporter{
+		cfg:        cfg,
+		options:    opts,
+		handler:    selectingHandler(nc, createMetricsHandler(snapshot)),
+		gatherer:   snapshot,
+		collectors: []prometheus.Collector{nc},
+		snapshots:  map[string]*snapshotGatherer{"": snapshot},
+	}, nil
+}
+
+func createRegistry(cfg *config.Config, opts []collector.Option) (*prometheus.Registry, prometheus.Collector, error) {
+	nc, err := collector.NewCollector(cfg, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	registry := prometheus.NewRegistry()
+	cs := []prometheus.Collector{
+		nc,
+		version.NewCollector("mikrotik_exporter"),
+		collectors.NewBuildInfoCollector(),
+	}
+	if *goCollector {
+		cs = append(cs, collectors.NewGoCollector())
+	}
+	if *processCollector {
+		cs = append(cs, collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	}
+
+	for _, c := range cs {
+		err = registry.Register(c)
+		if err != nil {
+			_ = nc.(io.Closer).Close()
+			return nil, nil, err
+		}
+	}
+
+	return registry, nc, nil
+}
+
+// createTenantsExporter serves the devices of each tenant to requests bearing
+// the tenant's token only.
+func createTenantsExporter(cfg *config.Config, opts []collector.Option) (*exporter, error) {
+	handlers := make(map[string]http.Handler)
+	e := &exporter{
+		cfg:       cfg,
+		options:   opts,
+		snapshots: make(map[string]*snapshotGatherer),
+		tenants:   make(map[string]prometheus.Collector),
+	}
+	gatherers := prometheus.Gatherers{}
+	tenants := make(map[string]bool)
+
+	for _, t := range cfg.Tenants {
+		if t.Token == "" {
+			e.close()
+			return nil, fmt.Errorf("missing token for tenant %s", t.Name)
+		}
+
+		tc := *cfg
+		tc.Devices = cfg.TenantDevices(t.Name)
+		nc, err := collector.NewCollector(&tc, opts...)
+		if err != nil {
+			e.close()
+			return nil, err
+		}
+		e.collectors = append(e.collectors, nc)
+
+		registry := prometheus.NewRegistry()
+		err = registry.Register(nc)
+		if err != nil {
+			e.close()
+			return nil, err
+		}
+
+		snapshot := &snapshotGatherer{Gatherer: registry}
+		handlers[t.Token] = selectingHandler(nc, createMetricsHandler(snapshot))
+		e.snapshots[t.Token] = snapshot
+		e.tenants[t.Token] = nc
+		tenants[t.Name] = true
+		gatherers = append(gatherers, snapshot)
+
+		log.WithFields(log.Fields{
+			"tenant":     t.Name,
+			"numDevices": len(tc.Devices),
+		}).Info("serving tenant")
+	}
+
+	for _, d := range cfg.Devices {
+		if !tenants[d.Tenant] {
+			log.WithFields(log.Fields{
+				"device": d.Name,
+			}).Warn("device is not assigned to a tenant and will not be scraped")
+		}
+	}
+
+	e.gatherer = gatherers
+	e.handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if ok {
+			for t, h := range handlers {
+				if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+					h.ServeHTTP(w, r)
+					return
+				}
+			}
+		}
+
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	})
+
+	return e, nil
+}
+
+func createMetricsHandler(registry prometheus.Gatherer) http.Handler {
+	opts := promhttp.HandlerOpts{
+		ErrorLog:                            log.New(),
+		ErrorHandling:                       promhttp.ContinueOnError,
+		EnableOpenMetrics:                   true,
+		EnableOpenMetricsTextCreatedSamples: true,
+	}
+	text := promhttp.HandlerFor(registry, opts)
+	openMetrics := promhttp.HandlerFor(openMetricsGatherer{registry}, opts)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if expfmt.NegotiateIncludingOpenMetrics(req.Header).FormatType() == expfmt.TypeOpenMetrics {
+			openMetrics.ServeHTTP(w, req)
+			return
+		}
+		text.ServeHTTP(w, req)
+	})
+}
+
+// selector is implemented by the device collectors to scrape a subset of
+// their collectors.
+type selector interface {
+	Select(collect, exclude []string) (prometheus.Collector, error)
+}
+
+// selectingHandler serves the metrics of the collectors selected by the
+// collect[] or exclude[] parameters of the request with a registry of its
+// own, so that Prometheus jobs can scrape different collectors, and passes
+// other requests to h.
+func selectingHandler(nc prometheus.Collector, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		collect, exclude := req.URL.Query()["collect[]"], req.URL.Query()["exclude[]"]
+		if len(collect) == 0 && len(exclude) == 0 {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		sc, err := nc.(selector).Select(collect, exclude)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		registry := prometheus.NewRegistry()
+		if err := registry.Register(sc); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		createMetricsHandler(registry).ServeHTTP(w, req)
+	})
+}
+
+// openMetricsGatherer appends the _total suffix OpenMetrics requires to the
+// names of the counters lacking it, which would be exposed with the unknown
+// type otherwise. The families are copied as the snapshot of the collection
+// keeps them.
+type openMetricsGatherer struct {
+	prometheus.Gatherer
+}
+
+func (g openMetricsGatherer) Gather() ([]*dto.MetricFamily, error) {
+	mfs, err := g.Gatherer.Gather()
+	for i, mf := range mfs {
+		if mf.GetType() == dto.MetricType_COUNTER && !strings.HasSuffix(mf.GetName(), "_total") {
+			name := mf.GetName() + "_total"
+			mfs[i] = &dto.MetricFamily{Name: &name, Help: mf.Help, Type: mf.Type, Unit: mf.Unit, Metric: mf.Metric}
+		}
+	}
+
+	return mfs, err
+}
+
+func startGraphite(ctx context.Context, g prometheus.Gatherer) {
+	gc := cfg.Graphite
+	if *graphiteAddress != "" {
+		gc.Address = *graphiteAddress
+	}
+	if *graphitePrefix != "" {
+		gc.Prefix = *graphitePrefix
+	}
+	if *graphiteInterval != 0 {
+		gc.Interval = *graphiteInterval
+	}
+	if *graphiteProtocol != "" {
+		gc.Protocol = *graphiteProtocol
+	}
+	if gc.Address == "" {
+		return
+	}
+	if gc.Interval <= 0 {
+		gc.Interval = defaultGraphiteInterval
+	}
+
+	if gc.Protocol == config.GraphiteProtocolPickle {
+		w := &graphitePickleWriter{address: gc.Address, prefix: gc.Prefix, interval: gc.Interval}
+
+		log.WithFields(log.Fields{
+			"address":  gc.Address,
+			"interval": gc.Interval,
+			"protocol": gc.Protocol,
+		}).Info("pushing metrics to graphite")
+
+		go every(ctx, gc.Interval, func() {
+			w.write(ctx, g)
+		})
+		return
+	}
+
+	b, err := graphite.NewBridge(&graphite.Config{
+		URL:           gc.Address,
+		Prefix:        gc.Prefix,
+		Interval:      gc.Interval,
+		Gatherer:      g,
+		Logger:        log.StandardLogger(),
+		ErrorHandling: graphite.ContinueOnError,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.WithFields(log.Fields{
+		"address":  gc.Address,
+		"interval": gc.Interval,
+	}).Info("pushing metrics to graphite")
+
+	go b.Run(ctx)
+}
+
+// every runs f right away and then every interval until the context is done.
+func every(ctx context.Context, interval time.Duration, f func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		f()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// parseCollectorIntervals parses a comma separated list of collector=interval
+// pairs.
+func parseCollectorIntervals(s string) map[string]time.Duration {
+	intervals := make(map[string]time.Duration)
+	for _, pair := range strings.Split(s, ",") {
+		name, value, ok := strings.Cut(pair, "=")
+		interval, err := time.ParseDuration(value)
+		if !ok || err != nil {
+			log.Fatalf("invalid collector interval %q, expected collector=interval", pair)
+		}
+		intervals[strings.TrimSpace(name)] = interval
+	}
+
+	return intervals
+}
+
+// featureSelection selects the features of a config, recording those enabled
+// for all devices.
+type featureSelection struct {
+	cfg      *config.Config
+	defaults []string
+}
+
+// enabled returns whether the feature is enabled by its flag, the config file,
+// by enabling all features without excluding it or by a device selecting it.
+func (f *featureSelection) enabled(feature string, flagValue, cfgValue bool) bool {
+	if f.enabledForAll(feature, flagValue, cfgValue) {
+		f.defaults = append(f.defaults, feature)
+		return true
+	}
+
+	return f.cfg.DeviceFeature(feature)
+}
+
+func (f *featureSelection) enabledForAll(feature string, flagValue, cfgValue bool) bool {
+	if flagValue || cfgValue {
+		return true
+	}
+	if !*withAll && !f.cfg.Features.All {
+		return false
+	}
+
+	excluded := f.cfg.Features.Exclude
+	if *exclude != "" {
+		excluded = append(excluded, strings.Split(*exclude, ",")...)
+	}
+
+	return !slices.Contains(excluded, feature)
+}
+
+// collectorOptions returns the options of the collectors of the config.
+func collectorOptions(cfg *config.Config) []collector.Option {
+	opts := []collector.Option{}
+	features := &featureSelection{cfg: cfg}
+
+	if features.enabled("bgp", *withBgp, cfg.Features.BGP) {
+		opts = append(opts, collector.WithBGP())
+	}
+
+	if features.enabled("routes", *withRoutes, cfg.Features.Routes) {
+		opts = append(opts, collector.WithRoutes())
+	}
+
+	if features.enabled("dhcp", *withDHCP, cfg.Features.DHCP) {
+		opts = append(opts, collector.WithDHCP())
+	}
+
+	if features.enabled("dhcpl", *withDHCPL, cfg.Features.DHCPL) {
+		opts = append(opts, collector.WithDHCPL(*commentLabels || cfg.CommentLabels))
+	}
+
+	if features.enabled("dhcpv6", *withDHCPv6, cfg.Features.DHCPv6) {
+		opts = append(opts, collector.WithDHCPv6())
+	}
+
+	if features.enabled("firmware", *withFirmware, cfg.Features.Firmware) {
+		opts = append(opts, collector.WithFirmware())
+	}
+
+	if features.enabled("health", *withHealth, cfg.Features.Health) {
+		opts = append(opts, collector.WithHealth())
+	}
+
+	if features.enabled("poe", *withPOE, cfg.Features.POE) {
+		opts = append(opts, collector.WithPOE())
+	}
+
+	if features.enabled("pools", *withPools, cfg.Features.Pools) {
+		opts = append(opts, collector.WithPools())
+	}
+
+	if features.enabled("optics", *withOptics, cfg.Features.Optics) {
+		opts = append(opts, collector.WithOptics())
+	}
+
+	if features.enabled("w60g", *withW60G, cfg.Features.W60G) {
+		opts = append(opts, collector.WithW60G())
+	}
+
+	if features.enabled("wlansta", *withWlanSTA, cfg.Features.WlanSTA) {
+		opts = append(opts, collector.WithWlanSTA())
+	}
+
+	if features.enabled("capsman", *withCapsman, cfg.Features.Capsman) {
+		opts = append(opts, collector.WithCapsman())
+	}
+
+	if features.enabled("wlanif", *withWlanIF, cfg.Features.WlanIF) {
+		opts = append(opts, collector.WithWlanIF())
+	}
+
+	if features.enabled("monitor", *withMonitor, cfg.Features.Monitor) {
+		opts = append(opts, collector.Monitor())
+	}
+
+	if features.enabled("ipsec", *withIpsec, cfg.Features.Ipsec) {
+		opts = append(opts, collector.WithIpsec())
+	}
+
+	if features.enabled("conntrack", *withConntrack, cfg.Features.Conntrack) {
+		opts = append(opts, collector.WithConntrack())
+	}
+
+	if features.enabled("lte", *withLte, cfg.Features.Lte) {
+		opts = append(opts, collector.WithLte())
+	}
+
+	if features.enabled("netwatch", *withNetwatch, cfg.Features.Netwatch) {
+		opts = append(opts, collector.WithNetwatch())
+	}
+
+	if features.enabled("history", *withHistory, cfg.Features.History) {
+		opts = append(opts, collector.WithHistory())
+	}
+
+	if features.enabled("traffic", *withTraffic, cfg.Features.Traffic) {
+		interfaces := cfg.Traffic.Interfaces
+		if *trafficInterfaces != "" {
+			interfaces = strings.Split(*trafficInterfaces, ",")
+		}
+		opts = append(opts, collector.WithTraffic(interfaces))
+	}
+
+	if features.enabled("wireguard", *withWireguard, cfg.Features.Wireguard) {
+		opts = append(opts, collector.WithWireguard())
+	}
+
+	if features.enabled("ospf", *withOSPF, cfg.Features.OSPF) {
+		opts = append(opts, collector.WithOSPF())
+	}
+
+	if features.enabled("queues", *withQueues, cfg.Features.Queues) {
+		opts = append(opts, collector.WithQueues())
+	}
+
+	if features.enabled("nat", *withNAT, cfg.Features.NAT) {
+		opts = append(opts, collector.WithNAT())
+	}
+
+	if features.enabled("mangle", *withMangle, cfg.Features.Mangle) {
+		opts = append(opts, collector.WithMangle())
+	}
+
+	if features.enabled("bridge_hosts", *withBridgeHosts, cfg.Features.BridgeHosts) {
+		opts = append(opts, collector.WithBridgeHosts())
+	}
+
+	if features.enabled("stp", *withSTP, cfg.Features.STP) {
+		opts = append(opts, collector.WithSTP())
+	}
+
+	if features.enabled("vrrp", *withVRRP, cfg.Features.VRRP) {
+		opts = append(opts, collector.WithVRRP())
+	}
+
+	if features.enabled("ppp", *withPPP, cfg.Features.PPP) {
+		opts = append(opts, collector.WithPPP(*pppSessions || cfg.PPP.Sessions))
+	}
+
+	if features.enabled("pppoe", *withPPPoE, cfg.Features.PPPoE) {
+		opts = append(opts, collector.WithPPPoE())
+	}
+
+	if features.enabled("hotspot", *withHotspot, cfg.Features.Hotspot) {
+		opts = append(opts, collector.WithHotspot())
+	}
+
+	if features.enabled("usermanager", *withUserManager, cfg.Features.UserManager) {
+		opts = append(opts, collector.WithUserManager())
+	}
+
+	if features.enabled("dns", *withDNS, cfg.Features.DNS) {
+		opts = append(opts, collector.WithDNS())
+	}
+
+	if features.enabled("ntp", *withNTP, cfg.Features.NTP) {
+		opts = append(opts, collector.WithNTP())
+	}
+
+	if features.enabled("disk", *withDisk, cfg.Features.Disk) {
+		opts = append(opts, collector.WithDisk())
+	}
+
+	if features.enabled("container", *withContainer, cfg.Features.Container) {
+		opts = append(opts, collector.WithContainer())
+	}
+
+	if features.enabled("gps", *withGPS, cfg.Features.GPS) {
+		opts = append(opts, collector.WithGPS())
+	}
+
+	if features.enabled("mpls", *withMPLS, cfg.Features.MPLS) {
+		opts = append(opts, collector.WithMPLS())
+	}
+
+	if features.enabled("tunnels", *withTunnels, cfg.Features.Tunnels) {
+		opts = append(opts, collector.WithTunnels())
+	}
+
+	if features.enabled("vxlan", *withVXLAN, cfg.Features.VXLAN) {
+		opts = append(opts, collector.WithVXLAN())
+	}
+
+	if features.enabled("bonding", *withBonding, cfg.Features.Bonding) {
+		opts = append(opts, collector.WithBonding())
+	}
+
+	if features.enabled("switch_ports", *withSwitchPorts, cfg.Features.SwitchPorts) {
+		opts = append(opts, collector.WithSwitchPorts())
+	}
+
+	if features.enabled("cpu", *withCPU, cfg.Features.CPU) {
+		opts = append(opts, collector.WithCPU())
+	}
+
+	if features.enabled("dot1x", *withDot1x, cfg.Features.Dot1x) {
+		opts = append(opts, collector.WithDot1x())
+	}
+
+	if features.enabled("ip_services", *withIPServices, cfg.Features.IPServices) {
+		opts = append(opts, collector.WithIPServices())
+	}
+
+	if features.enabled("log", *withLog, cfg.Features.Log) {
+		opts = append(opts, collector.WithLog())
+	}
+
+	if features.enabled("scheduler", *withScheduler, cfg.Features.Scheduler) {
+		opts = append(opts, collector.WithScheduler())
+	}
+
+	if features.enabled("interface_queues", *withInterfaceQueues, cfg.Features.InterfaceQueues) {
+		opts = append(opts, collector.WithInterfaceQueues())
+	}
+
+	if features.enabled("vlan", *withVLAN, cfg.Features.VLAN) {
+		opts = append(opts, collector.WithVLAN())
+	}
+
+	if features.enabled("neighbors", *withNeighbors, cfg.Features.Neighbors) {
+		opts = append(opts, collector.WithNeighbors())
+	}
+
+	if features.enabled("rip", *withRIP, cfg.Features.RIP) {
+		opts = append(opts, collector.WithRIP())
+	}
+
+	if features.enabled("kid_control", *withKidControl, cfg.Features.KidControl) {
+		opts = append(opts, collector.WithKidControl())
+	}
+
+	if features.enabled("web_proxy", *withWebProxy, cfg.Features.WebProxy) {
+		opts = append(opts, collector.WithWebProxy())
+	}
+
+	if features.enabled("upnp", *withUPnP, cfg.Features.UPnP) {
+		opts = append(opts, collector.WithUPnP())
+	}
+
+	if features.enabled("dhcp_client", *withDHCPClient, cfg.Features.DHCPClient) {
+		opts = append(opts, collector.WithDHCPClient())
+	}
+
+	if features.enabled("dhcpv6_client", *withDHCPv6Client, cfg.Features.DHCPv6Client) {
+		opts = append(opts, collector.WithDHCPv6Client())
+	}
+
+	if features.enabled("radius", *withRADIUS, cfg.Features.RADIUS) {
+		opts = append(opts, collector.WithRADIUS())
+	}
+
+	if features.enabled("zerotier", *withZeroTier, cfg.Features.ZeroTier) {
+		opts = append(opts, collector.WithZeroTier())
+	}
+
+	if features.enabled("smb", *withSMB, cfg.Features.SMB) {
+		opts = append(opts, collector.WithSMB())
+	}
+
+	if features.enabled("wlan_spectrum", *withWlanSpectrum, cfg.Features.WlanSpectrum) {
+		opts = append(opts, collector.WithWlanSpectrum())
+	}
+
+	if features.enabled("traffic_flow", *withTrafficFlow, cfg.Features.TrafficFlow) {
+		opts = append(opts, collector.WithTrafficFlow())
+	}
+
+	if features.enabled("back_to_home", *withBackToHome, cfg.Features.BackToHome) {
+		opts = append(opts, collector.WithBackToHome())
+	}
+
+	if features.enabled("arp", *withARP, cfg.Features.ARP) {
+		opts = append(opts, collector.WithARP())
+	}
+
+	if features.enabled("profile", *withProfile, cfg.Features.Profile) {
+		opts = append(opts, collector.WithProfile(cfg.Profile))
+	}
+
+	if features.enabled("torch", *withTorch, cfg.Features.Torch) {
+		t := cfg.Torch
+		if *torchInterface != "" {
+			t.Interface = *torchInterface
+		}
+		opts = append(opts, collector.WithTorch(t))
+	}
+
+	if features.enabled("ping", *withPing, cfg.Features.Ping) {
+		p := cfg.Ping
+		if *pingTargets != "" {
+			p.Targets = strings.Split(*pingTargets, ",")
+		}
+		opts = append(opts, collector.WithPing(p))
+	}
+
+	if *counterWraps || cfg.CounterWrapCorrection {
+		opts = append(opts, collector.WithCounterWrapCorrection())
+	}
+
+	if *legacyTypes || cfg.LegacyMetricTypes {
+		opts = append(opts, collector.WithLegacyMetricTypes())
+	}
+
+	if elector != nil {
+		opts = append(opts, collector.WithLeaderElection(elector.IsLeader))
+	}
+
+	rl := cfg.RateLimit
+	if *rateLimit > 0 {
+		rl.Rate = *rateLimit
+	}
+	if *rateBurst > 0 {
+		rl.Burst = *rateBurst
+	}
+	if rate, burst := rl.Limit(); rate > 0 {
+		opts = append(opts, collector.WithRateLimit(rate, burst))
+	}
+
+	grace := cfg.StaleGracePeriod
+	if *staleGracePeriod > 0 {
+		grace = *staleGracePeriod
+	}
+	if grace > 0 {
+		opts = append(opts, collector.WithStaleGracePeriod(grace))
+	}
+
+	ttl := cfg.ScrapeCacheTTL
+	if *scrapeCacheTTL > 0 {
+		ttl = *scrapeCacheTTL
+	}
+	if ttl > 0 {
+		opts = append(opts, collector.WithScrapeCacheTTL(ttl))
+	}
+
+	poll := cfg.PollInterval
+	if *pollInterval > 0 {
+		poll = *pollInterval
+	}
+	if poll > 0 {
+		opts = append(opts, collector.WithBackgroundPolling(poll))
+	}
+
+	intervals := cfg.CollectorIntervals
+	if *collectorIntervals != "" {
+		intervals = parseCollectorIntervals(*collectorIntervals)
+	}
+	if len(intervals) > 0 {
+		opts = append(opts, collector.WithCollectorIntervals(intervals))
+	}
+
+	limit := cfg.SeriesLimit
+	if *seriesLimit > 0 {
+		limit = *seriesLimit
+	}
+	if limit > 0 || len(cfg.CollectorSeriesLimits) > 0 {
+		opts = append(opts, collector.WithSeriesLimits(limit, cfg.CollectorSeriesLimits))
+	}
+
+	if len(cfg.MetricFilters) > 0 {
+		opts = append(opts, collector.WithMetricFilters(cfg.MetricFilters))
+	}
+	if len(cfg.Relabel) > 0 {
+		opts = append(opts, collector.WithRelabeling(cfg.Relabel))
+	}
+	if len(cfg.CustomCollectors) > 0 {
+		opts = append(opts, collector.WithCustomCollectors(cfg.CustomCollectors))
+	}
+	if len(cfg.Scripts) > 0 {
+		opts = append(opts, collector.WithScripts(cfg.Scripts))
+	}
+	if len(cfg.BandwidthTests) > 0 {
+		opts = append(opts, collector.WithBandwidthTests(cfg.BandwidthTests))
+	}
+
+	if len(cfg.Maintenance) > 0 {
+		opts = append(opts, collector.WithMaintenanceWindows(cfg.Maintenance))
+	}
+
+	identity := cfg.IdentityLabels
+	if *identityLabels != "" {
+		identity = *identityLabels
+	}
+	if identity != "" {
+		opts = append(opts, collector.WithIdentityLabels(identity))
+	}
+
+	if *lastErrorInfo || cfg.LastErrorInfo {
+		opts = append(opts, collector.WithLastErrorInfo())
+	}
+	if *warmUp || cfg.WarmUp {
+		opts = append(opts, collector.WithWarmUp())
+	}
+
+	idle := cfg.PoolIdleTimeout
+	if *poolIdleTimeout > 0 {
+		idle = *poolIdleTimeout
+	}
+	if idle > 0 {
+		opts = append(opts, collector.WithConnectionPool(idle))
+	}
+
+	if *timeout != collector.DefaultTimeout {
+		opts = append(opts, collector.WithTimeout(*timeout))
+	}
+
+	st := cfg.ScrapeTimeout
+	if *scrapeTimeout > 0 {
+		st = *scrapeTimeout
+	}
+	if st > 0 {
+		opts = append(opts, collector.WithScrapeTimeout(st))
+	}
+
+	mc := cfg.MaxConcurrentScrapes
+	if *maxConcurrent > 0 {
+		mc = *maxConcurrent
+	}
+	if mc > 0 {
+		opts = append(opts, collector.WithMaxConcurrentScrapes(mc))
+	}
+
+	par := cfg.Parallelism
+	if *parallelism > 0 {
+		par = *parallelism
+	}
+	if par > 1 {
+		opts = append(opts, collector.WithParallelism(par))
+	}
+
+	// an explicit 0 disables retries, so only unset attempts get the default
+	attempts := collector.DefaultRetryAttempts
+	if cfg.Retry.Attempts != nil {
+		attempts = *cfg.Retry.Attempts
+	}
+	if *retryAttempts >= 0 {
+		attempts = *retryAttempts
+	}
+	backoff := cfg.Retry.Backoff
+	if *retryBackoff > 0 {
+		backoff = *retryBackoff
+	}
+	if backoff == 0 {
+		backoff = collector.DefaultRetryBackoff
+	}
+	opts = append(opts, collector.WithRetry(attempts, backoff))
+
+	if cfg.Proxy != (config.Proxy{}) {
+		opts = append(opts, collector.WithProxy(cfg.Proxy))
+	}
+
+	source := cfg.SourceAddress
+	if *sourceAddress != "" {
+		source = *sourceAddress
+	}
+	if source != "" {
+		opts = append(opts, collector.WithSourceAddress(source))
+	}
+
+	cb := cfg.CircuitBreaker
+	if *circuitFailures > 0 {
+		cb.Failures = *circuitFailures
+	}
+	if *circuitProbeInterval > 0 {
+		cb.ProbeInterval = *circuitProbeInterval
+	}
+	if cb.Failures > 0 {
+		if cb.ProbeInterval == 0 {
+			cb.ProbeInterval = collector.DefaultCircuitProbeInterval
+		}
+		opts = append(opts, collector.WithCircuitBreaker(cb.Failures, cb.ProbeInterval))
+	}
+
+	if *tls {
+		opts = append(opts, collector.WithTLS(*insecure))
+	}
+
+	if *tlsCA != "" {
+		opts = append(opts, collector.WithTLSCA(*tlsCA))
+	}
+
+	if tracer != nil {
+		opts = append(opts, collector.WithTracer(tracer))
+	}
+
+	opts = append(opts, collector.WithDefaultFeatures(features.defaults))
+
 	return opts
 }
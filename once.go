@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	log "github.com/sirupsen/logrus"
+
+	"mikrotik-exporter/collector"
+)
+
+// runOnce scrapes the devices once, writes the metrics in the text format and
+// returns the exit code, which is 1 if a device or collector failed.
+func runOnce() int {
+	nc, err := collector.NewCollector(cfg, collectorOptions(cfg)...)
+	if err != nil {
+		log.WithError(err).Error("could not create collector")
+		return 1
+	}
+	e := &exporter{collectors: []prometheus.Collector{nc}}
+	defer e.close()
+
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(nc); err != nil {
+		log.WithError(err).Error("could not register collector")
+		return 1
+	}
+
+	mfs, err := registry.Gather()
+	code := 0
+	if err != nil {
+		log.WithError(err).Error("error gathering metrics, writing the gathered ones")
+		code = 1
+	}
+
+	var buf bytes.Buffer
+	enc := expfmt.NewEncoder(&buf, expfmt.NewFormat(expfmt.TypeTextPlain))
+	for _, mf := range mfs {
+		if err := enc.Encode(mf); err != nil {
+			log.WithError(err).Error("error encoding metrics")
+			return 1
+		}
+		if failed(mf) {
+			code = 1
+		}
+	}
+
+	if err := writeOnceOutput(buf.Bytes()); err != nil {
+		log.WithError(err).Error("error writing metrics")
+		return 1
+	}
+
+	return code
+}
+
+// failed returns whether the family is the result of the device or collector
+// scrapes and one of them failed, which is logged.
+func failed(mf *dto.MetricFamily) bool {
+	if mf.GetName() != "mikrotik_scrape_success" && mf.GetName() != "mikrotik_scrape_collector_success" {
+		return false
+	}
+
+	failed := false
+	for _, m := range mf.GetMetric() {
+		if m.GetGauge().GetValue() != 0 {
+			continue
+		}
+
+		fields := log.Fields{}
+		for _, l := range m.GetLabel() {
+			fields[l.GetName()] = l.GetValue()
+		}
+		log.WithFields(fields).Error("scrape failed")
+		failed = true
+	}
+
+	return failed
+}
+
+// writeOnceOutput writes the metrics to stdout or -once.output. The file is
+// replaced by renaming a temporary file, so the textfile collector of the
+// node exporter never reads a partially written file.
+func writeOnceOutput(b []byte) error {
+	if *onceOutput == "" {
+		_, err := os.Stdout.Write(b)
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(*onceOutput), "."+filepath.Base(*onceOutput)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(0o644); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(f.Name(), *onceOutput); err != nil {
+		return fmt.Errorf("error replacing %s: %w", *onceOutput, err)
+	}
+
+	return nil
+}
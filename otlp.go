@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	log "github.com/sirupsen/logrus"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	insecurecreds "google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+
+	"mikrotik-exporter/config"
+)
+
+const defaultOTLPInterval = 15 * time.Second
+
+// otlpExporter exports metrics to an OpenTelemetry collector.
+type otlpExporter interface {
+	export(ctx context.Context, req *colmetricpb.ExportMetricsServiceRequest) error
+}
+
+// otlpConfig returns the OTLP config with the flags applied and the defaults
+// set, false if no endpoint is configured.
+func otlpConfig() (config.OTLPConfig, bool) {
+	oc := cfg.OTLP
+	if *otlpEndpoint != "" {
+		oc.Endpoint = *otlpEndpoint
+	}
+	if *otlpProtocol != "" {
+		oc.Protocol = *otlpProtocol
+	}
+	if *otlpInsecure {
+		oc.Insecure = true
+	}
+	if *otlpInterval != 0 {
+		oc.Interval = *otlpInterval
+	}
+	if *otlpTraces {
+		oc.Traces = true
+	}
+	if oc.Endpoint == "" {
+		return oc, false
+	}
+	if oc.Protocol == "" {
+		oc.Protocol = "grpc"
+	}
+	if oc.Interval <= 0 {
+		oc.Interval = defaultOTLPInterval
+	}
+
+	return oc, true
+}
+
+func startOTLP(ctx context.Context, g prometheus.Gatherer) {
+	oc, ok := otlpConfig()
+	if !ok {
+		return
+	}
+
+	var (
+		e   otlpExporter
+		err error
+	)
+	switch oc.Protocol {
+	case "grpc":
+		e, err = newOTLPGRPCExporter(oc)
+	case "http":
+		e = &otlpHTTPExporter{
+			url:     oc.Endpoint,
+			headers: oc.Headers,
+			client:  &http.Client{Timeout: oc.Interval},
+		}
+	default:
+		err = fmt.Errorf("unknown OTLP protocol %q, expected grpc or http", oc.Protocol)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.WithFields(log.Fields{
+		"endpoint": oc.Endpoint,
+		"protocol": oc.Protocol,
+		"interval": oc.Interval,
+	}).Info("exporting metrics to opentelemetry collector")
+
+	start := time.Now()
+	go every(ctx, oc.Interval, func() {
+		mfs, err := g.Gather()
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Warn("error gathering metrics to export, exporting the gathered ones")
+		}
+
+		req := otlpRequest(mfs, start, time.Now())
+		if len(req.ResourceMetrics) == 0 {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, oc.Interval)
+		defer cancel()
+		if err := e.export(ctx, req); err != nil && ctx.Err() != context.Canceled {
+			log.WithFields(log.Fields{
+				"endpoint": oc.Endpoint,
+				"error":    err,
+			}).Error("error exporting metrics to opentelemetry collector")
+		}
+	})
+}
+
+type otlpGRPCExporter struct {
+	client  colmetricpb.MetricsServiceClient
+	headers metadata.MD
+}
+
+func newOTLPGRPCExporter(oc config.OTLPConfig) (*otlpGRPCExporter, error) {
+	conn, err := dialOTLP(oc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &otlpGRPCExporter{
+		client:  colmetricpb.NewMetricsServiceClient(conn),
+		headers: metadata.New(oc.Headers),
+	}, nil
+}
+
+// dialOTLP creates the gRPC connection to the OpenTelemetry collector.
+func dialOTLP(oc config.OTLPConfig) (*grpc.ClientConn, error) {
+	creds := credentials.NewClientTLSFromCert(nil, "")
+	if oc.Insecure {
+		creds = insecurecreds.NewCredentials()
+	}
+
+	return grpc.NewClient(oc.Endpoint, grpc.WithTransportCredentials(creds))
+}
+
+func (e *otlpGRPCExporter) export(ctx context.Context, req *colmetricpb.ExportMetricsServiceRequest) error {
+	_, err := e.client.Export(metadata.NewOutgoingContext(ctx, e.headers), req)
+	return err
+}
+
+// otlpHTTPExporter exports metrics, or traces, with OTLP/HTTP in the binary
+// protobuf encoding.
+type otlpHTTPExporter struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func (e *otlpHTTPExporter) export(ctx context.Context, req *colmetricpb.ExportMetricsServiceRequest) error {
+	return e.post(ctx, req)
+}
+
+// post sends the export request to the URL of the exporter.
+func (e *otlpHTTPExporter) post(ctx context.Context, req proto.Message) error {
+	b, err := proto.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/x-protobuf")
+	for k, v := range e.headers {
+		r.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	return nil
+}
+
+// otlpRequest converts the metric families to an export request with a
+// resource per device, identified by the device attribute, and one for the
+// metrics of the exporter itself. Counters are cumulative since start.
+func otlpRequest(mfs []*dto.MetricFamily, start, now time.Time) *colmetricpb.ExportMetricsServiceRequest {
+	groups := groupByDevice(mfs)
+	devices := make([]string, 0, len(groups))
+	for device := range groups {
+		devices = append(devices, device)
+	}
+	sort.Strings(devices)
+
+	req := &colmetricpb.ExportMetricsServiceRequest{}
+	for _, device := range devices {
+		attrs := []*commonpb.KeyValue{
+			otlpAttribute("service.name", "mikrotik-exporter"),
+			otlpAttribute("service.version", appVersion),
+		}
+		if device != "" {
+			attrs = append(attrs, otlpAttribute("device", device))
+		}
+
+		var metrics []*metricpb.Metric
+		for _, mf := range groups[device] {
+			metrics = append(metrics, otlpMetric(mf, uint64(start.UnixNano()), uint64(now.UnixNano())))
+		}
+
+		req.ResourceMetrics = append(req.ResourceMetrics, &metricpb.ResourceMetrics{
+			Resource: &resourcepb.Resource{Attributes: attrs},
+			ScopeMetrics: []*metricpb.ScopeMetrics{{
+				Scope:   &commonpb.InstrumentationScope{Name: "mikrotik-exporter", Version: appVersion},
+				Metrics: metrics,
+			}},
+		})
+	}
+
+	return req
+}
+
+func otlpMetric(mf *dto.MetricFamily, start, now uint64) *metricpb.Metric {
+	m := &metricpb.Metric{Name: mf.GetName(), Description: mf.GetHelp(), Unit: mf.GetUnit()}
+
+	switch mf.GetType() {
+	case dto.MetricType_COUNTER:
+		sum := &metricpb.Sum{IsMonotonic: true, AggregationTemporality: metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE}
+		for _, s := range mf.GetMetric() {
+			sum.DataPoints = append(sum.DataPoints, otlpNumberPoint(s, s.GetCounter().GetValue(), start, now))
+		}
+		m.Data = &metricpb.Metric_Sum{Sum: sum}
+	case dto.MetricType_SUMMARY:
+		summary := &metricpb.Summary{}
+		for _, s := range mf.GetMetric() {
+			p := &metricpb.SummaryDataPoint{
+				Attributes:        otlpAttributes(s.GetLabel()),
+				StartTimeUnixNano: start,
+				TimeUnixNano:      otlpTime(s, now),
+				Count:             s.GetSummary().GetSampleCount(),
+				Sum:               s.GetSummary().GetSampleSum(),
+			}
+			for _, q := range s.GetSummary().GetQuantile() {
+				p.QuantileValues = append(p.QuantileValues, &metricpb.SummaryDataPoint_ValueAtQuantile{Quantile: q.GetQuantile(), Value: q.GetValue()})
+			}
+			summary.DataPoints = append(summary.DataPoints, p)
+		}
+		m.Data = &metricpb.Metric_Summary{Summary: summary}
+	case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+		histogram := &metricpb.Histogram{AggregationTemporality: metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE}
+		for _, s := range mf.GetMetric() {
+			histogram.DataPoints = append(histogram.DataPoints, otlpHistogramPoint(s, start, now))
+		}
+		m.Data = &metricpb.Metric_Histogram{Histogram: histogram}
+	default:
+		gauge := &metricpb.Gauge{}
+		for _, s := range mf.GetMetric() {
+			v := s.GetGauge().GetValue()
+			if mf.GetType() == dto.MetricType_UNTYPED {
+				v = s.GetUntyped().GetValue()
+			}
+			gauge.DataPoints = append(gauge.DataPoints, otlpNumberPoint(s, v, 0, now))
+		}
+		m.Data = &metricpb.Metric_Gauge{Gauge: gauge}
+	}
+
+	return m
+}
+
+func otlpNumberPoint(s *dto.Metric, v float64, start, now uint64) *metricpb.NumberDataPoint {
+	return &metricpb.NumberDataPoint{
+		Attributes:        otlpAttributes(s.GetLabel()),
+		StartTimeUnixNano: start,
+		TimeUnixNano:      otlpTime(s, now),
+		Value:             &metricpb.NumberDataPoint_AsDouble{AsDouble: v},
+	}
+}
+
+// otlpHistogramPoint converts the cumulative buckets of the histogram to the
+// bucket counts of OTLP, with the +Inf bucket implied by the sample count.
+func otlpHistogramPoint(s *dto.Metric, start, now uint64) *metricpb.HistogramDataPoint {
+	h := s.GetHistogram()
+	sum := h.GetSampleSum()
+	p := &metricpb.HistogramDataPoint{
+		Attributes:        otlpAttributes(s.GetLabel()),
+		StartTimeUnixNano: start,
+		TimeUnixNano:      otlpTime(s, now),
+		Count:             h.GetSampleCount(),
+		Sum:               &sum,
+	}
+
+	var previous uint64
+	for _, b := range h.GetBucket() {
+		if math.IsInf(b.GetUpperBound(), 1) {
+			continue
+		}
+		p.ExplicitBounds = append(p.ExplicitBounds, b.GetUpperBound())
+		p.BucketCounts = append(p.BucketCounts, b.GetCumulativeCount()-previous)
+		previous = b.GetCumulativeCount()
+	}
+	p.BucketCounts = append(p.BucketCounts, h.GetSampleCount()-previous)
+
+	return p
+}
+
+// otlpTime returns the timestamp of the metric, or now if it has none.
+func otlpTime(s *dto.Metric, now uint64) uint64 {
+	if s.TimestampMs == nil {
+		return now
+	}
+
+	return uint64(s.GetTimestampMs()) * uint64(time.Millisecond)
+}
+
+func otlpAttributes(labels []*dto.LabelPair) []*commonpb.KeyValue {
+	attrs := make([]*commonpb.KeyValue, 0, len(labels))
+	for _, l := range labels {
+		attrs = append(attrs, otlpAttribute(l.GetName(), l.GetValue()))
+	}
+
+	return attrs
+}
+
+func otlpAttribute(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}}}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc/metadata"
+
+	"mikrotik-exporter/collector"
+	"mikrotik-exporter/config"
+)
+
+// tracesQueue is the number of scrape traces waiting to be exported before
+// further ones are dropped
+const tracesQueue = 16
+
+// tracer exports the traces of the scrapes, nil unless enabled
+var tracer collector.Tracer
+
+// otlpTraceExporter exports traces to an OpenTelemetry collector.
+type otlpTraceExporter interface {
+	exportTraces(ctx context.Context, req *coltracepb.ExportTraceServiceRequest) error
+}
+
+// otlpTracer queues the traces of the scrapes and exports them in the
+// background, so slow exports don't hold up scrapes.
+type otlpTracer struct {
+	traces chan []collector.Span
+}
+
+func (t *otlpTracer) Export(spans []collector.Span) {
+	select {
+	case t.traces <- spans:
+	default:
+		log.Warn("opentelemetry trace export queue full, dropping trace of scrape")
+	}
+}
+
+// startOTLPTraces sets up exporting the traces of the scrapes if enabled in
+// the OTLP config.
+func startOTLPTraces(ctx context.Context) {
+	oc, ok := otlpConfig()
+	if !ok || !oc.Traces {
+		return
+	}
+
+	var (
+		e   otlpTraceExporter
+		err error
+	)
+	switch oc.Protocol {
+	case "grpc":
+		e, err = newOTLPGRPCTraceExporter(oc)
+	case "http":
+		var url string
+		url, err = otlpTracesURL(oc.Endpoint)
+		e = &otlpHTTPExporter{
+			url:     url,
+			headers: oc.Headers,
+			client:  &http.Client{Timeout: oc.Interval},
+		}
+	default:
+		err = fmt.Errorf("unknown OTLP protocol %q, expected grpc or http", oc.Protocol)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.WithFields(log.Fields{
+		"endpoint": oc.Endpoint,
+		"protocol": oc.Protocol,
+	}).Info("exporting traces to opentelemetry collector")
+
+	t := &otlpTracer{traces: make(chan []collector.Span, tracesQueue)}
+	tracer = t
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case spans := <-t.traces:
+				ctx, cancel := context.WithTimeout(ctx, oc.Interval)
+				err := e.exportTraces(ctx, otlpTraceRequest(spans))
+				cancel()
+				if err != nil && ctx.Err() != context.Canceled {
+					log.WithFields(log.Fields{
+						"endpoint": oc.Endpoint,
+						"error":    err,
+					}).Error("error exporting traces to opentelemetry collector")
+				}
+			}
+		}
+	}()
+}
+
+// otlpTracesURL returns the URL to export traces to over HTTP from the URL
+// metrics are exported to.
+func otlpTracesURL(metricsURL string) (string, error) {
+	base, ok := strings.CutSuffix(metricsURL, "/v1/metrics")
+	if !ok {
+		return "", fmt.Errorf("OTLP endpoint %q must end in /v1/metrics to export traces over http", metricsURL)
+	}
+
+	return base + "/v1/traces", nil
+}
+
+type otlpGRPCTraceExporter struct {
+	client  coltracepb.TraceServiceClient
+	headers metadata.MD
+}
+
+func newOTLPGRPCTraceExporter(oc config.OTLPConfig) (*otlpGRPCTraceExporter, error) {
+	conn, err := dialOTLP(oc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &otlpGRPCTraceExporter{
+		client:  coltracepb.NewTraceServiceClient(conn),
+		headers: metadata.New(oc.Headers),
+	}, nil
+}
+
+func (e *otlpGRPCTraceExporter) exportTraces(ctx context.Context, req *coltracepb.ExportTraceServiceRequest) error {
+	_, err := e.client.Export(metadata.NewOutgoingContext(ctx, e.headers), req)
+	return err
+}
+
+func (e *otlpHTTPExporter) exportTraces(ctx context.Context, req *coltracepb.ExportTraceServiceRequest) error {
+	return e.post(ctx, req)
+}
+
+// otlpTraceRequest converts the spans of a scrape to an export request.
+func otlpTraceRequest(spans []collector.Span) *coltracepb.ExportTraceServiceRequest {
+	out := make([]*tracepb.Span, 0, len(spans))
+	for _, s := range spans {
+		out = append(out, otlpSpan(s))
+	}
+
+	return &coltracepb.ExportTraceServiceRequest{
+		ResourceSpans: []*tracepb.ResourceSpans{{
+			Resource: &resourcepb.Resource{Attributes: []*commonpb.KeyValue{
+				otlpAttribute("service.name", "mikrotik-exporter"),
+				otlpAttribute("service.version", appVersion),
+			}},
+			ScopeSpans: []*tracepb.ScopeSpans{{
+				Scope: &commonpb.InstrumentationScope{Name: "mikrotik-exporter", Version: appVersion},
+				Spans: out,
+			}},
+		}},
+	}
+}
+
+func otlpSpan(s collector.Span) *tracepb.Span {
+	span := &tracepb.Span{
+		TraceId:           s.TraceID[:],
+		SpanId:            s.SpanID[:],
+		Name:              s.Name,
+		Kind:              tracepb.Span_SPAN_KIND_INTERNAL,
+		StartTimeUnixNano: uint64(s.Start.UnixNano()),
+		EndTimeUnixNano:   uint64(s.End.UnixNano()),
+	}
+	if s.ParentID != [8]byte{} {
+		span.ParentSpanId = s.ParentID[:]
+	}
+	if s.Err != nil {
+		span.Status = &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR, Message: s.Err.Error()}
+	}
+
+	keys := make([]string, 0, len(s.Attributes))
+	for k := range s.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		span.Attributes = append(span.Attributes, otlpSpanAttribute(k, s.Attributes[k]))
+	}
+
+	return span
+}
+
+func otlpSpanAttribute(key string, value any) *commonpb.KeyValue {
+	switch v := value.(type) {
+	case int64:
+		return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: v}}}
+	case bool:
+		return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: v}}}
+	default:
+		return otlpAttribute(key, fmt.Sprint(v))
+	}
+}
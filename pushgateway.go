@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	dto "github.com/prometheus/client_model/go"
+	log "github.com/sirupsen/logrus"
+
+	"mikrotik-exporter/config"
+)
+
+const (
+	defaultPushgatewayJob      = "mikrotik"
+	defaultPushgatewayInterval = 15 * time.Second
+)
+
+func startPushgateway(ctx context.Context, g prometheus.Gatherer) {
+	pc := cfg.Pushgateway
+	if *pushgatewayURL != "" {
+		pc.URL = *pushgatewayURL
+	}
+	if *pushgatewayJob != "" {
+		pc.Job = *pushgatewayJob
+	}
+	if *pushgatewayInterval != 0 {
+		pc.Interval = *pushgatewayInterval
+	}
+	if pc.URL == "" {
+		return
+	}
+	if pc.Job == "" {
+		pc.Job = defaultPushgatewayJob
+	}
+	if pc.Interval <= 0 {
+		pc.Interval = defaultPushgatewayInterval
+	}
+
+	log.WithFields(log.Fields{
+		"url":      pc.URL,
+		"job":      pc.Job,
+		"interval": pc.Interval,
+	}).Info("pushing metrics to pushgateway")
+
+	go every(ctx, pc.Interval, func() {
+		pushMetrics(ctx, pc, g)
+	})
+}
+
+// pushMetrics gathers the metrics once and pushes the metrics of each device
+// to its own group, replacing the metrics the device was pushed with before.
+// The metrics without a device label, like those of the exporter itself, are
+// pushed to the group of the job.
+func pushMetrics(ctx context.Context, pc config.PushgatewayConfig, g prometheus.Gatherer) {
+	mfs, err := g.Gather()
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Warn("error gathering metrics to push, pushing the gathered ones")
+	}
+
+	groups := groupByDevice(mfs)
+	devices := make([]string, 0, len(groups))
+	for device := range groups {
+		devices = append(devices, device)
+	}
+	sort.Strings(devices)
+
+	for _, device := range devices {
+		families := groups[device]
+		p := push.New(pc.URL, pc.Job).Gatherer(prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+			return families, nil
+		}))
+		if device != "" {
+			p = p.Grouping("device", device)
+		}
+
+		if err := p.PushContext(ctx); err != nil && ctx.Err() == nil {
+			log.WithFields(log.Fields{
+				"device": device,
+				"url":    pc.URL,
+				"error":  err,
+			}).Error("error pushing metrics to pushgateway")
+		}
+	}
+}
+
+// groupByDevice splits the metric families by the device of their metrics.
+// The device label of the exporter metrics is removed as the groups carry it,
+// e.g. as the grouping key of the pushgateway. Metrics of no device, like
+// those of the exporter itself, are grouped under the empty device.
+func groupByDevice(mfs []*dto.MetricFamily) map[string][]*dto.MetricFamily {
+	groups := make(map[string][]*dto.MetricFamily)
+	for _, mf := range mfs {
+		byDevice := make(map[string]*dto.MetricFamily)
+		for _, m := range mf.GetMetric() {
+			device, labels := splitDeviceLabel(m.GetLabel())
+
+			f, ok := byDevice[device]
+			if !ok {
+				f = &dto.MetricFamily{Name: mf.Name, Help: mf.Help, Type: mf.Type, Unit: mf.Unit}
+				byDevice[device] = f
+				groups[device] = append(groups[device], f)
+			}
+
+			f.Metric = append(f.Metric, &dto.Metric{
+				Label:       labels,
+				Gauge:       m.Gauge,
+				Counter:     m.Counter,
+				Summary:     m.Summary,
+				Untyped:     m.Untyped,
+				Histogram:   m.Histogram,
+				TimestampMs: m.TimestampMs,
+			})
+		}
+	}
+
+	return groups
+}
+
+// splitDeviceLabel returns the device of the metric and its labels without the
+// device label. The exporter metrics label the device with device, the device
+// metrics with name next to address, or devicename.
+func splitDeviceLabel(labels []*dto.LabelPair) (string, []*dto.LabelPair) {
+	var name, address, devicename string
+	for i, l := range labels {
+		switch l.GetName() {
+		case "device":
+			rest := make([]*dto.LabelPair, 0, len(labels)-1)
+			rest = append(rest, labels[:i]...)
+			rest = append(rest, labels[i+1:]...)
+			return l.GetValue(), rest
+		case "name":
+			name = l.GetValue()
+		case "address":
+			address = l.GetValue()
+		case "devicename":
+			devicename = l.GetValue()
+		}
+	}
+
+	if name != "" && address != "" {
+		return name, labels
+	}
+
+	return devicename, labels
+}
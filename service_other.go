@@ -0,0 +1,22 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+func runningAsService() bool {
+	return false
+}
+
+func runService(func(ctx context.Context)) error {
+	return nil
+}
+
+func runServiceCommand([]string) int {
+	fmt.Fprintln(os.Stderr, "services are only supported on Windows")
+	return 2
+}
@@ -0,0 +1,147 @@
+//go:build windows
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+const (
+	serviceName        = "mikrotik-exporter"
+	serviceDisplayName = "MikroTik exporter"
+	serviceDescription = "Exports metrics of MikroTik devices to Prometheus"
+)
+
+// runningAsService returns whether the exporter was started by the service
+// control manager.
+func runningAsService() bool {
+	ok, err := svc.IsWindowsService()
+	return err == nil && ok
+}
+
+// runService runs f as a service until the service control manager stops
+// it.
+func runService(f func(ctx context.Context)) error {
+	return svc.Run(serviceName, &service{run: f})
+}
+
+type service struct {
+	run func(ctx context.Context)
+}
+
+func (s *service) Execute(_ []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.run(ctx)
+		close(done)
+	}()
+
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case r := <-requests:
+			switch r.Cmd {
+			case svc.Interrogate:
+				status <- r.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				cancel()
+				<-done
+				return false, 0
+			}
+		case <-done:
+			// the server stopped on its own
+			cancel()
+			return false, 1
+		}
+	}
+}
+
+// runServiceCommand installs or uninstalls the service and returns the exit
+// code. The arguments after install are the flags the service runs with.
+func runServiceCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: mikrotik-exporter service install [flags] | uninstall")
+		return 2
+	}
+
+	var err error
+	switch args[0] {
+	case "install":
+		err = installService(args[1:])
+	case "uninstall":
+		err = uninstallService()
+	default:
+		fmt.Fprintf(os.Stderr, "unknown service command %q\n", args[0])
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+func installService(args []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("error connecting to the service control manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.CreateService(serviceName, exe, mgr.Config{
+		DisplayName: serviceDisplayName,
+		Description: serviceDescription,
+		StartType:   mgr.StartAutomatic,
+	}, args...)
+	if err != nil {
+		return fmt.Errorf("error installing service: %w", err)
+	}
+	defer s.Close()
+
+	log.WithFields(log.Fields{
+		"service": serviceName,
+		"args":    args,
+	}).Info("installed service")
+
+	return nil
+}
+
+func uninstallService() error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("error connecting to the service control manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(serviceName)
+	if err != nil {
+		return fmt.Errorf("error opening service: %w", err)
+	}
+	defer s.Close()
+
+	if err := s.Delete(); err != nil {
+		return fmt.Errorf("error uninstalling service: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"service": serviceName,
+	}).Info("uninstalled service")
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// logrusHandler passes the records of libraries logging with slog, e.g. the
+// exporter toolkit, to logrus.
+type logrusHandler struct {
+	fields log.Fields
+}
+
+func (h *logrusHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return log.IsLevelEnabled(logrusLevel(level))
+}
+
+func (h *logrusHandler) Handle(_ context.Context, r slog.Record) error {
+	fields := make(log.Fields, len(h.fields)+r.NumAttrs())
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		fields[a.Key] = a.Value.Any()
+		return true
+	})
+	log.WithFields(fields).Log(logrusLevel(r.Level), r.Message)
+
+	return nil
+}
+
+func (h *logrusHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make(log.Fields, len(h.fields)+len(attrs))
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	for _, a := range attrs {
+		fields[a.Key] = a.Value.Any()
+	}
+
+	return &logrusHandler{fields: fields}
+}
+
+// WithGroup is not supported, attributes of groups are logged without their
+// group.
+func (h *logrusHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+func logrusLevel(level slog.Level) log.Level {
+	switch {
+	case level >= slog.LevelError:
+		return log.ErrorLevel
+	case level >= slog.LevelWarn:
+		return log.WarnLevel
+	case level >= slog.LevelInfo:
+		return log.InfoLevel
+	default:
+		return log.DebugLevel
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	log "github.com/sirupsen/logrus"
+)
+
+// snapshotGatherer keeps the metrics of the last collection, e.g. by a scrape
+// or a push to a sink, to serve them as JSON.
+type snapshotGatherer struct {
+	prometheus.Gatherer
+
+	mu        sync.Mutex
+	families  []*dto.MetricFamily
+	collected time.Time
+}
+
+func (g *snapshotGatherer) Gather() ([]*dto.MetricFamily, error) {
+	begin := time.Now()
+	mfs, err := g.Gatherer.Gather()
+
+	g.mu.Lock()
+	g.families = mfs
+	g.collected = begin
+	g.mu.Unlock()
+
+	return mfs, err
+}
+
+// latest returns the metrics of the last collection, collecting them first if
+// there was none yet.
+func (g *snapshotGatherer) latest() ([]*dto.MetricFamily, time.Time) {
+	g.mu.Lock()
+	mfs, collected := g.families, g.collected
+	g.mu.Unlock()
+
+	if collected.IsZero() {
+		mfs, _ = g.Gather()
+		collected = time.Now()
+	}
+
+	return mfs, collected
+}
+
+type deviceSnapshot struct {
+	Device    string           `json:"device"`
+	Collected time.Time        `json:"collected"`
+	Metrics   []metricSnapshot `json:"metrics"`
+}
+
+type metricSnapshot struct {
+	Name    string           `json:"name"`
+	Help    string           `json:"help"`
+	Type    string           `json:"type"`
+	Samples []sampleSnapshot `json:"samples"`
+}
+
+// sampleSnapshot is a sample of a metric. Values which can't be represented
+// in JSON, NaN and infinities, are omitted.
+type sampleSnapshot struct {
+	Labels map[string]string `json:"labels"`
+	Value  *float64          `json:"value,omitempty"`
+	// Count and Sum are set for histograms and summaries instead of Value
+	Count *uint64  `json:"count,omitempty"`
+	Sum   *float64 `json:"sum,omitempty"`
+}
+
+// snapshotHandler serves the metrics of the last collection of a device as
+// JSON. With tenants the device must belong to the tenant whose token the
+// request bears.
+func snapshotHandler(r *reloadableExporter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		e, release := r.acquire()
+		defer release()
+
+		snapshots := e.snapshots
+
+		// without tenants the snapshot is not keyed by a token
+		s := snapshots[""]
+		if token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer "); s == nil && ok {
+			for t, g := range snapshots {
+				if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+					s = g
+				}
+			}
+		}
+		if s == nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		device := req.PathValue("name")
+		mfs, collected := s.latest()
+		families, ok := groupByDevice(mfs)[device]
+		if !ok || device == "" {
+			http.Error(w, "unknown device", http.StatusNotFound)
+			return
+		}
+
+		snapshot := deviceSnapshot{
+			Device:    device,
+			Collected: collected,
+			Metrics:   make([]metricSnapshot, 0, len(families)),
+		}
+		for _, mf := range families {
+			snapshot.Metrics = append(snapshot.Metrics, metricSnapshotOf(mf))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(snapshot); err != nil {
+			log.WithError(err).Error("error encoding snapshot")
+		}
+	})
+}
+
+func metricSnapshotOf(mf *dto.MetricFamily) metricSnapshot {
+	m := metricSnapshot{
+		Name:    mf.GetName(),
+		Help:    mf.GetHelp(),
+		Type:    strings.ToLower(mf.GetType().String()),
+		Samples: make([]sampleSnapshot, 0, len(mf.GetMetric())),
+	}
+
+	for _, metric := range mf.GetMetric() {
+		s := sampleSnapshot{Labels: make(map[string]string, len(metric.GetLabel()))}
+		for _, l := range metric.GetLabel() {
+			s.Labels[l.GetName()] = l.GetValue()
+		}
+
+		switch mf.GetType() {
+		case dto.MetricType_COUNTER:
+			s.Value = jsonFloat(metric.GetCounter().GetValue())
+		case dto.MetricType_GAUGE:
+			s.Value = jsonFloat(metric.GetGauge().GetValue())
+		case dto.MetricType_UNTYPED:
+			s.Value = jsonFloat(metric.GetUntyped().GetValue())
+		case dto.MetricType_SUMMARY:
+			count := metric.GetSummary().GetSampleCount()
+			s.Count = &count
+			s.Sum = jsonFloat(metric.GetSummary().GetSampleSum())
+		case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+			count := metric.GetHistogram().GetSampleCount()
+			s.Count = &count
+			s.Sum = jsonFloat(metric.GetHistogram().GetSampleSum())
+		}
+
+		m.Samples = append(m.Samples, s)
+	}
+
+	return m
+}
+
+func jsonFloat(v float64) *float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil
+	}
+
+	return &v
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/coreos/go-systemd/v22/activation"
+	"github.com/coreos/go-systemd/v22/daemon"
+	log "github.com/sirupsen/logrus"
+)
+
+// systemdListener returns the socket passed by systemd socket activation.
+func systemdListener() (net.Listener, error) {
+	listeners, err := activation.Listeners()
+	if err != nil {
+		return nil, err
+	}
+	if len(listeners) != 1 {
+		return nil, fmt.Errorf("expected one socket passed by systemd, got %d", len(listeners))
+	}
+
+	return listeners[0], nil
+}
+
+// notifySystemd sends the state to systemd, which is a no-op unless the
+// exporter runs as a Type=notify service.
+func notifySystemd(state string) {
+	if _, err := daemon.SdNotify(false, state); err != nil {
+		log.WithFields(log.Fields{
+			"state": state,
+			"error": err,
+		}).Warn("error notifying systemd")
+	}
+}
+
+// watchdog pings the systemd watchdog in half its interval until ctx is
+// done, if the service has WatchdogSec set.
+func watchdog(ctx context.Context) {
+	interval, err := daemon.SdWatchdogEnabled(false)
+	if err != nil || interval == 0 {
+		return
+	}
+
+	every(ctx, interval/2, func() {
+		notifySystemd(daemon.SdNotifyWatchdog)
+	})
+}